@@ -0,0 +1,334 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/thomasjungblut/go-sstables/sstables"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// tableFlags are the flags that every command reading a table supports.
+type tableFlags struct {
+	blobStorePath string
+	formats       *formatFlags
+}
+
+func newTableFlags(name string, withFormats bool) (*flag.FlagSet, *tableFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	tf := &tableFlags{}
+	fs.StringVar(&tf.blobStorePath, "blob-store", "", "path of the blob store, required for tables written with value separation")
+	if withFormats {
+		tf.formats = addFormatFlags(fs)
+	}
+	return fs, tf
+}
+
+// parseArgs parses the flags and returns the table path alongside the remaining positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string, numPositional int) (string, []string, error) {
+	err := fs.Parse(args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if fs.NArg() != numPositional+1 {
+		return "", nil, fmt.Errorf("expected %d arguments, but got %d", numPositional+1, fs.NArg())
+	}
+
+	return fs.Arg(0), fs.Args()[1:], nil
+}
+
+// openBlobStore returns the blob store of the flag alongside its closer, the store is nil if the flag isn't set.
+func (tf *tableFlags) openBlobStore() (*sstables.BlobStore, func() error, error) {
+	if tf.blobStorePath == "" {
+		return nil, func() error { return nil }, nil
+	}
+
+	store, err := sstables.NewBlobStore(tf.blobStorePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, store.Close, nil
+}
+
+func (tf *tableFlags) openReader(path string, readerOptions ...sstables.ReadOption) (sstables.SSTableReaderI, func() error, error) {
+	store, closer, err := tf.openBlobStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	if store != nil {
+		readerOptions = append(readerOptions, sstables.ReadWithBlobStore(store))
+	}
+
+	reader, err := sstables.NewSSTableReader(append(readerOptions, sstables.ReadBasePath(path))...)
+	if err != nil {
+		return nil, nil, errors.Join(err, closer())
+	}
+
+	return reader, func() error { return errors.Join(reader.Close(), closer()) }, nil
+}
+
+func runMeta(args []string, out io.Writer) (err error) {
+	fs, tf := newTableFlags("meta", false)
+	path, _, err := parseArgs(fs, args, 0)
+	if err != nil {
+		return err
+	}
+
+	// the values are never read, so the blob references don't need to be resolved
+	reader, closer, err := tf.openReader(path, sstables.ReadEncodedValues(), sstables.SkipHashCheckOnLoad())
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, closer()) }()
+
+	text, err := prototext.MarshalOptions{Multiline: true}.Marshal(reader.MetaData())
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(text)
+	return err
+}
+
+func runDump(args []string, out io.Writer) (err error) {
+	fs, tf := newTableFlags("dump", true)
+	path, _, err := parseArgs(fs, args, 0)
+	if err != nil {
+		return err
+	}
+
+	reader, closer, err := tf.openReader(path)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, closer()) }()
+
+	it, err := reader.Scan()
+	if err != nil {
+		return err
+	}
+
+	return printRecords(it, tf.formats, out)
+}
+
+func runGet(args []string, out io.Writer) (err error) {
+	fs, tf := newTableFlags("get", true)
+	path, rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	key, err := tf.formats.parseKey(rest[0])
+	if err != nil {
+		return err
+	}
+
+	reader, closer, err := tf.openReader(path)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, closer()) }()
+
+	value, err := reader.Get(key)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := tf.formats.formatValue(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, formatted)
+	return err
+}
+
+func runScan(args []string, out io.Writer) (err error) {
+	fs, tf := newTableFlags("scan", true)
+	from := fs.String("from", "", "the lower key of the range (inclusive), scans from the beginning if empty")
+	to := fs.String("to", "", "the higher key of the range (inclusive), scans until the end if empty")
+	path, _, err := parseArgs(fs, args, 0)
+	if err != nil {
+		return err
+	}
+
+	var fromKey, toKey []byte
+	if *from != "" {
+		fromKey, err = tf.formats.parseKey(*from)
+		if err != nil {
+			return err
+		}
+	}
+	if *to != "" {
+		toKey, err = tf.formats.parseKey(*to)
+		if err != nil {
+			return err
+		}
+	}
+
+	reader, closer, err := tf.openReader(path)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, closer()) }()
+
+	var it sstables.SSTableIteratorI
+	switch {
+	case fromKey != nil && toKey != nil:
+		it, err = reader.ScanRange(fromKey, toKey)
+	case fromKey != nil:
+		it, err = reader.ScanStartingAt(fromKey)
+	case toKey != nil:
+		it, err = reader.ScanRange(reader.MetaData().MinKey, toKey)
+	default:
+		it, err = reader.Scan()
+	}
+	if err != nil {
+		return err
+	}
+
+	return printRecords(it, tf.formats, out)
+}
+
+func printRecords(it sstables.SSTableIteratorI, formats *formatFlags, out io.Writer) error {
+	for {
+		k, v, err := it.Next()
+		if err != nil {
+			if errors.Is(err, sstables.Done) {
+				return nil
+			}
+			return err
+		}
+
+		formattedValue, err := formats.formatValue(v)
+		if err != nil {
+			return fmt.Errorf("error while formatting value of key %s: %w", formats.formatKey(k), err)
+		}
+
+		_, err = fmt.Fprintf(out, "%s\t%s\n", formats.formatKey(k), formattedValue)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func runVerify(args []string, out io.Writer) (err error) {
+	fs, tf := newTableFlags("verify", false)
+	path, _, err := parseArgs(fs, args, 0)
+	if err != nil {
+		return err
+	}
+
+	// the blob references are only resolved when the store is given, otherwise the values are checked against their
+	// checksums only
+	store, closer, err := tf.openBlobStore()
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, closer()) }()
+
+	var verifyOptions []sstables.VerifyOption
+	if store != nil {
+		verifyOptions = append(verifyOptions, sstables.VerifyWithBlobStore(store))
+	}
+
+	report, err := sstables.Verify(path, verifyOptions...)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range report.Problems {
+		_, _ = fmt.Fprintln(out, p)
+	}
+
+	if !report.Ok() {
+		if report.Truncated {
+			_, _ = fmt.Fprintln(out, "more problems were found, but not reported")
+		}
+		return fmt.Errorf("verification of '%s' found %d problems", path, len(report.Problems))
+	}
+
+	_, err = fmt.Fprintf(out, "verified %d records in '%s', no problems found\n", report.NumRecords, path)
+	return err
+}
+
+func runStats(args []string, out io.Writer) (err error) {
+	fs, tf := newTableFlags("stats", false)
+	path, _, err := parseArgs(fs, args, 0)
+	if err != nil {
+		return err
+	}
+
+	reader, closer, err := tf.openReader(path, sstables.SkipHashCheckOnLoad())
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, closer()) }()
+
+	it, err := reader.Scan()
+	if err != nil {
+		return err
+	}
+
+	keySizes := &sizeHistogram{}
+	valueSizes := &sizeHistogram{}
+	for {
+		k, v, err := it.Next()
+		if err != nil {
+			if errors.Is(err, sstables.Done) {
+				break
+			}
+			return err
+		}
+
+		keySizes.add(len(k))
+		valueSizes.add(len(v))
+	}
+
+	metaData := reader.MetaData()
+	_, _ = fmt.Fprintf(out, "records: %d\n", keySizes.count)
+	_, _ = fmt.Fprintf(out, "key bytes: %d\n", keySizes.sum)
+	_, _ = fmt.Fprintf(out, "value bytes: %d\n", valueSizes.sum)
+	_, _ = fmt.Fprintf(out, "data file bytes: %d\n", metaData.DataBytes)
+	_, _ = fmt.Fprintf(out, "index file bytes: %d\n", metaData.IndexBytes)
+	if metaData.DataBytes > 0 {
+		_, _ = fmt.Fprintf(out, "data compression ratio: %.2f\n", float64(valueSizes.sum)/float64(metaData.DataBytes))
+	}
+
+	_, _ = fmt.Fprintf(out, "\nkey sizes:\n")
+	keySizes.print(out)
+	_, _ = fmt.Fprintf(out, "\nvalue sizes:\n")
+	valueSizes.print(out)
+	return nil
+}
+
+// sizeHistogram counts sizes in power of two buckets, where bucket i contains the sizes in [2^(i-1), 2^i).
+type sizeHistogram struct {
+	buckets [65]uint64
+	count   uint64
+	sum     uint64
+}
+
+func (h *sizeHistogram) add(size int) {
+	h.buckets[bits.Len64(uint64(size))]++
+	h.count++
+	h.sum += uint64(size)
+}
+
+func (h *sizeHistogram) print(out io.Writer) {
+	for i, c := range h.buckets {
+		if c == 0 {
+			continue
+		}
+
+		lower, upper := uint64(0), uint64(1)
+		if i > 0 {
+			lower, upper = uint64(1)<<(i-1), uint64(1)<<i
+		}
+		_, _ = fmt.Fprintf(out, "  [%d, %d): %d\n", lower, upper, c)
+	}
+}
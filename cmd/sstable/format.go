@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	formatHex   = "hex"
+	formatText  = "text"
+	formatProto = "proto"
+)
+
+// formatFlags define how keys and values are parsed from the arguments and printed.
+type formatFlags struct {
+	keyFormat         string
+	valueFormat       string
+	descriptorSetPath string
+	messageName       string
+
+	messageType protoreflect.MessageType
+}
+
+func addFormatFlags(fs *flag.FlagSet) *formatFlags {
+	f := &formatFlags{}
+	fs.StringVar(&f.keyFormat, "key-format", formatText, "format of the keys, either hex or text")
+	fs.StringVar(&f.valueFormat, "value-format", formatText, "format of the values, either hex, text or proto")
+	fs.StringVar(&f.descriptorSetPath, "descriptor-set", "",
+		"path of a serialized FileDescriptorSet (protoc --descriptor_set_out) to decode proto values with")
+	fs.StringVar(&f.messageName, "message", "", "the full name of the proto message of the values, e.g. my.package.Message")
+	return f
+}
+
+func (f *formatFlags) parseKey(key string) ([]byte, error) {
+	switch f.keyFormat {
+	case formatHex:
+		return hex.DecodeString(key)
+	case formatText:
+		return []byte(key), nil
+	default:
+		return nil, fmt.Errorf("unknown key format '%s'", f.keyFormat)
+	}
+}
+
+func (f *formatFlags) formatKey(key []byte) string {
+	if f.keyFormat == formatHex {
+		return hex.EncodeToString(key)
+	}
+	return string(key)
+}
+
+func (f *formatFlags) formatValue(value []byte) (string, error) {
+	switch f.valueFormat {
+	case formatHex:
+		return hex.EncodeToString(value), nil
+	case formatText:
+		return string(value), nil
+	case formatProto:
+		messageType, err := f.protoMessageType()
+		if err != nil {
+			return "", err
+		}
+
+		msg := messageType.New().Interface()
+		err = proto.Unmarshal(value, msg)
+		if err != nil {
+			return "", err
+		}
+
+		text, err := prototext.MarshalOptions{}.Marshal(msg)
+		return string(text), err
+	default:
+		return "", fmt.Errorf("unknown value format '%s'", f.valueFormat)
+	}
+}
+
+// protoMessageType resolves the message type from the descriptor set once.
+func (f *formatFlags) protoMessageType() (protoreflect.MessageType, error) {
+	if f.messageType != nil {
+		return f.messageType, nil
+	}
+
+	if f.descriptorSetPath == "" || f.messageName == "" {
+		return nil, errors.New("decoding proto values requires both -descriptor-set and -message")
+	}
+
+	content, err := os.ReadFile(f.descriptorSetPath)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading descriptor set '%s': %w", f.descriptorSetPath, err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	err = proto.Unmarshal(content, set)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing descriptor set '%s': %w", f.descriptorSetPath, err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("error while resolving descriptor set '%s': %w", f.descriptorSetPath, err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(f.messageName))
+	if err != nil {
+		return nil, fmt.Errorf("error while finding message '%s': %w", f.messageName, err)
+	}
+
+	messageDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a message", f.messageName)
+	}
+
+	f.messageType = dynamicpb.NewMessageType(messageDesc)
+	return f.messageType, nil
+}
@@ -0,0 +1,71 @@
+// Command sstable inspects sstable directories written by the sstables package.
+//
+// Usage:
+//
+//	sstable <command> [flags] <sstable path> [arguments]
+//
+// The commands are:
+//
+//	meta     prints the metadata of the table
+//	dump     prints all keys and values
+//	get      prints the value of a single key
+//	scan     prints all keys and values in a key range
+//	verify   checks the checksums of all files, the key ordering and the bloom filter
+//	stats    prints key and value size histograms and the compression ratio
+//
+// Flags have to be supplied before the path, run "sstable <command> -h" for the flags of each command.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, out io.Writer) error
+}
+
+var commands = []command{
+	{"meta", "prints the metadata of the table", runMeta},
+	{"dump", "prints all keys and values", runDump},
+	{"get", "prints the value of a single key", runGet},
+	{"scan", "prints all keys and values in a key range", runScan},
+	{"verify", "checks the checksums of all files, the key ordering and the bloom filter", runVerify},
+	{"stats", "prints key and value size histograms and the compression ratio", runStats},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, out io.Writer, errOut io.Writer) int {
+	if len(args) == 0 {
+		printUsage(errOut)
+		return 2
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			err := c.run(args[1:], out)
+			if err != nil {
+				_, _ = fmt.Fprintf(errOut, "sstable %s: %v\n", c.name, err)
+				return 1
+			}
+			return 0
+		}
+	}
+
+	_, _ = fmt.Fprintf(errOut, "sstable: unknown command '%s'\n", args[0])
+	printUsage(errOut)
+	return 2
+}
+
+func printUsage(out io.Writer) {
+	_, _ = fmt.Fprintf(out, "usage: sstable <command> [flags] <sstable path> [arguments]\n\ncommands:\n")
+	for _, c := range commands {
+		_, _ = fmt.Fprintf(out, "  %-8s %s\n", c.name, c.usage)
+	}
+}
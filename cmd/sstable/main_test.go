@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables"
+	sProto "github.com/thomasjungblut/go-sstables/sstables/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, 2, run(nil, out, errOut))
+	assert.Contains(t, errOut.String(), "usage")
+	assert.Equal(t, 2, run([]string{"unknown"}, out, errOut))
+	assert.Contains(t, errOut.String(), "unknown command")
+}
+
+func TestMeta(t *testing.T) {
+	path := writeCliTestTable(t, map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	out := runCliSuccessfully(t, "meta", path)
+	assert.Contains(t, out, "numRecords:")
+	assert.Contains(t, out, "2")
+}
+
+func TestDumpGetAndScan(t *testing.T) {
+	path := writeCliTestTable(t, map[string][]byte{"a": []byte("1"), "b": []byte("2"), "c": []byte("3"), "d": []byte("4")})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	assert.Equal(t, "a\t1\nb\t2\nc\t3\nd\t4\n", runCliSuccessfully(t, "dump", path))
+	assert.Equal(t, "61\t31\n62\t32\n63\t33\n64\t34\n",
+		runCliSuccessfully(t, "dump", "-key-format", "hex", "-value-format", "hex", path))
+
+	assert.Equal(t, "3\n", runCliSuccessfully(t, "get", path, "c"))
+	assert.Equal(t, "3\n", runCliSuccessfully(t, "get", "-key-format", "hex", path, hex.EncodeToString([]byte("c"))))
+	assert.Error(t, runCli("get", path, "e"))
+
+	assert.Equal(t, "b\t2\nc\t3\n", runCliSuccessfully(t, "scan", "-from", "b", "-to", "c", path))
+	assert.Equal(t, "c\t3\nd\t4\n", runCliSuccessfully(t, "scan", "-from", "c", path))
+	assert.Equal(t, "a\t1\nb\t2\n", runCliSuccessfully(t, "scan", "-to", "b", path))
+}
+
+func TestDumpProtoValues(t *testing.T) {
+	value, err := proto.Marshal(&sProto.IndexEntry{Key: []byte("k"), ValueOffset: 42})
+	require.Nil(t, err)
+	path := writeCliTestTable(t, map[string][]byte{"a": value})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(sProto.File_sstables_proto_sstable_proto),
+	}}
+	setBytes, err := proto.Marshal(set)
+	require.Nil(t, err)
+	setPath := filepath.Join(t.TempDir(), "descriptors.pb")
+	require.Nil(t, os.WriteFile(setPath, setBytes, 0600))
+
+	out := runCliSuccessfully(t, "dump", "-value-format", "proto", "-descriptor-set", setPath, "-message", "proto.IndexEntry", path)
+	assert.True(t, strings.HasPrefix(out, "a\t"))
+	assert.Contains(t, out, "valueOffset:")
+	assert.Contains(t, out, "42")
+
+	assert.Error(t, runCli("dump", "-value-format", "proto", path))
+	assert.Error(t, runCli("dump", "-value-format", "proto", "-descriptor-set", setPath, "-message", "proto.Unknown", path))
+}
+
+func TestVerify(t *testing.T) {
+	path := writeCliTestTable(t, map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+	assert.Contains(t, runCliSuccessfully(t, "verify", path), "verified 2 records")
+
+	// a bloom filter of another table misses the keys
+	other := writeCliTestTable(t, map[string][]byte{"x": []byte("1"), "y": []byte("2")})
+	defer func() { require.Nil(t, os.RemoveAll(other)) }()
+	bloom, err := os.ReadFile(filepath.Join(other, sstables.BloomFileName))
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(filepath.Join(path, sstables.BloomFileName), bloom, 0600))
+
+	out := &bytes.Buffer{}
+	assert.Error(t, runCommand("verify", []string{path}, out))
+	assert.Contains(t, out.String(), "bloom filter doesn't contain 2 keys")
+}
+
+func TestVerifyWithBlobStore(t *testing.T) {
+	storePath := t.TempDir()
+	store, err := sstables.NewBlobStore(storePath)
+	require.Nil(t, err)
+
+	path := t.TempDir()
+	writer, err := sstables.NewSSTableStreamWriter(sstables.WriteBasePath(path), sstables.WithKeyComparator(skiplist.BytesComparator{}),
+		sstables.WithValueSeparation(store, 10))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	require.Nil(t, writer.WriteNext([]byte("a"), bytes.Repeat([]byte{1}, 100)))
+	require.Nil(t, writer.WriteNext([]byte("b"), []byte("2")))
+	require.Nil(t, writer.Close())
+	require.Nil(t, store.Close())
+
+	assert.Contains(t, runCliSuccessfully(t, "verify", "-blob-store", storePath, path), "verified 2 records")
+
+	// without the blob file, only the verification against the store fails
+	require.Nil(t, os.Remove(filepath.Join(storePath, fmt.Sprintf(sstables.BlobFileNamePattern, 0))))
+	assert.Contains(t, runCliSuccessfully(t, "verify", path), "verified 2 records")
+
+	out := &bytes.Buffer{}
+	assert.Error(t, runCommand("verify", []string{"-blob-store", storePath, path}, out))
+	assert.Contains(t, out.String(), fmt.Sprintf(sstables.BlobFileNamePattern, 0))
+}
+
+func TestStats(t *testing.T) {
+	path := writeCliTestTable(t, map[string][]byte{"a": []byte("1"), "bb": bytes.Repeat([]byte{1}, 100), "ccc": bytes.Repeat([]byte{2}, 1000)})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	out := runCliSuccessfully(t, "stats", path)
+	assert.Contains(t, out, "records: 3\n")
+	assert.Contains(t, out, "key bytes: 6\n")
+	assert.Contains(t, out, "value bytes: 1101\n")
+	assert.Contains(t, out, "data compression ratio:")
+	assert.Contains(t, out, "key sizes:\n  [1, 2): 1\n  [2, 4): 2\n")
+	assert.Contains(t, out, "value sizes:\n  [1, 2): 1\n  [64, 128): 1\n  [512, 1024): 1\n")
+}
+
+func TestSizeHistogram(t *testing.T) {
+	h := &sizeHistogram{}
+	for _, s := range []int{0, 1, 2, 3, 4, 1024} {
+		h.add(s)
+	}
+	assert.Equal(t, uint64(6), h.count)
+	assert.Equal(t, uint64(1034), h.sum)
+	out := &bytes.Buffer{}
+	h.print(out)
+	assert.Equal(t, "  [0, 1): 1\n  [1, 2): 1\n  [2, 4): 2\n  [4, 8): 1\n  [1024, 2048): 1\n", out.String())
+}
+
+func writeCliTestTable(t *testing.T, records map[string][]byte) string {
+	tmpDir, err := os.MkdirTemp("", "sstable_cli")
+	require.Nil(t, err)
+
+	var keys []string
+	for k := range records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	writer, err := sstables.NewSSTableStreamWriter(sstables.WriteBasePath(tmpDir), sstables.WithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, k := range keys {
+		require.Nil(t, writer.WriteNext([]byte(k), records[k]))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+func runCommand(name string, args []string, out *bytes.Buffer) error {
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(args, out)
+		}
+	}
+	return fmt.Errorf("unknown command '%s'", name)
+}
+
+func runCli(name string, args ...string) error {
+	return runCommand(name, args, &bytes.Buffer{})
+}
+
+func runCliSuccessfully(t *testing.T, name string, args ...string) string {
+	out := &bytes.Buffer{}
+	require.Nil(t, runCommand(name, args, out))
+	return out.String()
+}
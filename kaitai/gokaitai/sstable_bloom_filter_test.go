@@ -0,0 +1,48 @@
+package gokaitai
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/sstables"
+)
+
+func TestHappyPathReadSSTableBloomFilter(t *testing.T) {
+	path := writeTestSSTable(t)
+	defer func() { require.NoError(t, os.RemoveAll(path)) }()
+
+	f, err := os.Open(filepath.Join(path, sstables.BloomFileName))
+	require.NoError(t, err)
+	defer f.Close()
+	gzipReader, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	content, err := io.ReadAll(gzipReader)
+	require.NoError(t, err)
+	require.NoError(t, gzipReader.Close())
+
+	bf := NewSstableBloomFilter()
+	require.NoError(t, bf.Read(kaitai.NewStream(bytes.NewReader(content)), nil, bf))
+	require.Equal(t, uint64(numTestRecords), bf.NumElements)
+	require.Equal(t, int(bf.NumHashes), len(bf.HashKeys))
+	require.Equal(t, int((bf.NumBits+63)/64), len(bf.Bits))
+	digest := sha512.Sum384(content[:len(content)-len(bf.Sha384)])
+	require.Equal(t, digest[:], bf.Sha384)
+
+	for i := 0; i < numTestRecords; i++ {
+		hash := fnv.New64()
+		_, err := hash.Write(testKey(i))
+		require.NoError(t, err)
+		for _, hashKey := range bf.HashKeys {
+			bit := (hash.Sum64() ^ hashKey) % bf.NumBits
+			require.NotZero(t, bf.Bits[bit/64]&(1<<(bit%64)), "key %d", i)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package gokaitai
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc64"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/recordio"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables"
+)
+
+const numTestRecords = 100
+
+// writeTestSSTable writes keys 0 to numTestRecords as big endian integers, the values are the keys repeated.
+func writeTestSSTable(t *testing.T) string {
+	tmpDir, err := os.MkdirTemp("", "kaitai_sstable")
+	require.NoError(t, err)
+
+	writer, err := sstables.NewSSTableStreamWriter(
+		sstables.WriteBasePath(tmpDir),
+		sstables.WithKeyComparator(skiplist.BytesComparator{}),
+		sstables.DataCompressionType(recordio.CompressionTypeNone),
+		sstables.BloomExpectedNumberOfElements(numTestRecords))
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	for i := 0; i < numTestRecords; i++ {
+		require.NoError(t, writer.WriteNext(testKey(i), testValue(i)))
+	}
+	require.NoError(t, writer.Close())
+	return tmpDir
+}
+
+func testKey(i int) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(i))
+}
+
+func testValue(i int) []byte {
+	return bytes.Repeat(testKey(i), i%5+1)
+}
+
+// vlqUint64 decodes the full 64-bit value of the groups, which VlqBase128Le.Value can't for more than eight groups.
+func vlqUint64(v *VlqBase128Le) uint64 {
+	var result uint64
+	for i, group := range v.Groups {
+		result |= uint64(group.B&0x7F) << (7 * i)
+	}
+	return result
+}
+
+func TestHappyPathReadSSTableIndex(t *testing.T) {
+	path := writeTestSSTable(t)
+	defer func() { require.NoError(t, os.RemoveAll(path)) }()
+
+	indexFile, err := os.Open(filepath.Join(path, sstables.IndexFileName))
+	require.NoError(t, err)
+	defer indexFile.Close()
+	index := NewRecordioV4()
+	require.NoError(t, index.Read(kaitai.NewStream(indexFile), nil, index))
+	require.Equal(t, RecordioV4_Compression__None, index.FileHeader.CompressionType)
+	require.Equal(t, numTestRecords, len(index.Record))
+
+	dataFile, err := os.Open(filepath.Join(path, sstables.DataFileName))
+	require.NoError(t, err)
+	defer dataFile.Close()
+	dataStream := kaitai.NewStream(dataFile)
+
+	for i, record := range index.Record {
+		entry := NewSstableIndexEntry()
+		require.NoError(t, entry.Read(kaitai.NewStream(bytes.NewReader(record.Payload)), nil, entry))
+		require.Equal(t, 3, len(entry.Pairs))
+
+		var key []byte
+		var valueOffset, checksum uint64
+		for _, pair := range entry.Pairs {
+			field, err := pair.Field()
+			require.NoError(t, err)
+			switch field {
+			case SstableIndexEntry_Field__Key:
+				key = pair.Value.(*SstableIndexEntry_DelimitedBytes).Body
+			case SstableIndexEntry_Field__ValueOffset:
+				valueOffset = vlqUint64(pair.Value.(*VlqBase128Le))
+			case SstableIndexEntry_Field__Checksum:
+				checksum = vlqUint64(pair.Value.(*VlqBase128Le))
+			}
+		}
+		require.Equal(t, testKey(i), key)
+
+		// the value offset points to the record of the value in the data file
+		_, err = dataStream.Seek(int64(valueOffset), io.SeekStart)
+		require.NoError(t, err)
+		value := NewRecordioV4_Record()
+		require.NoError(t, value.Read(dataStream, nil, nil))
+		require.Equal(t, testValue(i), value.Payload)
+		require.Equal(t, crc64.Checksum(value.Payload, crc64.MakeTable(crc64.ISO)), checksum)
+	}
+}
@@ -0,0 +1,62 @@
+package gokaitai
+
+import (
+	"bytes"
+	"hash/crc64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaitai-io/kaitai_struct_go_runtime/kaitai"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/sstables"
+)
+
+func TestHappyPathReadSSTableMetaData(t *testing.T) {
+	path := writeTestSSTable(t)
+	defer func() { require.NoError(t, os.RemoveAll(path)) }()
+
+	content, err := os.ReadFile(filepath.Join(path, sstables.MetaFileName))
+	require.NoError(t, err)
+	metaData := NewSstableMetaData()
+	require.NoError(t, metaData.Read(kaitai.NewStream(bytes.NewReader(content)), nil, metaData))
+
+	fields := map[SstableMetaData_Field]*SstableMetaData_Pair{}
+	for _, pair := range metaData.Pairs {
+		field, err := pair.Field()
+		require.NoError(t, err)
+		fields[field] = pair
+	}
+
+	require.Equal(t, uint64(numTestRecords), vlqUint64(fields[SstableMetaData_Field__NumRecords].Value.(*VlqBase128Le)))
+	require.Equal(t, uint64(sstables.Version), vlqUint64(fields[SstableMetaData_Field__Version].Value.(*VlqBase128Le)))
+	require.Equal(t, testKey(0), fields[SstableMetaData_Field__MinKey].Value.(*SstableMetaData_DelimitedBytes).Body)
+	require.Equal(t, testKey(numTestRecords-1), fields[SstableMetaData_Field__MaxKey].Value.(*SstableMetaData_DelimitedBytes).Body)
+
+	// every file checksum is a map entry with the file name as key and the checksum as value
+	var fileNames []string
+	for _, pair := range metaData.Pairs {
+		field, err := pair.Field()
+		require.NoError(t, err)
+		if field != SstableMetaData_Field__FileChecksums {
+			continue
+		}
+		entry := NewSstableMetaData()
+		body := pair.Value.(*SstableMetaData_DelimitedBytes).Body
+		require.NoError(t, entry.Read(kaitai.NewStream(bytes.NewReader(body)), nil, entry))
+		require.Equal(t, 2, len(entry.Pairs))
+		fileNames = append(fileNames, string(entry.Pairs[0].Value.(*SstableMetaData_DelimitedBytes).Body))
+	}
+	require.ElementsMatch(t, []string{sstables.IndexFileName, sstables.DataFileName, sstables.BloomFileName}, fileNames)
+
+	// the checksum is the last field and covers all bytes before it
+	last := metaData.Pairs[len(metaData.Pairs)-1]
+	field, err := last.Field()
+	require.NoError(t, err)
+	require.Equal(t, SstableMetaData_Field__MetaDataChecksum, field)
+	wireType, err := last.WireType()
+	require.NoError(t, err)
+	require.Equal(t, SstableMetaData_WireType__Bit64, wireType)
+	checksumFooterSize := 2 + 8
+	require.Equal(t, crc64.Checksum(content[:len(content)-checksumFooterSize], crc64.MakeTable(crc64.ISO)), last.Value)
+}
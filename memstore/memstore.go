@@ -2,6 +2,8 @@ package memstore
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/thomasjungblut/go-sstables/skiplist"
 	"github.com/thomasjungblut/go-sstables/sstables"
@@ -10,6 +12,7 @@ import (
 var KeyAlreadyExists = errors.New("key already exists")
 var KeyNotFound = errors.New("key not found")
 var KeyTombstoned = errors.New("key was tombstoned")
+var KeyExpired = fmt.Errorf("%w: key has expired", KeyTombstoned)
 var KeyNil = errors.New("key was nil")
 var ValueNil = errors.New("value was nil")
 
@@ -28,6 +31,10 @@ type MemStoreI interface {
 	// Upsert inserts when the key does not exist yet, updates the current value if the key exists.
 	// Neither nil key nor values are allowed, KeyNil and ValueNil will be returned accordingly.
 	Upsert(key []byte, value []byte) error
+	// UpsertWithExpiry is the same as Upsert, but the value expires at the given time. Expired keys are treated as if
+	// they were tombstoned, Get returns KeyExpired for them. Once the memstore contains values with an expiry, Flush
+	// writes the table WithExpiringValues and the iterators return all values encoded with sstables.EncodeExpiringValue.
+	UpsertWithExpiry(key []byte, value []byte, expiresAt time.Time) error
 	// Delete deletes the key from the MemStore, returns a KeyNotFound error if the key does not exist.
 	// Effectively this will set a tombstone for the given key and set its value to be nil.
 	Delete(key []byte) error
@@ -49,13 +56,65 @@ type MemStoreI interface {
 	// if there is a tombstoned record, the key will be returned but the value will be nil.
 	// this is especially useful when you want to merge on-disk sstables with in memory memstores
 	SStableIterator() sstables.SSTableIteratorI
+	// SeekableIterator returns the current memstore as a bidirectional sstables.SSTableSeekableIteratorI, positioned
+	// at the first key. Same as in SStableIterator, tombstoned records will be returned with a nil value.
+	SeekableIterator() sstables.SSTableSeekableIteratorI
 	// Size Returns how many elements are in this memstore. This also includes tombstoned keys.
 	Size() int
+	// Merge applies the merge operand to the key. When the key was put or tombstoned in this memstore, the operand is
+	// fully merged with the operator right away, otherwise it is kept until the memstore is flushed. Get returns
+	// sstables.MergeOperandFound for keys that only have operands, GetMergeValue returns them.
+	// Once the memstore contains operands, Flush writes the table WithMergeOperands and the iterators return all values
+	// encoded as sstables.MergeValue.
+	Merge(key []byte, operand []byte, operator sstables.MergeOperator) error
+	// GetMergeValue returns the value of the key as a put, delete or the merge operands that were not merged yet.
+	// Returns KeyNotFound if the key does not exist.
+	GetMergeValue(key []byte) (sstables.MergeValue, error)
 }
 
 type ValueStruct struct {
 	// when deleting, we're simply tomb-stoning the key by setting value = nil, which also saves memory
 	value *[]byte
+	// operands that could not be merged yet, ordered from the oldest to the newest. Only set for keys that were merged.
+	operands *[][]byte
+	// the time the value expires at, zero if it never expires
+	expiresAt *time.Time
+}
+
+func newValueStruct(value []byte) ValueStruct {
+	return ValueStruct{value: &value, expiresAt: &time.Time{}}
+}
+
+// expired returns true if the value has an expiry time that has passed, unmerged operands never expire.
+func (v ValueStruct) expired(now time.Time) bool {
+	return !v.isMerge() && *v.value != nil && sstables.IsExpired(*v.expiresAt, now)
+}
+
+func (v ValueStruct) isMerge() bool {
+	return v.operands != nil && *v.operands != nil
+}
+
+func (v ValueStruct) mergeValue() sstables.MergeValue {
+	if v.isMerge() {
+		return sstables.MergeValue{Kind: sstables.ValueKindMerge, Operands: *v.operands}
+	}
+	if *v.value == nil || v.expired(time.Now()) {
+		return sstables.MergeValue{Kind: sstables.ValueKindDelete}
+	}
+	return sstables.MergeValue{Kind: sstables.ValueKindPut, Value: *v.value}
+}
+
+// clearOperands removes all unmerged operands, which are superseded by a put or a delete. Returns their size.
+func (v ValueStruct) clearOperands() int {
+	if !v.isMerge() {
+		return 0
+	}
+	size := 0
+	for _, operand := range *v.operands {
+		size += len(operand)
+	}
+	*v.operands = nil
+	return size
 }
 
 func (v ValueStruct) GetValue() []byte {
@@ -66,6 +125,10 @@ type MemStore struct {
 	skipListMap   skiplist.MapI[[]byte, ValueStruct]
 	estimatedSize uint64
 	comparator    skiplist.BytesComparator
+	// mergeOperands is set once any key was merged without a value to merge it with
+	mergeOperands bool
+	// expiringValues is set once any key was upserted with an expiry time
+	expiringValues bool
 }
 
 func (m *MemStore) Add(key []byte, value []byte) error {
@@ -78,10 +141,10 @@ func (m *MemStore) Contains(key []byte) bool {
 	if errors.Is(err, skiplist.NotFound) {
 		return false
 	}
-	if *element.value == nil {
+	if *element.value == nil && !element.isMerge() {
 		return false
 	}
-	return true
+	return !element.expired(time.Now())
 }
 
 func (m *MemStore) IsTombstoned(key []byte) bool {
@@ -93,10 +156,10 @@ func (m *MemStore) IsTombstoned(key []byte) bool {
 	if errors.Is(err, skiplist.NotFound) {
 		return false
 	}
-	if *element.value == nil {
+	if *element.value == nil && !element.isMerge() {
 		return true
 	}
-	return false
+	return element.expired(time.Now())
 }
 
 func (m *MemStore) Get(key []byte) ([]byte, error) {
@@ -105,17 +168,94 @@ func (m *MemStore) Get(key []byte) ([]byte, error) {
 	if errors.Is(err, skiplist.NotFound) {
 		return nil, KeyNotFound
 	}
+	if element.isMerge() {
+		return nil, sstables.MergeOperandFound
+	}
 	val := *element.value
 	if val == nil {
 		return nil, KeyTombstoned
 	}
+	if element.expired(time.Now()) {
+		return nil, KeyExpired
+	}
 	return val, nil
 }
 
+func (m *MemStore) GetMergeValue(key []byte) (sstables.MergeValue, error) {
+	element, err := m.skipListMap.Get(key)
+	if errors.Is(err, skiplist.NotFound) {
+		return sstables.MergeValue{}, KeyNotFound
+	}
+	return element.mergeValue(), nil
+}
+
+func (m *MemStore) Merge(key []byte, operand []byte, operator sstables.MergeOperator) error {
+	if key == nil {
+		return KeyNil
+	}
+
+	if operand == nil {
+		return ValueNil
+	}
+
+	element, err := m.skipListMap.Get(key)
+	if errors.Is(err, skiplist.NotFound) {
+		m.mergeOperands = true
+		operands := [][]byte{operand}
+		value := newValueStruct(nil)
+		value.operands = &operands
+		m.skipListMap.Insert(key, value)
+		m.estimatedSize += uint64(len(key)) + uint64(len(operand))
+		return nil
+	}
+
+	if element.isMerge() {
+		*element.operands = append(*element.operands, operand)
+		m.estimatedSize += uint64(len(operand))
+		return nil
+	}
+
+	// the key was put or tombstoned in this memstore, so nothing older needs to be merged with
+	if operator == nil {
+		return sstables.MergeOperandFound
+	}
+	// an expired value is merged like a tombstone, the merged value keeps the expiry of a value that didn't expire yet
+	existing := *element.value
+	if element.expired(time.Now()) {
+		existing = nil
+		*element.expiresAt = time.Time{}
+	}
+	merged, err := operator.FullMerge(key, existing, [][]byte{operand})
+	if err != nil {
+		return err
+	}
+	if merged == nil {
+		merged = []byte{}
+	}
+	prevLen := len(*element.value)
+	*element.value = merged
+	m.estimatedSize = m.estimatedSize - uint64(prevLen) + uint64(len(merged))
+	return nil
+}
+
 func (m *MemStore) Upsert(key []byte, value []byte) error {
 	return upsertInternal(m, key, value, false)
 }
 
+func (m *MemStore) UpsertWithExpiry(key []byte, value []byte, expiresAt time.Time) error {
+	err := upsertInternal(m, key, value, false)
+	if err != nil {
+		return err
+	}
+
+	if !expiresAt.IsZero() {
+		m.expiringValues = true
+		element, _ := m.skipListMap.Get(key)
+		*element.expiresAt = expiresAt
+	}
+	return nil
+}
+
 func upsertInternal(m *MemStore, key []byte, value []byte, errorIfKeyExist bool) error {
 	if key == nil {
 		return KeyNil
@@ -127,14 +267,15 @@ func upsertInternal(m *MemStore, key []byte, value []byte, errorIfKeyExist bool)
 
 	element, err := m.skipListMap.Get(key)
 	if !errors.Is(err, skiplist.NotFound) {
-		if *element.value != nil && errorIfKeyExist {
+		if (*element.value != nil || element.isMerge()) && !element.expired(time.Now()) && errorIfKeyExist {
 			return KeyAlreadyExists
 		}
-		prevLen := len(*element.value)
+		prevLen := len(*element.value) + element.clearOperands()
 		*element.value = value
+		*element.expiresAt = time.Time{}
 		m.estimatedSize = m.estimatedSize - uint64(prevLen) + uint64(len(value))
 	} else {
-		m.skipListMap.Insert(key, ValueStruct{value: &value})
+		m.skipListMap.Insert(key, newValueStruct(value))
 		m.estimatedSize += uint64(len(key)) + uint64(len(value))
 	}
 	return nil
@@ -155,8 +296,9 @@ func deleteInternal(m *MemStore, key []byte, errorIfKeyNotFound bool) error {
 			return KeyNotFound
 		}
 	} else {
-		m.estimatedSize -= uint64(len(*element.value))
+		m.estimatedSize -= uint64(len(*element.value) + element.clearOperands())
 		*element.value = nil
+		*element.expiresAt = time.Time{}
 	}
 
 	return nil
@@ -165,13 +307,12 @@ func deleteInternal(m *MemStore, key []byte, errorIfKeyNotFound bool) error {
 func (m *MemStore) Tombstone(key []byte) error {
 	element, err := m.skipListMap.Get(key)
 	if !errors.Is(err, skiplist.NotFound) {
-		prevLen := len(*element.value)
+		prevLen := len(*element.value) + element.clearOperands()
 		*element.value = nil
+		*element.expiresAt = time.Time{}
 		m.estimatedSize = m.estimatedSize - uint64(prevLen)
 	} else {
-		var vByte []byte
-		v := ValueStruct{value: &vByte}
-		m.skipListMap.Insert(key, v)
+		m.skipListMap.Insert(key, newValueStruct(nil))
 		m.estimatedSize += uint64(len(key))
 	}
 	return nil
@@ -196,6 +337,12 @@ func (m *MemStore) FlushWithTombstones(writerOptions ...sstables.WriterOption) e
 
 func flushMemstore(m *MemStore, includeTombstones bool, writerOptions ...sstables.WriterOption) (err error) {
 	writerOptions = append(writerOptions, sstables.WithKeyComparator(m.comparator))
+	if m.mergeOperands {
+		writerOptions = append(writerOptions, sstables.WithMergeOperands())
+	}
+	if m.expiringValues {
+		writerOptions = append(writerOptions, sstables.WithExpiringValues())
+	}
 	writer, err := sstables.NewSSTableStreamWriter(writerOptions...)
 	if err != nil {
 		return err
@@ -210,6 +357,7 @@ func flushMemstore(m *MemStore, includeTombstones bool, writerOptions ...sstable
 		err = errors.Join(err, writer.Close())
 	}()
 
+	now := time.Now()
 	it, _ := m.skipListMap.Iterator()
 	for {
 		k, v, err := it.Next()
@@ -220,26 +368,30 @@ func flushMemstore(m *MemStore, includeTombstones bool, writerOptions ...sstable
 			return err
 		}
 
-		if includeTombstones {
-			if err := writer.WriteNext(k, *v.value); err != nil {
+		if includeTombstones || (*v.value != nil || v.isMerge()) && !v.expired(now) {
+			if err := writer.WriteNext(k, m.flushValue(v, now)); err != nil {
 				return err
 			}
-		} else {
-			// do not write tombstones to the final file
-			if *v.value != nil {
-				if err := writer.WriteNext(k, *v.value); err != nil {
-					return err
-				}
-			}
 		}
 	}
 
 	return nil
 }
 
+// flushValue returns the value as it's written into a table, which is encoded once the memstore contains operands
+// or values with an expiry time.
+func (m *MemStore) flushValue(v ValueStruct, now time.Time) []byte {
+	return entryValue(v, m.mergeOperands, m.expiringValues, now)
+}
+
 func (m *MemStore) SStableIterator() sstables.SSTableIteratorI {
 	it, _ := m.skipListMap.Iterator()
-	return &SkipListSStableIterator{iterator: it}
+	return &SkipListSStableIterator{iterator: it, encode: m.mergeOperands, encodeExpiry: m.expiringValues}
+}
+
+func (m *MemStore) SeekableIterator() sstables.SSTableSeekableIteratorI {
+	it, _ := m.skipListMap.SeekableIterator()
+	return &SkipListSeekableSStableIterator{iterator: it, encode: m.mergeOperands, encodeExpiry: m.expiringValues}
 }
 
 func NewMemStore() MemStoreI {
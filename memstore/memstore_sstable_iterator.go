@@ -2,12 +2,18 @@ package memstore
 
 import (
 	"errors"
+	"time"
+
 	"github.com/thomasjungblut/go-sstables/skiplist"
 	"github.com/thomasjungblut/go-sstables/sstables"
 )
 
 type SkipListSStableIterator struct {
 	iterator skiplist.IteratorI[[]byte, ValueStruct]
+	// encode returns the values encoded as sstables.MergeValue
+	encode bool
+	// encodeExpiry returns the values encoded with sstables.EncodeExpiringValue
+	encodeExpiry bool
 }
 
 func (s SkipListSStableIterator) Next() ([]byte, []byte, error) {
@@ -19,5 +25,70 @@ func (s SkipListSStableIterator) Next() ([]byte, []byte, error) {
 			return nil, nil, err
 		}
 	}
-	return key, *val.value, nil
+	return key, entryValue(val, s.encode, s.encodeExpiry, time.Now()), nil
+}
+
+type SkipListSeekableSStableIterator struct {
+	iterator skiplist.SeekableIteratorI[[]byte, ValueStruct]
+	// encode returns the values encoded as sstables.MergeValue
+	encode bool
+	// encodeExpiry returns the values encoded with sstables.EncodeExpiringValue
+	encodeExpiry bool
+}
+
+func (s SkipListSeekableSStableIterator) Next() ([]byte, []byte, error) {
+	key, val, err := s.iterator.Next()
+	return toSStableEntry(key, val, s.encode, s.encodeExpiry, err)
+}
+
+func (s SkipListSeekableSStableIterator) Prev() ([]byte, []byte, error) {
+	key, val, err := s.iterator.Prev()
+	return toSStableEntry(key, val, s.encode, s.encodeExpiry, err)
+}
+
+func (s SkipListSeekableSStableIterator) Seek(key []byte) error {
+	return s.iterator.Seek(key)
+}
+
+func (s SkipListSeekableSStableIterator) SeekForPrev(key []byte) error {
+	return s.iterator.SeekForPrev(key)
+}
+
+func (s SkipListSeekableSStableIterator) SeekToFirst() error {
+	return s.iterator.SeekToFirst()
+}
+
+func (s SkipListSeekableSStableIterator) SeekToLast() error {
+	return s.iterator.SeekToLast()
+}
+
+func (s SkipListSeekableSStableIterator) Valid() bool {
+	return s.iterator.Valid()
+}
+
+func toSStableEntry(key []byte, val ValueStruct, encode bool, encodeExpiry bool, err error) ([]byte, []byte, error) {
+	if err != nil {
+		if errors.Is(err, skiplist.Done) {
+			return nil, nil, sstables.Done
+		} else {
+			return nil, nil, err
+		}
+	}
+	return key, entryValue(val, encode, encodeExpiry, time.Now()), nil
+}
+
+// entryValue returns expired values as tombstones, all other values are encoded as requested.
+func entryValue(val ValueStruct, encode bool, encodeExpiry bool, now time.Time) []byte {
+	if val.expired(now) {
+		return []byte{}
+	}
+
+	value := *val.value
+	if encode {
+		value = val.mergeValue().Encode()
+	}
+	if encodeExpiry && value != nil {
+		return sstables.EncodeExpiringValue(value, *val.expiresAt)
+	}
+	return value
 }
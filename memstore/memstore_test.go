@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -345,6 +346,44 @@ func TestMemStoreSStableIteratorWithTombstones(t *testing.T) {
 	assert.Equal(t, sstables.Done, err)
 }
 
+func TestMemStoreSeekableIteratorReverse(t *testing.T) {
+	m := newMemStoreTest()
+	assert.Nil(t, m.Upsert([]byte("akey"), []byte("aval")))
+	assert.Nil(t, m.Upsert([]byte("bkey"), []byte("bval")))
+	assert.Nil(t, m.Upsert([]byte("ckey"), []byte("cval")))
+	assert.Nil(t, m.Delete([]byte("bkey")))
+
+	it := m.SeekableIterator()
+	assert.True(t, it.Valid())
+	require.NoError(t, it.SeekToLast())
+	for _, e := range []string{"c", "b", "a"} {
+		actualKey, actualValue, err := it.Prev()
+		require.NoError(t, err)
+		assert.Equal(t, e+"key", string(actualKey))
+		if e == "b" {
+			assert.Nil(t, actualValue)
+		} else {
+			assert.Equal(t, e+"val", string(actualValue))
+		}
+	}
+	assert.False(t, it.Valid())
+	_, _, err := it.Prev()
+	assert.Equal(t, sstables.Done, err)
+
+	require.NoError(t, it.SeekForPrev([]byte("bz")))
+	actualKey, _, err := it.Prev()
+	require.NoError(t, err)
+	assert.Equal(t, "bkey", string(actualKey))
+
+	require.NoError(t, it.Seek([]byte("bz")))
+	actualKey, actualValue, err := it.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "ckey", string(actualKey))
+	assert.Equal(t, "cval", string(actualValue))
+	_, _, err = it.Next()
+	assert.Equal(t, sstables.Done, err)
+}
+
 func TestMemStoreTombstoneExistingKey(t *testing.T) {
 	m := newMemStoreTest()
 	assert.Equal(t, 0, m.Size())
@@ -374,6 +413,162 @@ func TestMemStoreTombstoneNotExistingKey(t *testing.T) {
 	assert.Equal(t, KeyTombstoned, err)
 }
 
+// appendMergeOperator appends the operands to the existing value.
+type appendMergeOperator struct{}
+
+func (appendMergeOperator) FullMerge(_ []byte, existing []byte, operands [][]byte) ([]byte, error) {
+	merged := append([]byte{}, existing...)
+	for _, operand := range operands {
+		merged = append(merged, operand...)
+	}
+	return merged, nil
+}
+
+func (appendMergeOperator) PartialMerge(_ []byte, _ [][]byte) ([]byte, bool) {
+	return nil, false
+}
+
+func TestMemStoreMergeExistingKeys(t *testing.T) {
+	m := newMemStoreTest()
+	assert.Nil(t, m.Upsert([]byte("a"), []byte("a")))
+	assert.Nil(t, m.Tombstone([]byte("b")))
+
+	assert.Nil(t, m.Merge([]byte("a"), []byte("b"), appendMergeOperator{}))
+	assert.Nil(t, m.Merge([]byte("b"), []byte("c"), appendMergeOperator{}))
+	assert.Equal(t, []byte("ab"), getValue(t, m, "a"))
+	assert.Equal(t, []byte("c"), getValue(t, m, "b"))
+	assert.Equal(t, uint64(5), m.estimatedSize)
+
+	assert.Equal(t, sstables.MergeOperandFound, m.Merge([]byte("a"), []byte("b"), nil))
+	assert.Equal(t, ValueNil, m.Merge([]byte("a"), nil, appendMergeOperator{}))
+	assert.Equal(t, KeyNil, m.Merge(nil, []byte("a"), appendMergeOperator{}))
+}
+
+func getValue(t *testing.T, m *MemStore, key string) []byte {
+	v, err := m.Get([]byte(key))
+	require.Nil(t, err)
+	return v
+}
+
+func TestMemStoreMergeOperands(t *testing.T) {
+	m := newMemStoreTest()
+	assert.Nil(t, m.Merge([]byte("a"), []byte("1"), appendMergeOperator{}))
+	assert.Nil(t, m.Merge([]byte("a"), []byte("2"), appendMergeOperator{}))
+	assert.True(t, m.Contains([]byte("a")))
+	assert.False(t, m.IsTombstoned([]byte("a")))
+	assert.Equal(t, uint64(3), m.estimatedSize)
+
+	_, err := m.Get([]byte("a"))
+	assert.Equal(t, sstables.MergeOperandFound, err)
+	v, err := m.GetMergeValue([]byte("a"))
+	require.Nil(t, err)
+	assert.Equal(t, sstables.MergeValue{Kind: sstables.ValueKindMerge, Operands: [][]byte{[]byte("1"), []byte("2")}}, v)
+	_, err = m.GetMergeValue([]byte("b"))
+	assert.Equal(t, KeyNotFound, err)
+
+	assert.Equal(t, KeyAlreadyExists, m.Add([]byte("a"), []byte("3")))
+	assert.Nil(t, m.Upsert([]byte("a"), []byte("3")))
+	assert.Equal(t, uint64(2), m.estimatedSize)
+	v, err = m.GetMergeValue([]byte("a"))
+	require.Nil(t, err)
+	assert.Equal(t, sstables.MergeValue{Kind: sstables.ValueKindPut, Value: []byte("3")}, v)
+
+	assert.Nil(t, m.Merge([]byte("c"), []byte("1"), appendMergeOperator{}))
+	assert.Nil(t, m.Delete([]byte("c")))
+	assert.True(t, m.IsTombstoned([]byte("c")))
+	assert.Equal(t, uint64(3), m.estimatedSize)
+}
+
+func TestMemStoreFlushMergeOperands(t *testing.T) {
+	m := newMemStoreTest()
+	assert.Nil(t, m.Upsert([]byte("a"), []byte("aval")))
+	assert.Nil(t, m.Merge([]byte("b"), []byte("1"), appendMergeOperator{}))
+	assert.Nil(t, m.Tombstone([]byte("c")))
+
+	expected := [][]byte{
+		sstables.MergeValue{Kind: sstables.ValueKindPut, Value: []byte("aval")}.Encode(),
+		sstables.MergeValue{Kind: sstables.ValueKindMerge, Operands: [][]byte{[]byte("1")}}.Encode(),
+		{},
+	}
+	it := m.SStableIterator()
+	for _, e := range expected {
+		_, v, err := it.Next()
+		require.Nil(t, err)
+		assert.Equal(t, e, v)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "memstore_flush")
+	require.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(tmpDir)) }()
+	require.Nil(t, m.FlushWithTombstones(sstables.WriteBasePath(tmpDir)))
+
+	reader, err := sstables.NewSSTableReader(
+		sstables.ReadBasePath(tmpDir),
+		sstables.ReadWithKeyComparator(m.comparator))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.True(t, reader.MetaData().MergeOperands)
+
+	for i, key := range []string{"a", "b", "c"} {
+		v, err := reader.Get([]byte(key))
+		require.Nil(t, err)
+		assert.Equal(t, expected[i], v)
+	}
+}
+
+func TestMemStoreUpsertWithExpiry(t *testing.T) {
+	m := newMemStoreTest()
+	assert.Nil(t, m.UpsertWithExpiry([]byte("a"), []byte("aval"), time.Now().Add(-time.Hour)))
+	assert.Nil(t, m.UpsertWithExpiry([]byte("b"), []byte("bval"), time.Now().Add(time.Hour)))
+	assert.Nil(t, m.Upsert([]byte("c"), []byte("cval")))
+
+	_, err := m.Get([]byte("a"))
+	assert.ErrorIs(t, err, KeyExpired)
+	assert.ErrorIs(t, err, KeyTombstoned)
+	assert.False(t, m.Contains([]byte("a")))
+	assert.True(t, m.IsTombstoned([]byte("a")))
+	assert.Equal(t, []byte("bval"), getValue(t, m, "b"))
+	assert.True(t, m.Contains([]byte("b")))
+
+	// merging onto an expired value starts from scratch
+	assert.Nil(t, m.Merge([]byte("a"), []byte("1"), appendMergeOperator{}))
+	assert.Equal(t, []byte("1"), getValue(t, m, "a"))
+	assert.Nil(t, m.UpsertWithExpiry([]byte("a"), []byte("aval"), time.Now().Add(-time.Hour)))
+	assert.Nil(t, m.Add([]byte("a"), []byte("aval2")))
+	assert.Equal(t, []byte("aval2"), getValue(t, m, "a"))
+	assert.Nil(t, m.UpsertWithExpiry([]byte("a"), []byte("aval"), time.Now().Add(-time.Hour)))
+
+	tmpDir, err := os.MkdirTemp("", "memstore_flush")
+	require.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(tmpDir)) }()
+	require.Nil(t, m.Flush(sstables.WriteBasePath(tmpDir)))
+
+	reader, err := sstables.NewSSTableReader(
+		sstables.ReadBasePath(tmpDir),
+		sstables.ReadWithKeyComparator(m.comparator))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.True(t, reader.MetaData().ExpiringValues)
+	assert.Equal(t, uint64(2), reader.MetaData().NumRecords)
+	_, err = reader.Get([]byte("a"))
+	assert.ErrorIs(t, err, sstables.NotFound)
+	for _, key := range []string{"b", "c"} {
+		v, err := reader.Get([]byte(key))
+		require.Nil(t, err)
+		assert.Equal(t, []byte(key+"val"), v)
+	}
+
+	it := m.SStableIterator()
+	_, v, err := it.Next()
+	require.Nil(t, err)
+	assert.Equal(t, []byte{}, v)
+	_, v, err = it.Next()
+	require.Nil(t, err)
+	value, _, err := sstables.DecodeExpiringValue(v)
+	require.Nil(t, err)
+	assert.Equal(t, []byte("bval"), value)
+}
+
 func closeReader(t *testing.T, reader sstables.SSTableReaderI) {
 	func() { assert.Nil(t, reader.Close()) }()
 }
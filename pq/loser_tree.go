@@ -0,0 +1,137 @@
+package pq
+
+import (
+	"errors"
+	"fmt"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+type leaf[K any, V any, CTX any] struct {
+	key       K
+	value     V
+	exhausted bool
+	iterator  IteratorWithContext[K, V, CTX]
+}
+
+// LoserTree is a tournament tree over k iterators. Every inner node stores the loser of the match between the
+// winners of its two subtrees, the overall winner is kept at the root. Replacing the winner replays only the matches
+// on the path from its leaf to the root, which takes exactly log2(k) comparisons. A binary heap needs up to twice
+// as many, since it compares both children at every level.
+// Keys that are equal are returned in the order of their iterators.
+type LoserTree[K any, V any, CTX any] struct {
+	leaves []*leaf[K, V, CTX]
+	// tree[0] is the index of the winning leaf, tree[1:] are the losers of the inner nodes
+	tree []int
+	comp skiplist.Comparator[K]
+}
+
+// beats returns true if leaf a is returned before leaf b.
+func (t *LoserTree[K, V, CTX]) beats(a, b int) bool {
+	la, lb := t.leaves[a], t.leaves[b]
+	if la.exhausted {
+		return false
+	}
+	if lb.exhausted {
+		return true
+	}
+
+	c := t.comp.Compare(la.key, lb.key)
+	if c == 0 {
+		return a < b
+	}
+	return c < 0
+}
+
+func (t *LoserTree[K, V, CTX]) fillNext(l *leaf[K, V, CTX]) error {
+	k, v, err := l.iterator.Next()
+	if err != nil {
+		if errors.Is(err, Done) {
+			l.exhausted = true
+			return nil
+		}
+		return err
+	}
+
+	l.key = k
+	l.value = v
+	return nil
+}
+
+func (t *LoserTree[K, V, CTX]) init(iterators []IteratorWithContext[K, V, CTX]) error {
+	k := len(iterators)
+	for _, it := range iterators {
+		l := &leaf[K, V, CTX]{iterator: it}
+		err := t.fillNext(l)
+		if err != nil {
+			return fmt.Errorf("INIT couldn't fill next leaf: %w", err)
+		}
+		t.leaves = append(t.leaves, l)
+	}
+
+	if k == 0 {
+		return nil
+	}
+
+	// the leaves are the nodes k to 2k-1, the parent of node i is i/2
+	winners := make([]int, 2*k)
+	for i := 0; i < k; i++ {
+		winners[k+i] = i
+	}
+
+	t.tree = make([]int, k)
+	for node := k - 1; node > 0; node-- {
+		left, right := winners[2*node], winners[2*node+1]
+		if t.beats(left, right) {
+			winners[node], t.tree[node] = left, right
+		} else {
+			winners[node], t.tree[node] = right, left
+		}
+	}
+
+	if k == 1 {
+		t.tree[0] = 0
+	} else {
+		t.tree[0] = winners[1]
+	}
+
+	return nil
+}
+
+func (t *LoserTree[K, V, CTX]) Next() (_ K, _ V, _ CTX, err error) {
+	err = Done
+	if len(t.leaves) == 0 {
+		return
+	}
+
+	winner := t.tree[0]
+	l := t.leaves[winner]
+	if l.exhausted {
+		return
+	}
+
+	k, v, c := l.key, l.value, l.iterator.Context()
+	err = t.fillNext(l)
+	if err != nil {
+		err = fmt.Errorf("NEXT couldn't fill next leaf: %w", err)
+		return
+	}
+
+	// replay the matches on the path to the root, the winner of each one moves up
+	for node := (winner + len(t.leaves)) / 2; node > 0; node /= 2 {
+		if t.beats(t.tree[node], winner) {
+			t.tree[node], winner = winner, t.tree[node]
+		}
+	}
+	t.tree[0] = winner
+
+	return k, v, c, nil
+}
+
+func NewLoserTree[K any, V any, CTX any](comp skiplist.Comparator[K], iterators []IteratorWithContext[K, V, CTX]) (PriorityQueueI[K, V, CTX], error) {
+	t := &LoserTree[K, V, CTX]{comp: comp}
+	err := t.init(iterators)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,87 @@
+package pq
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+type failingIterator struct {
+	SliceIterator
+	err error
+}
+
+func (f *failingIterator) Next() (int, int, error) {
+	if f.currentIndex >= len(f.list) {
+		return 0, 0, f.err
+	}
+	return f.SliceIterator.Next()
+}
+
+func TestLoserTreeEqualKeysInIteratorOrder(t *testing.T) {
+	var iterators []IteratorWithContext[int, int, int]
+	for i := 0; i < 5; i++ {
+		iterators = append(iterators, &SliceIterator{list: []int{1, 2, 3}, context: i})
+	}
+
+	tree, err := NewLoserTree[int, int, int](skiplist.OrderedComparator[int]{}, iterators)
+	require.Nil(t, err)
+
+	for k := 1; k <= 3; k++ {
+		for i := 0; i < 5; i++ {
+			key, _, ctx, err := tree.Next()
+			require.Nil(t, err)
+			assert.Equal(t, k, key)
+			assert.Equal(t, i, ctx)
+		}
+	}
+
+	_, _, _, err = tree.Next()
+	assert.ErrorIs(t, err, Done)
+}
+
+func TestLoserTreeRandomLists(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	for numLists := 1; numLists <= 17; numLists++ {
+		var lists [][]int
+		for i := 0; i < numLists; i++ {
+			list := make([]int, r.Intn(50))
+			for j := range list {
+				list[j] = r.Intn(100)
+			}
+			sort.Ints(list)
+			lists = append(lists, list)
+		}
+		assertMergeAndListMatches(t, lists...)
+	}
+}
+
+func TestLoserTreeNoIterators(t *testing.T) {
+	tree, err := NewLoserTree[int, int, int](skiplist.OrderedComparator[int]{}, nil)
+	require.Nil(t, err)
+	_, _, _, err = tree.Next()
+	assert.ErrorIs(t, err, Done)
+}
+
+func TestLoserTreePropagatesErrors(t *testing.T) {
+	expectedErr := errors.New("read failure")
+	_, err := NewLoserTree[int, int, int](skiplist.OrderedComparator[int]{}, []IteratorWithContext[int, int, int]{
+		&failingIterator{err: expectedErr},
+	})
+	assert.ErrorIs(t, err, expectedErr)
+
+	tree, err := NewLoserTree[int, int, int](skiplist.OrderedComparator[int]{}, []IteratorWithContext[int, int, int]{
+		&SliceIterator{list: []int{2, 3}},
+		&failingIterator{SliceIterator: SliceIterator{list: []int{1}}, err: expectedErr},
+	})
+	require.Nil(t, err)
+
+	// the error surfaces when the iterator is advanced after its last key
+	_, _, _, err = tree.Next()
+	assert.ErrorIs(t, err, expectedErr)
+}
@@ -82,29 +82,38 @@ func TestMultiListAllEmpty(t *testing.T) {
 	assertMergeAndListMatches(t, []int{}, []int{}, []int{})
 }
 
-func assertMergeAndListMatches(t *testing.T, lists ...[]int) {
-	var iterators []IteratorWithContext[int, int, int]
-	var expected []int
-
-	for _, v := range lists {
-		iterators = append(iterators, &SliceIterator{list: v, context: len(v)})
-		expected = append(expected, v...)
-	}
-
-	pq, err := NewPriorityQueue[int, int, int](skiplist.OrderedComparator[int]{}, iterators)
-	require.Nil(t, err)
-
-	var actualKeys []int
-	for {
-		k, v, _, err := pq.Next()
-		if errors.Is(err, Done) {
-			break
-		}
+var queueConstructors = map[string]func(skiplist.Comparator[int], []IteratorWithContext[int, int, int]) (PriorityQueueI[int, int, int], error){
+	"heap":      NewPriorityQueue[int, int, int],
+	"loserTree": NewLoserTree[int, int, int],
+}
 
-		assert.Equal(t, k+1, v)
-		actualKeys = append(actualKeys, k)
+func assertMergeAndListMatches(t *testing.T, lists ...[]int) {
+	for name, newQueue := range queueConstructors {
+		t.Run(name, func(t *testing.T) {
+			var iterators []IteratorWithContext[int, int, int]
+			var expected []int
+
+			for _, v := range lists {
+				iterators = append(iterators, &SliceIterator{list: v, context: len(v)})
+				expected = append(expected, v...)
+			}
+
+			pq, err := newQueue(skiplist.OrderedComparator[int]{}, iterators)
+			require.Nil(t, err)
+
+			var actualKeys []int
+			for {
+				k, v, _, err := pq.Next()
+				if errors.Is(err, Done) {
+					break
+				}
+
+				assert.Equal(t, k+1, v)
+				actualKeys = append(actualKeys, k)
+			}
+
+			sort.Ints(expected)
+			assert.Exactly(t, expected, actualKeys)
+		})
 	}
-
-	sort.Ints(expected)
-	assert.Exactly(t, expected, actualKeys)
 }
@@ -14,7 +14,7 @@ type FileReader struct {
 	closed bool
 
 	currentOffset uint64
-	file          *os.File
+	file          namedReadSeekCloser
 	header        *Header
 	reader        ByteReaderResetCount
 	bufferPool    *pool.Pool
@@ -450,6 +450,7 @@ func readNextV3(r *FileReader) ([]byte, error) {
 type FileReaderOptions struct {
 	path            string
 	file            *os.File
+	readerAt        *sectionFile
 	bufferSizeBytes int
 	factory         IOFactory
 }
@@ -472,6 +473,14 @@ func ReaderFile(p *os.File) FileReaderOption {
 	}
 }
 
+// ReaderFromReaderAt reads the recordio file of the given size from r, for example a file that is stored remotely.
+// The name is only used in error messages. Either this, Path or File must be supplied.
+func ReaderFromReaderAt(r io.ReaderAt, size int64, name string) FileReaderOption {
+	return func(args *FileReaderOptions) {
+		args.readerAt = newSectionFile(r, size, name)
+	}
+}
+
 // BufferSizeBytes sets the IoFactory, by default it uses BufferedIOFactory.
 func ReaderIoFactory(factory IOFactory) FileReaderOption {
 	return func(args *FileReaderOptions) {
@@ -500,6 +509,18 @@ func NewFileReader(readerOptions ...FileReaderOption) (ReaderI, error) {
 		readOption(opts)
 	}
 
+	if opts.readerAt != nil {
+		if opts.file != nil || opts.path != "" {
+			return nil, errors.New("NewFileReader: either io.ReaderAt, os.File or string path must be supplied, never multiple")
+		}
+
+		block := make([]byte, opts.bufferSizeBytes)
+		return &FileReader{
+			file:   opts.readerAt,
+			reader: NewCountingByteReader(NewReaderBuf(opts.readerAt, block)),
+		}, nil
+	}
+
 	if (opts.file == nil) == (opts.path == "") {
 		return nil, errors.New("NewFileReader: either os.File or string path must be supplied, never both")
 	}
@@ -232,6 +232,29 @@ func (w *FileWriter) Write(record []byte) (uint64, error) {
 	return prevOffset, nil
 }
 
+// WriteRaw appends a record that was read with ReadRawAt from a file with the same compression type, without
+// compressing it again. Returns the current offset this item was written to.
+func (w *FileWriter) WriteRaw(record RawRecord) (uint64, error) {
+	if !w.open || w.closed {
+		return 0, errors.New("writer was either not opened yet or is closed already")
+	}
+
+	if record.CompressionType != w.compressionType {
+		return 0, fmt.Errorf("can't write raw record with compression type %d into file at '%s' with compression type %d",
+			record.CompressionType, w.file.Name(), w.compressionType)
+	}
+
+	prevOffset := w.currentOffset
+	bytesWritten, err := w.bufWriter.Write(record.Bytes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write raw record in file at '%s' failed with %w", w.file.Name(), err)
+	}
+
+	w.currentOffset = prevOffset + uint64(bytesWritten)
+	w.largestOffset = max(w.largestOffset, w.currentOffset)
+	return prevOffset, nil
+}
+
 // WriteSync appends a record of bytes and forces a disk sync, returns the current offset this item was written to.
 // When directIO is enabled however, we can't write misaligned blocks and immediately returns DirectIOSyncWriteErr
 func (w *FileWriter) WriteSync(record []byte) (uint64, error) {
@@ -285,6 +308,10 @@ func (w *FileWriter) Size() uint64 {
 	return w.currentOffset
 }
 
+func (w *FileWriter) CompressionType() int {
+	return w.compressionType
+}
+
 func (w *FileWriter) Seek(offset uint64) error {
 	if offset < w.headerOffset {
 		return fmt.Errorf("can't seek into the header range, supplied: %d header: %d", offset, w.headerOffset)
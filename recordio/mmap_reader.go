@@ -12,8 +12,9 @@ import (
 	pool "capnproto.org/go/capnp/v3/exp/bufferpool"
 )
 
+// MMapReader reads records at random offsets, either from a memory mapped file or any other io.ReaderAt.
 type MMapReader struct {
-	mmapReader *mmap.ReaderAt
+	mmapReader sizedReaderAt
 	header     *Header
 	open       bool
 	closed     bool
@@ -202,6 +203,63 @@ func (r *MMapReader) ReadNextAt(offset uint64) ([]byte, error) {
 	}
 }
 
+func (r *MMapReader) Version() uint32 {
+	return r.header.fileVersion
+}
+
+func (r *MMapReader) CompressionType() int {
+	return r.header.compressionType
+}
+
+// ReadRawAt reads the record at the given offset including its header, without decompressing its payload.
+func (r *MMapReader) ReadRawAt(offset uint64) (RawRecord, error) {
+	if !r.open || r.closed {
+		return RawRecord{}, fmt.Errorf("reader at '%s' was either not opened yet or is closed already", r.path)
+	}
+	if r.header.fileVersion != CurrentVersion {
+		return RawRecord{}, fmt.Errorf("raw reads are unsupported on files with version %d in mmap reader for '%s'", r.header.fileVersion, r.path)
+	}
+
+	headerBuf := make([]byte, RecordHeaderV4MaxSizeBytes)
+	numRead, err := r.mmapReader.ReadAt(headerBuf, int64(offset))
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			return RawRecord{}, fmt.Errorf("ReadRawAt failed reading at offset %d in mmap reader for '%s': %w", offset, r.path, err)
+		}
+		if numRead == 0 {
+			return RawRecord{}, io.EOF
+		}
+	}
+
+	headerByteReader := newChecksumByteReader(bytes.NewReader(headerBuf[:numRead]), make([]byte, RecordHeaderV4MaxSizeBytes))
+	payloadSizeUncompressed, payloadSizeCompressed, recordNil, err := readRecordHeaderV4(headerByteReader)
+	if err != nil {
+		return RawRecord{}, fmt.Errorf("failed reading record header at offset %d in mmap reader for '%s': %w", offset, r.path, err)
+	}
+
+	recordSize := uint64(headerByteReader.Count())
+	if !recordNil {
+		if r.header.compressor != nil {
+			recordSize += payloadSizeCompressed
+		} else {
+			recordSize += payloadSizeUncompressed
+		}
+	}
+
+	recordBuf := make([]byte, recordSize)
+	numRead, err = r.mmapReader.ReadAt(recordBuf, int64(offset))
+	if err != nil && !(errors.Is(err, io.EOF) && numRead == len(recordBuf)) {
+		return RawRecord{}, fmt.Errorf("failed reading record at offset %d in mmap reader for '%s': %w", offset, r.path, err)
+	}
+
+	return RawRecord{
+		Bytes:                   recordBuf,
+		CompressionType:         r.header.compressionType,
+		PayloadSizeUncompressed: payloadSizeUncompressed,
+		Nil:                     recordNil,
+	}, nil
+}
+
 func readNextAtV1(r *MMapReader, offset uint64) ([]byte, error) {
 	headerBufPooled := r.bufferPool.Get(RecordHeaderSizeBytesV1V2)
 	defer r.bufferPool.Put(headerBufPooled)
@@ -270,3 +270,70 @@ func newTestMMapReader(file string, t *testing.T) *MMapReader {
 	require.NoError(t, err)
 	return r.(*MMapReader)
 }
+
+func TestMMapReaderReadRawAtCopiesRecords(t *testing.T) {
+	writer, err := newCompressedTestWriter(CompressionTypeSnappy)
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	defer removeFileWriterFile(t, writer)
+	var offsets []uint64
+	for _, record := range [][]byte{ascendingBytes(100), nil, {}, randomRecordOfSize(13)} {
+		offset, err := writer.Write(record)
+		require.NoError(t, err)
+		offsets = append(offsets, offset)
+	}
+	require.NoError(t, writer.Close())
+
+	reader := newOpenedTestMMapReader(t, writer.file.Name())
+	defer closeMMapReader(t, reader)
+	require.Equal(t, CurrentVersion, reader.Version())
+	require.Equal(t, CompressionTypeSnappy, reader.CompressionType())
+
+	copyWriter, err := newCompressedTestWriter(CompressionTypeSnappy)
+	require.NoError(t, err)
+	require.NoError(t, copyWriter.Open())
+	defer removeFileWriterFile(t, copyWriter)
+	for _, offset := range offsets {
+		record, err := reader.ReadRawAt(offset)
+		require.NoError(t, err)
+		copyOffset, err := copyWriter.WriteRaw(record)
+		require.NoError(t, err)
+		// the file headers are the same, thus the records end up at the same offsets
+		require.Equal(t, offset, copyOffset)
+	}
+	require.Equal(t, writer.Size(), copyWriter.Size())
+	require.NoError(t, copyWriter.Close())
+
+	nilRecord, err := reader.ReadRawAt(offsets[1])
+	require.NoError(t, err)
+	require.True(t, nilRecord.Nil)
+	record, err := reader.ReadRawAt(offsets[0])
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), record.PayloadSizeUncompressed)
+	require.False(t, record.Nil)
+	_, err = reader.ReadRawAt(writer.Size())
+	require.ErrorIs(t, err, io.EOF)
+
+	copyReader := newOpenedTestMMapReader(t, copyWriter.file.Name())
+	defer closeMMapReader(t, copyReader)
+	buf, err := copyReader.ReadNextAt(offsets[0])
+	require.NoError(t, err)
+	assertAscendingBytes(t, buf, 100)
+	buf, err = copyReader.ReadNextAt(offsets[1])
+	require.NoError(t, err)
+	require.Nil(t, buf)
+
+	uncompressedWriter := newOpenedWriter(t)
+	defer removeFileWriterFile(t, uncompressedWriter)
+	_, err = uncompressedWriter.WriteRaw(record)
+	require.ErrorContains(t, err, "can't write raw record with compression type 2")
+	require.NoError(t, uncompressedWriter.Close())
+}
+
+func TestMMapReaderReadRawAtUnsupportedVersion(t *testing.T) {
+	reader := newOpenedTestMMapReader(t, "test_files/v3_compat/recordio_UncompressedSingleRecord")
+	defer closeMMapReader(t, reader)
+
+	_, err := reader.ReadRawAt(FileHeaderSizeBytes)
+	require.ErrorContains(t, err, "raw reads are unsupported on files with version 3")
+}
@@ -1,6 +1,8 @@
 package proto
 
 import (
+	"io"
+
 	"github.com/thomasjungblut/go-sstables/recordio"
 	"google.golang.org/protobuf/proto"
 )
@@ -45,3 +47,14 @@ func NewMMapProtoReaderWithPath(path string) (ReadAtI, error) {
 
 	return &MMapProtoReader{r}, nil
 }
+
+// NewReaderAtProtoReader creates a random access proto reader that reads the recordio file of the given size from r.
+// The name is only used in error messages.
+func NewReaderAtProtoReader(r io.ReaderAt, size int64, name string) (ReadAtI, error) {
+	reader, err := recordio.NewReaderAtReader(r, size, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MMapProtoReader{reader}, nil
+}
@@ -3,6 +3,7 @@ package proto
 import (
 	"errors"
 	"google.golang.org/protobuf/encoding/protowire"
+	"io"
 	"os"
 
 	"github.com/thomasjungblut/go-sstables/recordio"
@@ -33,6 +34,8 @@ func (r *Reader) ReadNext(record gproto.Message) (gproto.Message, error) {
 type ReaderOptions struct {
 	path         string
 	file         *os.File
+	readerAt     io.ReaderAt
+	readerAtSize int64
 	bufSizeBytes int
 }
 
@@ -50,6 +53,14 @@ func ReaderFile(p *os.File) ReaderOption {
 	}
 }
 
+// ReaderFromReaderAt reads the recordio file of the given size from r, path is then only used in error messages.
+func ReaderFromReaderAt(r io.ReaderAt, size int64) ReaderOption {
+	return func(args *ReaderOptions) {
+		args.readerAt = r
+		args.readerAtSize = size
+	}
+}
+
 func ReadBufferSizeBytes(p int) ReaderOption {
 	return func(args *ReaderOptions) {
 		args.bufSizeBytes = p
@@ -72,15 +83,27 @@ func NewReader(readerOptions ...ReaderOption) (ReaderI, error) {
 		return nil, errors.New("either os.File or string path must be supplied, never both")
 	}
 
-	if opts.file == nil {
-		if opts.path == "" {
-			return nil, errors.New("path was not supplied")
+	var reader recordio.ReaderI
+	var err error
+	if opts.readerAt != nil {
+		if opts.file != nil {
+			return nil, errors.New("either io.ReaderAt or os.File must be supplied, never both")
+		}
+
+		reader, err = recordio.NewFileReader(
+			recordio.ReaderFromReaderAt(opts.readerAt, opts.readerAtSize, opts.path),
+			recordio.ReaderBufferSizeBytes(opts.bufSizeBytes))
+	} else {
+		if opts.file == nil {
+			if opts.path == "" {
+				return nil, errors.New("path was not supplied")
+			}
 		}
+		reader, err = recordio.NewFileReader(
+			recordio.ReaderPath(opts.path),
+			recordio.ReaderFile(opts.file),
+			recordio.ReaderBufferSizeBytes(opts.bufSizeBytes))
 	}
-	reader, err := recordio.NewFileReader(
-		recordio.ReaderPath(opts.path),
-		recordio.ReaderFile(opts.file),
-		recordio.ReaderBufferSizeBytes(opts.bufSizeBytes))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,50 @@
+package recordio
+
+import (
+	"fmt"
+	"io"
+)
+
+// namedReadSeekCloser is the subset of os.File the FileReader needs.
+type namedReadSeekCloser interface {
+	io.ReadSeekCloser
+	Name() string
+}
+
+// sizedReaderAt is the subset of mmap.ReaderAt the MMapReader needs.
+type sizedReaderAt interface {
+	io.ReaderAt
+	io.Closer
+	Len() int
+}
+
+// sectionFile exposes an io.ReaderAt of a known size as a file, closing it is a no-op.
+type sectionFile struct {
+	*io.SectionReader
+	name string
+}
+
+func (f *sectionFile) Name() string {
+	return f.name
+}
+
+func (f *sectionFile) Len() int {
+	return int(f.Size())
+}
+
+func (f *sectionFile) Close() error {
+	return nil
+}
+
+func newSectionFile(r io.ReaderAt, size int64, name string) *sectionFile {
+	return &sectionFile{SectionReader: io.NewSectionReader(r, 0, size), name: name}
+}
+
+// NewReaderAtReader creates a new random access reader that reads the recordio file of the given size from r, for
+// example a file that is stored remotely. The name is only used in error messages. r must be safe for concurrent use.
+func NewReaderAtReader(r io.ReaderAt, size int64, name string) (ReadAtI, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("invalid size %d of '%s'", size, name)
+	}
+	return &MMapReader{mmapReader: newSectionFile(r, size, name), path: name, seekLen: 4 * 1024}, nil
+}
@@ -0,0 +1,79 @@
+package recordio
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestFileContent(t *testing.T, path string) []byte {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return content
+}
+
+func TestFileReaderFromReaderAt(t *testing.T) {
+	content := readTestFileContent(t, "test_files/v4_compat/recordio_SnappyWriterMultiRecord_asc")
+	r, err := NewFileReader(
+		ReaderFromReaderAt(bytes.NewReader(content), int64(len(content)), "in-memory"),
+		ReaderBufferSizeBytes(64))
+	require.NoError(t, err)
+	reader := r.(*FileReader)
+	require.NoError(t, reader.Open())
+	defer closeFileReader(t, reader)
+
+	for expectedLen := 0; expectedLen < 255; expectedLen++ {
+		if expectedLen%3 == 0 {
+			require.NoError(t, reader.SkipNext())
+			continue
+		}
+		buf, err := reader.ReadNext()
+		require.NoError(t, err)
+		assertAscendingBytes(t, buf, expectedLen)
+	}
+	readNextExpectEOF(t, reader)
+}
+
+func TestFileReaderFromReaderAtForbidsPath(t *testing.T) {
+	_, err := NewFileReader(
+		ReaderFromReaderAt(bytes.NewReader(nil), 0, "in-memory"),
+		ReaderPath("test_files/v4_compat/recordio_UncompressedSingleRecord"))
+	require.Error(t, err)
+}
+
+func TestReaderAtReader(t *testing.T) {
+	content := readTestFileContent(t, "test_files/v4_compat/recordio_UncompressedWriterMultiRecord_asc")
+	reader, err := NewReaderAtReader(bytes.NewReader(content), int64(len(content)), "in-memory")
+	require.NoError(t, err)
+	require.NoError(t, reader.Open())
+	defer func() { require.NoError(t, reader.Close()) }()
+	assert.Equal(t, uint64(len(content)), reader.Size())
+
+	mmapReader := newOpenedTestMMapReader(t, "test_files/v4_compat/recordio_UncompressedWriterMultiRecord_asc")
+	defer closeMMapReader(t, mmapReader)
+
+	// both readers must behave exactly the same
+	for offset := uint64(FileHeaderSizeBytes); offset < reader.Size(); offset += 97 {
+		expectedOffset, expectedBuf, expectedErr := mmapReader.SeekNext(offset)
+		off, buf, err := reader.SeekNext(offset)
+		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, expectedOffset, off)
+		assert.Equal(t, expectedBuf, buf)
+
+		if err == nil {
+			buf, err = reader.ReadNextAt(off)
+			require.NoError(t, err)
+			assert.Equal(t, expectedBuf, buf)
+		}
+	}
+
+	buf, err := reader.ReadNextAt(FileHeaderSizeBytes)
+	require.NoError(t, err)
+	assertAscendingBytes(t, buf, 0)
+
+	_, err = NewReaderAtReader(bytes.NewReader(content), -1, "in-memory")
+	require.Error(t, err)
+}
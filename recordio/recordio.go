@@ -104,6 +104,39 @@ type ReadAtI interface {
 	SeekNext(offset uint64) (uint64, []byte, error)
 }
 
+// RawRecord is a record as it is stored in a file of the CurrentVersion, including its header and the payload in its
+// compressed form.
+type RawRecord struct {
+	Bytes []byte
+	// CompressionType of the file the record was read from
+	CompressionType         int
+	PayloadSizeUncompressed uint64
+	Nil                     bool
+}
+
+// RawReadAtI is implemented by readers that can read records without decompressing them, which allows to copy records
+// between files of the same compression type using RawWriterI.
+type RawReadAtI interface {
+	ReadAtI
+	// Version returns the version of the opened file, only files of the CurrentVersion can be read raw.
+	Version() uint32
+	// CompressionType returns the compression type of the opened file.
+	CompressionType() int
+	// ReadRawAt reads the record at the given offset without decompressing it, EOF error when it reaches the end
+	// signalled by io.EOF. The header checksum is verified, the payload is not.
+	ReadRawAt(offset uint64) (RawRecord, error)
+}
+
+// RawWriterI is implemented by writers that can append records as they were read by RawReadAtI.
+type RawWriterI interface {
+	WriterI
+	// CompressionType returns the compression type of the file.
+	CompressionType() int
+	// WriteRaw appends the record as it is, returns the current offset this item was written to.
+	// An error is returned when the record has a different compression type than the file.
+	WriteRaw(record RawRecord) (uint64, error)
+}
+
 type ReaderWriterCloserFactory interface {
 	CreateNewReader(filePath string, bufSize int) (*os.File, ByteReaderResetCount, error)
 	CreateNewWriter(filePath string, bufSize int) (*os.File, WriteSeekerCloserFlusher, error)
@@ -2,6 +2,7 @@ package simpledb
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -73,65 +74,147 @@ func executeCompaction(db *DB) (compactionMetadata *proto.CompactionMetadata, er
 
 	log.Printf("starting compaction of %d files in %v with %v\n", len(paths), writeFolder, strings.Join(paths, ","))
 
-	writer, err := sstables.NewSSTableStreamWriter(
-		sstables.WriteBasePath(writeFolder),
-		sstables.WithKeyComparator(skiplist.BytesComparator{}),
-		sstables.BloomExpectedNumberOfElements(numRecords))
-	if err != nil {
-		return nil, err
-	}
-
-	err = writer.Open()
-	if err != nil {
-		return nil, err
-	}
-
+	var readers []sstables.SSTableReaderI
 	defer func() {
-		err = errors.Join(err, writer.Close())
+		for _, reader := range readers {
+			err = errors.Join(err, reader.Close())
+		}
 	}()
 
-	var readers []sstables.SSTableReaderI
-	var iterators []sstables.SSTableMergeIteratorContext
+	// separated values are copied by their reference, which requires the values to stay encoded. Merge operands and
+	// expiring values need to be decoded by the merger, those values are separated again by the writer instead.
+	encodedValues := db.enableValueSeparation && compactionAction.encodedValues
+
+	// the merge operands are only collapsed, since older tables that aren't part of the compaction might still
+	// contain values for their keys. Thus, the results need to keep the encoding if any of the tables had operands.
+	// The same applies to the expiry times of values that did not expire yet, expired values are dropped.
+	mergeOperands := false
+	expiringValues := false
 	for i := 0; i < len(paths); i++ {
-		reader, err := sstables.NewSSTableReader(
+		readerOptions := []sstables.ReadOption{
 			sstables.ReadBasePath(paths[i]),
 			sstables.ReadWithKeyComparator(db.cmp),
-		)
+			sstables.ReadWithBlobStore(db.blobStore),
+		}
+		if encodedValues {
+			readerOptions = append(readerOptions, sstables.ReadEncodedValues())
+		} else {
+			readerOptions = append(readerOptions, sstables.ReadEncodedExpiry())
+		}
+
+		reader, err := sstables.NewSSTableReader(readerOptions...)
 		if err != nil {
 			return nil, err
 		}
+		readers = append(readers, reader)
+		mergeOperands = mergeOperands || reader.MetaData().MergeOperands
+		expiringValues = expiringValues || reader.MetaData().ExpiringValues
+	}
 
-		scanner, err := reader.Scan()
+	tableOptions := []sstables.WriterOption{
+		sstables.WithKeyComparator(skiplist.BytesComparator{}),
+		sstables.BloomExpectedNumberOfElements(numRecords),
+	}
+	if mergeOperands {
+		tableOptions = append(tableOptions, sstables.WithMergeOperands())
+	}
+	if expiringValues {
+		tableOptions = append(tableOptions, sstables.WithExpiringValues())
+	}
+	if db.enableValueSeparation {
+		tableOptions = append(tableOptions,
+			sstables.WithValueSeparation(db.blobStore, db.blobThresholdBytes))
+	}
+	if encodedValues {
+		// the blob files that are mostly garbage have their remaining values copied, so they can be deleted after
+		rewriteFiles, err := db.sstableManager.blobFilesToRewrite(paths, db.blobGarbageRatio)
 		if err != nil {
 			return nil, err
 		}
-
-		readers = append(readers, reader)
-		iterators = append(iterators, sstables.NewMergeIteratorContext(i, scanner))
+		tableOptions = append(tableOptions, sstables.WriteEncodedValues(), sstables.RewriteBlobFiles(rewriteFiles...))
 	}
 
+	// the keys are split into ranges that are merged concurrently, every partition is written into its own folder
+	// and split into multiple tables once a table reaches the max size
+	var rollingWriters []*sstables.RollingSSTableWriter
+	var writers []sstables.SSTableStreamWriterI
 	defer func() {
-		for _, reader := range readers {
-			err = errors.Join(err, reader.Close())
+		for _, writer := range rollingWriters {
+			err = errors.Join(err, writer.Close())
 		}
 	}()
 
+	for i := 0; i < db.compactionPartitions; i++ {
+		writer, err := sstables.NewRollingSSTableWriter(
+			sstables.RollingBasePath(filepath.Join(writeFolder, fmt.Sprintf(CompactionPartitionPattern, i))),
+			sstables.RollAtSizeBytes(db.compactedMaxSizeBytes),
+			sstables.RollingTableOptions(tableOptions...))
+		if err != nil {
+			return nil, err
+		}
+
+		err = writer.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		rollingWriters = append(rollingWriters, writer)
+		writers = append(writers, writer)
+	}
+
+	// a compaction that includes the oldest table can drop the keys whose values have all expired
+	mergerOptions := []sstables.ParallelMergerOption{sstables.ParallelMergeOperator(db.mergeOperator)}
+	if compactionAction.bottommost {
+		mergerOptions = append(mergerOptions, sstables.ParallelBottommostMerge())
+	}
+
 	reduceFunc := sstables.ScanReduceLatestWinsSkipTombstones
-	err = sstables.NewSSTableMerger(db.cmp).MergeCompact(iterators, writer, reduceFunc)
+	err = sstables.NewParallelSSTableMerger(db.cmp, mergerOptions...).MergeCompactPartitioned(readers, writers, reduceFunc)
 	if err != nil {
 		return nil, err
 	}
 
+	var tables []sstables.RolledSSTable
+	for _, writer := range rollingWriters {
+		err = writer.Close()
+		if err != nil {
+			return nil, err
+		}
+		written, err := writer.Tables()
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, written...)
+	}
+
 	// in order to be portable, we are taking only relative paths from the db base path
 	// later in reconstruction they are "rebased" over the database base path
 	for i := 0; i < len(paths); i++ {
 		paths[i] = filepath.Base(paths[i])
 	}
 
+	// the partitions and their tables are ordered by their key ranges
+	var writePaths []string
+	for _, table := range tables {
+		relativePath, err := filepath.Rel(db.basePath, table.BasePath)
+		if err != nil {
+			return nil, err
+		}
+		writePaths = append(writePaths, relativePath)
+	}
+
+	nextPath := ""
+	if compactionAction.nextPath != "" {
+		nextPath = filepath.Base(compactionAction.nextPath)
+	}
+
 	compactionMetadata = &proto.CompactionMetadata{
-		WritePath:       filepath.Base(writeFolder),
-		ReplacementPath: paths[0],
-		SstablePaths:    paths,
+		WritePath:        filepath.Base(writeFolder),
+		ReplacementPath:  paths[0],
+		SstablePaths:     paths,
+		WritePaths:       writePaths,
+		ReplacementPaths: compactionReplacementPaths(paths[0], nextPath, len(writePaths)),
+		Version:          CompactionMetadataVersionSplitResults,
 	}
 
 	// at this point the compaction is finished, we save the metadata that this was successful for potential recoveries
@@ -140,11 +223,46 @@ func executeCompaction(db *DB) (compactionMetadata *proto.CompactionMetadata, er
 		return nil, err
 	}
 
-	log.Printf("done compacting %d sstables in %v. Path: [%s]\n", len(paths), time.Since(start), writeFolder)
+	log.Printf("done compacting %d sstables into %d in %v. Path: [%s]\n", len(paths), len(writePaths), time.Since(start), writeFolder)
 
 	return compactionMetadata, nil
 }
 
+// compactionReplacementPaths returns n ascending table names that sort after the first compacted table and before the
+// next table that was not part of the compaction, which preserves the order of all tables during recovery.
+// The first name replaces the first compacted table, the others are nested below it, e.g. "sstable_000000000000042_00001".
+// In case the next table is nested below the same name already, we descend another level with "_00000".
+func compactionReplacementPaths(first string, next string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+
+	base := first
+	for {
+		result := []string{first}
+		for i := 1; i < n; i++ {
+			result = append(result, fmt.Sprintf(SSTableNestedPattern, base, i))
+		}
+
+		if next == "" || result[len(result)-1] < next {
+			return result
+		}
+
+		base = fmt.Sprintf(SSTableNestedPattern, base, 0)
+	}
+}
+
+// compactionResultPaths returns the write and replacement paths of all compaction results, which is a single one for
+// compactions that were written before the results could be split. Compactions that dropped all of their keys have
+// no results at all.
+func compactionResultPaths(m *proto.CompactionMetadata) ([]string, []string) {
+	if m.Version < CompactionMetadataVersionSplitResults {
+		return []string{m.WritePath}, []string{m.ReplacementPath}
+	}
+
+	return m.WritePaths, m.ReplacementPaths
+}
+
 func saveCompactionMetadata(writeFolder string, compactionMetadata *proto.CompactionMetadata) (err error) {
 	metaWriter, err := rProto.NewWriter(
 		rProto.Path(filepath.Join(writeFolder, CompactionFinishedSuccessfulFileName)),
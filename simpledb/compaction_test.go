@@ -2,11 +2,13 @@ package simpledb
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thomasjungblut/go-sstables/memstore"
 	"github.com/thomasjungblut/go-sstables/sstables"
 	"github.com/thomasjungblut/go-sstables/sstables/proto"
@@ -177,6 +179,251 @@ func TestCompactionWithTombstonesBeyondMaxSize(t *testing.T) {
 	assert.Equal(t, 10, int(db.sstableManager.currentSSTable().MetaData().NumRecords))
 }
 
+func TestExecCompactionSplitsIntoMultipleTables(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_compactionSplit")
+	defer cleanDatabaseFolder(t, db)
+	closeDatabase(t, db)
+	db.closed = false
+	db.compactionFileThreshold = 0
+	db.compactionRatio = 0
+	db.compactedMaxSizeBytes = 1024
+
+	writeSSTableWithDataInDatabaseFolder(t, db, fmt.Sprintf(SSTablePattern, 42))
+	writeSSTableWithTombstoneInDatabaseFolder(t, db, fmt.Sprintf(SSTablePattern, 43))
+	assert.Nil(t, db.reconstructSSTables())
+
+	compactionMeta, err := executeCompaction(db)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sstable_000000000000042", "sstable_000000000000043"}, compactionMeta.SstablePaths)
+	assert.Greater(t, len(compactionMeta.WritePaths), 1)
+	assert.Equal(t, len(compactionMeta.WritePaths), len(compactionMeta.ReplacementPaths))
+	assert.Equal(t, "sstable_000000000000042", compactionMeta.ReplacementPaths[0])
+	assert.Equal(t, "sstable_000000000000042_00001", compactionMeta.ReplacementPaths[1])
+
+	err = db.sstableManager.reflectCompactionResult(compactionMeta)
+	assert.NoError(t, err)
+	assert.Equal(t, len(compactionMeta.ReplacementPaths), len(db.sstableManager.allSSTableReaders))
+	assert.Equal(t, 700, int(db.sstableManager.currentSSTable().MetaData().NumRecords))
+	_, err = os.Stat(filepath.Join(db.basePath, compactionMeta.WritePath))
+	assert.Truef(t, os.IsNotExist(err), "%v", err)
+
+	assertSplitCompactionContent := func() {
+		for _, k := range []int{0, 100, 499, 800, 999} {
+			v, err := db.Get(fmt.Sprintf("%d", k))
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("%d", k), v)
+		}
+		_, err := db.Get("512")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assertSplitCompactionContent()
+
+	// the nested tables must be picked up in the same order again
+	assert.Nil(t, db.sstableManager.currentReader.Close())
+	db.sstableManager.clearReaders()
+	db.currentGeneration = 0
+	assert.Nil(t, db.reconstructSSTables())
+	assert.Equal(t, len(compactionMeta.ReplacementPaths), len(db.sstableManager.allSSTableReaders))
+	assert.Equal(t, uint64(42), db.currentGeneration)
+	for i, p := range compactionMeta.ReplacementPaths {
+		assert.Equal(t, filepath.Join(db.basePath, p), db.sstableManager.allSSTableReaders[i].BasePath())
+	}
+	assertSplitCompactionContent()
+
+	// for cleanups
+	assert.Nil(t, db.sstableManager.currentReader.Close())
+}
+
+func TestExecCompactionPartitionsByKeyRange(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_compactionPartitioned")
+	defer cleanDatabaseFolder(t, db)
+	closeDatabase(t, db)
+	db.closed = false
+	db.compactionFileThreshold = 0
+	db.compactionRatio = 0
+	db.compactionPartitions = 4
+
+	writeSSTableWithDataInDatabaseFolder(t, db, fmt.Sprintf(SSTablePattern, 42))
+	writeSSTableWithTombstoneInDatabaseFolder(t, db, fmt.Sprintf(SSTablePattern, 43))
+	assert.Nil(t, db.reconstructSSTables())
+
+	compactionMeta, err := executeCompaction(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(compactionMeta.WritePaths))
+	for i, p := range compactionMeta.WritePaths {
+		assert.Equal(t, fmt.Sprintf(CompactionPartitionPattern, i), filepath.Base(filepath.Dir(p)))
+	}
+
+	// every partition covers a disjoint key range, in the order of the write paths
+	var previousMaxKey []byte
+	totalRecords := uint64(0)
+	for _, p := range compactionMeta.WritePaths {
+		reader, err := sstables.NewSSTableReader(sstables.ReadBasePath(filepath.Join(db.basePath, p)))
+		assert.NoError(t, err)
+		if previousMaxKey != nil {
+			assert.Less(t, string(previousMaxKey), string(reader.MetaData().MinKey))
+		}
+		previousMaxKey = reader.MetaData().MaxKey
+		totalRecords += reader.MetaData().NumRecords
+		assert.NoError(t, reader.Close())
+	}
+	assert.Equal(t, uint64(700), totalRecords)
+
+	err = db.sstableManager.reflectCompactionResult(compactionMeta)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(db.sstableManager.allSSTableReaders))
+	for _, k := range []int{0, 100, 499, 800, 999} {
+		v, err := db.Get(fmt.Sprintf("%d", k))
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%d", k), v)
+	}
+	_, err = db.Get("512")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// for cleanups
+	assert.Nil(t, db.sstableManager.currentReader.Close())
+}
+
+func TestCompactionRewritesPartlyLiveBlobFiles(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "simpledb_compactionBlobGarbage")
+	require.Nil(t, err)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+
+	rnd := rand.New(rand.NewSource(0))
+	values := map[string][]byte{}
+	// every session ends with a flush of the memstore on close, the first one is overwritten mostly by the second
+	writeSession := func(start, end int) {
+		db, err := NewSimpleDB(tmpDir, DisableCompactions(), ValueSeparation(64))
+		require.Nil(t, err)
+		require.Nil(t, db.Open())
+		for i := start; i < end; i++ {
+			k := fmt.Sprintf("%04d", i)
+			values[k] = make([]byte, 1024)
+			rnd.Read(values[k])
+			require.Nil(t, db.PutBytes([]byte(k), values[k]))
+		}
+		require.Nil(t, db.Close())
+	}
+	writeSession(0, 500)
+	writeSession(0, 400)
+
+	db, err := NewSimpleDB(tmpDir, DisableCompactions(), ValueSeparation(64), CompactionFileThreshold(0))
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	sizeBefore := blobFolderSize(t, db)
+
+	// the first compaction drops the references to the overwritten values, the second one rewrites the file
+	// that is mostly garbage by now
+	for i := 0; i < 2; i++ {
+		compactionMeta, err := executeCompaction(db)
+		require.Nil(t, err)
+		require.NotNil(t, compactionMeta)
+		require.Nil(t, db.sstableManager.reflectCompactionResult(compactionMeta))
+	}
+
+	sizeAfter := blobFolderSize(t, db)
+	assert.Less(t, sizeAfter, sizeBefore*6/10)
+	assertDatabaseContainsBytes(t, db, values)
+	require.Nil(t, db.Close())
+
+	// a file of a compaction that crashed is removed on the next open, the separated values stay readable
+	// after value separation was turned off again
+	orphanPath := filepath.Join(tmpDir, BlobFolder, fmt.Sprintf(sstables.BlobFileNamePattern, 1000))
+	require.Nil(t, os.WriteFile(orphanPath, []byte{1, 2, 3}, 0600))
+	db, err = NewSimpleDB(tmpDir, DisableCompactions())
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	defer closeDatabase(t, db)
+	_, err = os.Stat(orphanPath)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, sizeAfter, blobFolderSize(t, db))
+	assertDatabaseContainsBytes(t, db, values)
+}
+
+func assertDatabaseContainsBytes(t *testing.T, db *DB, values map[string][]byte) {
+	for k, v := range values {
+		actual, err := db.GetBytes([]byte(k))
+		require.Nil(t, err)
+		assert.Equal(t, v, actual)
+	}
+}
+
+func blobFolderSize(t *testing.T, db *DB) int64 {
+	entries, err := os.ReadDir(filepath.Join(db.basePath, BlobFolder))
+	require.Nil(t, err)
+	var size int64
+	for _, e := range entries {
+		info, err := e.Info()
+		require.Nil(t, err)
+		size += info.Size()
+	}
+	return size
+}
+
+func TestCompactionWithAllKeysDeleted(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "simpledb_compactionAllDeleted")
+	require.Nil(t, err)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+
+	// the second flushed table deletes every key of the first one
+	for _, deletes := range []bool{false, true} {
+		db, err := NewSimpleDB(tmpDir, DisableCompactions())
+		require.Nil(t, err)
+		require.Nil(t, db.Open())
+		for i := 0; i < 100; i++ {
+			if deletes {
+				require.Nil(t, db.Delete(fmt.Sprintf("%04d", i)))
+			} else {
+				require.Nil(t, db.Put(fmt.Sprintf("%04d", i), "v"))
+			}
+		}
+		require.Nil(t, db.Close())
+	}
+
+	db, err := NewSimpleDB(tmpDir, DisableCompactions(), CompactionFileThreshold(1))
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	compactionMeta, err := executeCompaction(db)
+	require.Nil(t, err)
+	assert.Equal(t, 2, len(compactionMeta.SstablePaths))
+	assert.Empty(t, compactionMeta.WritePaths)
+	require.Nil(t, db.sstableManager.reflectCompactionResult(compactionMeta))
+
+	assert.Empty(t, db.sstableManager.allSSTableReaders)
+	for _, p := range append(compactionMeta.SstablePaths, compactionMeta.WritePath) {
+		_, err = os.Stat(filepath.Join(tmpDir, p))
+		assert.Truef(t, os.IsNotExist(err), "%v", err)
+	}
+	_, err = db.Get("0042")
+	assert.Equal(t, ErrNotFound, err)
+	require.Nil(t, db.Put("0042", "new"))
+	require.Nil(t, db.Close())
+
+	db, err = NewSimpleDB(tmpDir, DisableCompactions())
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	defer closeDatabase(t, db)
+	v, err := db.Get("0042")
+	require.Nil(t, err)
+	assert.Equal(t, "new", v)
+	_, err = db.Get("0043")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestCompactionReplacementPaths(t *testing.T) {
+	assert.Nil(t, compactionReplacementPaths("sstable_1", "", 0))
+	assert.Equal(t, []string{"sstable_1"}, compactionReplacementPaths("sstable_1", "sstable_1_00001", 1))
+	assert.Equal(t, []string{"sstable_1", "sstable_1_00001", "sstable_1_00002"},
+		compactionReplacementPaths("sstable_1", "", 3))
+	assert.Equal(t, []string{"sstable_1", "sstable_1_00001", "sstable_1_00002"},
+		compactionReplacementPaths("sstable_1", "sstable_2", 3))
+	// the next table is nested below the first one already
+	assert.Equal(t, []string{"sstable_1", "sstable_1_00000_00001", "sstable_1_00000_00002"},
+		compactionReplacementPaths("sstable_1", "sstable_1_00002", 3))
+	assert.Equal(t, []string{"sstable_1", "sstable_1_00000_00000_00001"},
+		compactionReplacementPaths("sstable_1", "sstable_1_00000_00001", 2))
+}
+
 func writeSSTableWithDataInDatabaseFolder(t *testing.T, db *DB, p string) {
 	writeSSTableWithDataInDatabaseFolderRange(t, db, p, 0, 1000)
 }
@@ -19,22 +19,30 @@ import (
 
 const SSTablePrefix = "sstable"
 const SSTablePattern = SSTablePrefix + "_%015d"
+const SSTableNestedPattern = "%s_%05d"
 const SSTableCompactionPathPrefix = SSTablePrefix + "_compaction"
 const CompactionFinishedSuccessfulFileName = "compaction_successful"
+const CompactionPartitionPattern = "partition_%03d"
+const CompactionMetadataVersionSplitResults uint32 = 1
 const WriteAheadFolder = "wal"
+const BlobFolder = "blobs"
 const MemStoreMaxSizeBytes uint64 = 1024 * 1024 * 1024 // 1gb
 const NumSSTablesToTriggerCompaction int = 10
 const DefaultCompactionMaxSizeBytes uint64 = 5 * 1024 * 1024 * 1024 // 5gb
 const DefaultCompactionInterval = 5 * time.Second
 const DefaultCompactionRatio = float32(0.2)
+const DefaultCompactionPartitions = 4
 const DefaultWriteBufferSizeBytes uint64 = 4 * 1024 * 1024 // 4Mb
 const DefaultReadBufferSizeBytes uint64 = 4 * 1024 * 1024  // 4Mb
+const DefaultBlobGarbageRatio = 0.5
 
 var ErrNotFound = errors.New("ErrNotFound")
 var ErrNotOpenedYet = errors.New("database has not been opened yet, please call Open() first")
 var ErrAlreadyOpen = errors.New("database is already open")
 var ErrAlreadyClosed = errors.New("database is already closed")
 var ErrEmptyKeyValue = errors.New("neither empty keys nor values are allowed")
+var ErrNoMergeOperator = errors.New("merging requires a merge operator, please configure one using WithMergeOperator")
+var ErrInvalidTTL = errors.New("the ttl must be positive")
 
 type DatabaseI interface {
 	recordio.OpenClosableI
@@ -59,6 +67,16 @@ type DatabaseI interface {
 	// Unfortunately this method does not support empty keys and values, that will immediately return an error.
 	PutBytes(key, value []byte) error
 
+	// PutWithTTL adds the given value for the given key, just like Put, but the value expires after the given ttl.
+	// Expired values are treated as if they were deleted and are dropped by compactions.
+	// Returns ErrInvalidTTL when the ttl is not positive.
+	PutWithTTL(key, value string, ttl time.Duration) error
+
+	// PutBytesWithTTL adds the given value for the given key, just like PutBytes, but the value expires after the
+	// given ttl. Expired values are treated as if they were deleted and are dropped by compactions.
+	// Returns ErrInvalidTTL when the ttl is not positive.
+	PutBytesWithTTL(key, value []byte, ttl time.Duration) error
+
 	// Delete will delete the value for the given key. It will ignore when a key does not exist in the database.
 	// Underneath it will be tombstoned, which still stores it and makes it not retrievable through this interface.
 	Delete(key string) error
@@ -66,16 +84,40 @@ type DatabaseI interface {
 	// DeleteBytes will delete the value for the given key. It will ignore when a key does not exist in the database.
 	// Underneath it will be tombstoned, which still stores it and makes it not retrievable through this interface.
 	DeleteBytes(key []byte) error
+
+	// Merge applies the given operand to the value of the key using the merge operator of the database, without
+	// reading the value first. The operands are folded when reading and collapsed during compactions.
+	// Returns ErrNoMergeOperator when the database was created without WithMergeOperator.
+	Merge(key, operand string) error
+
+	// MergeBytes applies the given operand to the value of the key using the merge operator of the database, without
+	// reading the value first. The operands are folded when reading and collapsed during compactions.
+	// Returns ErrNoMergeOperator when the database was created without WithMergeOperator.
+	MergeBytes(key, operand []byte) error
+
+	// IngestExternalSSTables adds the sstables in the given paths to the database, which were written externally
+	// using the same comparator. The ingested tables take precedence over all existing data, later paths take
+	// precedence over earlier ones.
+	IngestExternalSSTables(paths []string) error
 }
 
 type compactionAction struct {
 	pathsToCompact []string
 	totalRecords   uint64
+	// the path of the first table after the compacted ones, empty if the compaction includes the latest table
+	nextPath string
+	// bottommost is true when the compaction includes the oldest table
+	bottommost bool
+	// encodedValues is true when none of the tables has merge operands or expiring values, so the separated values
+	// can be copied by their reference
+	encodedValues bool
 }
 
 type memStoreFlushAction struct {
 	memStore *memstore.MemStoreI
 	walPath  string
+	// set when external sstables should be ingested instead of flushing a memstore
+	ingestion *sstableIngestion
 }
 
 type DB struct {
@@ -90,6 +132,7 @@ type DB struct {
 	compactionFileThreshold int
 	compactionInterval      time.Duration
 	compactionRatio         float32
+	compactionPartitions    int
 	compactedMaxSizeBytes   uint64
 	enableCompactions       bool
 	enableAsyncWAL          bool
@@ -111,6 +154,14 @@ type DB struct {
 
 	writeBufferSizeBytes uint64
 	readBufferSizeBytes  uint64
+
+	mergeOperator sstables.MergeOperator
+
+	// blobStore is nil unless value separation is enabled, or the database contains separated values already
+	blobStore             *sstables.BlobStore
+	enableValueSeparation bool
+	blobThresholdBytes    int
+	blobGarbageRatio      float64
 }
 
 func (db *DB) Open() error {
@@ -126,10 +177,26 @@ func (db *DB) Open() error {
 		return err
 	}
 
+	err = db.repairIngestions()
+	if err != nil {
+		return err
+	}
+
+	err = db.openBlobStore()
+	if err != nil {
+		return err
+	}
+
 	err = db.reconstructSSTables()
 	if err != nil {
 		return err
 	}
+
+	err = db.collectBlobGarbage()
+	if err != nil {
+		return err
+	}
+
 	err = db.replayAndSetupWriteAheadLog()
 	if err != nil {
 		return err
@@ -183,7 +250,11 @@ func (db *DB) Close() error {
 		<-db.doneCompactionChannel
 	}
 
-	return errors.Join(db.wal.Close(), db.sstableManager.currentSSTable().Close())
+	err = errors.Join(db.wal.Close(), db.sstableManager.currentSSTable().Close())
+	if db.blobStore != nil {
+		err = errors.Join(err, db.blobStore.Close())
+	}
+	return err
 }
 
 func (db *DB) Get(key string) (string, error) {
@@ -209,7 +280,8 @@ func (db *DB) GetBytes(keyBytes []byte) ([]byte, error) {
 	// we have to read the sstable first and then augment it with
 	// any changes that were reflected in the memstore
 	var sstableNotFound bool
-	ssTableVal, err := db.sstableManager.currentSSTable().Get(keyBytes)
+	ssTable, flushedMemStore := db.sstableManager.currentSSTableAndFlushedMemStore()
+	ssTableVal, err := ssTable.Get(keyBytes)
 	if err != nil {
 		if errors.Is(err, sstables.NotFound) {
 			sstableNotFound = true
@@ -232,6 +304,11 @@ func (db *DB) GetBytes(keyBytes []byte) ([]byte, error) {
 			// regardless of what we found on the sstable:
 			// if the memstore says it's tombstoned it's considered deleted
 			return nil, ErrNotFound
+		} else if errors.Is(err, sstables.MergeOperandFound) {
+			if sstableNotFound {
+				ssTableVal = nil
+			}
+			return db.getMerged(keyBytes, ssTableVal, flushedMemStore)
 		} else {
 			return nil, err
 		}
@@ -241,6 +318,43 @@ func (db *DB) GetBytes(keyBytes []byte) ([]byte, error) {
 	return memStoreVal, nil
 }
 
+// getMerged folds the merge operands of the memstore onto the given value of the sstables, which is nil if the key
+// doesn't exist in the sstables. The operands of the flushed memstore are skipped, they are already in the sstables.
+func (db *DB) getMerged(keyBytes []byte, ssTableVal []byte, flushedMemStore memstore.MemStoreI) ([]byte, error) {
+	operands, base, err := db.memStore.mergeOperands(keyBytes, flushedMemStore)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(operands) == 0 && base == nil {
+		if len(ssTableVal) == 0 {
+			return nil, ErrNotFound
+		}
+		return ssTableVal, nil
+	}
+
+	existing := ssTableVal
+	if base != nil {
+		existing = nil
+		if base.Kind == sstables.ValueKindPut {
+			existing = base.Value
+		}
+	}
+
+	if db.mergeOperator == nil {
+		return nil, ErrNoMergeOperator
+	}
+
+	merged, err := db.mergeOperator.FullMerge(keyBytes, existing, operands)
+	if err != nil {
+		return nil, err
+	}
+	if len(merged) == 0 {
+		return nil, ErrNotFound
+	}
+	return merged, nil
+}
+
 func (db *DB) Put(key, value string) error {
 	if len(key) == 0 || len(value) == 0 {
 		return ErrEmptyKeyValue
@@ -254,12 +368,39 @@ func (db *DB) Put(key, value string) error {
 }
 
 func (db *DB) PutBytes(keyBytes, valBytes []byte) error {
+	return db.putBytes(keyBytes, valBytes, time.Time{})
+}
+
+func (db *DB) PutWithTTL(key, value string, ttl time.Duration) error {
+	if len(key) == 0 || len(value) == 0 {
+		return ErrEmptyKeyValue
+	}
+
+	return db.PutBytesWithTTL([]byte(key), []byte(value), ttl)
+}
+
+func (db *DB) PutBytesWithTTL(keyBytes, valBytes []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	return db.putBytes(keyBytes, valBytes, time.Now().Add(ttl))
+}
+
+// putBytes upserts the value, which expires at the given time unless it's zero.
+func (db *DB) putBytes(keyBytes, valBytes []byte, expiresAt time.Time) error {
+	var expiresAtMillis int64
+	if !expiresAt.IsZero() {
+		expiresAtMillis = expiresAt.UnixMilli()
+	}
+
 	// proto marshal takes 60%(!) of this method execution time
 	walBytes, err := proto.Marshal(&dbproto.WalMutation{
 		Mutation: &dbproto.WalMutation_Addition{
 			Addition: &dbproto.UpsertMutation{
-				KeyBytes:   keyBytes,
-				ValueBytes: valBytes,
+				KeyBytes:        keyBytes,
+				ValueBytes:      valBytes,
+				ExpiresAtMillis: expiresAtMillis,
 			},
 		},
 	})
@@ -291,7 +432,11 @@ func (db *DB) PutBytes(keyBytes, valBytes []byte) error {
 			}
 		}
 
-		err = db.memStore.Upsert(keyBytes, valBytes)
+		if expiresAt.IsZero() {
+			err = db.memStore.Upsert(keyBytes, valBytes)
+		} else {
+			err = db.memStore.UpsertWithExpiry(keyBytes, valBytes, expiresAt)
+		}
 		if err != nil {
 			return err
 		}
@@ -346,6 +491,65 @@ func (db *DB) DeleteBytes(byteKey []byte) error {
 	return db.memStore.Delete(byteKey)
 }
 
+func (db *DB) Merge(key, operand string) error {
+	return db.MergeBytes([]byte(key), []byte(operand))
+}
+
+func (db *DB) MergeBytes(keyBytes, operandBytes []byte) error {
+	if len(keyBytes) == 0 || len(operandBytes) == 0 {
+		return ErrEmptyKeyValue
+	}
+
+	if db.mergeOperator == nil {
+		return ErrNoMergeOperator
+	}
+
+	walBytes, err := proto.Marshal(&dbproto.WalMutation{
+		Mutation: &dbproto.WalMutation_Merge{
+			Merge: &dbproto.MergeMutation{
+				KeyBytes:     keyBytes,
+				OperandBytes: operandBytes,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	db.rwLock.Lock()
+	defer db.rwLock.Unlock()
+
+	if !db.open {
+		return ErrNotOpenedYet
+	}
+
+	if db.closed {
+		return ErrAlreadyClosed
+	}
+
+	if db.enableAsyncWAL {
+		err = db.wal.Append(walBytes)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = db.wal.AppendSync(walBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = db.memStore.Merge(keyBytes, operandBytes, db.mergeOperator)
+	if err != nil {
+		return err
+	}
+
+	if db.memStore.EstimatedSizeInBytes() > db.memstoreMaxSize {
+		return db.rotateWalAndFlushMemstore()
+	}
+	return nil
+}
+
 // NewSimpleDB creates a new db that requires a directory that exist, it can be empty in case of existing databases.
 // The error in case it doesn't exist can be checked using normal os package functions like os.IsNotExist(err)
 func NewSimpleDB(basePath string, extraOptions ...ExtraOption) (*DB, error) {
@@ -366,6 +570,11 @@ func NewSimpleDB(basePath string, extraOptions ...ExtraOption) (*DB, error) {
 		DefaultCompactionRatio,
 		DefaultWriteBufferSizeBytes,
 		DefaultReadBufferSizeBytes,
+		DefaultCompactionPartitions,
+		nil,
+		false,
+		0,
+		DefaultBlobGarbageRatio,
 	}
 
 	for _, extraOption := range extraOptions {
@@ -381,6 +590,7 @@ func NewSimpleDB(basePath string, extraOptions ...ExtraOption) (*DB, error) {
 	compactionTimerStopChannel := make(chan interface{}, 1)
 
 	sstableManager := NewSSTableManager(cmp, rwLock, basePath)
+	sstableManager.mergeOperator = extraOpts.mergeOperator
 
 	return &DB{
 		currentGeneration:           uint64(0),
@@ -395,6 +605,7 @@ func NewSimpleDB(basePath string, extraOptions ...ExtraOption) (*DB, error) {
 		enableDirectIOWAL:           extraOpts.enableDirectIOWAL,
 		compactionInterval:          extraOpts.compactionRunInterval,
 		compactionRatio:             extraOpts.compactionRatio,
+		compactionPartitions:        extraOpts.compactionPartitions,
 		closed:                      false,
 		rwLock:                      rwLock,
 		wal:                         nil,
@@ -406,6 +617,10 @@ func NewSimpleDB(basePath string, extraOptions ...ExtraOption) (*DB, error) {
 		doneCompactionChannel:       doneCompactionChan,
 		readBufferSizeBytes:         extraOpts.readBufferSizeBytes,
 		writeBufferSizeBytes:        extraOpts.writeBufferSizeBytes,
+		mergeOperator:               extraOpts.mergeOperator,
+		enableValueSeparation:       extraOpts.enableValueSeparation,
+		blobThresholdBytes:          extraOpts.blobThresholdBytes,
+		blobGarbageRatio:            extraOpts.blobGarbageRatio,
 	}, nil
 }
 
@@ -422,6 +637,11 @@ type ExtraOptions struct {
 	compactionRatio         float32
 	writeBufferSizeBytes    uint64
 	readBufferSizeBytes     uint64
+	compactionPartitions    int
+	mergeOperator           sstables.MergeOperator
+	enableValueSeparation   bool
+	blobThresholdBytes      int
+	blobGarbageRatio        float64
 }
 
 type ExtraOption func(options *ExtraOptions)
@@ -495,6 +715,18 @@ func CompactionMaxSizeBytes(n uint64) ExtraOption {
 	}
 }
 
+// CompactionPartitions tells into how many key ranges a compaction is split, the ranges are merged concurrently and
+// each of them is written into its own sstables. The boundaries are sampled from the compacted sstables, so small
+// compactions may use fewer partitions. Default is DefaultCompactionPartitions.
+func CompactionPartitions(n int) ExtraOption {
+	if n < 1 {
+		panic(fmt.Sprintf("invalid number of compaction partitions: %d, must be at least 1", n))
+	}
+	return func(args *ExtraOptions) {
+		args.compactionPartitions = n
+	}
+}
+
 // WriteBufferSizeBytes is the write buffer size for all buffer used by simple db.
 func WriteBufferSizeBytes(n uint64) ExtraOption {
 	return func(args *ExtraOptions) {
@@ -508,3 +740,37 @@ func ReadBufferSizeBytes(n uint64) ExtraOption {
 		args.readBufferSizeBytes = n
 	}
 }
+
+// WithMergeOperator sets the sstables.MergeOperator that is used to fold the operands of Merge, which is required to
+// call Merge and to read databases that contain merge operands. By default, there is no merge operator.
+func WithMergeOperator(operator sstables.MergeOperator) ExtraOption {
+	return func(args *ExtraOptions) {
+		args.mergeOperator = operator
+	}
+}
+
+// ValueSeparation stores values with at least thresholdBytes in blob files in the BlobFolder, the sstables only
+// contain references to them. Compactions copy the references instead of rewriting the values, unless a blob file is
+// mostly garbage, see BlobGarbageRatio. By default, all values are stored in the sstables.
+func ValueSeparation(thresholdBytes int) ExtraOption {
+	if thresholdBytes < 0 {
+		panic(fmt.Sprintf("invalid value separation threshold: %d, must not be negative", thresholdBytes))
+	}
+	return func(args *ExtraOptions) {
+		args.enableValueSeparation = true
+		args.blobThresholdBytes = thresholdBytes
+	}
+}
+
+// BlobGarbageRatio configures when compactions rewrite the live values of a blob file into a new one, so the old file
+// can be deleted. The ratio is measured as the amount of bytes in the file that are not referenced anymore divided by
+// its total bytes. It must be between 0.0 and 1.0 and by default is DefaultBlobGarbageRatio, a value of 1.0 only
+// deletes blob files that are not referenced at all.
+func BlobGarbageRatio(ratio float64) ExtraOption {
+	if ratio < 0.0 || ratio > 1.0 {
+		panic(fmt.Sprintf("invalid blob garbage ratio: %f, must be between 0 and 1", ratio))
+	}
+	return func(args *ExtraOptions) {
+		args.blobGarbageRatio = ratio
+	}
+}
@@ -141,6 +141,156 @@ func TestCloseDeniesCrudOperations(t *testing.T) {
 	assert.Equal(t, ErrAlreadyClosed, err)
 }
 
+// counterMergeOperator adds up decimal numbers.
+type counterMergeOperator struct{}
+
+func (counterMergeOperator) FullMerge(_ []byte, existing []byte, operands [][]byte) ([]byte, error) {
+	sum := 0
+	for _, v := range append([][]byte{existing}, operands...) {
+		if v == nil {
+			continue
+		}
+		n, err := strconv.Atoi(string(v))
+		if err != nil {
+			return nil, err
+		}
+		sum += n
+	}
+	return []byte(strconv.Itoa(sum)), nil
+}
+
+func (c counterMergeOperator) PartialMerge(key []byte, operands [][]byte) ([]byte, bool) {
+	merged, err := c.FullMerge(key, nil, operands)
+	return merged, err == nil
+}
+
+func TestMergeWithoutOperator(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpleDB_testMergeWithoutOperator")
+	defer cleanDatabaseFolder(t, db)
+	defer closeDatabase(t, db)
+
+	assert.Equal(t, ErrNoMergeOperator, db.Merge("a", "1"))
+}
+
+func TestMergeCounter(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "simpleDB_testMergeCounter")
+	require.Nil(t, err)
+	db, err := NewSimpleDB(tmpDir, DisableCompactions(), WithMergeOperator(counterMergeOperator{}))
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	defer cleanDatabaseFolder(t, db)
+
+	assert.Equal(t, ErrEmptyKeyValue, db.Merge("a", ""))
+	require.Nil(t, db.Put("a", "10"))
+	require.Nil(t, db.Merge("a", "1"))
+	require.Nil(t, db.Merge("b", "5"))
+	require.Nil(t, db.Merge("b", "2"))
+	assertValue(t, db, "a", "11")
+	assertValue(t, db, "b", "7")
+
+	// the operands are folded onto the flushed sstables
+	closeDatabase(t, db)
+	db, err = NewSimpleDB(tmpDir, DisableCompactions(), WithMergeOperator(counterMergeOperator{}))
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	require.Nil(t, db.Merge("a", "2"))
+	require.Nil(t, db.Delete("b"))
+	require.Nil(t, db.Merge("b", "3"))
+	require.Nil(t, db.Merge("c", "4"))
+	assertValue(t, db, "a", "13")
+	assertValue(t, db, "b", "3")
+	assertValue(t, db, "c", "4")
+
+	closeDatabase(t, db)
+	db, err = NewSimpleDB(tmpDir, DisableCompactions(), WithMergeOperator(counterMergeOperator{}))
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	require.Nil(t, db.Merge("a", "1"))
+	require.Nil(t, db.Merge("c", "1"))
+	closeDatabase(t, db)
+
+	// the compaction collapses the operands of all tables
+	db, err = NewSimpleDB(tmpDir, DisableCompactions(), WithMergeOperator(counterMergeOperator{}))
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	defer closeDatabase(t, db)
+	assert.Equal(t, 3, len(db.sstableManager.allSSTableReaders))
+	db.compactionFileThreshold = 0
+	db.compactionRatio = 0
+	compactionMeta, err := executeCompaction(db)
+	require.Nil(t, err)
+	require.Nil(t, db.sstableManager.reflectCompactionResult(compactionMeta))
+	assertValue(t, db, "a", "14")
+	assertValue(t, db, "b", "3")
+	assertValue(t, db, "c", "5")
+
+	_, err = db.Get("d")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestPutWithTTL(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "simpleDB_testPutWithTTL")
+	require.Nil(t, err)
+	db, err := NewSimpleDB(tmpDir, DisableCompactions())
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	defer cleanDatabaseFolder(t, db)
+
+	assert.Equal(t, ErrInvalidTTL, db.PutWithTTL("a", "1", 0))
+	assert.Equal(t, ErrEmptyKeyValue, db.PutWithTTL("a", "", time.Hour))
+	require.Nil(t, db.PutWithTTL("a", "1", time.Hour))
+	require.Nil(t, db.PutWithTTL("b", "2", 50*time.Millisecond))
+	require.Nil(t, db.Put("c", "3"))
+	require.Nil(t, db.Put("d", "4"))
+	assertValue(t, db, "b", "2")
+	time.Sleep(100 * time.Millisecond)
+	_, err = db.Get("b")
+	assert.Equal(t, ErrNotFound, err)
+	assertValue(t, db, "a", "1")
+
+	// the expired value in the newer table shadows the value of the older table
+	closeDatabase(t, db)
+	db, err = NewSimpleDB(tmpDir, DisableCompactions())
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	require.Nil(t, db.PutWithTTL("d", "5", 50*time.Millisecond))
+	require.Nil(t, db.PutWithTTL("e", "6", 50*time.Millisecond))
+	closeDatabase(t, db)
+	time.Sleep(100 * time.Millisecond)
+
+	db, err = NewSimpleDB(tmpDir, DisableCompactions())
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	defer closeDatabase(t, db)
+	assertValue(t, db, "a", "1")
+	assertValue(t, db, "c", "3")
+	for _, key := range []string{"b", "d", "e"} {
+		_, err = db.Get(key)
+		assert.Equal(t, ErrNotFound, err, "key %s", key)
+	}
+
+	// the compaction drops the expired values and keeps the expiry of the others
+	assert.Equal(t, 2, len(db.sstableManager.allSSTableReaders))
+	db.compactionFileThreshold = 0
+	db.compactionRatio = 0
+	compactionMeta, err := executeCompaction(db)
+	require.Nil(t, err)
+	require.Nil(t, db.sstableManager.reflectCompactionResult(compactionMeta))
+	assert.Equal(t, uint64(2), db.sstableManager.currentSSTable().MetaData().NumRecords)
+	assertValue(t, db, "a", "1")
+	assertValue(t, db, "c", "3")
+	for _, key := range []string{"b", "d", "e"} {
+		_, err = db.Get(key)
+		assert.Equal(t, ErrNotFound, err, "key %s", key)
+	}
+}
+
+func assertValue(t *testing.T, db *DB, key string, expected string) {
+	val, err := db.Get(key)
+	require.Nil(t, err)
+	assert.Equal(t, expected, val)
+}
+
 func TestDisableCompactions(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "simpleDB_testDisabledCompactions")
 	require.Nil(t, err)
@@ -16,6 +16,12 @@ func flushMemstoreContinuously(db *DB) {
 	defer func() { db.doneFlushChannel <- true }()
 	err := func(db *DB) error {
 		for flushAction := range db.storeFlushChannel {
+			// ingestion failures are reported back to the caller instead of stopping the flusher
+			if flushAction.ingestion != nil {
+				flushAction.ingestion.done <- executeIngestion(db, flushAction.ingestion)
+				continue
+			}
+
 			err := executeFlush(db, flushAction)
 			if err != nil {
 				return err
@@ -43,16 +49,20 @@ func executeFlush(db *DB, flushAction memStoreFlushAction) error {
 
 	gen := atomic.AddUint64(&db.currentGeneration, uint64(1))
 	writePath := filepath.Join(db.basePath, fmt.Sprintf(SSTablePattern, gen))
-	err := os.MkdirAll(writePath, 0700)
-	if err != nil {
-		return err
-	}
-
-	err = memStoreToFlush.FlushWithTombstones(
+	// the table only appears under its final path once it's complete, a crash while flushing leaves a staging
+	// directory behind, which is removed during recovery. The WAL is only removed after the flush succeeded.
+	writerOptions := []sstables.WriterOption{
 		sstables.WriteBasePath(writePath),
+		sstables.WriteAtomically(),
 		sstables.WithKeyComparator(db.cmp),
 		sstables.WriteBufferSizeBytes(int(db.writeBufferSizeBytes)),
-		sstables.BloomExpectedNumberOfElements(numElements))
+		sstables.BloomExpectedNumberOfElements(numElements),
+	}
+	if db.enableValueSeparation {
+		writerOptions = append(writerOptions, sstables.WithValueSeparation(db.blobStore, db.blobThresholdBytes))
+	}
+
+	err := memStoreToFlush.FlushWithTombstones(writerOptions...)
 	if err != nil {
 		return err
 	}
@@ -68,6 +78,7 @@ func executeFlush(db *DB, flushAction memStoreFlushAction) error {
 		sstables.ReadBasePath(writePath),
 		sstables.ReadWithKeyComparator(db.cmp),
 		sstables.ReadBufferSizeBytes(int(db.readBufferSizeBytes)),
+		sstables.ReadWithBlobStore(db.blobStore),
 	)
 	if err != nil {
 		return err
@@ -79,11 +90,9 @@ func executeFlush(db *DB, flushAction memStoreFlushAction) error {
 	log.Printf("done flushing memstore to sstable of size %d bytes (%2.f mb/s) in %v. Path: [%s]\n",
 		totalBytes, throughput, elapsedDuration, writePath)
 
-	// add the newly created reader into the rotation
+	// add the newly created reader into the rotation, from now on the flushed memstore is contained in the sstables
 	// note that this CAN block here waiting on a current compaction to finish
-	db.sstableManager.addReader(reader)
-
-	return nil
+	return db.sstableManager.addFlushedReader(reader, memStoreToFlush)
 }
 
 func (db *DB) rotateWalAndFlushMemstore() error {
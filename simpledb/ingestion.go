@@ -0,0 +1,321 @@
+package simpledb
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/thomasjungblut/go-sstables/memstore"
+	"github.com/thomasjungblut/go-sstables/sstables"
+	sProto "github.com/thomasjungblut/go-sstables/sstables/proto"
+)
+
+const IngestionPathPrefix = "ingestion"
+
+type sstableIngestion struct {
+	paths []string
+	done  chan error
+}
+
+// IngestExternalSSTables adds externally written sstables to the database, without replaying them through the WAL.
+// The tables must have been written with the same comparator as the database, empty values are treated as deletes.
+// Ingested tables take precedence over all existing data including the memstore, which is flushed beforehand.
+// Later paths take precedence over earlier ones in case they contain the same keys.
+// Each table is hardlinked into the database directory, which leaves the given table intact. If that's not possible,
+// for example because the table is on another device, the table directory is moved into the database directory instead.
+func (db *DB) IngestExternalSSTables(paths []string) error {
+	for _, p := range paths {
+		err := db.validateExternalSSTable(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	db.rwLock.Lock()
+	defer db.rwLock.Unlock()
+
+	if !db.open {
+		return ErrNotOpenedYet
+	}
+
+	if db.closed {
+		return ErrAlreadyClosed
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	// the memstore is older than the ingested tables, thus it needs to be flushed with an earlier generation
+	if db.memStore.Size() > 0 {
+		err := db.rotateWalAndFlushMemstore()
+		if err != nil {
+			return err
+		}
+	}
+
+	// the ingestion is executed by the flusher, this ensures the generations are ordered after any pending flushes
+	ingestion := &sstableIngestion{paths: paths, done: make(chan error, 1)}
+	db.storeFlushChannel <- memStoreFlushAction{ingestion: ingestion}
+	err := <-ingestion.done
+	if err != nil {
+		return err
+	}
+
+	// the flushed memstore is still readable and would otherwise take precedence over the ingested tables
+	db.memStore = &RWMemstore{
+		readStore:  memstore.NewMemStore(),
+		writeStore: db.memStore.writeStore,
+	}
+
+	return nil
+}
+
+// validateExternalSSTable ensures the table can be read with the database's comparator and its keys are strictly ascending.
+// Tables with merge operands require the database's merge operator, and all values need to match the encoding of the
+// table, as a compaction would otherwise fail on them long after the ingestion.
+func (db *DB) validateExternalSSTable(p string) (err error) {
+	// the expiry is kept, so that expired values are validated like any other value
+	reader, err := sstables.NewSSTableReader(
+		sstables.ReadBasePath(p),
+		sstables.ReadWithKeyComparator(db.cmp),
+		sstables.ReadBufferSizeBytes(int(db.readBufferSizeBytes)),
+		sstables.ReadEncodedExpiry(),
+	)
+	if err != nil {
+		return fmt.Errorf("error while opening external sstable '%s': %w", p, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, reader.Close())
+	}()
+
+	if reader.MetaData().InternalKeys {
+		return fmt.Errorf("external sstable '%s' was written with internal keys, which are not supported", p)
+	}
+
+	if reader.MetaData().MergeOperands && db.mergeOperator == nil {
+		return fmt.Errorf("external sstable '%s' contains merge operands, but the database has no merge operator: %w", p, ErrNoMergeOperator)
+	}
+
+	it, err := reader.Scan()
+	if err != nil {
+		return fmt.Errorf("error while scanning external sstable '%s': %w", p, err)
+	}
+
+	var lastKey []byte
+	numRecords := uint64(0)
+	for {
+		k, v, err := it.Next()
+		if err != nil {
+			if errors.Is(err, sstables.Done) {
+				break
+			}
+			return fmt.Errorf("error while scanning external sstable '%s': %w", p, err)
+		}
+
+		err = validateExternalValue(reader.MetaData(), v)
+		if err != nil {
+			return fmt.Errorf("external sstable '%s' has an invalid value for key %v: %w", p, k, err)
+		}
+
+		if numRecords == 0 && db.cmp.Compare(k, reader.MetaData().MinKey) != 0 {
+			return fmt.Errorf("external sstable '%s' has a different min key than its first key", p)
+		}
+
+		if lastKey != nil && db.cmp.Compare(lastKey, k) >= 0 {
+			return fmt.Errorf("external sstable '%s' is not strictly ascending under the database's comparator", p)
+		}
+
+		lastKey = append(lastKey[:0], k...)
+		numRecords++
+	}
+
+	if numRecords > 0 && db.cmp.Compare(lastKey, reader.MetaData().MaxKey) != 0 {
+		return fmt.Errorf("external sstable '%s' has a different max key than its last key", p)
+	}
+
+	if numRecords != reader.MetaData().NumRecords {
+		return fmt.Errorf("external sstable '%s' contains %d records, but its metadata says %d",
+			p, numRecords, reader.MetaData().NumRecords)
+	}
+
+	return nil
+}
+
+// validateExternalValue decodes the expiry and the merge operands of the value, if the table was written with them.
+func validateExternalValue(metaData *sProto.MetaData, v []byte) error {
+	var err error
+	if metaData.ExpiringValues {
+		v, _, err = sstables.DecodeExpiringValue(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	if metaData.MergeOperands {
+		_, err = sstables.DecodeMergeValue(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func executeIngestion(db *DB, ingestion *sstableIngestion) error {
+	start := time.Now()
+	for _, p := range ingestion.paths {
+		gen := atomic.AddUint64(&db.currentGeneration, uint64(1))
+		writePath := filepath.Join(db.basePath, fmt.Sprintf(SSTablePattern, gen))
+		err := linkOrMoveSSTable(db.basePath, p, writePath)
+		if err != nil {
+			return fmt.Errorf("error while ingesting external sstable '%s': %w", p, err)
+		}
+
+		reader, err := sstables.NewSSTableReader(
+			sstables.ReadBasePath(writePath),
+			sstables.ReadWithKeyComparator(db.cmp),
+			sstables.ReadBufferSizeBytes(int(db.readBufferSizeBytes)),
+		)
+		if err != nil {
+			return err
+		}
+
+		err = db.sstableManager.addReader(reader)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("done ingesting %d external sstables in %v\n", len(ingestion.paths), time.Since(start))
+	return nil
+}
+
+// linkOrMoveSSTable hardlinks all files of the table into a temporary folder, which is renamed to the final path once complete.
+// Only if linking isn't possible, the table folder itself is moved. Either way, the files and the directories are synced,
+// so that the ingested table is complete after a crash.
+func linkOrMoveSSTable(basePath string, sourcePath string, writePath string) error {
+	tmpPath, err := os.MkdirTemp(basePath, IngestionPathPrefix)
+	if err != nil {
+		return err
+	}
+
+	err = linkSSTableFiles(sourcePath, tmpPath)
+	if err == nil {
+		err = syncSSTableDir(tmpPath)
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(tmpPath, writePath)
+		if err != nil {
+			return err
+		}
+
+		return syncPath(basePath)
+	}
+
+	log.Printf("could not hardlink external sstable '%s', moving it instead: %v\n", sourcePath, err)
+	err = os.RemoveAll(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	err = syncSSTableDir(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(sourcePath, writePath)
+	if err != nil {
+		return err
+	}
+
+	err = syncPath(basePath)
+	if err != nil {
+		return err
+	}
+
+	return syncPath(filepath.Dir(filepath.Clean(sourcePath)))
+}
+
+func linkSSTableFiles(sourcePath string, targetPath string) error {
+	entries, err := os.ReadDir(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			return fmt.Errorf("unexpected directory '%s' in sstable", e.Name())
+		}
+
+		err = os.Link(filepath.Join(sourcePath, e.Name()), filepath.Join(targetPath, e.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncSSTableDir makes all files of the table and the directory itself durable.
+func syncSSTableDir(path string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		err = syncPath(filepath.Join(path, e.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return syncPath(path)
+}
+
+func syncPath(path string) (err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error while opening '%s' for syncing: %w", path, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	err = f.Sync()
+	if err != nil {
+		return fmt.Errorf("error while syncing '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// repairIngestions deletes the temporary folders of ingestions that didn't finish, the ingested tables are intact.
+func (db *DB) repairIngestions() error {
+	entries, err := os.ReadDir(db.basePath)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() && strings.HasPrefix(e.Name(), IngestionPathPrefix) {
+			log.Printf("found unfinished ingestion to be deleted in %v", e.Name())
+			err = os.RemoveAll(filepath.Join(db.basePath, e.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package simpledb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables"
+)
+
+func TestIngestExternalSSTables(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_ingestion")
+	defer cleanDatabaseFolder(t, db)
+
+	require.Nil(t, db.Put("a", "old"))
+	require.Nil(t, db.Put("b", "old"))
+	require.Nil(t, db.Put("d", "old"))
+
+	first := writeExternalSSTable(t, skiplist.BytesComparator{}, [][2]string{{"a", "first"}, {"b", ""}, {"c", "first"}})
+	defer func() { require.Nil(t, os.RemoveAll(first)) }()
+	second := writeExternalSSTable(t, skiplist.BytesComparator{}, [][2]string{{"c", "second"}, {"e", "second"}})
+	defer func() { require.Nil(t, os.RemoveAll(second)) }()
+
+	require.Nil(t, db.IngestExternalSSTables([]string{first, second}))
+
+	expected := map[string]string{"a": "first", "c": "second", "d": "old", "e": "second"}
+	assertIngestedContent := func() {
+		for k, v := range expected {
+			actual, err := db.Get(k)
+			require.Nil(t, err)
+			assert.Equal(t, v, actual)
+		}
+		_, err := db.Get("b")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assertIngestedContent()
+
+	// the external tables were linked, thus are still intact
+	_, err := os.Stat(filepath.Join(first, sstables.IndexFileName))
+	require.Nil(t, err)
+
+	// newer writes take precedence over the ingested tables again
+	require.Nil(t, db.Put("a", "newest"))
+	expected["a"] = "newest"
+	assertIngestedContent()
+
+	closeDatabase(t, db)
+	db, err = NewSimpleDB(db.basePath)
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	defer closeDatabase(t, db)
+	assertIngestedContent()
+}
+
+func TestIngestExternalSSTablesValidation(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_ingestionValidation")
+	defer cleanDatabaseFolder(t, db)
+	defer closeDatabase(t, db)
+
+	assert.Error(t, db.IngestExternalSSTables([]string{filepath.Join(db.basePath, "does_not_exist")}))
+
+	reversed := writeExternalSSTable(t, reverseComparator{}, [][2]string{{"b", "1"}, {"a", "1"}})
+	defer func() { require.Nil(t, os.RemoveAll(reversed)) }()
+	assert.Error(t, db.IngestExternalSSTables([]string{reversed}))
+
+	valid := writeExternalSSTable(t, skiplist.BytesComparator{}, [][2]string{{"a", "1"}})
+	defer func() { require.Nil(t, os.RemoveAll(valid)) }()
+	// nothing is ingested when one of the tables is invalid
+	assert.Error(t, db.IngestExternalSSTables([]string{valid, reversed}))
+	_, err := db.Get("a")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 0, len(db.sstableManager.allSSTableReaders))
+}
+
+func TestIngestExternalSSTablesWithMergeOperands(t *testing.T) {
+	operand := sstables.MergeValue{Kind: sstables.ValueKindMerge, Operands: [][]byte{[]byte("5")}}.Encode()
+	path := writeExternalSSTable(t, skiplist.BytesComparator{}, [][2]string{{"a", string(operand)}}, sstables.WithMergeOperands())
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	withoutOperator := newOpenedSimpleDB(t, "simpledb_ingestionWithoutMergeOperator")
+	defer cleanDatabaseFolder(t, withoutOperator)
+	defer closeDatabase(t, withoutOperator)
+	assert.ErrorIs(t, withoutOperator.IngestExternalSSTables([]string{path}), ErrNoMergeOperator)
+
+	tmpDir, err := os.MkdirTemp("", "simpledb_ingestionMergeOperands")
+	require.Nil(t, err)
+	db, err := NewSimpleDB(tmpDir, DisableCompactions(), WithMergeOperator(counterMergeOperator{}))
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	defer cleanDatabaseFolder(t, db)
+	defer closeDatabase(t, db)
+
+	// the operand is only validated by its kind when writing, the truncated operand is rejected by the ingestion
+	truncated := writeExternalSSTable(t, skiplist.BytesComparator{}, [][2]string{{"a", string([]byte{byte(sstables.ValueKindMerge), 0xFF})}},
+		sstables.WithMergeOperands())
+	defer func() { require.Nil(t, os.RemoveAll(truncated)) }()
+	assert.Error(t, db.IngestExternalSSTables([]string{truncated}))
+
+	require.Nil(t, db.Put("a", "10"))
+	require.Nil(t, db.IngestExternalSSTables([]string{path}))
+	assertValue(t, db, "a", "15")
+}
+
+func TestIngestExternalSSTablesWithExpiringValues(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_ingestionExpiringValues")
+	defer cleanDatabaseFolder(t, db)
+	defer closeDatabase(t, db)
+
+	require.Nil(t, db.Put("a", "old"))
+	require.Nil(t, db.Put("b", "old"))
+
+	path := writeExternalSSTable(t, skiplist.BytesComparator{}, [][2]string{
+		{"a", string(sstables.EncodeExpiringValue([]byte("expired"), time.Now().Add(-time.Hour)))},
+		{"b", string(sstables.EncodeExpiringValue([]byte("forever"), time.Time{}))},
+		{"c", string(sstables.EncodeExpiringValue([]byte("later"), time.Now().Add(time.Hour)))},
+	}, sstables.WithExpiringValues())
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+	require.Nil(t, db.IngestExternalSSTables([]string{path}))
+
+	// the expired value hides the older one
+	_, err := db.Get("a")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assertValue(t, db, "b", "forever")
+	assertValue(t, db, "c", "later")
+}
+
+func TestIngestExternalSSTablesUnopened(t *testing.T) {
+	db := newSimpleDBWithTemp(t, "simpledb_ingestionUnopened")
+	defer cleanDatabaseFolder(t, db)
+
+	assert.ErrorIs(t, db.IngestExternalSSTables(nil), ErrNotOpenedYet)
+}
+
+func TestRepairIngestions(t *testing.T) {
+	db := newSimpleDBWithTemp(t, "simpledb_repairIngestions")
+	defer cleanDatabaseFolder(t, db)
+
+	unfinished, err := os.MkdirTemp(db.basePath, IngestionPathPrefix)
+	require.Nil(t, err)
+	require.Nil(t, db.Open())
+	defer closeDatabase(t, db)
+
+	_, err = os.Stat(unfinished)
+	assert.Truef(t, os.IsNotExist(err), "%v", err)
+}
+
+func writeExternalSSTable(t *testing.T, cmp skiplist.Comparator[[]byte], records [][2]string, writerOptions ...sstables.WriterOption) string {
+	tmpDir, err := os.MkdirTemp("", "simpledb_externalSSTable")
+	require.Nil(t, err)
+
+	writerOptions = append(writerOptions, sstables.WriteBasePath(tmpDir), sstables.WithKeyComparator(cmp))
+	writer, err := sstables.NewSSTableStreamWriter(writerOptions...)
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, r := range records {
+		require.Nil(t, writer.WriteNext([]byte(r[0]), []byte(r[1])))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+type reverseComparator struct{}
+
+func (reverseComparator) Compare(a []byte, b []byte) int {
+	return bytes.Compare(b, a)
+}
@@ -23,12 +23,19 @@ const (
 
 type CompactionMetadata struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// database root relative write path of the compaction result
+	// database root relative write path of the compaction result,
+	// when writePaths are set this is the folder that contains all results
 	WritePath string `protobuf:"bytes,1,opt,name=writePath,proto3" json:"writePath,omitempty"`
 	// database root relative desired replacement path for the compaction result
 	ReplacementPath string `protobuf:"bytes,2,opt,name=replacementPath,proto3" json:"replacementPath,omitempty"`
 	// database root relative set of paths that contributed to that compaction result
-	SstablePaths  []string `protobuf:"bytes,3,rep,name=sstablePaths,proto3" json:"sstablePaths,omitempty"`
+	SstablePaths []string `protobuf:"bytes,3,rep,name=sstablePaths,proto3" json:"sstablePaths,omitempty"`
+	// database root relative write paths of all compaction results, ordered by their key range
+	WritePaths []string `protobuf:"bytes,4,rep,name=writePaths,proto3" json:"writePaths,omitempty"`
+	// database root relative desired replacement paths, one for each of the writePaths
+	ReplacementPaths []string `protobuf:"bytes,5,rep,name=replacementPaths,proto3" json:"replacementPaths,omitempty"`
+	// 0 when the only result is in writePath, 1 when the results are in writePaths, which can be empty
+	Version       uint32 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -84,13 +91,34 @@ func (x *CompactionMetadata) GetSstablePaths() []string {
 	return nil
 }
 
+func (x *CompactionMetadata) GetWritePaths() []string {
+	if x != nil {
+		return x.WritePaths
+	}
+	return nil
+}
+
+func (x *CompactionMetadata) GetReplacementPaths() []string {
+	if x != nil {
+		return x.ReplacementPaths
+	}
+	return nil
+}
+
+func (x *CompactionMetadata) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 var File_simpledb_proto_compaction_metadata_proto protoreflect.FileDescriptor
 
 var file_simpledb_proto_compaction_metadata_proto_rawDesc = string([]byte{
 	0x0a, 0x28, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x64, 0x62, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
 	0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x65, 0x74, 0x61,
 	0x64, 0x61, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x22, 0x80, 0x01, 0x0a, 0x12, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x6f, 0x22, 0xe6, 0x01, 0x0a, 0x12, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
 	0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1c, 0x0a, 0x09, 0x77, 0x72, 0x69, 0x74,
 	0x65, 0x50, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x77, 0x72, 0x69,
 	0x74, 0x65, 0x50, 0x61, 0x74, 0x68, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63,
@@ -98,10 +126,16 @@ var file_simpledb_proto_compaction_metadata_proto_rawDesc = string([]byte{
 	0x0f, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x50, 0x61, 0x74, 0x68,
 	0x12, 0x22, 0x0a, 0x0c, 0x73, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x50, 0x61, 0x74, 0x68, 0x73,
 	0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x73, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x50,
-	0x61, 0x74, 0x68, 0x73, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x74, 0x68, 0x6f, 0x6d, 0x61, 0x73, 0x6a, 0x75, 0x6e, 0x67, 0x62, 0x6c, 0x75,
-	0x74, 0x2f, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x64, 0x62, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x61, 0x74, 0x68, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x77, 0x72, 0x69, 0x74, 0x65, 0x50, 0x61, 0x74,
+	0x68, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x77, 0x72, 0x69, 0x74, 0x65, 0x50,
+	0x61, 0x74, 0x68, 0x73, 0x12, 0x2a, 0x0a, 0x10, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x50, 0x61, 0x74, 0x68, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x10,
+	0x72, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x50, 0x61, 0x74, 0x68, 0x73,
+	0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x68, 0x6f, 0x6d, 0x61, 0x73, 0x6a,
+	0x75, 0x6e, 0x67, 0x62, 0x6c, 0x75, 0x74, 0x2f, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x64, 0x62,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 })
 
 var (
@@ -22,13 +22,14 @@ const (
 )
 
 type UpsertMutation struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
-	KeyBytes      []byte                 `protobuf:"bytes,3,opt,name=keyBytes,proto3" json:"keyBytes,omitempty"`
-	ValueBytes    []byte                 `protobuf:"bytes,4,opt,name=valueBytes,proto3" json:"valueBytes,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Key             string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value           string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	KeyBytes        []byte                 `protobuf:"bytes,3,opt,name=keyBytes,proto3" json:"keyBytes,omitempty"`
+	ValueBytes      []byte                 `protobuf:"bytes,4,opt,name=valueBytes,proto3" json:"valueBytes,omitempty"`
+	ExpiresAtMillis int64                  `protobuf:"varint,5,opt,name=expiresAtMillis,proto3" json:"expiresAtMillis,omitempty"` // unix timestamp in milliseconds when the value expires, zero if it never expires
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *UpsertMutation) Reset() {
@@ -89,6 +90,13 @@ func (x *UpsertMutation) GetValueBytes() []byte {
 	return nil
 }
 
+func (x *UpsertMutation) GetExpiresAtMillis() int64 {
+	if x != nil {
+		return x.ExpiresAtMillis
+	}
+	return 0
+}
+
 type DeleteTombstoneMutation struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -141,12 +149,65 @@ func (x *DeleteTombstoneMutation) GetKeyBytes() []byte {
 	return nil
 }
 
+type MergeMutation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyBytes      []byte                 `protobuf:"bytes,1,opt,name=keyBytes,proto3" json:"keyBytes,omitempty"`
+	OperandBytes  []byte                 `protobuf:"bytes,2,opt,name=operandBytes,proto3" json:"operandBytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MergeMutation) Reset() {
+	*x = MergeMutation{}
+	mi := &file_simpledb_proto_wal_mutation_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MergeMutation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergeMutation) ProtoMessage() {}
+
+func (x *MergeMutation) ProtoReflect() protoreflect.Message {
+	mi := &file_simpledb_proto_wal_mutation_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergeMutation.ProtoReflect.Descriptor instead.
+func (*MergeMutation) Descriptor() ([]byte, []int) {
+	return file_simpledb_proto_wal_mutation_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MergeMutation) GetKeyBytes() []byte {
+	if x != nil {
+		return x.KeyBytes
+	}
+	return nil
+}
+
+func (x *MergeMutation) GetOperandBytes() []byte {
+	if x != nil {
+		return x.OperandBytes
+	}
+	return nil
+}
+
 type WalMutation struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Types that are valid to be assigned to Mutation:
 	//
 	//	*WalMutation_Addition
 	//	*WalMutation_DeleteTombStone
+	//	*WalMutation_Merge
 	Mutation      isWalMutation_Mutation `protobuf_oneof:"mutation"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -154,7 +215,7 @@ type WalMutation struct {
 
 func (x *WalMutation) Reset() {
 	*x = WalMutation{}
-	mi := &file_simpledb_proto_wal_mutation_proto_msgTypes[2]
+	mi := &file_simpledb_proto_wal_mutation_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -166,7 +227,7 @@ func (x *WalMutation) String() string {
 func (*WalMutation) ProtoMessage() {}
 
 func (x *WalMutation) ProtoReflect() protoreflect.Message {
-	mi := &file_simpledb_proto_wal_mutation_proto_msgTypes[2]
+	mi := &file_simpledb_proto_wal_mutation_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -179,7 +240,7 @@ func (x *WalMutation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WalMutation.ProtoReflect.Descriptor instead.
 func (*WalMutation) Descriptor() ([]byte, []int) {
-	return file_simpledb_proto_wal_mutation_proto_rawDescGZIP(), []int{2}
+	return file_simpledb_proto_wal_mutation_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *WalMutation) GetMutation() isWalMutation_Mutation {
@@ -207,6 +268,15 @@ func (x *WalMutation) GetDeleteTombStone() *DeleteTombstoneMutation {
 	return nil
 }
 
+func (x *WalMutation) GetMerge() *MergeMutation {
+	if x != nil {
+		if x, ok := x.Mutation.(*WalMutation_Merge); ok {
+			return x.Merge
+		}
+	}
+	return nil
+}
+
 type isWalMutation_Mutation interface {
 	isWalMutation_Mutation()
 }
@@ -219,41 +289,58 @@ type WalMutation_DeleteTombStone struct {
 	DeleteTombStone *DeleteTombstoneMutation `protobuf:"bytes,2,opt,name=deleteTombStone,proto3,oneof"`
 }
 
+type WalMutation_Merge struct {
+	Merge *MergeMutation `protobuf:"bytes,3,opt,name=merge,proto3,oneof"`
+}
+
 func (*WalMutation_Addition) isWalMutation_Mutation() {}
 
 func (*WalMutation_DeleteTombStone) isWalMutation_Mutation() {}
 
+func (*WalMutation_Merge) isWalMutation_Mutation() {}
+
 var File_simpledb_proto_wal_mutation_proto protoreflect.FileDescriptor
 
 var file_simpledb_proto_wal_mutation_proto_rawDesc = string([]byte{
 	0x0a, 0x21, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x64, 0x62, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
 	0x2f, 0x77, 0x61, 0x6c, 0x5f, 0x6d, 0x75, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x74, 0x0a, 0x0e, 0x55, 0x70,
-	0x73, 0x65, 0x72, 0x74, 0x4d, 0x75, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03,
-	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
-	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
-	0x61, 0x6c, 0x75, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65, 0x73,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65, 0x73,
-	0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73,
-	0x22, 0x47, 0x0a, 0x17, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74,
-	0x6f, 0x6e, 0x65, 0x4d, 0x75, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x6b,
-	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x1a, 0x0a,
-	0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65, 0x73, 0x22, 0x9a, 0x01, 0x0a, 0x0b, 0x57, 0x61,
-	0x6c, 0x4d, 0x75, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x33, 0x0a, 0x08, 0x61, 0x64, 0x64,
-	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x70, 0x73, 0x65, 0x72, 0x74, 0x4d, 0x75, 0x74, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x48, 0x00, 0x52, 0x08, 0x61, 0x64, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x4a,
-	0x0a, 0x0f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x54, 0x6f, 0x6d, 0x62, 0x53, 0x74, 0x6f, 0x6e,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
-	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x4d,
-	0x75, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0f, 0x64, 0x65, 0x6c, 0x65, 0x74,
-	0x65, 0x54, 0x6f, 0x6d, 0x62, 0x53, 0x74, 0x6f, 0x6e, 0x65, 0x42, 0x0a, 0x0a, 0x08, 0x6d, 0x75,
-	0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
-	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x68, 0x6f, 0x6d, 0x61, 0x73, 0x6a, 0x75, 0x6e, 0x67, 0x62,
-	0x6c, 0x75, 0x74, 0x2f, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x64, 0x62, 0x2f, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x9e, 0x01, 0x0a, 0x0e, 0x55,
+	0x70, 0x73, 0x65, 0x72, 0x74, 0x4d, 0x75, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x12, 0x28, 0x0a, 0x0f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x4d, 0x69,
+	0x6c, 0x6c, 0x69, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x65, 0x78, 0x70, 0x69,
+	0x72, 0x65, 0x73, 0x41, 0x74, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x22, 0x47, 0x0a, 0x17, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x4d, 0x75,
+	0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x22, 0x4f, 0x0a, 0x0d, 0x4d, 0x65, 0x72, 0x67, 0x65, 0x4d, 0x75, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x12, 0x22, 0x0a, 0x0c, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x6e, 0x64, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x6e, 0x64,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x22, 0xc8, 0x01, 0x0a, 0x0b, 0x57, 0x61, 0x6c, 0x4d, 0x75, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x33, 0x0a, 0x08, 0x61, 0x64, 0x64, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x55, 0x70, 0x73, 0x65, 0x72, 0x74, 0x4d, 0x75, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00,
+	0x52, 0x08, 0x61, 0x64, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x4a, 0x0a, 0x0f, 0x64, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x54, 0x6f, 0x6d, 0x62, 0x53, 0x74, 0x6f, 0x6e, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x4d, 0x75, 0x74, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x54, 0x6f, 0x6d,
+	0x62, 0x53, 0x74, 0x6f, 0x6e, 0x65, 0x12, 0x2c, 0x0a, 0x05, 0x6d, 0x65, 0x72, 0x67, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4d, 0x65,
+	0x72, 0x67, 0x65, 0x4d, 0x75, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x05, 0x6d,
+	0x65, 0x72, 0x67, 0x65, 0x42, 0x0a, 0x0a, 0x08, 0x6d, 0x75, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74,
+	0x68, 0x6f, 0x6d, 0x61, 0x73, 0x6a, 0x75, 0x6e, 0x67, 0x62, 0x6c, 0x75, 0x74, 0x2f, 0x73, 0x69,
+	0x6d, 0x70, 0x6c, 0x65, 0x64, 0x62, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 })
 
 var (
@@ -268,20 +355,22 @@ func file_simpledb_proto_wal_mutation_proto_rawDescGZIP() []byte {
 	return file_simpledb_proto_wal_mutation_proto_rawDescData
 }
 
-var file_simpledb_proto_wal_mutation_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_simpledb_proto_wal_mutation_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_simpledb_proto_wal_mutation_proto_goTypes = []any{
 	(*UpsertMutation)(nil),          // 0: proto.UpsertMutation
 	(*DeleteTombstoneMutation)(nil), // 1: proto.DeleteTombstoneMutation
-	(*WalMutation)(nil),             // 2: proto.WalMutation
+	(*MergeMutation)(nil),           // 2: proto.MergeMutation
+	(*WalMutation)(nil),             // 3: proto.WalMutation
 }
 var file_simpledb_proto_wal_mutation_proto_depIdxs = []int32{
 	0, // 0: proto.WalMutation.addition:type_name -> proto.UpsertMutation
 	1, // 1: proto.WalMutation.deleteTombStone:type_name -> proto.DeleteTombstoneMutation
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	2, // 2: proto.WalMutation.merge:type_name -> proto.MergeMutation
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_simpledb_proto_wal_mutation_proto_init() }
@@ -289,9 +378,10 @@ func file_simpledb_proto_wal_mutation_proto_init() {
 	if File_simpledb_proto_wal_mutation_proto != nil {
 		return
 	}
-	file_simpledb_proto_wal_mutation_proto_msgTypes[2].OneofWrappers = []any{
+	file_simpledb_proto_wal_mutation_proto_msgTypes[3].OneofWrappers = []any{
 		(*WalMutation_Addition)(nil),
 		(*WalMutation_DeleteTombStone)(nil),
+		(*WalMutation_Merge)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -299,7 +389,7 @@ func file_simpledb_proto_wal_mutation_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_simpledb_proto_wal_mutation_proto_rawDesc), len(file_simpledb_proto_wal_mutation_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -16,6 +16,7 @@ import (
 	dbproto "github.com/thomasjungblut/go-sstables/simpledb/proto"
 	"github.com/thomasjungblut/go-sstables/sstables"
 	"github.com/thomasjungblut/go-sstables/wal"
+	"golang.org/x/exp/slices"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -86,28 +87,44 @@ func (db *DB) repairCompactions() error {
 	}
 
 	for _, meta := range compactionsToFinish {
-		absWritePath := filepath.Join(db.basePath, meta.WritePath)
-		absReplacementPath := filepath.Join(db.basePath, meta.ReplacementPath)
+		writePaths, replacementPaths := compactionResultPaths(meta)
+		for i := range writePaths {
+			absWritePath := filepath.Join(db.basePath, writePaths[i])
+			absReplacementPath := filepath.Join(db.basePath, replacementPaths[i])
+
+			// a previous attempt might have crashed after moving some of the results already
+			_, err := os.Stat(absWritePath)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
 
-		log.Printf("finishing compaction in %s into %s", absWritePath, absReplacementPath)
-		err := os.RemoveAll(absReplacementPath)
-		if err != nil {
-			return err
-		}
+			log.Printf("finishing compaction in %s into %s", absWritePath, absReplacementPath)
+			err = os.RemoveAll(absReplacementPath)
+			if err != nil {
+				return err
+			}
 
-		err = os.Rename(absWritePath, absReplacementPath)
-		if err != nil {
-			return err
+			err = os.Rename(absWritePath, absReplacementPath)
+			if err != nil {
+				return err
+			}
 		}
 
 		for _, sstablePath := range meta.SstablePaths {
-			if sstablePath != meta.ReplacementPath {
+			if !slices.Contains(replacementPaths, sstablePath) {
 				err := os.RemoveAll(filepath.Join(db.basePath, sstablePath))
 				if err != nil {
 					return err
 				}
 			}
 		}
+
+		if meta.Version >= CompactionMetadataVersionSplitResults {
+			err := os.RemoveAll(filepath.Join(db.basePath, meta.WritePath))
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -116,12 +133,19 @@ func (db *DB) repairCompactions() error {
 // this reads all existing sstables and adds them (if any), along with the generation number
 func (db *DB) reconstructSSTables() error {
 	var tablePaths []string
+	var stagingPaths []string
 
 	err := filepath.Walk(db.basePath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
+		// incomplete tables of a flush that crashed, their WAL is still around to be replayed
+		if info.IsDir() && sstables.IsStagingDir(info.Name()) {
+			stagingPaths = append(stagingPaths, path)
+			return filepath.SkipDir
+		}
+
 		if info.IsDir() && strings.HasPrefix(info.Name(), SSTablePrefix) {
 			tablePaths = append(tablePaths, path)
 		}
@@ -132,6 +156,14 @@ func (db *DB) reconstructSSTables() error {
 		return err
 	}
 
+	for _, p := range stagingPaths {
+		log.Printf("removing incomplete sstable in %s\n", p)
+		err = os.RemoveAll(p)
+		if err != nil {
+			return err
+		}
+	}
+
 	if len(db.sstableManager.allSSTableReaders) != 0 {
 		return fmt.Errorf("unexpected number of sstables found during reconstruction,"+
 			" should be none, but were %d", len(db.sstableManager.allSSTableReaders))
@@ -142,8 +174,9 @@ func (db *DB) reconstructSSTables() error {
 		// do not rely on the order of the FS, we do an additional sort to make sure we start reading from 0000 to 9999
 		sort.Strings(tablePaths)
 		for _, p := range tablePaths {
+			// nested tables from split compactions share the generation of the table they are nested in
 			suffix := filepath.Base(p)[len(SSTablePrefix)+1:]
-			i, err := strconv.ParseUint(suffix, 10, 64)
+			i, err := strconv.ParseUint(strings.SplitN(suffix, "_", 2)[0], 10, 64)
 			if err != nil {
 				return err
 			}
@@ -152,6 +185,7 @@ func (db *DB) reconstructSSTables() error {
 				sstables.ReadBasePath(p),
 				sstables.ReadWithKeyComparator(db.cmp),
 				sstables.ReadBufferSizeBytes(int(db.readBufferSizeBytes)),
+				sstables.ReadWithBlobStore(db.blobStore),
 			)
 			if err != nil {
 				return err
@@ -161,10 +195,55 @@ func (db *DB) reconstructSSTables() error {
 				db.currentGeneration = i
 			}
 
-			db.sstableManager.addReader(reader)
+			err = db.sstableManager.addReader(reader)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// openBlobStore opens the store of the separated values, which is also needed to read a database that had value
+// separation enabled before.
+func (db *DB) openBlobStore() error {
+	blobPath := filepath.Join(db.basePath, BlobFolder)
+	if !db.enableValueSeparation {
+		_, err := os.Stat(blobPath)
+		if os.IsNotExist(err) {
+			return nil
 		}
+		if err != nil {
+			return err
+		}
+	}
+
+	store, err := sstables.NewBlobStore(blobPath)
+	if err != nil {
+		return err
 	}
 
+	db.blobStore = store
+	db.sstableManager.blobStore = store
+	return nil
+}
+
+// collectBlobGarbage deletes the blob files that are not referenced by any sstable, which are left behind by flushes
+// and compactions that crashed.
+func (db *DB) collectBlobGarbage() error {
+	if db.blobStore == nil {
+		return nil
+	}
+
+	deleted, err := db.blobStore.CollectGarbage(db.sstableManager.allSSTableReaders)
+	if err != nil {
+		return err
+	}
+
+	if len(deleted) > 0 {
+		log.Printf("removed %d unreferenced blob files\n", len(deleted))
+	}
 	return nil
 }
 
@@ -223,7 +302,10 @@ func (db *DB) replayAndSetupWriteAheadLog() error {
 
 		switch u := mutation.Mutation.(type) {
 		case *dbproto.WalMutation_Addition:
-			if len(u.Addition.KeyBytes) > 0 {
+			if u.Addition.ExpiresAtMillis > 0 {
+				err = db.memStore.UpsertWithExpiry(u.Addition.KeyBytes, u.Addition.ValueBytes,
+					time.UnixMilli(u.Addition.ExpiresAtMillis))
+			} else if len(u.Addition.KeyBytes) > 0 {
 				err = db.memStore.Upsert(u.Addition.KeyBytes, u.Addition.ValueBytes)
 			} else {
 				err = db.memStore.Upsert([]byte(u.Addition.Key), []byte(u.Addition.Value))
@@ -234,6 +316,11 @@ func (db *DB) replayAndSetupWriteAheadLog() error {
 			} else {
 				err = db.memStore.Tombstone([]byte(u.DeleteTombStone.Key))
 			}
+		case *dbproto.WalMutation_Merge:
+			if db.mergeOperator == nil {
+				return ErrNoMergeOperator
+			}
+			err = db.memStore.Merge(u.Merge.KeyBytes, u.Merge.OperandBytes, db.mergeOperator)
 		}
 
 		return err
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestRecoveryReconstructSSTables(t *testing.T) {
@@ -34,6 +35,40 @@ func TestRecoveryReconstructSSTables(t *testing.T) {
 	assert.Equal(t, 1, len(db.sstableManager.allSSTableReaders))
 }
 
+func TestRecoveryReconstructRemovesStagingDirectories(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_recoveryReconstructRemovesStagingDirectories")
+	defer cleanDatabaseFolder(t, db)
+	defer closeDatabase(t, db)
+
+	// a flush that crashed before renaming the table to its final path
+	tablePath := filepath.Join(db.basePath, fmt.Sprintf(SSTablePattern, 1337))
+	writer, err := sstables.NewSSTableStreamWriter(
+		sstables.WriteBasePath(tablePath),
+		sstables.WithKeyComparator(db.cmp),
+		sstables.WriteAtomically())
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Open())
+	assert.Nil(t, writer.WriteNext([]byte("hello"), []byte("world")))
+
+	entries, err := os.ReadDir(db.basePath)
+	assert.Nil(t, err)
+	var stagingPath string
+	for _, e := range entries {
+		if sstables.IsStagingDir(e.Name()) {
+			stagingPath = filepath.Join(db.basePath, e.Name())
+		}
+	}
+	assert.NotEmpty(t, stagingPath)
+
+	err = db.reconstructSSTables()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(db.sstableManager.allSSTableReaders))
+	_, err = os.Stat(stagingPath)
+	assert.Truef(t, os.IsNotExist(err), "%v", err)
+	_, err = os.Stat(tablePath)
+	assert.Truef(t, os.IsNotExist(err), "%v", err)
+}
+
 func TestRecoveryReconstructSSTablesWithExistingReaders(t *testing.T) {
 	db := newOpenedSimpleDB(t, "simpledb_recoveryReconstructSSTablesWithExistingReaders")
 	defer cleanDatabaseFolder(t, db)
@@ -164,6 +199,90 @@ func TestRecoverySuccessfulCompaction(t *testing.T) {
 	assert.Nil(t, db.sstableManager.currentReader.Close())
 }
 
+func TestRecoverySuccessfulSplitCompaction(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_recoverySuccessfulSplitCompaction")
+	defer cleanDatabaseFolder(t, db)
+
+	closeDatabase(t, db)
+	firstTablePath := fmt.Sprintf(SSTablePattern, 1337)
+	writeSSTableInDatabaseFolder(t, db, firstTablePath)
+	otherTablePath := fmt.Sprintf(SSTablePattern, 1338)
+	writeSSTableInDatabaseFolder(t, db, otherTablePath)
+
+	compactionPath := fmt.Sprintf("%s-%d", SSTableCompactionPathPrefix, 1337)
+	absCompactionPath := filepath.Join(db.basePath, compactionPath)
+	writePaths := []string{filepath.Join(compactionPath, "table_000000"), filepath.Join(compactionPath, "table_000001")}
+	replacementPaths := []string{firstTablePath, firstTablePath + "_00001"}
+	for _, p := range writePaths {
+		writeSSTableInDatabaseFolder(t, db, p)
+	}
+	compMeta := &dbproto.CompactionMetadata{
+		WritePath:        compactionPath,
+		ReplacementPath:  firstTablePath,
+		SstablePaths:     []string{firstTablePath, otherTablePath},
+		WritePaths:       writePaths,
+		ReplacementPaths: replacementPaths,
+		Version:          CompactionMetadataVersionSplitResults,
+	}
+	assert.Nil(t, saveCompactionMetadata(absCompactionPath, compMeta))
+
+	// pretend a previous attempt crashed after moving the first result already
+	assert.Nil(t, os.RemoveAll(filepath.Join(db.basePath, firstTablePath)))
+	assert.Nil(t, os.Rename(filepath.Join(db.basePath, writePaths[0]), filepath.Join(db.basePath, firstTablePath)))
+
+	err := db.repairCompactions()
+	assert.Nil(t, err)
+	_, err = os.Stat(absCompactionPath)
+	assert.Truef(t, os.IsNotExist(err), "%v", err)
+	_, err = os.Stat(filepath.Join(db.basePath, otherTablePath))
+	assert.Truef(t, os.IsNotExist(err), "%v", err)
+
+	err = db.reconstructSSTables()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(db.sstableManager.allSSTableReaders))
+	for i, p := range replacementPaths {
+		assert.Equal(t, filepath.Join(db.basePath, p), db.sstableManager.allSSTableReaders[i].BasePath())
+	}
+	assert.Equal(t, uint64(1337), db.currentGeneration)
+
+	// just for the clean up to work
+	assert.Nil(t, db.sstableManager.currentReader.Close())
+}
+
+func TestRecoverySuccessfulCompactionWithoutResults(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_recoverySuccessfulEmptyCompaction")
+	defer cleanDatabaseFolder(t, db)
+
+	closeDatabase(t, db)
+	firstTablePath := fmt.Sprintf(SSTablePattern, 1337)
+	writeSSTableInDatabaseFolder(t, db, firstTablePath)
+	otherTablePath := fmt.Sprintf(SSTablePattern, 1338)
+	writeSSTableInDatabaseFolder(t, db, otherTablePath)
+
+	// all keys were deleted, the partitions didn't write any table
+	compactionPath := fmt.Sprintf("%s-%d", SSTableCompactionPathPrefix, 1337)
+	absCompactionPath := filepath.Join(db.basePath, compactionPath)
+	assert.Nil(t, os.MkdirAll(filepath.Join(absCompactionPath, fmt.Sprintf(CompactionPartitionPattern, 0)), 0700))
+	compMeta := &dbproto.CompactionMetadata{
+		WritePath:       compactionPath,
+		ReplacementPath: firstTablePath,
+		SstablePaths:    []string{firstTablePath, otherTablePath},
+		Version:         CompactionMetadataVersionSplitResults,
+	}
+	assert.Nil(t, saveCompactionMetadata(absCompactionPath, compMeta))
+
+	err := db.repairCompactions()
+	assert.Nil(t, err)
+	for _, p := range []string{compactionPath, firstTablePath, otherTablePath} {
+		_, err = os.Stat(filepath.Join(db.basePath, p))
+		assert.Truef(t, os.IsNotExist(err), "%v", err)
+	}
+
+	err = db.reconstructSSTables()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(db.sstableManager.allSSTableReaders))
+}
+
 func TestRecoveryWALHappyPath(t *testing.T) {
 	db := newOpenedSimpleDB(t, "simpledb_recoverySuccessfulWAL")
 	defer cleanDatabaseFolder(t, db)
@@ -189,6 +308,62 @@ func TestRecoveryWALHappyPath(t *testing.T) {
 	assert.Equal(t, "world", v)
 }
 
+func TestRecoveryWALWithMerges(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_recoveryWALWithMerges")
+	defer cleanDatabaseFolder(t, db)
+	defer closeDatabase(t, db)
+
+	mutations := []*dbproto.WalMutation{
+		{Mutation: &dbproto.WalMutation_Addition{
+			Addition: &dbproto.UpsertMutation{KeyBytes: []byte("a"), ValueBytes: []byte("1")},
+		}},
+		{Mutation: &dbproto.WalMutation_Merge{
+			Merge: &dbproto.MergeMutation{KeyBytes: []byte("a"), OperandBytes: []byte("2")},
+		}},
+		{Mutation: &dbproto.WalMutation_Merge{
+			Merge: &dbproto.MergeMutation{KeyBytes: []byte("b"), OperandBytes: []byte("3")},
+		}},
+	}
+	assert.Nil(t, createWALWithEntries(db, mutations))
+	assert.ErrorIs(t, db.replayAndSetupWriteAheadLog(), ErrNoMergeOperator)
+
+	db.mergeOperator = counterMergeOperator{}
+	db.sstableManager.mergeOperator = db.mergeOperator
+	assert.Nil(t, db.replayAndSetupWriteAheadLog())
+
+	v, err := db.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", v)
+	v, err = db.Get("b")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", v)
+}
+
+func TestRecoveryWALWithExpiringValues(t *testing.T) {
+	db := newOpenedSimpleDB(t, "simpledb_recoveryWALWithExpiringValues")
+	defer cleanDatabaseFolder(t, db)
+	defer closeDatabase(t, db)
+
+	mutations := []*dbproto.WalMutation{
+		{Mutation: &dbproto.WalMutation_Addition{
+			Addition: &dbproto.UpsertMutation{KeyBytes: []byte("a"), ValueBytes: []byte("1"),
+				ExpiresAtMillis: time.Now().Add(time.Hour).UnixMilli()},
+		}},
+		{Mutation: &dbproto.WalMutation_Addition{
+			Addition: &dbproto.UpsertMutation{KeyBytes: []byte("b"), ValueBytes: []byte("2"),
+				ExpiresAtMillis: time.Now().Add(-time.Hour).UnixMilli()},
+		}},
+	}
+	assert.Nil(t, createWALWithEntries(db, mutations))
+	assert.Nil(t, db.replayAndSetupWriteAheadLog())
+
+	v, err := db.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+	_, err = db.Get("b")
+	assert.Equal(t, ErrNotFound, err)
+}
+
 func createWALWithEntries(db *DB, mutations []*dbproto.WalMutation) error {
 	// close the current WAL to overwrite the state
 	err := db.wal.Close()
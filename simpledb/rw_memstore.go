@@ -2,6 +2,8 @@ package simpledb
 
 import (
 	"errors"
+	"time"
+
 	"github.com/thomasjungblut/go-sstables/memstore"
 	"github.com/thomasjungblut/go-sstables/sstables"
 )
@@ -35,6 +37,34 @@ func (c *RWMemstore) Get(key []byte) ([]byte, error) {
 	return writeVal, nil
 }
 
+// mergeOperands returns the operands of both stores from the oldest to the newest, and the put or delete they need to
+// be merged with. The base is nil when neither store contains a put or a delete for the key.
+// The readStore is skipped when it's the given flushed store, since its operands are already part of the sstables.
+func (c *RWMemstore) mergeOperands(key []byte, flushed memstore.MemStoreI) ([][]byte, *sstables.MergeValue, error) {
+	stores := []memstore.MemStoreI{c.writeStore}
+	if c.readStore != c.writeStore && c.readStore != flushed {
+		stores = append(stores, c.readStore)
+	}
+
+	var operands [][]byte
+	for _, store := range stores {
+		value, err := store.GetMergeValue(key)
+		if err != nil {
+			if errors.Is(err, memstore.KeyNotFound) {
+				continue
+			}
+			return nil, nil, err
+		}
+
+		if value.Kind != sstables.ValueKindMerge {
+			return operands, &value, nil
+		}
+		operands = append(append([][]byte{}, value.Operands...), operands...)
+	}
+
+	return operands, nil, nil
+}
+
 // write paths, just proxy to the writeStore
 
 func (c *RWMemstore) Add(key []byte, value []byte) error {
@@ -45,6 +75,10 @@ func (c *RWMemstore) Upsert(key []byte, value []byte) error {
 	return c.writeStore.Upsert(key, value)
 }
 
+func (c *RWMemstore) UpsertWithExpiry(key []byte, value []byte, expiresAt time.Time) error {
+	return c.writeStore.UpsertWithExpiry(key, value, expiresAt)
+}
+
 func (c *RWMemstore) Delete(key []byte) error {
 	err := c.writeStore.Delete(key)
 	if errors.Is(err, memstore.KeyNotFound) {
@@ -58,6 +92,10 @@ func (c *RWMemstore) DeleteIfExists(key []byte) error {
 	return c.Delete(key)
 }
 
+func (c *RWMemstore) Merge(key []byte, operand []byte, operator sstables.MergeOperator) error {
+	return c.writeStore.Merge(key, operand, operator)
+}
+
 func (c *RWMemstore) Tombstone(key []byte) error {
 	return c.writeStore.Tombstone(key)
 }
@@ -70,6 +108,10 @@ func (c *RWMemstore) SStableIterator() sstables.SSTableIteratorI {
 	return c.writeStore.SStableIterator()
 }
 
+func (c *RWMemstore) SeekableIterator() sstables.SSTableSeekableIteratorI {
+	return c.writeStore.SeekableIterator()
+}
+
 func (c *RWMemstore) Flush(opts ...sstables.WriterOption) error {
 	return c.writeStore.Flush(opts...)
 }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/thomasjungblut/go-sstables/memstore"
 	"github.com/thomasjungblut/go-sstables/simpledb/proto"
 	"github.com/thomasjungblut/go-sstables/skiplist"
 	"github.com/thomasjungblut/go-sstables/sstables"
@@ -19,6 +20,11 @@ type SSTableManager struct {
 	managerLock       *sync.RWMutex
 	allSSTableReaders []sstables.SSTableReaderI
 	currentReader     sstables.SSTableReaderI
+	mergeOperator     sstables.MergeOperator
+	// blobStore contains the separated values of the sstables, nil if there are none
+	blobStore *sstables.BlobStore
+	// flushedMemStore is the memstore that was flushed last, its contents are already part of the currentReader
+	flushedMemStore memstore.MemStoreI
 }
 
 func (s *SSTableManager) reflectCompactionResult(m *proto.CompactionMetadata) error {
@@ -29,61 +35,100 @@ func (s *SSTableManager) reflectCompactionResult(m *proto.CompactionMetadata) er
 		defer s.databaseLock.Unlock()
 		defer s.managerLock.Unlock()
 
+		// the compacted tables are contiguous, the results are taking the place of them in the same order
+		firstIndex := indexOfReader(s.allSSTableReaders, m.SstablePaths[0])
+		if firstIndex < 0 {
+			return fmt.Errorf("couldn't find first compacted sstable in current readers. Path: %v", m.SstablePaths[0])
+		}
+
+		var compactedReaders []sstables.SSTableReaderI
 		for _, p := range m.SstablePaths {
 			i := indexOfReader(s.allSSTableReaders, p)
-			if i >= 0 {
-				err := s.allSSTableReaders[i].Close()
-				if err != nil {
-					return err
-				}
-				// this is actually a "neuralgic" point in terms of recovery, we know that the SSTable backed by newReader
-				// contains the whole data of all the SSTables we're about to remove. So it's safe to delete them here.
-				err = os.RemoveAll(filepath.Join(s.basePath, p))
-				if err != nil {
-					return err
-				}
+			if i < 0 {
+				return fmt.Errorf("couldn't find sstable in current readers. Path: %v", p)
+			}
+			compactedReaders = append(compactedReaders, s.allSSTableReaders[i])
+
+			err := s.allSSTableReaders[i].Close()
+			if err != nil {
+				return err
+			}
+			// this is actually a "neuralgic" point in terms of recovery, we know that the SSTables written by the compaction
+			// contain the whole data of all the SSTables we're about to remove. So it's safe to delete them here.
+			err = os.RemoveAll(filepath.Join(s.basePath, p))
+			if err != nil {
+				return err
 			}
+			s.allSSTableReaders = removeReaderAt(s.allSSTableReaders, i)
 		}
 
 		// this is another important step in the recovery process, we need to ensure the ordering is preserved in case of crashes and
-		// thus replace the very first written SSTable in the path set. This creates a couple of "holes" in the numbering schema of
-		// the SSTables, but we guarantee that the compaction is in the right place.
-		err := os.Rename(filepath.Join(s.basePath, m.WritePath), filepath.Join(s.basePath, m.ReplacementPath))
-		if err != nil {
-			return err
+		// thus replace the very first written SSTable in the path set, additional results are nested right after it.
+		// This creates a couple of "holes" in the numbering schema of the SSTables, but we guarantee that the compaction is in the right place.
+		writePaths, replacementPaths := compactionResultPaths(m)
+		var replacedReaders []sstables.SSTableReaderI
+		for i := range writePaths {
+			err := os.Rename(filepath.Join(s.basePath, writePaths[i]), filepath.Join(s.basePath, replacementPaths[i]))
+			if err != nil {
+				return err
+			}
+
+			replacedReader, err := sstables.NewSSTableReader(
+				sstables.ReadBasePath(filepath.Join(s.basePath, replacementPaths[i])),
+				sstables.ReadWithKeyComparator(s.cmp),
+				sstables.ReadWithBlobStore(s.blobStore),
+			)
+			if err != nil {
+				return err
+			}
+			replacedReaders = append(replacedReaders, replacedReader)
 		}
+		s.allSSTableReaders = slices.Insert(s.allSSTableReaders, firstIndex, replacedReaders...)
 
-		replacedReader, err := sstables.NewSSTableReader(
-			sstables.ReadBasePath(filepath.Join(s.basePath, m.ReplacementPath)),
-			sstables.ReadWithKeyComparator(s.cmp),
-		)
-		if err != nil {
-			return err
+		// the results were written into sub folders, which leaves the compaction folder with the metadata behind
+		if m.Version >= CompactionMetadataVersionSplitResults {
+			err := os.RemoveAll(filepath.Join(s.basePath, m.WritePath))
+			if err != nil {
+				return err
+			}
 		}
 
-		i := indexOfReader(s.allSSTableReaders, m.ReplacementPath)
-		if i < 0 {
-			return fmt.Errorf("couldn't find replacement sstable in current readers. Path: %v", m.ReplacementPath)
+		currentReader, err := sstables.NewCheckedSuperSSTableReader(s.allSSTableReaders, s.cmp,
+			sstables.SuperReadWithMergeOperator(s.mergeOperator))
+		if err != nil {
+			return err
 		}
+		s.currentReader = currentReader
 
-		s.allSSTableReaders[i] = replacedReader
-		// remove the remainder of the deleted paths
-		for _, p := range m.SstablePaths {
-			if p != m.ReplacementPath {
-				readerIndex := indexOfReader(s.allSSTableReaders, p)
-				if readerIndex < 0 {
-					return fmt.Errorf("couldn't find sstable in current readers. Path: %v", p)
-				}
-				s.allSSTableReaders = removeReaderAt(s.allSSTableReaders, readerIndex)
+		// the blob files that were only referenced by the compacted tables are garbage now, which includes the
+		// files whose values the compaction rewrote. Files of tables that are still being flushed are left alone.
+		if s.blobStore != nil {
+			_, err = s.blobStore.CollectGarbageOf(compactedReaders, s.allSSTableReaders)
+			if err != nil {
+				return err
 			}
 		}
 
-		s.currentReader = sstables.NewSuperSSTableReader(s.allSSTableReaders, s.cmp)
-
 		return nil
 	}()
 }
 
+// blobFilesToRewrite returns the blob files of the given tables that are mostly garbage, see
+// sstables.BlobStore.FilesToRewrite.
+func (s *SSTableManager) blobFilesToRewrite(paths []string, garbageRatio float64) ([]uint64, error) {
+	s.managerLock.RLock()
+	defer s.managerLock.RUnlock()
+
+	var compactedReaders []sstables.SSTableReaderI
+	for _, reader := range s.allSSTableReaders {
+		if slices.Contains(paths, reader.BasePath()) {
+			compactedReaders = append(compactedReaders, reader)
+		}
+	}
+
+	return s.blobStore.FilesToRewrite(compactedReaders, s.allSSTableReaders, garbageRatio)
+}
+
 func (s *SSTableManager) clearReaders() {
 	s.managerLock.Lock()
 	func() {
@@ -94,17 +139,43 @@ func (s *SSTableManager) clearReaders() {
 	}()
 }
 
-func (s *SSTableManager) addReader(newReader sstables.SSTableReaderI) {
+func (s *SSTableManager) addReader(newReader sstables.SSTableReaderI) error {
 	s.managerLock.Lock()
-	func() {
+	return func() error {
+		defer s.managerLock.Unlock()
+
+		return s.appendReader(newReader)
+	}()
+}
+
+// addFlushedReader adds the reader of the given flushed memstore, which is swapped together with the current reader.
+func (s *SSTableManager) addFlushedReader(newReader sstables.SSTableReaderI, flushed memstore.MemStoreI) error {
+	s.managerLock.Lock()
+	return func() error {
 		defer s.managerLock.Unlock()
 
-		allSSTableReaders := append(s.allSSTableReaders, newReader)
-		s.currentReader = sstables.NewSuperSSTableReader(allSSTableReaders, s.cmp)
-		s.allSSTableReaders = allSSTableReaders
+		err := s.appendReader(newReader)
+		if err != nil {
+			return err
+		}
+		s.flushedMemStore = flushed
+		return nil
 	}()
 }
 
+// appendReader requires the managerLock to be held.
+func (s *SSTableManager) appendReader(newReader sstables.SSTableReaderI) error {
+	allSSTableReaders := append(s.allSSTableReaders, newReader)
+	currentReader, err := sstables.NewCheckedSuperSSTableReader(allSSTableReaders, s.cmp,
+		sstables.SuperReadWithMergeOperator(s.mergeOperator))
+	if err != nil {
+		return err
+	}
+	s.currentReader = currentReader
+	s.allSSTableReaders = allSSTableReaders
+	return nil
+}
+
 func (s *SSTableManager) currentSSTable() sstables.SSTableReaderI {
 	s.managerLock.RLock()
 	defer s.managerLock.RUnlock()
@@ -112,6 +183,14 @@ func (s *SSTableManager) currentSSTable() sstables.SSTableReaderI {
 	return s.currentReader
 }
 
+// currentSSTableAndFlushedMemStore returns the current reader along with the last memstore that it already contains.
+func (s *SSTableManager) currentSSTableAndFlushedMemStore() (sstables.SSTableReaderI, memstore.MemStoreI) {
+	s.managerLock.RLock()
+	defer s.managerLock.RUnlock()
+
+	return s.currentReader, s.flushedMemStore
+}
+
 func (s *SSTableManager) candidateTablesForCompaction(compactionMaxSizeBytes uint64, compactionRatio float32) compactionAction {
 	s.managerLock.RLock()
 	defer s.managerLock.RUnlock()
@@ -140,18 +219,27 @@ func (s *SSTableManager) candidateTablesForCompaction(compactionMaxSizeBytes uin
 		The below function will fill the holes, by also selecting the tables in between for compaction - so the lineage is always preserved.
 	*/
 	numRecords := uint64(0)
+	encodedValues := true
 	selectedForCompaction = floodFill(selectedForCompaction)
 	var selectedPaths []string
+	nextPath := ""
 	for i := 0; i < len(s.allSSTableReaders); i++ {
 		if selectedForCompaction[i] {
 			selectedPaths = append(selectedPaths, s.allSSTableReaders[i].BasePath())
 			numRecords += s.allSSTableReaders[i].MetaData().NumRecords
+			encodedValues = encodedValues && !s.allSSTableReaders[i].MetaData().MergeOperands &&
+				!s.allSSTableReaders[i].MetaData().ExpiringValues
+		} else if len(selectedPaths) > 0 && nextPath == "" {
+			nextPath = s.allSSTableReaders[i].BasePath()
 		}
 	}
 
 	return compactionAction{
 		pathsToCompact: selectedPaths,
 		totalRecords:   numRecords,
+		nextPath:       nextPath,
+		bottommost:     len(selectedForCompaction) > 0 && selectedForCompaction[0],
+		encodedValues:  encodedValues,
 	}
 }
 
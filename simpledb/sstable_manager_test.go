@@ -99,6 +99,10 @@ func TestSSTableManagerSelectCompactionCandidates(t *testing.T) {
 	assertCompactionAction(t, 105, []string{"2", "3", "4"}, manager.candidateTablesForCompaction(51, 1))
 	assertCompactionAction(t, 115, []string{"1", "2", "3", "4"}, manager.candidateTablesForCompaction(101, 1))
 	assertCompactionAction(t, 115, []string{"1", "2", "3", "4"}, manager.candidateTablesForCompaction(1500, 1))
+
+	// only compactions that include the oldest table are bottommost
+	assert.False(t, manager.candidateTablesForCompaction(51, 1).bottommost)
+	assert.True(t, manager.candidateTablesForCompaction(101, 1).bottommost)
 }
 
 func TestSSTableManagerSelectCompactionCandidatesTombstoneRatios(t *testing.T) {
@@ -223,6 +227,10 @@ func (m *MockSSTableReader) MetaData() *proto.MetaData {
 	return m.metadata
 }
 
+func (m *MockSSTableReader) Properties() map[string][]byte {
+	return m.metadata.Properties
+}
+
 func (m *MockSSTableReader) Contains(key []byte) (bool, error) {
 	return false, nil
 }
@@ -231,6 +239,31 @@ func (m *MockSSTableReader) Get(key []byte) ([]byte, error) {
 	return nil, sstables.NotFound
 }
 
+func (m *MockSSTableReader) ApproximateOffsetOf(_ []byte) (uint64, error) {
+	return 0, nil
+}
+
+func (m *MockSSTableReader) ApproximateSizeOfRange(_ []byte, _ []byte) (uint64, error) {
+	return 0, nil
+}
+
+func (m *MockSSTableReader) SampleKeys(_ int) ([][]byte, error) {
+	return nil, nil
+}
+
+func (m *MockSSTableReader) KeyQuantiles(_ int) ([][]byte, error) {
+	return nil, nil
+}
+
+func (m *MockSSTableReader) MultiGet(keys [][]byte) ([][]byte, []error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		values[i], errs[i] = m.Get(key)
+	}
+	return values, errs
+}
+
 func (m *MockSSTableReader) Scan() (sstables.SSTableIteratorI, error) {
 	return sstables.EmptySSTableIterator{}, nil
 }
@@ -243,6 +276,22 @@ func (m *MockSSTableReader) ScanRange(keyLower []byte, keyHigher []byte) (sstabl
 	return sstables.EmptySSTableIterator{}, nil
 }
 
+func (m *MockSSTableReader) GetAsOf(userKey []byte, sequence uint64) ([]byte, error) {
+	return nil, sstables.NotFound
+}
+
+func (m *MockSSTableReader) ScanPrefix(prefix []byte) (sstables.SSTableIteratorI, error) {
+	return sstables.EmptySSTableIterator{}, nil
+}
+
+func (m *MockSSTableReader) SeekableIterator() (sstables.SSTableSeekableIteratorI, error) {
+	return sstables.EmptySSTableIterator{}, nil
+}
+
+func (m *MockSSTableReader) RangeTombstones() sstables.RangeTombstones {
+	return nil
+}
+
 func (m *MockSSTableReader) Close() error {
 	return nil
 }
@@ -15,6 +15,22 @@ type Comparator[T any] interface {
 	Compare(a T, b T) int
 }
 
+// NamedComparator is a Comparator that can be identified by a name. The name is persisted alongside sorted data, for
+// example in the sstable metadata, to detect when the data is read with a different ordering than it was written with.
+type NamedComparator[T any] interface {
+	Comparator[T]
+	// Name returns a stable identifier of the ordering, it must change whenever the ordering changes.
+	Name() string
+}
+
+// ComparatorName returns the name of the given comparator, or an empty string if it is not a NamedComparator.
+func ComparatorName[T any](cmp Comparator[T]) string {
+	if named, ok := cmp.(NamedComparator[T]); ok {
+		return named.Name()
+	}
+	return ""
+}
+
 // Done indicates an iterator has returned all items.
 // https://github.com/GoogleCloudPlatform/google-cloud-go/wiki/Iterator-Guidelines
 var Done = errors.New("no more items in iterator")
@@ -44,12 +60,36 @@ func (BytesComparator) Compare(a []byte, b []byte) int {
 	return bytes.Compare(a, b)
 }
 
+func (BytesComparator) Name() string {
+	return "skiplist.BytesComparator"
+}
+
 type IteratorI[K any, V any] interface {
 	// Next returns the next key, value in sequence
 	// returns Done as the error when the iterator is exhausted
 	Next() (K, V, error)
 }
 
+// SeekableIteratorI is a bidirectional iterator that can be re-positioned without creating a new iterator.
+// The iterator is positioned at an entry, Next and Prev return the entry at the current position and
+// move the position forward or backward respectively.
+type SeekableIteratorI[K any, V any] interface {
+	IteratorI[K, V]
+	// Prev returns the current key, value in sequence and moves the iterator backwards
+	// returns Done as the error when the iterator is exhausted
+	Prev() (K, V, error)
+	// Seek positions the iterator at the first key that is greater or equal to the given key
+	Seek(key K) error
+	// SeekForPrev positions the iterator at the last key that is less or equal to the given key
+	SeekForPrev(key K) error
+	// SeekToFirst positions the iterator at the first key of the sequence
+	SeekToFirst() error
+	// SeekToLast positions the iterator at the last key of the sequence
+	SeekToLast() error
+	// Valid returns true when the iterator is positioned at an entry, false when it was exhausted in either direction
+	Valid() bool
+}
+
 type Iterator[K any, V any] struct {
 	comp      Comparator[K]
 	node      *Node[K, V]
@@ -78,6 +118,60 @@ func (it *Iterator[K, V]) Next() (_ K, _ V, done error) {
 	return cur.key, cur.value, nil
 }
 
+type SeekableIterator[K any, V any] struct {
+	list *Map[K, V]
+	node *Node[K, V]
+}
+
+func (it *SeekableIterator[K, V]) Next() (_ K, _ V, done error) {
+	done = Done
+	if it.node == nil {
+		return
+	}
+	cur := it.node
+	it.node = cur.Next(0)
+	return cur.key, cur.value, nil
+}
+
+func (it *SeekableIterator[K, V]) Prev() (_ K, _ V, done error) {
+	done = Done
+	if it.node == nil {
+		return
+	}
+	cur := it.node
+	// there are no backward pointers, so we search from the head as leveldb does
+	it.node = findLessThan(it.list, cur.key)
+	return cur.key, cur.value, nil
+}
+
+func (it *SeekableIterator[K, V]) Seek(key K) error {
+	it.node = findGreaterOrEqual(it.list, key, nil)
+	return nil
+}
+
+func (it *SeekableIterator[K, V]) SeekForPrev(key K) error {
+	node := findGreaterOrEqual(it.list, key, nil)
+	if node == nil || it.list.comp.Compare(node.key, key) > 0 {
+		node = findLessThan(it.list, key)
+	}
+	it.node = node
+	return nil
+}
+
+func (it *SeekableIterator[K, V]) SeekToFirst() error {
+	it.node = it.list.head.Next(0)
+	return nil
+}
+
+func (it *SeekableIterator[K, V]) SeekToLast() error {
+	it.node = findLast(it.list)
+	return nil
+}
+
+func (it *SeekableIterator[K, V]) Valid() bool {
+	return it.node != nil
+}
+
 type MapI[K any, V any] interface {
 	Size() int
 
@@ -103,6 +197,9 @@ type MapI[K any, V any] interface {
 	// Using keys that are out of the sequence range will result in either an empty iterator or the full sequence.
 	// If keyHigher is lower than keyLower an error will be returned
 	IteratorBetween(keyLower K, keyHigher K) (IteratorI[K, V], error)
+
+	// SeekableIterator returns a bidirectional iterator that is positioned at the first key of the sequence
+	SeekableIterator() (SeekableIteratorI[K, V], error)
 }
 
 type NodeI[K any, V any] interface {
@@ -211,6 +308,10 @@ func (list *Map[K, V]) IteratorBetween(keyLower K, keyHigher K) (IteratorI[K, V]
 	return &Iterator[K, V]{node: node, comp: list.comp, keyHigher: &keyHigher}, nil
 }
 
+func (list *Map[K, V]) SeekableIterator() (SeekableIteratorI[K, V], error) {
+	return &SeekableIterator[K, V]{list: list, node: list.head.Next(0)}, nil
+}
+
 func NewSkipListMap[K any, V any](comp Comparator[K]) MapI[K, V] {
 	const maxHeight = 12
 	return &Map[K, V]{head: newDefaultSkipListNode[K, V](maxHeight), comp: comp, maxHeight: maxHeight}
@@ -240,6 +341,46 @@ func findGreaterOrEqual[K any, V any](list *Map[K, V], key K, prevTable []*Node[
 	}
 }
 
+// findLessThan returns the last node with a key that is strictly less than the given key, nil if there is none
+func findLessThan[K any, V any](list *Map[K, V], key K) *Node[K, V] {
+	x := list.head
+	level := list.maxHeight - 1
+	for {
+		next := x.Next(level)
+		if next != nil && list.comp.Compare(next.key, key) < 0 {
+			x = next
+		} else {
+			if level == 0 {
+				if x == list.head {
+					return nil
+				}
+				return x
+			}
+			level--
+		}
+	}
+}
+
+// findLast returns the last node of the list, nil if the list is empty
+func findLast[K any, V any](list *Map[K, V]) *Node[K, V] {
+	x := list.head
+	level := list.maxHeight - 1
+	for {
+		next := x.Next(level)
+		if next != nil {
+			x = next
+		} else {
+			if level == 0 {
+				if x == list.head {
+					return nil
+				}
+				return x
+			}
+			level--
+		}
+	}
+}
+
 func randomHeight(maxHeight int) int {
 	const branchFactor = 4
 	height := 1
@@ -282,6 +282,111 @@ func TestSkipListRangeScanQuick(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestSkipListSeekableIteratorEmpty(t *testing.T) {
+	list := NewSkipListMap[int, int](OrderedComparator[int]{})
+	it, err := list.SeekableIterator()
+	require.NoError(t, err)
+	assert.False(t, it.Valid())
+	require.NoError(t, it.SeekToLast())
+	assert.False(t, it.Valid())
+	_, _, err = it.Prev()
+	assert.Equal(t, Done, err)
+	require.NoError(t, it.Seek(5))
+	_, _, err = it.Next()
+	assert.Equal(t, Done, err)
+	require.NoError(t, it.SeekForPrev(5))
+	assert.False(t, it.Valid())
+}
+
+func TestSkipListSeekableIterator(t *testing.T) {
+	list := NewSkipListMap[int, int](OrderedComparator[int]{})
+	batchInsertAndAssertContains(t, []int{10, 20, 30, 40, 50}, list)
+
+	it, err := list.SeekableIterator()
+	require.NoError(t, err)
+	assert.True(t, it.Valid())
+	assertIteratorOutputs(t, []int{10, 20, 30, 40, 50}, it)
+	assert.False(t, it.Valid())
+
+	require.NoError(t, it.SeekToLast())
+	assertReverseIteratorOutputs(t, []int{50, 40, 30, 20, 10}, it)
+	assert.False(t, it.Valid())
+
+	require.NoError(t, it.Seek(25))
+	assertIteratorOutputs(t, []int{30, 40, 50}, it)
+	require.NoError(t, it.Seek(30))
+	assertReverseIteratorOutputs(t, []int{30, 20, 10}, it)
+	require.NoError(t, it.Seek(51))
+	assert.False(t, it.Valid())
+
+	require.NoError(t, it.SeekForPrev(25))
+	assertReverseIteratorOutputs(t, []int{20, 10}, it)
+	require.NoError(t, it.SeekForPrev(30))
+	assertReverseIteratorOutputs(t, []int{30, 20, 10}, it)
+	require.NoError(t, it.SeekForPrev(100))
+	assertReverseIteratorOutputs(t, []int{50, 40, 30, 20, 10}, it)
+	require.NoError(t, it.SeekForPrev(9))
+	assert.False(t, it.Valid())
+
+	// changing directions returns the entry at the current position
+	require.NoError(t, it.SeekToFirst())
+	k, _, err := it.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 10, k)
+	k, _, err = it.Prev()
+	require.NoError(t, err)
+	assert.Equal(t, 20, k)
+	k, _, err = it.Prev()
+	require.NoError(t, err)
+	assert.Equal(t, 10, k)
+	assert.False(t, it.Valid())
+}
+
+func TestSkipListSeekableIteratorQuick(t *testing.T) {
+	err := quick.Check(func(input []int, seekKey int) bool {
+		slices.Sort(input)
+		input = slices.Compact(input)
+		list := NewSkipListMap[int, int](OrderedComparator[int]{})
+		for _, e := range input {
+			list.Insert(e, e+1)
+		}
+
+		it, err := list.SeekableIterator()
+		require.NoError(t, err)
+		require.NoError(t, it.SeekToLast())
+		expected := slices.Clone(input)
+		slices.Reverse(expected)
+		assertReverseIteratorOutputs(t, expected, it)
+
+		require.NoError(t, it.SeekForPrev(seekKey))
+		idx, found := slices.BinarySearch(input, seekKey)
+		if found {
+			idx++
+		}
+		expected = slices.Clone(input[:idx])
+		slices.Reverse(expected)
+		assertReverseIteratorOutputs(t, expected, it)
+		return true
+	}, nil)
+	require.NoError(t, err)
+}
+
+func assertReverseIteratorOutputs(t *testing.T, expectedSeq []int, it SeekableIteratorI[int, int]) {
+	currentIndex := 0
+	for {
+		k, v, err := it.Prev()
+		if errors.Is(err, Done) {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, expectedSeq[currentIndex], k)
+		assert.Equal(t, expectedSeq[currentIndex]+1, v)
+		currentIndex++
+	}
+
+	assert.Equal(t, len(expectedSeq), currentIndex)
+}
+
 func singleElementSkipList(t *testing.T) MapI[int, int] {
 	list := NewSkipListMap[int, int](OrderedComparator[int]{})
 	list.Insert(13, 91)
@@ -332,3 +437,8 @@ func batchInsertAndAssertContains(t *testing.T, toInsert []int, list MapI[int, i
 	assert.Nil(t, err)
 	assertIteratorOutputs(t, toInsert, it)
 }
+
+func TestComparatorName(t *testing.T) {
+	assert.Equal(t, "skiplist.BytesComparator", ComparatorName[[]byte](BytesComparator{}))
+	assert.Equal(t, "", ComparatorName[int](OrderedComparator[int]{}))
+}
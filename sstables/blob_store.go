@@ -0,0 +1,478 @@
+package sstables
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/thomasjungblut/go-sstables/recordio"
+)
+
+var BlobFileNamePattern = "blob_%015d.rio"
+
+// BlobNextFileIdFileName stores the id of the next blob file, so ids are never reused once their files were deleted.
+var BlobNextFileIdFileName = "next_file_id"
+
+const (
+	valueTagInline = byte(0)
+	valueTagBlob   = byte(1)
+
+	blobReferenceSizeBytes = 1 + 3*8
+)
+
+// BlobReference points to a value that was separated from the sstable into a blob file of a BlobStore.
+type BlobReference struct {
+	FileId uint64
+	Offset uint64
+	Length uint64
+}
+
+// BlobStore manages a directory of append-only blob files, which contain the values that were separated from sstables.
+// Multiple sstables can share the same store, which allows merges and compactions to copy the references
+// instead of rewriting the values. The store is thread-safe and has to be closed by the caller once all readers are closed.
+type BlobStore struct {
+	basePath string
+
+	lock    sync.Mutex
+	readers map[uint64]recordio.ReadAtI
+	// files that are currently being written to, these are never garbage collected
+	activeFiles map[uint64]struct{}
+	// the number of value bytes of the files that were written completely, which is the base of their garbage ratio
+	fileBytes map[uint64]uint64
+	// the id of the next file, which is persisted before the file is created
+	nextFileId uint64
+}
+
+// Get returns the value that the given reference points to.
+func (b *BlobStore) Get(ref BlobReference) ([]byte, error) {
+	reader, err := b.fileReader(ref.FileId)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := reader.ReadNextAt(ref.Offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error in blob store '%s' while reading file %d at offset %d: %w",
+			b.basePath, ref.FileId, ref.Offset, err)
+	}
+
+	if uint64(len(v)) != ref.Length {
+		return nil, fmt.Errorf("error in blob store '%s' while reading file %d at offset %d: expected %d bytes, but got %d",
+			b.basePath, ref.FileId, ref.Offset, ref.Length, len(v))
+	}
+
+	return v, nil
+}
+
+func (b *BlobStore) fileReader(fileId uint64) (recordio.ReadAtI, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if reader, ok := b.readers[fileId]; ok {
+		return reader, nil
+	}
+
+	reader, err := recordio.NewMemoryMappedReaderWithPath(b.filePath(fileId))
+	if err != nil {
+		return nil, fmt.Errorf("error in blob store '%s' while creating reader for file %d: %w", b.basePath, fileId, err)
+	}
+
+	err = reader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error in blob store '%s' while opening reader for file %d: %w", b.basePath, fileId, err)
+	}
+
+	b.readers[fileId] = reader
+	return reader, nil
+}
+
+// newFileWriter reserves a new blob file and returns its id alongside an opened writer.
+// The file is considered active and won't be garbage collected until it is released via releaseFile.
+func (b *BlobStore) newFileWriter(compressionType int, bufferSizeBytes int) (uint64, recordio.WriterI, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// the exclusive creation guards against other processes that write into the same directory
+	fileId := b.nextFileId
+	var file *os.File
+	for {
+		err := b.persistNextFileId(fileId + 1)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		file, err = os.OpenFile(b.filePath(fileId), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return 0, nil, fmt.Errorf("error in blob store '%s' while creating file %d: %w", b.basePath, fileId, err)
+		}
+		fileId++
+	}
+
+	writer, err := recordio.NewFileWriter(
+		recordio.File(file),
+		recordio.CompressionType(compressionType),
+		recordio.BufferSizeBytes(bufferSizeBytes))
+	if err != nil {
+		return 0, nil, fmt.Errorf("error in blob store '%s' while creating writer for file %d: %w", b.basePath, fileId, err)
+	}
+
+	err = writer.Open()
+	if err != nil {
+		return 0, nil, fmt.Errorf("error in blob store '%s' while opening writer for file %d: %w", b.basePath, fileId, err)
+	}
+
+	b.nextFileId = fileId + 1
+	b.activeFiles[fileId] = struct{}{}
+	return fileId, writer, nil
+}
+
+// syncFile makes the written file durable, which must happen before any table that references it is committed.
+func (b *BlobStore) syncFile(fileId uint64) error {
+	err := syncPath(b.filePath(fileId))
+	if err != nil {
+		return fmt.Errorf("error in blob store '%s' while syncing file %d: %w", b.basePath, fileId, err)
+	}
+
+	return syncPath(b.basePath)
+}
+
+// persistNextFileId durably replaces the id of the next file, a crash never leaves the store with a lower id than
+// any file it created.
+func (b *BlobStore) persistNextFileId(nextFileId uint64) (err error) {
+	path := filepath.Join(b.basePath, BlobNextFileIdFileName)
+	tmpPath := path + ".tmp"
+	err = func() (err error) {
+		file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err = errors.Join(err, file.Close())
+		}()
+
+		_, err = file.Write(binary.BigEndian.AppendUint64(nil, nextFileId))
+		if err != nil {
+			return err
+		}
+		return file.Sync()
+	}()
+	if err != nil {
+		return fmt.Errorf("error in blob store '%s' while writing the next file id: %w", b.basePath, err)
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return fmt.Errorf("error in blob store '%s' while replacing the next file id: %w", b.basePath, err)
+	}
+
+	return syncPath(b.basePath)
+}
+
+// loadNextFileId reads the persisted id of the next file. Stores that were written before the id was persisted
+// continue after their highest file.
+func (b *BlobStore) loadNextFileId() error {
+	ids, err := b.listFileIds()
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		b.nextFileId = ids[len(ids)-1] + 1
+	}
+
+	content, err := os.ReadFile(filepath.Join(b.basePath, BlobNextFileIdFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error in blob store '%s' while reading the next file id: %w", b.basePath, err)
+	}
+
+	if len(content) != 8 {
+		return fmt.Errorf("error in blob store '%s' while reading the next file id: expected 8 bytes, but got %d",
+			b.basePath, len(content))
+	}
+
+	b.nextFileId = max(b.nextFileId, binary.BigEndian.Uint64(content))
+	return nil
+}
+
+// releaseFile marks the file as completely written with the given number of value bytes.
+func (b *BlobStore) releaseFile(fileId uint64, numBytes uint64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.activeFiles, fileId)
+	b.fileBytes[fileId] = numBytes
+}
+
+// totalFileBytes returns the number of value bytes that were written into the file, files that weren't written by this
+// instance of the store are read once to count them.
+func (b *BlobStore) totalFileBytes(fileId uint64) (uint64, error) {
+	b.lock.Lock()
+	numBytes, ok := b.fileBytes[fileId]
+	b.lock.Unlock()
+	if ok {
+		return numBytes, nil
+	}
+
+	reader, err := recordio.NewFileReaderWithPath(b.filePath(fileId))
+	if err != nil {
+		return 0, fmt.Errorf("error in blob store '%s' while creating reader for file %d: %w", b.basePath, fileId, err)
+	}
+
+	err = reader.Open()
+	if err != nil {
+		return 0, fmt.Errorf("error in blob store '%s' while opening reader for file %d: %w", b.basePath, fileId, err)
+	}
+
+	for {
+		v, rErr := reader.ReadNext()
+		if errors.Is(rErr, io.EOF) {
+			break
+		}
+		if rErr != nil {
+			return 0, errors.Join(fmt.Errorf("error in blob store '%s' while reading file %d: %w", b.basePath, fileId, rErr), reader.Close())
+		}
+		numBytes += uint64(len(v))
+	}
+
+	err = reader.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.fileBytes[fileId] = numBytes
+	return numBytes, nil
+}
+
+// FilesToRewrite returns the ids of the blob files that are only referenced by the compacted tables, and whose ratio of
+// bytes that aren't referenced by any of the live tables is at least garbageRatio. A compaction of those tables that is
+// written with RewriteBlobFiles copies the remaining values into a new blob file, which leaves the old files without
+// any references to be deleted by CollectGarbage afterwards. The live tables must be the complete set of sstables that
+// reference this store, including the compacted ones.
+func (b *BlobStore) FilesToRewrite(compactedTables []SSTableReaderI, liveTables []SSTableReaderI, garbageRatio float64) ([]uint64, error) {
+	compactedPaths := map[string]struct{}{}
+	for _, table := range compactedTables {
+		compactedPaths[table.BasePath()] = struct{}{}
+	}
+
+	liveBytes := map[uint64]uint64{}
+	referencedElsewhere := map[uint64]struct{}{}
+	for _, table := range liveTables {
+		_, compacted := compactedPaths[table.BasePath()]
+		for fileId, numBytes := range table.MetaData().BlobFileBytes {
+			liveBytes[fileId] += numBytes
+			if !compacted {
+				referencedElsewhere[fileId] = struct{}{}
+			}
+		}
+	}
+
+	var rewrite []uint64
+	for fileId := range referencedFiles(compactedTables) {
+		if _, ok := referencedElsewhere[fileId]; ok {
+			continue
+		}
+
+		b.lock.Lock()
+		_, active := b.activeFiles[fileId]
+		b.lock.Unlock()
+		if active {
+			continue
+		}
+
+		totalBytes, err := b.totalFileBytes(fileId)
+		if err != nil {
+			return nil, err
+		}
+
+		if totalBytes > 0 && 1-float64(liveBytes[fileId])/float64(totalBytes) >= garbageRatio {
+			rewrite = append(rewrite, fileId)
+		}
+	}
+
+	sort.Slice(rewrite, func(i, j int) bool { return rewrite[i] < rewrite[j] })
+	return rewrite, nil
+}
+
+// CollectGarbage deletes all blob files that are not referenced by any of the given live sstables anymore and
+// returns the ids of the deleted files. Files that are still written by a writer of this store are never deleted.
+// The caller must ensure that the given readers are the complete set of sstables that reference this store.
+func (b *BlobStore) CollectGarbage(liveTables []SSTableReaderI) (deleted []uint64, err error) {
+	return b.deleteUnreferenced(nil, liveTables)
+}
+
+// CollectGarbageOf deletes the blob files that were referenced by the obsolete tables, but are not referenced by any
+// of the live tables anymore, and returns the ids of the deleted files. Unlike CollectGarbage, the files of tables that
+// were written but not yet added to the live tables are never deleted, as the obsolete tables can't reference them.
+func (b *BlobStore) CollectGarbageOf(obsoleteTables []SSTableReaderI, liveTables []SSTableReaderI) (deleted []uint64, err error) {
+	return b.deleteUnreferenced(referencedFiles(obsoleteTables), liveTables)
+}
+
+// deleteUnreferenced deletes the files that aren't referenced by the live tables, only the candidates are considered
+// unless they are nil.
+func (b *BlobStore) deleteUnreferenced(candidates map[uint64]struct{}, liveTables []SSTableReaderI) (deleted []uint64, err error) {
+	referenced := referencedFiles(liveTables)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	ids, err := b.listFileIds()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fileId := range ids {
+		if _, ok := referenced[fileId]; ok {
+			continue
+		}
+		if _, ok := b.activeFiles[fileId]; ok {
+			continue
+		}
+		if _, ok := candidates[fileId]; candidates != nil && !ok {
+			continue
+		}
+
+		if reader, ok := b.readers[fileId]; ok {
+			err = errors.Join(err, reader.Close())
+			delete(b.readers, fileId)
+		}
+
+		rmErr := os.Remove(b.filePath(fileId))
+		if rmErr != nil {
+			err = errors.Join(err, fmt.Errorf("error in blob store '%s' while deleting file %d: %w", b.basePath, fileId, rmErr))
+			continue
+		}
+		delete(b.fileBytes, fileId)
+		deleted = append(deleted, fileId)
+	}
+
+	return deleted, err
+}
+
+// referencedFiles returns the ids of all blob files that are referenced by the tables.
+func referencedFiles(tables []SSTableReaderI) map[uint64]struct{} {
+	referenced := map[uint64]struct{}{}
+	for _, table := range tables {
+		for fileId := range table.MetaData().BlobFileBytes {
+			referenced[fileId] = struct{}{}
+		}
+	}
+	return referenced
+}
+
+func (b *BlobStore) listFileIds() ([]uint64, error) {
+	entries, err := os.ReadDir(b.basePath)
+	if err != nil {
+		return nil, fmt.Errorf("error in blob store '%s' while listing files: %w", b.basePath, err)
+	}
+
+	var ids []uint64
+	for _, e := range entries {
+		var fileId uint64
+		if e.IsDir() {
+			continue
+		}
+		if _, err := fmt.Sscanf(e.Name(), BlobFileNamePattern, &fileId); err == nil && e.Name() == fmt.Sprintf(BlobFileNamePattern, fileId) {
+			ids = append(ids, fileId)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (b *BlobStore) filePath(fileId uint64) string {
+	return filepath.Join(b.basePath, fmt.Sprintf(BlobFileNamePattern, fileId))
+}
+
+func (b *BlobStore) BasePath() string {
+	return b.basePath
+}
+
+func (b *BlobStore) Close() (err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for fileId, reader := range b.readers {
+		err = errors.Join(err, reader.Close())
+		delete(b.readers, fileId)
+	}
+	return err
+}
+
+// NewBlobStore creates a blob store in the given directory, which is created if it doesn't exist yet.
+func NewBlobStore(basePath string) (*BlobStore, error) {
+	err := os.MkdirAll(basePath, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating blob store directory '%s': %w", basePath, err)
+	}
+
+	store := &BlobStore{
+		basePath:    basePath,
+		readers:     map[uint64]recordio.ReadAtI{},
+		activeFiles: map[uint64]struct{}{},
+		fileBytes:   map[uint64]uint64{},
+	}
+
+	err = store.loadNextFileId()
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// encodeInlineValue prefixes the value with the inline tag. Empty values are kept as they are, as those denote tombstones.
+func encodeInlineValue(value []byte) []byte {
+	if len(value) == 0 {
+		return value
+	}
+
+	encoded := make([]byte, len(value)+1)
+	encoded[0] = valueTagInline
+	copy(encoded[1:], value)
+	return encoded
+}
+
+func encodeBlobReference(ref BlobReference) []byte {
+	encoded := make([]byte, blobReferenceSizeBytes)
+	encoded[0] = valueTagBlob
+	binary.LittleEndian.PutUint64(encoded[1:], ref.FileId)
+	binary.LittleEndian.PutUint64(encoded[9:], ref.Offset)
+	binary.LittleEndian.PutUint64(encoded[17:], ref.Length)
+	return encoded
+}
+
+// decodeValue returns either the inline value or the blob reference of an encoded value.
+func decodeValue(encoded []byte) (value []byte, ref BlobReference, isReference bool, err error) {
+	if len(encoded) == 0 {
+		return encoded, BlobReference{}, false, nil
+	}
+
+	switch encoded[0] {
+	case valueTagInline:
+		return encoded[1:], BlobReference{}, false, nil
+	case valueTagBlob:
+		if len(encoded) != blobReferenceSizeBytes {
+			return nil, BlobReference{}, false, fmt.Errorf("invalid blob reference of length %d", len(encoded))
+		}
+		return nil, BlobReference{
+			FileId: binary.LittleEndian.Uint64(encoded[1:]),
+			Offset: binary.LittleEndian.Uint64(encoded[9:]),
+			Length: binary.LittleEndian.Uint64(encoded[17:]),
+		}, true, nil
+	default:
+		return nil, BlobReference{}, false, fmt.Errorf("unknown value tag %d", encoded[0])
+	}
+}
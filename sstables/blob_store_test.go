@@ -0,0 +1,389 @@
+package sstables
+
+import (
+	"bytes"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func TestValueEncoding(t *testing.T) {
+	v, _, isReference, err := decodeValue(encodeInlineValue([]byte{1, 2, 3}))
+	require.Nil(t, err)
+	assert.False(t, isReference)
+	assert.Equal(t, []byte{1, 2, 3}, v)
+
+	assert.Equal(t, []byte{}, encodeInlineValue([]byte{}))
+	v, _, isReference, err = decodeValue(nil)
+	require.Nil(t, err)
+	assert.False(t, isReference)
+	assert.Empty(t, v)
+
+	expected := BlobReference{FileId: 13, Offset: 4096, Length: 1 << 20}
+	_, ref, isReference, err := decodeValue(encodeBlobReference(expected))
+	require.Nil(t, err)
+	assert.True(t, isReference)
+	assert.Equal(t, expected, ref)
+
+	_, _, _, err = decodeValue([]byte{valueTagBlob, 1})
+	assert.Error(t, err)
+	_, _, _, err = decodeValue([]byte{42, 1})
+	assert.Error(t, err)
+}
+
+func TestValueSeparationReadWrite(t *testing.T) {
+	store := newTestBlobStore(t)
+	defer closeBlobStore(t, store)
+
+	values := map[int][]byte{
+		1: bytes.Repeat([]byte{1}, 5),
+		2: bytes.Repeat([]byte{2}, 100),
+		3: nil,
+		4: bytes.Repeat([]byte{4}, 1000),
+		5: bytes.Repeat([]byte{5}, 10),
+	}
+	path := writeBlobTestTable(t, store, values, WithValueSeparation(store, 10))
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	ids, err := store.listFileIds()
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{0}, ids)
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadWithBlobStore(store))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.True(t, reader.MetaData().ValueSeparation)
+	assert.Equal(t, map[uint64]uint64{0: 1110}, reader.MetaData().BlobFileBytes)
+	assertBlobTestTableContent(t, reader, values)
+
+	// without a store the references can't be resolved
+	_, err = NewSSTableReader(ReadBasePath(path))
+	assert.Error(t, err)
+
+	encodedReader, err := NewSSTableReader(ReadBasePath(path), ReadEncodedValues())
+	require.Nil(t, err)
+	defer closeReader(t, encodedReader)
+	v, err := encodedReader.Get(intToByteSlice(1))
+	require.Nil(t, err)
+	assert.Equal(t, encodeInlineValue(values[1]), v)
+	v, err = encodedReader.Get(intToByteSlice(4))
+	require.Nil(t, err)
+	_, ref, isReference, err := decodeValue(v)
+	require.Nil(t, err)
+	assert.True(t, isReference)
+	assert.Equal(t, uint64(1000), ref.Length)
+}
+
+func TestValueSeparationEncodedValuesRequireSeparation(t *testing.T) {
+	_, err := NewSSTableStreamWriter(
+		WriteBasePath("some_path"),
+		WithKeyComparator(skiplist.BytesComparator{}),
+		WriteEncodedValues())
+	assert.Error(t, err)
+}
+
+func TestValueSeparationMergeAndCollectGarbage(t *testing.T) {
+	store := newTestBlobStore(t)
+	defer closeBlobStore(t, store)
+
+	older := map[int][]byte{
+		1: bytes.Repeat([]byte{1}, 100),
+		2: bytes.Repeat([]byte{2}, 100),
+	}
+	olderPath := writeBlobTestTable(t, store, older, WithValueSeparation(store, 10))
+	defer func() { require.Nil(t, os.RemoveAll(olderPath)) }()
+
+	// a table without value separation can be merged as well
+	plain := map[int][]byte{
+		3: bytes.Repeat([]byte{3}, 100),
+	}
+	plainPath := writeBlobTestTable(t, store, plain)
+	defer func() { require.Nil(t, os.RemoveAll(plainPath)) }()
+
+	newer := map[int][]byte{
+		1: bytes.Repeat([]byte{11}, 200),
+		2: bytes.Repeat([]byte{12}, 200),
+		4: []byte{4},
+	}
+	newerPath := writeBlobTestTable(t, store, newer, WithValueSeparation(store, 10))
+	defer func() { require.Nil(t, os.RemoveAll(newerPath)) }()
+
+	var readers []SSTableReaderI
+	var iterators []SSTableMergeIteratorContext
+	for i, path := range []string{olderPath, plainPath, newerPath} {
+		reader, err := NewSSTableReader(ReadBasePath(path), ReadEncodedValues())
+		require.Nil(t, err)
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		readers = append(readers, reader)
+		iterators = append(iterators, NewMergeIteratorContext(i, it))
+	}
+
+	out, err := os.MkdirTemp("", "sstables_BlobMerge")
+	require.Nil(t, err)
+	defer func() { require.Nil(t, os.RemoveAll(out)) }()
+	writer, err := NewSSTableStreamWriter(
+		WriteBasePath(out),
+		WithKeyComparator(skiplist.BytesComparator{}),
+		WithValueSeparation(store, 10),
+		WriteEncodedValues())
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	require.Nil(t, NewSSTableMerger(skiplist.BytesComparator{}).MergeCompact(iterators, writer, ScanReduceLatestWins))
+	require.Nil(t, writer.Close())
+	for _, r := range readers {
+		require.Nil(t, r.Close())
+	}
+
+	merged, err := NewSSTableReader(ReadBasePath(out), ReadWithBlobStore(store))
+	require.Nil(t, err)
+	defer closeReader(t, merged)
+	assertBlobTestTableContent(t, merged, map[int][]byte{1: newer[1], 2: newer[2], 3: plain[3], 4: newer[4]})
+
+	// the references of the newer table were copied, only the inline value of the plain table needed a new blob
+	ids, err := store.listFileIds()
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{0, 1, 2}, ids)
+	assert.Equal(t, map[uint64]uint64{1: 400, 2: 100}, merged.MetaData().BlobFileBytes)
+
+	deleted, err := store.CollectGarbage([]SSTableReaderI{merged})
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{0}, deleted)
+	ids, err = store.listFileIds()
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{1, 2}, ids)
+	assertBlobTestTableContent(t, merged, map[int][]byte{1: newer[1], 2: newer[2], 3: plain[3], 4: newer[4]})
+
+	deleted, err = store.CollectGarbage([]SSTableReaderI{merged})
+	require.Nil(t, err)
+	assert.Empty(t, deleted)
+}
+
+func TestValueSeparationRewritePartlyLiveBlobFiles(t *testing.T) {
+	store := newTestBlobStore(t)
+	defer closeBlobStore(t, store)
+
+	older := map[int][]byte{}
+	for i := 0; i < 4; i++ {
+		older[i] = bytes.Repeat([]byte{byte(i)}, 1000)
+	}
+	olderPath := writeBlobTestTable(t, store, older, WithValueSeparation(store, 10))
+	defer func() { require.Nil(t, os.RemoveAll(olderPath)) }()
+
+	// overwrites three quarters of the older blob file
+	newer := map[int][]byte{}
+	for i := 0; i < 3; i++ {
+		newer[i] = bytes.Repeat([]byte{byte(i + 10)}, 1000)
+	}
+	newerPath := writeBlobTestTable(t, store, newer, WithValueSeparation(store, 10))
+	defer func() { require.Nil(t, os.RemoveAll(newerPath)) }()
+
+	expected := map[int][]byte{0: newer[0], 1: newer[1], 2: newer[2], 3: older[3]}
+	mergedPath := mergeBlobTestTables(t, store, []string{olderPath, newerPath})
+	defer func() { require.Nil(t, os.RemoveAll(mergedPath)) }()
+	merged, err := NewSSTableReader(ReadBasePath(mergedPath), ReadEncodedValues())
+	require.Nil(t, err)
+	assert.Equal(t, map[uint64]uint64{0: 1000, 1: 3000}, merged.MetaData().BlobFileBytes)
+
+	// the compaction only copied the references, which leaves the older file mostly as garbage
+	live := []SSTableReaderI{merged}
+	rewrite, err := store.FilesToRewrite(live, live, 0.5)
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{0}, rewrite)
+	rewrite, err = store.FilesToRewrite(live, live, 0.8)
+	require.Nil(t, err)
+	assert.Empty(t, rewrite)
+
+	// a file that is referenced outside the compaction can't be rewritten
+	newerReader, err := NewSSTableReader(ReadBasePath(newerPath), ReadEncodedValues())
+	require.Nil(t, err)
+	rewrite, err = store.FilesToRewrite([]SSTableReaderI{newerReader}, []SSTableReaderI{merged, newerReader}, 0)
+	require.Nil(t, err)
+	assert.Empty(t, rewrite)
+	require.Nil(t, newerReader.Close())
+
+	// files that weren't written by this instance are read to determine their size
+	reopened, err := NewBlobStore(store.BasePath())
+	require.Nil(t, err)
+	rewrite, err = reopened.FilesToRewrite(live, live, 0.5)
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{0}, rewrite)
+	require.Nil(t, reopened.Close())
+
+	rewrittenPath := mergeBlobTestTables(t, store, []string{mergedPath}, RewriteBlobFiles(rewrite...))
+	defer func() { require.Nil(t, os.RemoveAll(rewrittenPath)) }()
+	rewritten, err := NewSSTableReader(ReadBasePath(rewrittenPath), ReadWithBlobStore(store))
+	require.Nil(t, err)
+	defer closeReader(t, rewritten)
+	assert.Equal(t, map[uint64]uint64{1: 3000, 2: 1000}, rewritten.MetaData().BlobFileBytes)
+
+	// an unreferenced file outside the compaction, as of a table that was just written, is kept
+	unrelatedPath := writeBlobTestTable(t, store, map[int][]byte{5: bytes.Repeat([]byte{5}, 1000)}, WithValueSeparation(store, 10))
+	defer func() { require.Nil(t, os.RemoveAll(unrelatedPath)) }()
+
+	sizeBefore := blobStoreSize(t, store)
+	deleted, err := store.CollectGarbageOf(live, []SSTableReaderI{rewritten})
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{0}, deleted)
+	require.Nil(t, merged.Close())
+	ids, err := store.listFileIds()
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, ids)
+	assert.Less(t, blobStoreSize(t, store), sizeBefore)
+	assertBlobTestTableContent(t, rewritten, expected)
+}
+
+func TestBlobStoreNeverReusesFileIds(t *testing.T) {
+	store := newTestBlobStore(t)
+	defer closeBlobStore(t, store)
+
+	values := map[int][]byte{1: bytes.Repeat([]byte{1}, 100)}
+	firstPath := writeBlobTestTable(t, store, values, WithValueSeparation(store, 10))
+	require.Nil(t, os.RemoveAll(firstPath))
+
+	// deleting the highest file must not hand out its id again, neither to this nor to a reopened store
+	deleted, err := store.CollectGarbage(nil)
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{0}, deleted)
+
+	secondPath := writeBlobTestTable(t, store, values, WithValueSeparation(store, 10))
+	defer func() { require.Nil(t, os.RemoveAll(secondPath)) }()
+	ids, err := store.listFileIds()
+	require.Nil(t, err)
+	assert.Equal(t, []uint64{1}, ids)
+
+	_, err = store.CollectGarbage(nil)
+	require.Nil(t, err)
+	reopened, err := NewBlobStore(store.BasePath())
+	require.Nil(t, err)
+	defer func() { require.Nil(t, reopened.Close()) }()
+	fileId, writer, err := reopened.newFileWriter(0, 4096)
+	require.Nil(t, err)
+	require.Nil(t, writer.Close())
+	assert.Equal(t, uint64(2), fileId)
+}
+
+func TestValueSeparationRewriteRequiresEncodedValues(t *testing.T) {
+	store := newTestBlobStore(t)
+	defer closeBlobStore(t, store)
+
+	_, err := NewSSTableStreamWriter(WriteBasePath(t.TempDir()), WithValueSeparation(store, 10), RewriteBlobFiles(0))
+	assert.Error(t, err)
+}
+
+// mergeBlobTestTables merges the encoded values of the tables at the given paths, the later tables win.
+func mergeBlobTestTables(t *testing.T, store *BlobStore, paths []string, opts ...WriterOption) string {
+	var readers []SSTableReaderI
+	var iterators []SSTableMergeIteratorContext
+	for i, path := range paths {
+		reader, err := NewSSTableReader(ReadBasePath(path), ReadEncodedValues())
+		require.Nil(t, err)
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		readers = append(readers, reader)
+		iterators = append(iterators, NewMergeIteratorContext(i, it))
+	}
+
+	out, err := os.MkdirTemp("", "sstables_BlobMerge")
+	require.Nil(t, err)
+	opts = append(opts,
+		WriteBasePath(out),
+		WithKeyComparator(skiplist.BytesComparator{}),
+		WithValueSeparation(store, 10),
+		WriteEncodedValues())
+	writer, err := NewSSTableStreamWriter(opts...)
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	require.Nil(t, NewSSTableMerger(skiplist.BytesComparator{}).MergeCompact(iterators, writer, ScanReduceLatestWins))
+	require.Nil(t, writer.Close())
+	for _, r := range readers {
+		require.Nil(t, r.Close())
+	}
+	return out
+}
+
+func blobStoreSize(t *testing.T, store *BlobStore) int64 {
+	entries, err := os.ReadDir(store.BasePath())
+	require.Nil(t, err)
+	var size int64
+	for _, e := range entries {
+		info, err := e.Info()
+		require.Nil(t, err)
+		size += info.Size()
+	}
+	return size
+}
+
+func newTestBlobStore(t *testing.T) *BlobStore {
+	tmpDir, err := os.MkdirTemp("", "sstables_BlobStore")
+	require.Nil(t, err)
+	store, err := NewBlobStore(tmpDir)
+	require.Nil(t, err)
+	return store
+}
+
+func closeBlobStore(t *testing.T, store *BlobStore) {
+	require.Nil(t, store.Close())
+	require.Nil(t, os.RemoveAll(store.BasePath()))
+}
+
+func writeBlobTestTable(t *testing.T, store *BlobStore, values map[int][]byte, opts ...WriterOption) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_BlobTable")
+	require.Nil(t, err)
+
+	opts = append(opts, WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}))
+	writer, err := NewSSTableStreamWriter(opts...)
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, k := range sortedIntKeys(values) {
+		require.Nil(t, writer.WriteNext(intToByteSlice(k), values[k]))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+func assertBlobTestTableContent(t *testing.T, reader SSTableReaderI, values map[int][]byte) {
+	keys := sortedIntKeys(values)
+	for _, k := range keys {
+		v, err := reader.Get(intToByteSlice(k))
+		require.Nil(t, err)
+		assert.Equal(t, len(values[k]), len(v))
+		assert.True(t, bytes.Equal(values[k], v))
+	}
+
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	assertBlobTestIteratorContent(t, it, keys, values)
+
+	it, err = reader.ScanStartingAt(intToByteSlice(keys[0]))
+	require.Nil(t, err)
+	assertBlobTestIteratorContent(t, it, keys, values)
+
+	sit, err := reader.SeekableIterator()
+	require.Nil(t, err)
+	assertBlobTestIteratorContent(t, sit, keys, values)
+}
+
+func assertBlobTestIteratorContent(t *testing.T, it SSTableIteratorI, keys []int, values map[int][]byte) {
+	for _, k := range keys {
+		actualKey, actualValue, err := it.Next()
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(k), actualKey)
+		assert.True(t, bytes.Equal(values[k], actualValue))
+	}
+	_, _, err := it.Next()
+	assert.Equal(t, Done, err)
+}
+
+func sortedIntKeys(values map[int][]byte) []int {
+	var keys []int
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
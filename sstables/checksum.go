@@ -0,0 +1,96 @@
+package sstables
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// metaDataChecksumFieldNumber is the field number of MetaData.MetaDataChecksum.
+const metaDataChecksumFieldNumber = 23
+
+// metaDataChecksumFooterSizeBytes is the size of the tag and the fixed64 value of the MetaDataChecksum.
+var metaDataChecksumFooterSizeBytes = protowire.SizeTag(metaDataChecksumFieldNumber) + protowire.SizeFixed64()
+
+// componentFileNames returns the names of all files of a table that have their checksum recorded in the metadata.
+func componentFileNames() []string {
+	return []string{IndexFileName, DataFileName, BloomFileName, RangeTombstoneFileName, IndexPartitionsFileName,
+		HashIndexFileName}
+}
+
+func checksumFile(path string) (checksum uint64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("error while opening '%s' for checksumming: %w", path, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	crc := crc64.New(crc64.MakeTable(crc64.ISO))
+	_, err = io.Copy(crc, f)
+	if err != nil {
+		return 0, fmt.Errorf("error while checksumming '%s': %w", path, err)
+	}
+
+	return crc.Sum64(), nil
+}
+
+// checksumComponentFiles returns the checksums of all component files that exist in the given directory.
+func checksumComponentFiles(dir string) (map[string]uint64, error) {
+	checksums := map[string]uint64{}
+	for _, name := range componentFileNames() {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		checksum, err := checksumFile(path)
+		if err != nil {
+			return nil, err
+		}
+		checksums[name] = checksum
+	}
+
+	return checksums, nil
+}
+
+// appendMetaDataChecksum appends the MetaDataChecksum of the serialized metadata as its last field. Protobuf parses
+// the concatenation just like any other field, so the checksum is part of the metadata without covering itself.
+func appendMetaDataChecksum(metaData []byte) []byte {
+	crc := crc64.Checksum(metaData, crc64.MakeTable(crc64.ISO))
+	footer := protowire.AppendTag(nil, metaDataChecksumFieldNumber, protowire.Fixed64Type)
+	return append(metaData, protowire.AppendFixed64(footer, crc)...)
+}
+
+// verifyMetaDataChecksum compares the MetaDataChecksum with the bytes that precede it. Tables written before the
+// checksum existed don't have it and are always accepted, those are recognized by not having any FileChecksums either.
+func verifyMetaDataChecksum(content []byte, md *proto.MetaData) error {
+	if md.MetaDataChecksum == 0 && len(md.FileChecksums) == 0 {
+		return nil
+	}
+
+	footerStart := len(content) - metaDataChecksumFooterSizeBytes
+	if footerStart < 0 {
+		return errors.New("metadata is too short to contain its checksum")
+	}
+
+	num, typ, n := protowire.ConsumeTag(content[footerStart:])
+	if n < 0 || num != metaDataChecksumFieldNumber || typ != protowire.Fixed64Type {
+		return errors.New("metadata checksum is not the last field")
+	}
+
+	checksum := crc64.Checksum(content[:footerStart], crc64.MakeTable(crc64.ISO))
+	if checksum != md.MetaDataChecksum {
+		return ChecksumError{checksum, md.MetaDataChecksum}
+	}
+
+	return nil
+}
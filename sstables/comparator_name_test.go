@@ -0,0 +1,123 @@
+package sstables
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+type namedReverseBytesComparator struct {
+	reverseBytesComparator
+}
+
+func (namedReverseBytesComparator) Name() string {
+	return "test.ReverseBytesComparator"
+}
+
+func writeComparatorTestTable(t *testing.T, cmp skiplist.Comparator[[]byte], keys ...string) string {
+	tmpDir, err := os.MkdirTemp("", "sstable_ComparatorName")
+	require.Nil(t, err)
+
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(cmp))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, k := range keys {
+		require.Nil(t, writer.WriteNext([]byte(k), []byte(k)))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+func TestReaderRefusesDifferentComparator(t *testing.T) {
+	path := writeComparatorTestTable(t, namedReverseBytesComparator{}, "c", "b", "a")
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	_, err := NewSSTableReader(ReadBasePath(path))
+	assert.ErrorIs(t, err, ComparatorMismatch)
+	_, err = NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(reverseBytesComparator{}))
+	assert.ErrorIs(t, err, ComparatorMismatch)
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(namedReverseBytesComparator{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.Equal(t, "test.ReverseBytesComparator", reader.MetaData().ComparatorName)
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	assertIteratorMatchesKeys(t, it, []string{"c", "b", "a"})
+}
+
+// unnamedBytesComparator sorts like skiplist.BytesComparator, but doesn't implement skiplist.NamedComparator
+type unnamedBytesComparator struct{}
+
+func (unnamedBytesComparator) Compare(a []byte, b []byte) int {
+	return bytes.Compare(a, b)
+}
+
+func TestReaderChecksUnnamedComparatorByType(t *testing.T) {
+	path := writeComparatorTestTable(t, reverseBytesComparator{}, "c", "b", "a")
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	_, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(unnamedBytesComparator{}))
+	assert.ErrorIs(t, err, ComparatorMismatch)
+	_, err = NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(namedReverseBytesComparator{}))
+	assert.ErrorIs(t, err, ComparatorMismatch)
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(reverseBytesComparator{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.Equal(t, "sstables.reverseBytesComparator", reader.MetaData().ComparatorName)
+}
+
+func TestReaderAcceptsTablesWithoutComparatorName(t *testing.T) {
+	// tables written before the comparator name was persisted can't be checked
+	assert.Nil(t, checkComparatorName(reverseBytesComparator{}, ""))
+	assert.Nil(t, checkComparatorName(namedReverseBytesComparator{}, ""))
+	assert.ErrorIs(t, checkComparatorName(unnamedBytesComparator{}, "sstables.reverseBytesComparator"), ComparatorMismatch)
+}
+
+func TestSuperReaderAndMergerRefuseDifferentComparators(t *testing.T) {
+	bytesPath := writeComparatorTestTable(t, skiplist.BytesComparator{}, "a", "b")
+	defer func() { require.Nil(t, os.RemoveAll(bytesPath)) }()
+	reversePath := writeComparatorTestTable(t, namedReverseBytesComparator{}, "b", "a")
+	defer func() { require.Nil(t, os.RemoveAll(reversePath)) }()
+
+	bytesReader, err := NewSSTableReader(ReadBasePath(bytesPath))
+	require.Nil(t, err)
+	defer closeReader(t, bytesReader)
+	reverseReader, err := NewSSTableReader(ReadBasePath(reversePath), ReadWithKeyComparator(namedReverseBytesComparator{}))
+	require.Nil(t, err)
+	defer closeReader(t, reverseReader)
+
+	_, err = NewCheckedSuperSSTableReader([]SSTableReaderI{bytesReader, reverseReader}, skiplist.BytesComparator{})
+	assert.ErrorIs(t, err, ComparatorMismatch)
+	superReader, err := NewCheckedSuperSSTableReader([]SSTableReaderI{bytesReader}, skiplist.BytesComparator{})
+	require.Nil(t, err)
+	assert.Equal(t, "skiplist.BytesComparator", superReader.MetaData().ComparatorName)
+	// the unchecked reader trusts the caller
+	assert.NotNil(t, NewSuperSSTableReader([]SSTableReaderI{bytesReader, reverseReader}, skiplist.BytesComparator{}))
+
+	var iterators []SSTableMergeIteratorContext
+	for i, reader := range []SSTableReaderI{bytesReader, reverseReader} {
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		iterators = append(iterators, NewReaderMergeIteratorContext(i, it, reader))
+	}
+
+	outPath, err := os.MkdirTemp("", "sstable_ComparatorNameMerge")
+	require.Nil(t, err)
+	defer func() { require.Nil(t, os.RemoveAll(outPath)) }()
+	writer, err := NewSSTableStreamWriter(WriteBasePath(outPath), WithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	defer func() { require.Nil(t, writer.Close()) }()
+
+	merger := NewSSTableMerger(skiplist.BytesComparator{})
+	assert.ErrorIs(t, merger.Merge(iterators, writer), ComparatorMismatch)
+	assert.ErrorIs(t, merger.MergeCompact(iterators, writer, ScanReduceLatestWins), ComparatorMismatch)
+	_, err = merger.MergeCompactVersionsIterator(iterators, nil)
+	assert.ErrorIs(t, err, ComparatorMismatch)
+}
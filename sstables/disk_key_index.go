@@ -83,6 +83,52 @@ func (s *DiskKeyIndex) IteratorBetween(keyLower []byte, keyHigher []byte) (skipl
 	return s.newIterator(startOffset, endOffset), nil
 }
 
+func (s *DiskKeyIndex) SeekableIterator() (skiplist.SeekableIteratorI[[]byte, IndexVal], error) {
+	it := &DiskKeyIndexSeekableIterator{index: s}
+	if err := it.SeekToFirst(); err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+// findPrevious returns the offset of the last record that starts before the given offset. Records can only be found
+// in forward direction, so we seek from increasingly larger windows before the offset and then walk forward.
+func (s *DiskKeyIndex) findPrevious(offset uint64) (uint64, bool, error) {
+	window := uint64(256)
+	for {
+		start := uint64(recordio.FileHeaderSizeBytes)
+		if offset > start+window {
+			start = offset - window
+		}
+
+		cur, _, err := s.reader.SeekNext(&proto.IndexEntry{}, start)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, false, err
+		}
+
+		if err == nil && cur < offset {
+			for {
+				next, _, err := s.reader.SeekNext(&proto.IndexEntry{}, cur+1)
+				if err != nil {
+					if errors.Is(err, io.EOF) {
+						return cur, true, nil
+					}
+					return 0, false, err
+				}
+				if next >= offset {
+					return cur, true, nil
+				}
+				cur = next
+			}
+		}
+
+		if start == recordio.FileHeaderSizeBytes {
+			return 0, false, nil
+		}
+		window *= 2
+	}
+}
+
 // adjusted version of sort.BinarySearchFunc, returning a file offset instead of an index
 func (s *DiskKeyIndex) binarySearch(target []byte) (uint64, *proto.IndexEntry, bool, error) {
 	n := s.reader.Size()
@@ -166,6 +212,123 @@ func (s *DiskKeyIndexIterator) Next() ([]byte, IndexVal, error) {
 	}, nil
 }
 
+// DiskKeyIndexSeekableIterator is positioned at the record starting at currentOffset, as long as valid is true.
+type DiskKeyIndexSeekableIterator struct {
+	index         *DiskKeyIndex
+	currentOffset uint64
+	valid         bool
+}
+
+func (s *DiskKeyIndexSeekableIterator) Next() ([]byte, IndexVal, error) {
+	if !s.valid {
+		return nil, IndexVal{}, skiplist.Done
+	}
+
+	entry := &proto.IndexEntry{}
+	_, err := s.index.reader.ReadNextAt(entry, s.currentOffset)
+	if err != nil {
+		return nil, IndexVal{}, err
+	}
+
+	return entry.Key, IndexVal{Offset: entry.ValueOffset, Checksum: entry.Checksum}, s.seekFrom(s.currentOffset + 1)
+}
+
+func (s *DiskKeyIndexSeekableIterator) Prev() ([]byte, IndexVal, error) {
+	if !s.valid {
+		return nil, IndexVal{}, skiplist.Done
+	}
+
+	entry := &proto.IndexEntry{}
+	_, err := s.index.reader.ReadNextAt(entry, s.currentOffset)
+	if err != nil {
+		return nil, IndexVal{}, err
+	}
+
+	prev, found, err := s.index.findPrevious(s.currentOffset)
+	if err != nil {
+		return nil, IndexVal{}, err
+	}
+	s.currentOffset = prev
+	s.valid = found
+
+	return entry.Key, IndexVal{Offset: entry.ValueOffset, Checksum: entry.Checksum}, nil
+}
+
+func (s *DiskKeyIndexSeekableIterator) Seek(key []byte) error {
+	offset, _, _, err := s.index.binarySearch(key)
+	if err != nil {
+		return err
+	}
+	return s.seekFrom(offset)
+}
+
+func (s *DiskKeyIndexSeekableIterator) SeekForPrev(key []byte) error {
+	offset, _, found, err := s.index.binarySearch(key)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		return s.seekFrom(offset)
+	}
+
+	if err := s.seekFrom(offset); err != nil {
+		return err
+	}
+
+	// the search ended on the first key that is greater, so we need the record right before it
+	end := s.index.reader.Size()
+	if s.valid {
+		end = s.currentOffset
+	}
+	prev, found, err := s.index.findPrevious(end)
+	if err != nil {
+		return err
+	}
+	s.currentOffset = prev
+	s.valid = found
+	return nil
+}
+
+func (s *DiskKeyIndexSeekableIterator) SeekToFirst() error {
+	return s.seekFrom(recordio.FileHeaderSizeBytes)
+}
+
+func (s *DiskKeyIndexSeekableIterator) SeekToLast() error {
+	prev, found, err := s.index.findPrevious(s.index.reader.Size())
+	if err != nil {
+		return err
+	}
+	s.currentOffset = prev
+	s.valid = found
+	return nil
+}
+
+func (s *DiskKeyIndexSeekableIterator) Valid() bool {
+	return s.valid
+}
+
+// seekFrom positions the iterator at the first record that starts at or after the given offset
+func (s *DiskKeyIndexSeekableIterator) seekFrom(offset uint64) error {
+	if offset >= s.index.reader.Size() {
+		s.valid = false
+		return nil
+	}
+
+	actual, _, err := s.index.reader.SeekNext(&proto.IndexEntry{}, offset)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			s.valid = false
+			return nil
+		}
+		return err
+	}
+
+	s.currentOffset = actual
+	s.valid = true
+	return nil
+}
+
 type DiskIndexLoader struct {
 }
 
@@ -175,10 +338,24 @@ func (l *DiskIndexLoader) Load(indexPath string, _ *proto.MetaData) (_ SortedKey
 		return nil, fmt.Errorf("error while creating index reader of sstable in '%s': %w", indexPath, err)
 	}
 
-	idx := &DiskKeyIndex{
+	return newDiskKeyIndex(reader), nil
+}
+
+// LoadFromSource loads the index from a table that is opened with ReadFromSource, only the parts of the index file that
+// are needed for a lookup are read.
+func (l *DiskIndexLoader) LoadFromSource(source TableSource, _ *proto.MetaData) (SortedKeyIndex, error) {
+	reader, err := newSourceProtoReadAtReader(source, IndexFileName)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating index reader of sstable from source: %w", err)
+	}
+
+	return newDiskKeyIndex(reader), nil
+}
+
+func newDiskKeyIndex(reader rProto.ReadAtI) *DiskKeyIndex {
+	return &DiskKeyIndex{
 		reader:             reader,
 		offsetCacheMaxSize: 1024,
 		offsetCache:        make(map[uint64]*proto.IndexEntry),
 	}
-	return idx, nil
 }
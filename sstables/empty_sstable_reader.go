@@ -16,6 +16,34 @@ func (EmptySStableReader) Get(_ []byte) ([]byte, error) {
 	return nil, NotFound
 }
 
+func (EmptySStableReader) GetAsOf(_ []byte, _ uint64) ([]byte, error) {
+	return nil, NotFound
+}
+
+func (EmptySStableReader) ApproximateOffsetOf(_ []byte) (uint64, error) {
+	return 0, nil
+}
+
+func (EmptySStableReader) ApproximateSizeOfRange(_ []byte, _ []byte) (uint64, error) {
+	return 0, nil
+}
+
+func (EmptySStableReader) SampleKeys(_ int) ([][]byte, error) {
+	return nil, nil
+}
+
+func (EmptySStableReader) KeyQuantiles(_ int) ([][]byte, error) {
+	return nil, nil
+}
+
+func (EmptySStableReader) MultiGet(keys [][]byte) ([][]byte, []error) {
+	errs := make([]error, len(keys))
+	for i := range errs {
+		errs[i] = NotFound
+	}
+	return make([][]byte, len(keys)), errs
+}
+
 func (EmptySStableReader) Scan() (SSTableIteratorI, error) {
 	return EmptySSTableIterator{}, nil
 }
@@ -28,6 +56,18 @@ func (EmptySStableReader) ScanRange(_ []byte, _ []byte) (SSTableIteratorI, error
 	return EmptySSTableIterator{}, nil
 }
 
+func (EmptySStableReader) ScanPrefix(_ []byte) (SSTableIteratorI, error) {
+	return EmptySSTableIterator{}, nil
+}
+
+func (EmptySStableReader) SeekableIterator() (SSTableSeekableIteratorI, error) {
+	return EmptySSTableIterator{}, nil
+}
+
+func (EmptySStableReader) RangeTombstones() RangeTombstones {
+	return nil
+}
+
 func (EmptySStableReader) Close() error {
 	return nil
 }
@@ -40,6 +80,10 @@ func (EmptySStableReader) MetaData() *proto.MetaData {
 	}
 }
 
+func (EmptySStableReader) Properties() map[string][]byte {
+	return nil
+}
+
 func (EmptySStableReader) BasePath() string {
 	return ""
 }
@@ -49,3 +93,27 @@ type EmptySSTableIterator struct{}
 func (EmptySSTableIterator) Next() ([]byte, []byte, error) {
 	return nil, nil, Done
 }
+
+func (EmptySSTableIterator) Prev() ([]byte, []byte, error) {
+	return nil, nil, Done
+}
+
+func (EmptySSTableIterator) Seek(_ []byte) error {
+	return nil
+}
+
+func (EmptySSTableIterator) SeekForPrev(_ []byte) error {
+	return nil
+}
+
+func (EmptySSTableIterator) SeekToFirst() error {
+	return nil
+}
+
+func (EmptySSTableIterator) SeekToLast() error {
+	return nil
+}
+
+func (EmptySSTableIterator) Valid() bool {
+	return false
+}
@@ -0,0 +1,93 @@
+package sstables
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ExpiredValue is returned when reading a key whose value has expired, it also matches NotFound.
+var ExpiredValue = fmt.Errorf("%w: value has expired", NotFound)
+
+// EncodeExpiringValue returns the value as it's stored in tables that are written WithExpiringValues. The value is
+// prefixed with its expiry time in unix milliseconds as an uvarint, where zero means it never expires. Empty values are
+// stored without an expiry, so they are still tombstones.
+func EncodeExpiringValue(value []byte, expiresAt time.Time) []byte {
+	if len(value) == 0 {
+		return []byte{}
+	}
+
+	encoded := make([]byte, 0, binary.MaxVarintLen64+len(value))
+	encoded = binary.AppendUvarint(encoded, expiryMillis(expiresAt))
+	return append(encoded, value...)
+}
+
+// DecodeExpiringValue is the inverse to EncodeExpiringValue, the returned value points into the given slice.
+// The expiry time is zero for values that never expire.
+func DecodeExpiringValue(encoded []byte) ([]byte, time.Time, error) {
+	if len(encoded) == 0 {
+		return encoded, time.Time{}, nil
+	}
+
+	millis, n := binary.Uvarint(encoded)
+	if n <= 0 {
+		return nil, time.Time{}, fmt.Errorf("invalid expiring value, truncated expiry time: %v", encoded)
+	}
+
+	if millis == 0 {
+		return encoded[n:], time.Time{}, nil
+	}
+	return encoded[n:], time.UnixMilli(int64(millis)), nil
+}
+
+// IsExpired returns true if the given expiry time is not zero and not after now.
+func IsExpired(expiresAt time.Time, now time.Time) bool {
+	return !expiresAt.IsZero() && !expiresAt.After(now)
+}
+
+func expiryMillis(expiresAt time.Time) uint64 {
+	if expiresAt.IsZero() || expiresAt.UnixMilli() <= 0 {
+		return 0
+	}
+	return uint64(expiresAt.UnixMilli())
+}
+
+// decodeUnexpiredValue decodes the expiry of the value, it returns ExpiredValue if it has expired at the given time.
+func decodeUnexpiredValue(encoded []byte, now time.Time) ([]byte, error) {
+	value, expiresAt, err := DecodeExpiringValue(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsExpired(expiresAt, now) {
+		return nil, ExpiredValue
+	}
+	return value, nil
+}
+
+// expireValues replaces all expired values with tombstones, the remaining values keep their encoded expiry.
+// Values of contexts that weren't written WithExpiringValues are encoded without an expiry.
+// Alongside the values, the number of expired values is returned.
+func expireValues(values [][]byte, context []int, expiringContexts map[int]bool, now time.Time) ([][]byte, int, error) {
+	expired := make([][]byte, len(values))
+	numExpired := 0
+	for i, v := range values {
+		if !expiringContexts[context[i]] {
+			expired[i] = EncodeExpiringValue(v, time.Time{})
+			continue
+		}
+
+		_, err := decodeUnexpiredValue(v, now)
+		if err != nil {
+			if !errors.Is(err, ExpiredValue) {
+				return nil, 0, fmt.Errorf("error while decoding expiry in context %d: %w", context[i], err)
+			}
+			expired[i] = []byte{}
+			numExpired++
+			continue
+		}
+		expired[i] = v
+	}
+	return expired, numExpired, nil
+}
@@ -0,0 +1,235 @@
+package sstables
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+type expiryTestRecord struct {
+	key       int
+	value     []byte
+	expiresAt time.Time
+}
+
+// writeExpiryTestTable writes the records WithExpiringValues and returns the path of the table.
+func writeExpiryTestTable(t *testing.T, records []expiryTestRecord) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_ExpiringValues")
+	require.Nil(t, err)
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}),
+		WithExpiringValues())
+	require.Nil(t, err)
+	require.NoError(t, writer.Open())
+	for _, r := range records {
+		require.NoError(t, writer.WriteNext(intToByteSlice(r.key), EncodeExpiringValue(r.value, r.expiresAt)))
+	}
+	require.NoError(t, writer.Close())
+	return tmpDir
+}
+
+func TestExpiringValueEncodeDecode(t *testing.T) {
+	expiresAt := time.UnixMilli(1700000000123)
+	value, actualExpiry, err := DecodeExpiringValue(EncodeExpiringValue([]byte{1, 2, 3}, expiresAt))
+	require.Nil(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, value)
+	assert.True(t, expiresAt.Equal(actualExpiry))
+
+	value, actualExpiry, err = DecodeExpiringValue(EncodeExpiringValue([]byte{1}, time.Time{}))
+	require.Nil(t, err)
+	assert.Equal(t, []byte{1}, value)
+	assert.True(t, actualExpiry.IsZero())
+
+	assert.Equal(t, []byte{}, EncodeExpiringValue([]byte{}, expiresAt))
+	_, _, err = DecodeExpiringValue([]byte{0xFF})
+	assert.Error(t, err)
+
+	assert.True(t, IsExpired(expiresAt, expiresAt))
+	assert.False(t, IsExpired(expiresAt, expiresAt.Add(-time.Millisecond)))
+	assert.False(t, IsExpired(time.Time{}, expiresAt))
+}
+
+func TestReaderTreatsExpiredValuesAsNotFound(t *testing.T) {
+	now := time.Now()
+	path := writeExpiryTestTable(t, []expiryTestRecord{
+		{1, intToByteSlice(10), time.Time{}},
+		{2, intToByteSlice(20), now.Add(-time.Hour)},
+		{3, intToByteSlice(30), now.Add(time.Hour)},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.True(t, reader.MetaData().ExpiringValues)
+
+	v, err := reader.Get(intToByteSlice(1))
+	require.Nil(t, err)
+	assert.Equal(t, intToByteSlice(10), v)
+	_, err = reader.Get(intToByteSlice(2))
+	assert.ErrorIs(t, err, ExpiredValue)
+	assert.ErrorIs(t, err, NotFound)
+	v, err = reader.Get(intToByteSlice(3))
+	require.Nil(t, err)
+	assert.Equal(t, intToByteSlice(30), v)
+
+	// scans return expired values as tombstones
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	for _, expected := range [][]byte{intToByteSlice(10), {}, intToByteSlice(30)} {
+		_, v, err := it.Next()
+		require.Nil(t, err)
+		assert.Equal(t, expected, v)
+	}
+	_, _, err = it.Next()
+	assert.ErrorIs(t, err, Done)
+
+	encodedReader, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(skiplist.BytesComparator{}),
+		ReadEncodedExpiry())
+	require.Nil(t, err)
+	defer closeReader(t, encodedReader)
+	v, err = encodedReader.Get(intToByteSlice(2))
+	require.Nil(t, err)
+	assert.Equal(t, EncodeExpiringValue(intToByteSlice(20), now.Add(-time.Hour)), v)
+}
+
+func TestSuperReaderExpiredValueShadowsOlderTables(t *testing.T) {
+	writer, err := newTestSSTableStreamWriter()
+	require.Nil(t, err)
+	defer cleanWriterDir(t, writer)
+	require.NoError(t, writer.Open())
+	require.NoError(t, writer.WriteNext(intToByteSlice(1), intToByteSlice(10)))
+	require.NoError(t, writer.WriteNext(intToByteSlice(2), intToByteSlice(20)))
+	require.NoError(t, writer.Close())
+	older, err := NewSSTableReader(ReadBasePath(writer.opts.basePath))
+	require.Nil(t, err)
+	defer closeReader(t, older)
+
+	path := writeExpiryTestTable(t, []expiryTestRecord{
+		{1, intToByteSlice(11), time.Now().Add(-time.Hour)},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+	newer, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	defer closeReader(t, newer)
+
+	reader := NewSuperSSTableReader([]SSTableReaderI{older, newer}, skiplist.BytesComparator{})
+	_, err = reader.Get(intToByteSlice(1))
+	assert.ErrorIs(t, err, NotFound)
+	v, err := reader.Get(intToByteSlice(2))
+	require.Nil(t, err)
+	assert.Equal(t, intToByteSlice(20), v)
+
+	values, errs := reader.MultiGet([][]byte{intToByteSlice(1), intToByteSlice(2)})
+	assert.ErrorIs(t, errs[0], NotFound)
+	require.Nil(t, errs[1])
+	assert.Equal(t, intToByteSlice(20), values[1])
+}
+
+func TestMergeCompactDropsExpiredValues(t *testing.T) {
+	now := time.Now()
+	expiresAt := time.UnixMilli(now.Add(time.Hour).UnixMilli())
+	olderPath := writeExpiryTestTable(t, []expiryTestRecord{
+		{1, intToByteSlice(10), time.Time{}},
+		{2, intToByteSlice(20), expiresAt},
+		{3, intToByteSlice(30), time.Time{}},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(olderPath)) }()
+	newerPath := writeExpiryTestTable(t, []expiryTestRecord{
+		{1, intToByteSlice(11), now.Add(-time.Hour)},
+		{4, intToByteSlice(40), now.Add(-time.Hour)},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(newerPath)) }()
+
+	var iterators []SSTableMergeIteratorContext
+	for i, path := range []string{olderPath, newerPath} {
+		reader, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(skiplist.BytesComparator{}),
+			ReadEncodedExpiry())
+		require.Nil(t, err)
+		defer closeReader(t, reader)
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		iterators = append(iterators, NewReaderMergeIteratorContext(i, it, reader))
+	}
+
+	it, err := NewSSTableMerger(skiplist.BytesComparator{}).MergeCompactIterator(iterators,
+		func(key []byte, values [][]byte, context []int) ([]byte, []byte) {
+			_, v := ScanReduceLatestWins(key, values, context)
+			if len(v) == 0 {
+				return nil, nil
+			}
+			return key, v
+		})
+	require.Nil(t, err)
+
+	for _, expected := range []expiryTestRecord{
+		{2, intToByteSlice(20), expiresAt},
+		{3, intToByteSlice(30), time.Time{}},
+	} {
+		k, v, err := it.Next()
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(expected.key), k)
+		value, actualExpiry, err := DecodeExpiringValue(v)
+		require.Nil(t, err)
+		assert.Equal(t, expected.value, value)
+		assert.True(t, expected.expiresAt.Equal(actualExpiry))
+	}
+	_, _, err = it.Next()
+	assert.ErrorIs(t, err, Done)
+}
+
+func TestBottommostMergeCompactDropsExpiredKeys(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	olderPath := writeExpiryTestTable(t, []expiryTestRecord{
+		{1, intToByteSlice(10), time.Time{}},
+		{2, intToByteSlice(20), expired},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(olderPath)) }()
+	newerPath := writeExpiryTestTable(t, []expiryTestRecord{
+		{2, intToByteSlice(21), expired},
+		{3, intToByteSlice(30), expired},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(newerPath)) }()
+
+	for _, tc := range []struct {
+		opts       []MergerOption
+		numRecords int
+	}{
+		// the tombstones are kept, since older tables outside the merge might contain the keys
+		{nil, 3},
+		{[]MergerOption{WithBottommostMerge()}, 1},
+	} {
+		var iterators []SSTableMergeIteratorContext
+		for i, path := range []string{olderPath, newerPath} {
+			reader, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(skiplist.BytesComparator{}),
+				ReadEncodedExpiry())
+			require.Nil(t, err)
+			defer closeReader(t, reader)
+			it, err := reader.Scan()
+			require.Nil(t, err)
+			iterators = append(iterators, NewReaderMergeIteratorContext(i, it, reader))
+		}
+
+		outPath, err := os.MkdirTemp("", "sstables_ExpiringValuesBottommost")
+		require.Nil(t, err)
+		defer func() { require.Nil(t, os.RemoveAll(outPath)) }()
+		writer, err := NewSSTableStreamWriter(WriteBasePath(outPath), WithKeyComparator(skiplist.BytesComparator{}),
+			WithExpiringValues())
+		require.Nil(t, err)
+		require.NoError(t, writer.Open())
+		require.NoError(t, NewSSTableMerger(skiplist.BytesComparator{}, tc.opts...).
+			MergeCompact(iterators, writer, ScanReduceLatestWins))
+		require.NoError(t, writer.Close())
+
+		reader, err := NewSSTableReader(ReadBasePath(outPath), ReadWithKeyComparator(skiplist.BytesComparator{}))
+		require.Nil(t, err)
+		assert.Equal(t, tc.numRecords, int(reader.MetaData().NumRecords))
+		val, err := reader.Get(intToByteSlice(1))
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(10), val)
+		closeReader(t, reader)
+	}
+}
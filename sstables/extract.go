@@ -0,0 +1,273 @@
+package sstables
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/thomasjungblut/go-sstables/recordio"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+// ExtractRange writes all keys of src within [keyLower, keyHigher) into a new sstable in dstPath, a nil keyLower or
+// keyHigher means the range is unbounded on that side. See Split for how the table is written.
+func ExtractRange(src SSTableReaderI, keyLower []byte, keyHigher []byte, dstPath string, writerOptions ...WriterOption) error {
+	comp := extractComparator(src, writerOptions)
+	if keyLower != nil && keyHigher != nil && comp.Compare(keyLower, keyHigher) >= 0 {
+		return fmt.Errorf("error while extracting range from sstable '%s': keyHigher must be higher than keyLower", src.BasePath())
+	}
+
+	err := checkExtractable(src)
+	if err != nil {
+		return err
+	}
+
+	return extractPartition(src, comp, MergePartition{Lower: keyLower, Upper: keyHigher}, dstPath, writerOptions)
+}
+
+// Split writes the keys of src into len(boundaries)+1 new sstables in dstPaths. The i-th table contains the keys within
+// [boundaries[i-1], boundaries[i]), the first and the last table are unbounded, thus the boundaries must be ascending.
+// Range tombstones are clipped to the key range of each table, deleted keys are copied like any other key.
+// Tables read by an SSTableReader are written with its comparator, data compression, properties and value encodings,
+// which can be changed using writerOptions. Whenever possible, their records are copied as they are stored in the data
+// file of src, without decompressing and compressing them again. Their checksums are only verified when they need to
+// be decoded. Other readers are scanned and their values are written as they are returned, sorted by the comparator
+// supplied WithKeyComparator or skiplist.BytesComparator by default.
+// The tables are written one after another, a table that failed is left as it is, unless WriteAtomically is supplied.
+func Split(src SSTableReaderI, boundaries [][]byte, dstPaths []string, writerOptions ...WriterOption) error {
+	if len(dstPaths) != len(boundaries)+1 {
+		return fmt.Errorf("error while splitting sstable '%s': expected %d paths for %d boundaries, but got %d",
+			src.BasePath(), len(boundaries)+1, len(boundaries), len(dstPaths))
+	}
+
+	comp := extractComparator(src, writerOptions)
+	for i, boundary := range boundaries {
+		if boundary == nil {
+			return fmt.Errorf("error while splitting sstable '%s': boundary %d is nil", src.BasePath(), i)
+		}
+		if i > 0 && comp.Compare(boundaries[i-1], boundary) >= 0 {
+			return fmt.Errorf("error while splitting sstable '%s': boundaries must be strictly ascending", src.BasePath())
+		}
+	}
+
+	err := checkExtractable(src)
+	if err != nil {
+		return err
+	}
+
+	var lower []byte
+	for i, dstPath := range dstPaths {
+		var upper []byte
+		if i < len(boundaries) {
+			upper = boundaries[i]
+		}
+
+		err := extractPartition(src, comp, MergePartition{Lower: lower, Upper: upper}, dstPath, writerOptions)
+		if err != nil {
+			return err
+		}
+		lower = upper
+	}
+
+	return nil
+}
+
+// extractComparator returns the comparator the keys of src are sorted with, a comparator supplied WithKeyComparator
+// takes precedence over the one of the reader.
+func extractComparator(src SSTableReaderI, writerOptions []WriterOption) skiplist.Comparator[[]byte] {
+	opts := &SSTableWriterOptions{}
+	for _, writerOption := range writerOptions {
+		writerOption(opts)
+	}
+
+	if opts.keyComparator != nil {
+		return opts.keyComparator
+	}
+	if reader, ok := src.(*SSTableReader); ok {
+		return reader.opts.keyComparator
+	}
+	return skiplist.BytesComparator{}
+}
+
+// checkExtractable ensures that the encoded values of src can be written into new tables.
+func checkExtractable(src SSTableReaderI) error {
+	reader, ok := src.(*SSTableReader)
+	if ok && reader.metaData.ValueSeparation && reader.opts.blobStore == nil {
+		return fmt.Errorf("sstable '%s' is written with value separation, it needs to be read with a blob store to extract keys", reader.opts.basePath)
+	}
+	return nil
+}
+
+func extractPartition(src SSTableReaderI, comp skiplist.Comparator[[]byte], partition MergePartition, dstPath string,
+	writerOptions []WriterOption) (err error) {
+	err = os.MkdirAll(dstPath, 0700)
+	if err != nil {
+		return fmt.Errorf("error while creating directory '%s': %w", dstPath, err)
+	}
+
+	writer, err := NewSSTableStreamWriter(append(extractWriterOptions(src, comp, dstPath), writerOptions...)...)
+	if err != nil {
+		return fmt.Errorf("error while creating writer in '%s': %w", dstPath, err)
+	}
+
+	err = writer.Open()
+	if err != nil {
+		return fmt.Errorf("error while opening writer in '%s': %w", dstPath, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, writer.Close())
+	}()
+
+	if reader, ok := src.(*SSTableReader); ok {
+		err = extractRecords(reader, partition, writer)
+	} else {
+		err = extractScanned(src, comp, partition, writer)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, tombstone := range partition.clip(comp, src.RangeTombstones()) {
+		err = writer.WriteRangeTombstone(tombstone.Start, tombstone.End)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractRecords copies the records of the partition using the index of src, which keeps the values encoded.
+func extractRecords(src *SSTableReader, partition MergePartition, writer *SSTableStreamWriter) error {
+	var it skiplist.IteratorI[[]byte, IndexVal]
+	var err error
+	if partition.Lower == nil {
+		it, err = src.index.Iterator()
+	} else {
+		it, err = src.index.IteratorStartingAt(partition.Lower)
+	}
+	if err != nil {
+		return fmt.Errorf("error while iterating index of sstable '%s': %w", src.opts.basePath, err)
+	}
+
+	rawReader, copyRaw := copiesRawRecords(src, writer)
+	for {
+		key, iVal, iErr := it.Next()
+		if errors.Is(iErr, skiplist.Done) {
+			return nil
+		}
+		if iErr != nil {
+			return fmt.Errorf("error while iterating index of sstable '%s': %w", src.opts.basePath, iErr)
+		}
+
+		if partition.Upper != nil && src.opts.keyComparator.Compare(key, partition.Upper) >= 0 {
+			return nil
+		}
+
+		if copyRaw {
+			record, rErr := rawReader.ReadRawAt(iVal.Offset)
+			if rErr != nil {
+				return fmt.Errorf("error in sstable '%s' while reading record at offset %d: %w", src.opts.basePath, iVal.Offset, rErr)
+			}
+			err = writer.writeNextRaw(key, record, iVal.Checksum)
+		} else {
+			value, vErr := src.getValueAtOffset(iVal, src.opts.skipHashCheckOnRead)
+			if vErr != nil {
+				return vErr
+			}
+			err = writer.WriteNext(key, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractScanned writes the keys and values of the partition as they are returned by the scans of src.
+func extractScanned(src SSTableReaderI, comp skiplist.Comparator[[]byte], partition MergePartition, writer *SSTableStreamWriter) error {
+	it, err := partition.scan(comp, src)
+	if err != nil {
+		return fmt.Errorf("error while scanning sstable '%s': %w", src.BasePath(), err)
+	}
+
+	for {
+		key, value, iErr := it.Next()
+		if errors.Is(iErr, Done) {
+			return nil
+		}
+		if iErr != nil {
+			return fmt.Errorf("error while scanning sstable '%s': %w", src.BasePath(), iErr)
+		}
+
+		err = writer.WriteNext(key, value)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractWriterOptions returns the options to write a new table just like src.
+func extractWriterOptions(src SSTableReaderI, comp skiplist.Comparator[[]byte], dstPath string) []WriterOption {
+	metaData := src.MetaData()
+	opts := []WriterOption{
+		WriteBasePath(dstPath),
+		WithKeyComparator(comp),
+		BloomExpectedNumberOfElements(max(metaData.NumRecords, 1)),
+		WithProperties(src.Properties()),
+	}
+
+	if metaData.InternalKeys {
+		opts = append(opts, WithInternalKeys())
+	}
+	if metaData.MergeOperands {
+		opts = append(opts, WithMergeOperands())
+	}
+
+	// other readers return the values decoded, thus only the encodings of the keys are kept
+	reader, ok := src.(*SSTableReader)
+	if !ok {
+		return opts
+	}
+
+	if rawReader, ok := reader.dataReader.(recordio.RawReadAtI); ok {
+		opts = append(opts, DataCompressionType(rawReader.CompressionType()))
+	}
+
+	if reader.opts.prefixExtractor != nil && reader.opts.prefixExtractor.Name() == metaData.PrefixExtractor {
+		opts = append(opts, WithPrefixExtractor(reader.opts.prefixExtractor))
+	}
+
+	if metaData.ExpiringValues {
+		opts = append(opts, WithExpiringValues())
+	}
+
+	if metaData.ValueSeparation {
+		// the encoded values are copied, so blob references stay references and inline values stay inline
+		opts = append(opts, WithValueSeparation(reader.opts.blobStore, math.MaxInt), WriteEncodedValues())
+	}
+
+	return opts
+}
+
+// copiesRawRecords is true if the records of src can be copied into the writer as they are stored in the data file.
+func copiesRawRecords(src *SSTableReader, writer *SSTableStreamWriter) (recordio.RawReadAtI, bool) {
+	rawReader, ok := src.dataReader.(recordio.RawReadAtI)
+	if !ok || rawReader.Version() != recordio.CurrentVersion || rawReader.CompressionType() != writer.opts.dataCompressionType {
+		return nil, false
+	}
+
+	// value separation and properties collectors need the values themselves
+	if src.metaData.ValueSeparation || writer.opts.blobStore != nil || len(writer.propertiesCollectors) > 0 {
+		return nil, false
+	}
+
+	// raw records aren't validated by the writer, so their values must be encoded the same way
+	if src.metaData.InternalKeys != writer.opts.internalKeys || src.metaData.MergeOperands != writer.opts.mergeOperands ||
+		src.metaData.ExpiringValues != writer.opts.expiringValues {
+		return nil, false
+	}
+
+	return rawReader, true
+}
@@ -0,0 +1,272 @@
+package sstables
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/recordio"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+// writeExtractTestTable writes keys "key000" to "key099", every tenth key is deleted with a nil value, and a range
+// tombstone over [key045, key065).
+func writeExtractTestTable(t *testing.T) *SSTableReader {
+	path := t.TempDir()
+	writer, err := NewSSTableStreamWriter(WriteBasePath(path), WithKeyComparator(skiplist.BytesComparator{}),
+		WithProperties(map[string][]byte{"job": []byte("42")}))
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, writer.WriteNext(extractTestKey(i), extractTestValue(i)))
+	}
+	require.NoError(t, writer.WriteRangeTombstone([]byte("key045"), []byte("key065")))
+	require.NoError(t, writer.Close())
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, reader.Close()) })
+	return reader.(*SSTableReader)
+}
+
+func extractTestKey(i int) []byte {
+	return []byte(fmt.Sprintf("key%03d", i))
+}
+
+func extractTestValue(i int) []byte {
+	if i%10 == 0 {
+		return nil
+	}
+	return []byte(fmt.Sprintf("value%d", i))
+}
+
+// assertExtractedTable checks that the table in path contains exactly the keys within [from, to) and is valid.
+func assertExtractedTable(t *testing.T, path string, from int, to int) *SSTableReader {
+	report, err := Verify(path)
+	require.NoError(t, err)
+	require.True(t, report.Ok(), "%v", report.Problems)
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, reader.Close()) })
+
+	it, err := reader.Scan()
+	require.NoError(t, err)
+	nullValues := 0
+	for i := from; i < to; i++ {
+		k, v, err := it.Next()
+		require.NoError(t, err)
+		require.Equal(t, extractTestKey(i), k)
+		require.Equal(t, extractTestValue(i), v)
+		if v == nil {
+			nullValues++
+		}
+	}
+	_, _, err = it.Next()
+	require.ErrorIs(t, err, Done)
+
+	md := reader.MetaData()
+	require.Equal(t, uint64(to-from), md.NumRecords)
+	require.Equal(t, uint64(nullValues), md.NullValues)
+	require.Equal(t, []byte("42"), reader.Properties()["job"])
+	if to > from {
+		require.Equal(t, extractTestKey(from), md.MinKey)
+		require.Equal(t, extractTestKey(to-1), md.MaxKey)
+	}
+	return reader.(*SSTableReader)
+}
+
+func TestExtractRangeCopiesRecords(t *testing.T) {
+	src := writeExtractTestTable(t)
+	dst := filepath.Join(t.TempDir(), "extracted")
+
+	require.NoError(t, ExtractRange(src, extractTestKey(20), extractTestKey(50), dst))
+	extracted := assertExtractedTable(t, dst, 20, 50)
+	require.Equal(t, RangeTombstones{{Start: []byte("key045"), End: extractTestKey(50)}}, extracted.RangeTombstones())
+	require.Equal(t, src.MetaData().ComparatorName, extracted.MetaData().ComparatorName)
+	require.Equal(t, recordio.CompressionTypeSnappy, extracted.dataReader.(recordio.RawReadAtI).CompressionType())
+
+	var valueBytes uint64
+	for i := 20; i < 50; i++ {
+		valueBytes += uint64(len(extractTestValue(i)))
+	}
+	require.Equal(t, valueBytes, extracted.MetaData().ValueBytes)
+}
+
+func TestExtractRangeUnbounded(t *testing.T) {
+	src := writeExtractTestTable(t)
+
+	lower := filepath.Join(t.TempDir(), "lower")
+	require.NoError(t, ExtractRange(src, nil, extractTestKey(10), lower))
+	extracted := assertExtractedTable(t, lower, 0, 10)
+	require.Empty(t, extracted.RangeTombstones())
+
+	all := filepath.Join(t.TempDir(), "all")
+	require.NoError(t, ExtractRange(src, nil, nil, all))
+	extracted = assertExtractedTable(t, all, 0, 100)
+	require.Equal(t, src.RangeTombstones(), extracted.RangeTombstones())
+
+	// the records are copied as they are, so the data file is exactly the same
+	srcData, err := os.ReadFile(filepath.Join(src.BasePath(), DataFileName))
+	require.NoError(t, err)
+	dstData, err := os.ReadFile(filepath.Join(all, DataFileName))
+	require.NoError(t, err)
+	require.Equal(t, srcData, dstData)
+}
+
+func TestExtractRangeRecompresses(t *testing.T) {
+	src := writeExtractTestTable(t)
+	dst := filepath.Join(t.TempDir(), "extracted")
+
+	// records can't be copied raw into a table with another compression
+	require.NoError(t, ExtractRange(src, extractTestKey(55), nil, dst, DataCompressionType(recordio.CompressionTypeNone)))
+	extracted := assertExtractedTable(t, dst, 55, 100)
+	require.Equal(t, recordio.CompressionTypeNone, extracted.dataReader.(recordio.RawReadAtI).CompressionType())
+	require.Equal(t, RangeTombstones{{Start: extractTestKey(55), End: []byte("key065")}}, extracted.RangeTombstones())
+}
+
+func TestExtractRangeKeepsValueEncoding(t *testing.T) {
+	path := t.TempDir()
+	writer, err := NewSSTableStreamWriter(WriteBasePath(path), WithKeyComparator(skiplist.BytesComparator{}), WithExpiringValues())
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	require.NoError(t, writer.WriteNext([]byte("a"), EncodeExpiringValue([]byte("expired"), time.Now().Add(-time.Hour))))
+	require.NoError(t, writer.WriteNext([]byte("b"), EncodeExpiringValue([]byte("alive"), time.Now().Add(time.Hour))))
+	require.NoError(t, writer.Close())
+	src, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.NoError(t, err)
+	defer closeReader(t, src)
+
+	dst := filepath.Join(t.TempDir(), "extracted")
+	require.NoError(t, ExtractRange(src, nil, nil, dst))
+	extracted, err := NewSSTableReader(ReadBasePath(dst), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.NoError(t, err)
+	defer closeReader(t, extracted)
+
+	require.True(t, extracted.MetaData().ExpiringValues)
+	_, err = extracted.Get([]byte("a"))
+	require.ErrorIs(t, err, NotFound)
+	v, err := extracted.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("alive"), v)
+}
+
+func TestExtractRangeKeepsSeparatedEncodedValues(t *testing.T) {
+	store := newTestBlobStore(t)
+	defer closeBlobStore(t, store)
+
+	path := t.TempDir()
+	writer, err := NewSSTableStreamWriter(WriteBasePath(path), WithKeyComparator(skiplist.BytesComparator{}),
+		WithValueSeparation(store, 10), WithExpiringValues(), WithMergeOperands())
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	large := MergeValue{Kind: ValueKindPut, Value: bytes.Repeat([]byte{1}, 100)}.Encode()
+	small := MergeValue{Kind: ValueKindMerge, Operands: [][]byte{[]byte("small")}}.Encode()
+	require.NoError(t, writer.WriteNext([]byte("a"), EncodeExpiringValue(large, time.Now().Add(-time.Hour))))
+	require.NoError(t, writer.WriteNext([]byte("b"), EncodeExpiringValue(large, time.Now().Add(time.Hour))))
+	require.NoError(t, writer.WriteNext([]byte("c"), EncodeExpiringValue(small, time.Now().Add(time.Hour))))
+	require.NoError(t, writer.Close())
+	src, err := NewSSTableReader(ReadBasePath(path), ReadWithKeyComparator(skiplist.BytesComparator{}), ReadWithBlobStore(store))
+	require.NoError(t, err)
+	defer closeReader(t, src)
+
+	dst := filepath.Join(t.TempDir(), "extracted")
+	require.NoError(t, ExtractRange(src, nil, nil, dst))
+	extracted, err := NewSSTableReader(ReadBasePath(dst), ReadWithKeyComparator(skiplist.BytesComparator{}), ReadWithBlobStore(store))
+	require.NoError(t, err)
+	defer closeReader(t, extracted)
+
+	require.True(t, extracted.MetaData().ExpiringValues)
+	require.True(t, extracted.MetaData().MergeOperands)
+	require.True(t, extracted.MetaData().ValueSeparation)
+	// the blob references are copied, not the blobs
+	require.Equal(t, src.MetaData().BlobFileBytes, extracted.MetaData().BlobFileBytes)
+	_, err = extracted.Get([]byte("a"))
+	require.ErrorIs(t, err, NotFound)
+	v, err := extracted.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, large, v)
+	v, err = extracted.Get([]byte("c"))
+	require.NoError(t, err)
+	require.Equal(t, small, v)
+}
+
+func TestExtractRangeScansOtherReaders(t *testing.T) {
+	src := NewSuperSSTableReader([]SSTableReaderI{writeExtractTestTable(t)}, skiplist.BytesComparator{})
+
+	dir := t.TempDir()
+	dstPaths := []string{filepath.Join(dir, "0"), filepath.Join(dir, "1")}
+	require.NoError(t, Split(src, [][]byte{extractTestKey(30)}, dstPaths))
+
+	// the super reader skips the deleted keys, the others are written as they are scanned
+	for i, keyRange := range [][2]int{{0, 30}, {30, 100}} {
+		extracted, err := NewSSTableReader(ReadBasePath(dstPaths[i]), ReadWithKeyComparator(skiplist.BytesComparator{}))
+		require.NoError(t, err)
+		defer closeReader(t, extracted)
+
+		it, err := extracted.Scan()
+		require.NoError(t, err)
+		for j := keyRange[0]; j < keyRange[1]; j++ {
+			if extractTestValue(j) == nil {
+				continue
+			}
+			k, v, err := it.Next()
+			require.NoError(t, err)
+			require.Equal(t, extractTestKey(j), k)
+			require.Equal(t, extractTestValue(j), v)
+		}
+		_, _, err = it.Next()
+		require.ErrorIs(t, err, Done)
+		require.Equal(t, []byte("42"), extracted.Properties()["job"])
+	}
+
+	dst := filepath.Join(t.TempDir(), "extracted")
+	require.NoError(t, ExtractRange(src, extractTestKey(20), extractTestKey(50), dst))
+	extracted, err := NewSSTableReader(ReadBasePath(dst), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.NoError(t, err)
+	defer closeReader(t, extracted)
+	require.Equal(t, uint64(27), extracted.MetaData().NumRecords)
+	require.Equal(t, RangeTombstones{{Start: []byte("key045"), End: extractTestKey(50)}}, extracted.RangeTombstones())
+}
+
+func TestExtractRangeInvalidRange(t *testing.T) {
+	src := writeExtractTestTable(t)
+	err := ExtractRange(src, extractTestKey(50), extractTestKey(20), t.TempDir())
+	require.ErrorContains(t, err, "keyHigher must be higher than keyLower")
+	err = ExtractRange(src, extractTestKey(20), extractTestKey(20), t.TempDir())
+	require.ErrorContains(t, err, "keyHigher must be higher than keyLower")
+}
+
+func TestSplit(t *testing.T) {
+	src := writeExtractTestTable(t)
+	dir := t.TempDir()
+	dstPaths := []string{filepath.Join(dir, "0"), filepath.Join(dir, "1"), filepath.Join(dir, "2"), filepath.Join(dir, "3")}
+
+	boundaries := [][]byte{extractTestKey(30), extractTestKey(60), []byte("x")}
+	require.NoError(t, Split(src, boundaries, dstPaths))
+
+	first := assertExtractedTable(t, dstPaths[0], 0, 30)
+	require.Empty(t, first.RangeTombstones())
+	second := assertExtractedTable(t, dstPaths[1], 30, 60)
+	require.Equal(t, RangeTombstones{{Start: []byte("key045"), End: extractTestKey(60)}}, second.RangeTombstones())
+	third := assertExtractedTable(t, dstPaths[2], 60, 100)
+	require.Equal(t, RangeTombstones{{Start: extractTestKey(60), End: []byte("key065")}}, third.RangeTombstones())
+	// there are no keys after the last boundary
+	assertExtractedTable(t, dstPaths[3], 100, 100)
+}
+
+func TestSplitInvalidBoundaries(t *testing.T) {
+	src := writeExtractTestTable(t)
+	dir := t.TempDir()
+
+	err := Split(src, [][]byte{extractTestKey(30)}, []string{dir})
+	require.ErrorContains(t, err, "expected 2 paths for 1 boundaries, but got 1")
+	err = Split(src, [][]byte{extractTestKey(30), extractTestKey(30)}, []string{dir, dir, dir})
+	require.ErrorContains(t, err, "boundaries must be strictly ascending")
+	err = Split(src, [][]byte{nil}, []string{dir, dir})
+	require.ErrorContains(t, err, "boundary 0 is nil")
+}
@@ -0,0 +1,237 @@
+package sstables
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/exp/mmap"
+
+	rProto "github.com/thomasjungblut/go-sstables/recordio/proto"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+)
+
+// HashIndexFileName is an open-addressing hash table from the hash of every key to the offset of its entry in the
+// IndexFileName, it's written when the table is created WithHashIndex.
+var HashIndexFileName = "hash_index.bin"
+
+const hashIndexVersion uint32 = 1
+
+// hashIndexHeaderSizeBytes has a 4 byte version, 4 reserved bytes, 8 bytes for the number of slots and 8 bytes for
+// the number of keys.
+const hashIndexHeaderSizeBytes = 24
+
+// hashIndexSlotSizeBytes has 8 bytes for the hash of the key and 8 bytes for the offset of its index entry, where an
+// offset of zero marks an empty slot. Index entries never start at zero because of the recordio file header.
+const hashIndexSlotSizeBytes = 16
+
+type hashIndexEntry struct {
+	hash        uint64
+	indexOffset uint64
+}
+
+func hashKey(key []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(key)
+	return h.Sum64()
+}
+
+// hashIndexSlot spreads the hash over all slots, numSlots is always a power of two.
+func hashIndexSlot(hash uint64, numSlots uint64) uint64 {
+	// the finalizer of splitmix64, fnv doesn't distribute the lower bits well enough for short keys
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+	return hash & (numSlots - 1)
+}
+
+// hashIndexNumSlots keeps the load factor at or below one half, so that a probe sequence stays short.
+func hashIndexNumSlots(numKeys int) uint64 {
+	numSlots := uint64(1)
+	for numSlots < 2*uint64(numKeys) {
+		numSlots <<= 1
+	}
+	return numSlots
+}
+
+func writeHashIndex(path string, entries []hashIndexEntry) (err error) {
+	numSlots := hashIndexNumSlots(len(entries))
+	buf := make([]byte, hashIndexHeaderSizeBytes+numSlots*hashIndexSlotSizeBytes)
+	binary.LittleEndian.PutUint32(buf[0:4], hashIndexVersion)
+	binary.LittleEndian.PutUint64(buf[8:16], numSlots)
+	binary.LittleEndian.PutUint64(buf[16:24], uint64(len(entries)))
+
+	slots := buf[hashIndexHeaderSizeBytes:]
+	for _, e := range entries {
+		// linear probing until we find an empty slot
+		for slot := hashIndexSlot(e.hash, numSlots); ; slot = (slot + 1) & (numSlots - 1) {
+			s := slots[slot*hashIndexSlotSizeBytes : (slot+1)*hashIndexSlotSizeBytes]
+			if binary.LittleEndian.Uint64(s[8:16]) == 0 {
+				binary.LittleEndian.PutUint64(s[0:8], e.hash)
+				binary.LittleEndian.PutUint64(s[8:16], e.indexOffset)
+				break
+			}
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error while creating hash index in '%s': %w", path, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	_, err = f.Write(buf)
+	if err != nil {
+		return fmt.Errorf("error while writing hash index in '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// HashKeyIndex looks up keys with a single probe sequence in the memory mapped HashIndexFileName and reads the
+// matching entry from the index file. All ordered operations, like iterators, are served by the embedded DiskKeyIndex.
+type HashKeyIndex struct {
+	*DiskKeyIndex
+	table     io.ReaderAt
+	tableSize int64
+	closer    io.Closer
+	numSlots  uint64
+}
+
+func (s *HashKeyIndex) Open() error {
+	err := s.DiskKeyIndex.Open()
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, hashIndexHeaderSizeBytes)
+	_, err = s.table.ReadAt(header, 0)
+	if err != nil {
+		return fmt.Errorf("error while reading hash index header: %w", err)
+	}
+
+	version := binary.LittleEndian.Uint32(header[0:4])
+	if version != hashIndexVersion {
+		return fmt.Errorf("unsupported hash index version %d, expected %d", version, hashIndexVersion)
+	}
+
+	s.numSlots = binary.LittleEndian.Uint64(header[8:16])
+	if s.numSlots == 0 || s.numSlots&(s.numSlots-1) != 0 {
+		return fmt.Errorf("invalid number of hash index slots %d", s.numSlots)
+	}
+
+	expectedSize := int64(hashIndexHeaderSizeBytes + s.numSlots*hashIndexSlotSizeBytes)
+	if s.tableSize != expectedSize {
+		return fmt.Errorf("hash index size mismatch, expected %d bytes but was %d", expectedSize, s.tableSize)
+	}
+
+	return nil
+}
+
+func (s *HashKeyIndex) Close() error {
+	err := s.DiskKeyIndex.Close()
+	if s.closer != nil {
+		err = errors.Join(err, s.closer.Close())
+	}
+	return err
+}
+
+func (s *HashKeyIndex) Contains(key []byte) (bool, error) {
+	_, err := s.Get(key)
+	if err != nil {
+		if errors.Is(err, skiplist.NotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *HashKeyIndex) Get(key []byte) (IndexVal, error) {
+	hash := hashKey(key)
+	slotBuf := make([]byte, hashIndexSlotSizeBytes)
+	entry := &proto.IndexEntry{}
+
+	slot := hashIndexSlot(hash, s.numSlots)
+	for i := uint64(0); i < s.numSlots; i++ {
+		_, err := s.table.ReadAt(slotBuf, int64(hashIndexHeaderSizeBytes+slot*hashIndexSlotSizeBytes))
+		if err != nil {
+			return IndexVal{}, fmt.Errorf("error while reading hash index slot %d: %w", slot, err)
+		}
+
+		indexOffset := binary.LittleEndian.Uint64(slotBuf[8:16])
+		if indexOffset == 0 {
+			return IndexVal{}, skiplist.NotFound
+		}
+
+		// different keys can have the same hash, only the index entry can tell for sure
+		if binary.LittleEndian.Uint64(slotBuf[0:8]) == hash {
+			_, err = s.reader.ReadNextAt(entry, indexOffset)
+			if err != nil {
+				return IndexVal{}, fmt.Errorf("error while reading index entry at offset %d: %w", indexOffset, err)
+			}
+
+			if bytes.Equal(entry.Key, key) {
+				return IndexVal{Offset: entry.ValueOffset, Checksum: entry.Checksum}, nil
+			}
+		}
+
+		slot = (slot + 1) & (s.numSlots - 1)
+	}
+
+	return IndexVal{}, skiplist.NotFound
+}
+
+// HashIndexLoader loads the HashKeyIndex of tables written WithHashIndex. Other tables fall back to the DiskKeyIndex,
+// which does a binary search for every lookup.
+type HashIndexLoader struct{}
+
+func (l *HashIndexLoader) Load(indexPath string, _ *proto.MetaData) (SortedKeyIndex, error) {
+	reader, err := rProto.NewMMapProtoReaderWithPath(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating index reader of sstable in '%s': %w", indexPath, err)
+	}
+
+	hashIndexPath := filepath.Join(filepath.Dir(indexPath), HashIndexFileName)
+	if _, err := os.Stat(hashIndexPath); os.IsNotExist(err) {
+		return newDiskKeyIndex(reader), nil
+	}
+
+	table, err := mmap.Open(hashIndexPath)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("error while opening hash index in '%s': %w", hashIndexPath, err), reader.Close())
+	}
+
+	return &HashKeyIndex{DiskKeyIndex: newDiskKeyIndex(reader), table: table, tableSize: int64(table.Len()), closer: table}, nil
+}
+
+// LoadFromSource loads the index from a table that is opened with ReadFromSource, a lookup reads one probe sequence
+// of the hash index and one entry of the index file.
+func (l *HashIndexLoader) LoadFromSource(source TableSource, _ *proto.MetaData) (SortedKeyIndex, error) {
+	reader, err := newSourceProtoReadAtReader(source, IndexFileName)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating index reader of sstable from source: %w", err)
+	}
+
+	table, size, exists, err := sourceFileIfExists(source, HashIndexFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return newDiskKeyIndex(reader), nil
+	}
+
+	return &HashKeyIndex{DiskKeyIndex: newDiskKeyIndex(reader), table: table, tableSize: size}, nil
+}
@@ -0,0 +1,170 @@
+package sstables
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/recordio"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func writeHashIndexTestTable(t *testing.T, keys [][]byte, opts ...WriterOption) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_HashIndex")
+	require.Nil(t, err)
+
+	writer, err := NewSSTableStreamWriter(append(opts, WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}))...)
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, k := range keys {
+		require.Nil(t, writer.WriteNext(k, append([]byte("v_"), k...)))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+// variableLengthKeys returns sorted keys that are longer than any of the MapKeyIndex mappers support.
+func variableLengthKeys(n int) [][]byte {
+	var keys [][]byte
+	for i := 0; i < n; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("key_%06d_%s", i, strings.Repeat("x", i%40))))
+	}
+	return keys
+}
+
+func TestHashIndexWrittenByWriter(t *testing.T) {
+	keys := variableLengthKeys(1000)
+	path := writeHashIndexTestTable(t, keys, WithHashIndex())
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	stat, err := os.Stat(filepath.Join(path, HashIndexFileName))
+	require.Nil(t, err)
+	assert.Equal(t, int64(hashIndexHeaderSizeBytes+2048*hashIndexSlotSizeBytes), stat.Size())
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadIndexLoader(&HashIndexLoader{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	index := reader.(*SSTableReader).index.(*HashKeyIndex)
+	assert.Equal(t, uint64(2048), index.numSlots)
+
+	for _, k := range keys {
+		v, err := reader.Get(k)
+		require.Nil(t, err)
+		assert.Equal(t, append([]byte("v_"), k...), v)
+
+		contains, err := reader.Contains(k)
+		require.Nil(t, err)
+		assert.True(t, contains)
+
+		_, err = reader.Get(append(k, 'y'))
+		assert.ErrorIs(t, err, NotFound)
+	}
+
+	it, err := reader.ScanRange(keys[100], keys[199])
+	require.Nil(t, err)
+	var scanned [][]byte
+	for {
+		k, _, err := it.Next()
+		if errors.Is(err, Done) {
+			break
+		}
+		require.Nil(t, err)
+		scanned = append(scanned, k)
+	}
+	assert.Equal(t, keys[100:200], scanned)
+}
+
+func TestHashIndexCollidingHashes(t *testing.T) {
+	keys := variableLengthKeys(16)
+	path := writeHashIndexTestTable(t, keys, WithHashIndex())
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadIndexLoader(&HashIndexLoader{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	// the hash of a missing key points to the entry of the first key, which must not match
+	missing := []byte("missing")
+	collidingPath := filepath.Join(path, "colliding_"+HashIndexFileName)
+	require.Nil(t, writeHashIndex(collidingPath, []hashIndexEntry{
+		{hash: hashKey(missing), indexOffset: recordio.FileHeaderSizeBytes},
+	}))
+	content, err := os.ReadFile(collidingPath)
+	require.Nil(t, err)
+
+	index := &HashKeyIndex{
+		DiskKeyIndex: reader.(*SSTableReader).index.(*HashKeyIndex).DiskKeyIndex,
+		table:        bytes.NewReader(content),
+		tableSize:    int64(len(content)),
+		numSlots:     hashIndexNumSlots(1),
+	}
+
+	_, err = index.Get(missing)
+	assert.ErrorIs(t, err, skiplist.NotFound)
+	contains, err := index.Contains(missing)
+	require.Nil(t, err)
+	assert.False(t, contains)
+
+	// a key with a different hash is never compared
+	_, err = index.Get(keys[0])
+	assert.ErrorIs(t, err, skiplist.NotFound)
+}
+
+func TestHashIndexFallsBackToDiskIndex(t *testing.T) {
+	keys := variableLengthKeys(100)
+	path := writeHashIndexTestTable(t, keys)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	_, err := os.Stat(filepath.Join(path, HashIndexFileName))
+	require.True(t, os.IsNotExist(err))
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadIndexLoader(&HashIndexLoader{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	_, ok := reader.(*SSTableReader).index.(*DiskKeyIndex)
+	require.True(t, ok)
+
+	for _, k := range keys {
+		v, err := reader.Get(k)
+		require.Nil(t, err)
+		assert.Equal(t, append([]byte("v_"), k...), v)
+	}
+}
+
+func TestHashIndexEmptyTable(t *testing.T) {
+	path := writeHashIndexTestTable(t, nil, WithHashIndex())
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadIndexLoader(&HashIndexLoader{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	_, err = reader.Get([]byte("a"))
+	assert.ErrorIs(t, err, NotFound)
+
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	_, _, err = it.Next()
+	assert.ErrorIs(t, err, Done)
+}
+
+func TestHashIndexRejectsCorruptTable(t *testing.T) {
+	path := writeHashIndexTestTable(t, variableLengthKeys(10), WithHashIndex())
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	hashIndexPath := filepath.Join(path, HashIndexFileName)
+	content, err := os.ReadFile(hashIndexPath)
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(hashIndexPath, content[:len(content)-1], 0644))
+
+	_, err = NewSSTableReader(ReadBasePath(path), ReadIndexLoader(&HashIndexLoader{}))
+	require.ErrorContains(t, err, "hash index size mismatch")
+}
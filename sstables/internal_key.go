@@ -0,0 +1,176 @@
+package sstables
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ValueKind denotes what kind of operation a version of a key represents.
+type ValueKind byte
+
+const (
+	ValueKindDelete ValueKind = 0
+	ValueKindPut    ValueKind = 1
+	ValueKindMerge  ValueKind = 2
+)
+
+const internalKeyTrailerSizeBytes = 8 + 1
+
+var MergeOperandFound = errors.New("merge operand found, reading requires a merge operator")
+
+// InternalKey is a version of a user key, denoted by a sequence number and the kind of the value.
+type InternalKey struct {
+	UserKey  []byte
+	Sequence uint64
+	Kind     ValueKind
+}
+
+// KeyVersion is a single version of a user key alongside its value.
+type KeyVersion struct {
+	Sequence uint64
+	Kind     ValueKind
+	Value    []byte
+}
+
+// EncodeInternalKey encodes the user key with the sequence number and kind, so that the byte-wise ordering (as in
+// skiplist.BytesComparator) sorts by the user key ascending and then by the sequence number descending.
+// The user key is escaped and terminated, which allows user keys of any length and content:
+// every zero byte is replaced by [0x00, 0xFF] and the key is terminated with [0x00, 0x01].
+// The terminated user key is followed by the inverted sequence number (big endian) and the inverted kind.
+func EncodeInternalKey(userKey []byte, sequence uint64, kind ValueKind) []byte {
+	encoded := make([]byte, 0, len(userKey)+2+internalKeyTrailerSizeBytes)
+	for _, b := range userKey {
+		if b == 0x00 {
+			encoded = append(encoded, 0x00, 0xFF)
+		} else {
+			encoded = append(encoded, b)
+		}
+	}
+	encoded = append(encoded, 0x00, 0x01)
+	encoded = binary.BigEndian.AppendUint64(encoded, ^sequence)
+	return append(encoded, ^byte(kind))
+}
+
+// DecodeInternalKey is the inverse to EncodeInternalKey, the returned user key is always a copy.
+func DecodeInternalKey(key []byte) (InternalKey, error) {
+	userKey := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		if key[i] != 0x00 {
+			userKey = append(userKey, key[i])
+			continue
+		}
+
+		if i+1 >= len(key) {
+			return InternalKey{}, fmt.Errorf("invalid internal key, unterminated user key: %v", key)
+		}
+
+		switch key[i+1] {
+		case 0xFF:
+			userKey = append(userKey, 0x00)
+			i++
+		case 0x01:
+			trailer := key[i+2:]
+			if len(trailer) != internalKeyTrailerSizeBytes {
+				return InternalKey{}, fmt.Errorf("invalid internal key, trailer has %d bytes: %v", len(trailer), key)
+			}
+			return InternalKey{
+				UserKey:  userKey,
+				Sequence: ^binary.BigEndian.Uint64(trailer),
+				Kind:     ValueKind(^trailer[8]),
+			}, nil
+		default:
+			return InternalKey{}, fmt.Errorf("invalid internal key, unknown escape sequence: %v", key)
+		}
+	}
+
+	return InternalKey{}, fmt.Errorf("invalid internal key, unterminated user key: %v", key)
+}
+
+// internalSeekKey returns the smallest internal key for the given user key that has a sequence number lower or equal
+// to the given sequence. Seeking to it yields the latest version of the user key as of that sequence number.
+func internalSeekKey(userKey []byte, sequence uint64) []byte {
+	key := EncodeInternalKey(userKey, sequence, 0)
+	key[len(key)-1] = 0x00
+	return key
+}
+
+// getVersionAsOf returns the latest version of the user key with a sequence number lower or equal to the given one.
+func getVersionAsOf(reader SSTableReaderI, userKey []byte, sequence uint64) (KeyVersion, error) {
+	if !reader.MetaData().InternalKeys {
+		return KeyVersion{}, fmt.Errorf("sstable '%s' was not written with internal keys", reader.BasePath())
+	}
+
+	it, err := reader.ScanStartingAt(internalSeekKey(userKey, sequence))
+	if err != nil {
+		return KeyVersion{}, err
+	}
+
+	k, v, err := it.Next()
+	if err != nil {
+		if errors.Is(err, Done) {
+			return KeyVersion{}, NotFound
+		}
+		return KeyVersion{}, err
+	}
+
+	internalKey, err := DecodeInternalKey(k)
+	if err != nil {
+		return KeyVersion{}, fmt.Errorf("error in sstable '%s': %w", reader.BasePath(), err)
+	}
+
+	if !bytes.Equal(internalKey.UserKey, userKey) {
+		return KeyVersion{}, NotFound
+	}
+
+	return KeyVersion{Sequence: internalKey.Sequence, Kind: internalKey.Kind, Value: v}, nil
+}
+
+// versionValue turns a version into the result of a GetAsOf call.
+func versionValue(version KeyVersion) ([]byte, error) {
+	switch version.Kind {
+	case ValueKindPut:
+		return version.Value, nil
+	case ValueKindDelete:
+		return nil, NotFound
+	case ValueKindMerge:
+		return nil, MergeOperandFound
+	default:
+		return nil, fmt.Errorf("unknown value kind %d", version.Kind)
+	}
+}
+
+// VersionReduceFunc receives all versions of a user key, sorted by their sequence number descending,
+// and returns the versions that should be kept in the same order.
+type VersionReduceFunc func(userKey []byte, versions []KeyVersion) []KeyVersion
+
+// ReduceVersionsVisibleToSnapshots keeps the latest version and every version that is the latest as of one of the given
+// snapshot sequence numbers, all other versions can't be read anymore. When dropDeletes is true, a delete without any
+// older version left is removed as well, which is only safe when the merge includes the oldest data.
+func ReduceVersionsVisibleToSnapshots(snapshots []uint64, dropDeletes bool) VersionReduceFunc {
+	return func(userKey []byte, versions []KeyVersion) []KeyVersion {
+		var kept []KeyVersion
+		for i, version := range versions {
+			// the latest version is always visible, any other version is visible to the snapshots between its own
+			// sequence number (inclusive) and the sequence number of the next newer version (exclusive)
+			visible := i == 0
+			for _, s := range snapshots {
+				if visible {
+					break
+				}
+				visible = s >= version.Sequence && s < versions[i-1].Sequence
+			}
+
+			if visible {
+				kept = append(kept, version)
+			}
+		}
+
+		if dropDeletes && len(kept) > 0 && kept[len(kept)-1].Kind == ValueKindDelete {
+			kept = kept[:len(kept)-1]
+		}
+
+		return kept
+	}
+}
@@ -0,0 +1,255 @@
+package sstables
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func TestInternalKeyEncodingRoundTrip(t *testing.T) {
+	for _, userKey := range [][]byte{{}, {0}, {0, 0}, {1, 0, 2}, {0xFF, 0x01}, []byte("some key")} {
+		for _, seq := range []uint64{0, 1, 1 << 40, ^uint64(0)} {
+			for _, kind := range []ValueKind{ValueKindDelete, ValueKindPut, ValueKindMerge} {
+				decoded, err := DecodeInternalKey(EncodeInternalKey(userKey, seq, kind))
+				require.Nil(t, err)
+				assert.Equal(t, userKey, decoded.UserKey)
+				assert.Equal(t, seq, decoded.Sequence)
+				assert.Equal(t, kind, decoded.Kind)
+			}
+		}
+	}
+
+	for _, invalid := range [][]byte{nil, {1, 2, 3}, {1, 0}, {1, 0, 2}, {1, 0, 1, 2}} {
+		_, err := DecodeInternalKey(invalid)
+		assert.Error(t, err)
+	}
+}
+
+func TestInternalKeyOrdering(t *testing.T) {
+	var keys []InternalKey
+	for i := 0; i < 1000; i++ {
+		userKey := make([]byte, rand.Intn(4))
+		for j := range userKey {
+			// lots of zeros and small values to provoke the escaping
+			userKey[j] = byte(rand.Intn(3))
+		}
+		keys = append(keys, InternalKey{UserKey: userKey, Sequence: uint64(rand.Intn(10000)), Kind: ValueKind(rand.Intn(3))})
+	}
+
+	byEncoding := make([]InternalKey, len(keys))
+	copy(byEncoding, keys)
+	sort.SliceStable(byEncoding, func(i, j int) bool {
+		return bytes.Compare(
+			EncodeInternalKey(byEncoding[i].UserKey, byEncoding[i].Sequence, byEncoding[i].Kind),
+			EncodeInternalKey(byEncoding[j].UserKey, byEncoding[j].Sequence, byEncoding[j].Kind)) < 0
+	})
+
+	for i := 1; i < len(byEncoding); i++ {
+		prev, cur := byEncoding[i-1], byEncoding[i]
+		c := bytes.Compare(prev.UserKey, cur.UserKey)
+		require.True(t, c < 0 || (c == 0 && prev.Sequence >= cur.Sequence),
+			"%v must be sorted before %v", prev, cur)
+	}
+}
+
+func TestWriteInternalKeysValidation(t *testing.T) {
+	_, err := NewSSTableStreamWriter(WriteBasePath("some_path"), WithInternalKeys(),
+		WithKeyComparator(reverseBytesComparator{}))
+	assert.Error(t, err)
+
+	path := writeVersionTestTable(t, nil)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+	writer, err := NewSSTableStreamWriter(WriteBasePath(path), WithInternalKeys())
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	assert.Error(t, writer.WriteNext([]byte{1, 2, 3}, []byte{1}))
+	require.Nil(t, writer.WriteNext(EncodeInternalKey([]byte{1}, 2, ValueKindPut), []byte{1}))
+	require.Nil(t, writer.WriteNext(EncodeInternalKey([]byte{1}, 1, ValueKindPut), []byte{1}))
+	assert.Error(t, writer.WriteNext(EncodeInternalKey([]byte{1}, 1, ValueKindPut), []byte{1}))
+	assert.Error(t, writer.WriteNext(EncodeInternalKey([]byte{1}, 5, ValueKindPut), []byte{1}))
+	require.Nil(t, writer.Close())
+}
+
+func TestGetAsOf(t *testing.T) {
+	path := writeVersionTestTable(t, []versionTestEntry{
+		{"a", 10, ValueKindPut, "a10"},
+		{"a", 5, ValueKindPut, "a5"},
+		{"a", 3, ValueKindDelete, ""},
+		{"a", 2, ValueKindPut, "a2"},
+		{"b", 7, ValueKindMerge, "b7"},
+		{"c", 1, ValueKindPut, "c1"},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.True(t, reader.MetaData().InternalKeys)
+
+	assertGetAsOf(t, reader, "a", 100, "a10")
+	assertGetAsOf(t, reader, "a", 10, "a10")
+	assertGetAsOf(t, reader, "a", 9, "a5")
+	assertGetAsOf(t, reader, "a", 5, "a5")
+	assertGetAsOfError(t, reader, "a", 4, NotFound)
+	assertGetAsOfError(t, reader, "a", 3, NotFound)
+	assertGetAsOf(t, reader, "a", 2, "a2")
+	assertGetAsOfError(t, reader, "a", 1, NotFound)
+	assertGetAsOfError(t, reader, "b", 7, MergeOperandFound)
+	assertGetAsOfError(t, reader, "b", 6, NotFound)
+	assertGetAsOf(t, reader, "c", 1, "c1")
+	assertGetAsOfError(t, reader, "c", 0, NotFound)
+	assertGetAsOfError(t, reader, "aa", 100, NotFound)
+	assertGetAsOfError(t, reader, "d", 100, NotFound)
+
+	plain, err := NewSSTableReader(ReadBasePath("test_files/SimpleWriteHappyPathSSTableWithMetaData"))
+	require.Nil(t, err)
+	defer closeReader(t, plain)
+	_, err = plain.GetAsOf([]byte{1}, 1)
+	assert.Error(t, err)
+}
+
+func TestSuperGetAsOf(t *testing.T) {
+	older := writeVersionTestTable(t, []versionTestEntry{
+		{"a", 5, ValueKindPut, "a5"},
+		{"a", 1, ValueKindPut, "a1"},
+		{"b", 4, ValueKindPut, "b4"},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(older)) }()
+	newer := writeVersionTestTable(t, []versionTestEntry{
+		{"a", 3, ValueKindPut, "a3"},
+		{"b", 6, ValueKindDelete, ""},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(newer)) }()
+
+	r1, err := NewSSTableReader(ReadBasePath(older))
+	require.Nil(t, err)
+	r2, err := NewSSTableReader(ReadBasePath(newer))
+	require.Nil(t, err)
+	reader := NewSuperSSTableReader([]SSTableReaderI{r1, r2}, skiplist.BytesComparator{})
+	defer closeReader(t, reader)
+
+	assertGetAsOf(t, reader, "a", 10, "a5")
+	assertGetAsOf(t, reader, "a", 4, "a3")
+	assertGetAsOf(t, reader, "a", 2, "a1")
+	assertGetAsOfError(t, reader, "a", 0, NotFound)
+	assertGetAsOfError(t, reader, "b", 10, NotFound)
+	assertGetAsOf(t, reader, "b", 5, "b4")
+	assertGetAsOfError(t, reader, "c", 5, NotFound)
+}
+
+func TestReduceVersionsVisibleToSnapshots(t *testing.T) {
+	versions := []KeyVersion{
+		{Sequence: 10, Kind: ValueKindPut},
+		{Sequence: 8, Kind: ValueKindPut},
+		{Sequence: 5, Kind: ValueKindDelete},
+		{Sequence: 3, Kind: ValueKindPut},
+		{Sequence: 1, Kind: ValueKindPut},
+	}
+
+	assert.Equal(t, versions[:1], ReduceVersionsVisibleToSnapshots(nil, false)([]byte{}, versions))
+	assert.Equal(t, versions[:1], ReduceVersionsVisibleToSnapshots([]uint64{100, 0}, false)([]byte{}, versions))
+	assert.Equal(t, []KeyVersion{versions[0], versions[1]},
+		ReduceVersionsVisibleToSnapshots([]uint64{9}, false)([]byte{}, versions))
+	assert.Equal(t, []KeyVersion{versions[0], versions[2], versions[4]},
+		ReduceVersionsVisibleToSnapshots([]uint64{2, 6, 7}, false)([]byte{}, versions))
+
+	// the oldest visible version is a delete, which can be dropped as there is nothing older anymore
+	assert.Equal(t, []KeyVersion{versions[0], versions[2]},
+		ReduceVersionsVisibleToSnapshots([]uint64{6}, false)([]byte{}, versions))
+	assert.Equal(t, []KeyVersion{versions[0]},
+		ReduceVersionsVisibleToSnapshots([]uint64{6}, true)([]byte{}, versions))
+	assert.Empty(t, ReduceVersionsVisibleToSnapshots(nil, true)([]byte{}, versions[2:3]))
+}
+
+func TestMergeCompactVersions(t *testing.T) {
+	older := writeVersionTestTable(t, []versionTestEntry{
+		{"a", 5, ValueKindPut, "a5"},
+		{"a", 1, ValueKindPut, "a1"},
+		{"b", 4, ValueKindPut, "b4"},
+		{"c", 2, ValueKindPut, "c2"},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(older)) }()
+	newer := writeVersionTestTable(t, []versionTestEntry{
+		{"a", 7, ValueKindPut, "a7"},
+		{"a", 5, ValueKindPut, "a5-newer"},
+		{"a", 3, ValueKindPut, "a3"},
+		{"b", 6, ValueKindDelete, ""},
+	})
+	defer func() { require.Nil(t, os.RemoveAll(newer)) }()
+
+	var iterators []SSTableMergeIteratorContext
+	for i, path := range []string{older, newer} {
+		reader, err := NewSSTableReader(ReadBasePath(path))
+		require.Nil(t, err)
+		defer closeReader(t, reader)
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		iterators = append(iterators, NewMergeIteratorContext(i, it))
+	}
+
+	// snapshot at 5 sees a5 and b4, snapshot 2 sees a1 and c2
+	it, err := NewSSTableMerger(skiplist.BytesComparator{}).MergeCompactVersionsIterator(iterators,
+		ReduceVersionsVisibleToSnapshots([]uint64{2, 5}, true))
+	require.Nil(t, err)
+
+	expected := []versionTestEntry{
+		{"a", 7, ValueKindPut, "a7"},
+		{"a", 5, ValueKindPut, "a5-newer"},
+		{"a", 1, ValueKindPut, "a1"},
+		{"b", 6, ValueKindDelete, ""},
+		{"b", 4, ValueKindPut, "b4"},
+		{"c", 2, ValueKindPut, "c2"},
+	}
+	for _, e := range expected {
+		k, v, err := it.Next()
+		require.Nil(t, err)
+		assert.Equal(t, EncodeInternalKey([]byte(e.key), e.sequence, e.kind), k)
+		assert.Equal(t, e.value, string(v))
+	}
+	_, _, err = it.Next()
+	assert.Equal(t, Done, err)
+}
+
+type versionTestEntry struct {
+	key      string
+	sequence uint64
+	kind     ValueKind
+	value    string
+}
+
+func writeVersionTestTable(t *testing.T, entries []versionTestEntry) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_Versions")
+	require.Nil(t, err)
+
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithInternalKeys())
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, e := range entries {
+		require.Nil(t, writer.WriteNext(EncodeInternalKey([]byte(e.key), e.sequence, e.kind), []byte(e.value)))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+func assertGetAsOf(t *testing.T, reader SSTableReaderI, key string, sequence uint64, expected string) {
+	v, err := reader.GetAsOf([]byte(key), sequence)
+	require.Nil(t, err)
+	assert.Equal(t, expected, string(v))
+}
+
+func assertGetAsOfError(t *testing.T, reader SSTableReaderI, key string, sequence uint64, expected error) {
+	_, err := reader.GetAsOf([]byte(key), sequence)
+	assert.ErrorIs(t, err, expected)
+}
+
+type reverseBytesComparator struct{}
+
+func (reverseBytesComparator) Compare(a []byte, b []byte) int {
+	return bytes.Compare(b, a)
+}
@@ -0,0 +1,180 @@
+package sstables
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func writeKeyDistributionTestTable(t *testing.T, keys []int) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_KeyDistribution")
+	require.Nil(t, err)
+
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, k := range keys {
+		require.Nil(t, writer.WriteNext(intToByteSlice(k), make([]byte, 100)))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+func keyRange(start int, end int) []int {
+	var keys []int
+	for i := start; i < end; i++ {
+		keys = append(keys, i)
+	}
+	return keys
+}
+
+func TestApproximateOffsetOf(t *testing.T) {
+	path := writeKeyDistributionTestTable(t, keyRange(0, 100))
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	first, err := reader.ApproximateOffsetOf(intToByteSlice(0))
+	require.Nil(t, err)
+	middle, err := reader.ApproximateOffsetOf(intToByteSlice(50))
+	require.Nil(t, err)
+	last, err := reader.ApproximateOffsetOf(intToByteSlice(1000))
+	require.Nil(t, err)
+
+	assert.Less(t, first, middle)
+	assert.Less(t, middle, last)
+	assert.Equal(t, reader.(*SSTableReader).dataSize(), last)
+
+	// the offsets of the first half and the second half are about equal
+	lowerHalf, err := reader.ApproximateSizeOfRange(intToByteSlice(0), intToByteSlice(50))
+	require.Nil(t, err)
+	upperHalf, err := reader.ApproximateSizeOfRange(intToByteSlice(50), intToByteSlice(1000))
+	require.Nil(t, err)
+	assert.InDelta(t, lowerHalf, upperHalf, float64(lowerHalf)/10)
+	assert.Equal(t, last-first, lowerHalf+upperHalf)
+
+	size, err := reader.ApproximateSizeOfRange(intToByteSlice(50), intToByteSlice(10))
+	require.Nil(t, err)
+	assert.Equal(t, uint64(0), size)
+}
+
+func TestSampleKeys(t *testing.T) {
+	path := writeKeyDistributionTestTable(t, keyRange(0, 100))
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	samples, err := reader.SampleKeys(4)
+	require.Nil(t, err)
+	assert.Equal(t, [][]byte{intToByteSlice(0), intToByteSlice(25), intToByteSlice(50), intToByteSlice(75)}, samples)
+
+	samples, err = reader.SampleKeys(1000)
+	require.Nil(t, err)
+	assert.Equal(t, 100, len(samples))
+
+	samples, err = reader.SampleKeys(0)
+	require.Nil(t, err)
+	assert.Nil(t, samples)
+}
+
+func TestKeyQuantiles(t *testing.T) {
+	path := writeKeyDistributionTestTable(t, keyRange(0, 100))
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	quantiles, err := reader.KeyQuantiles(4)
+	require.Nil(t, err)
+	assert.Equal(t, [][]byte{intToByteSlice(25), intToByteSlice(50), intToByteSlice(75)}, quantiles)
+
+	quantiles, err = reader.KeyQuantiles(1)
+	require.Nil(t, err)
+	assert.Nil(t, quantiles)
+
+	// more ranges than keys yields every key except the first once
+	quantiles, err = reader.KeyQuantiles(1000)
+	require.Nil(t, err)
+	assert.Equal(t, 99, len(quantiles))
+}
+
+func TestKeyDistributionEmptyReader(t *testing.T) {
+	reader := EmptySStableReader{}
+	offset, err := reader.ApproximateOffsetOf([]byte{1})
+	require.Nil(t, err)
+	assert.Equal(t, uint64(0), offset)
+
+	samples, err := reader.SampleKeys(10)
+	require.Nil(t, err)
+	assert.Nil(t, samples)
+
+	quantiles, err := reader.KeyQuantiles(10)
+	require.Nil(t, err)
+	assert.Nil(t, quantiles)
+}
+
+func TestSuperKeyDistribution(t *testing.T) {
+	// the older table is three times as large as the newer one and they overlap
+	older := writeKeyDistributionTestTable(t, keyRange(0, 300))
+	defer func() { require.Nil(t, os.RemoveAll(older)) }()
+	newer := writeKeyDistributionTestTable(t, keyRange(200, 300))
+	defer func() { require.Nil(t, os.RemoveAll(newer)) }()
+
+	var readers []SSTableReaderI
+	for _, p := range []string{older, newer} {
+		reader, err := NewSSTableReader(ReadBasePath(p))
+		require.Nil(t, err)
+		readers = append(readers, reader)
+	}
+	reader := NewSuperSSTableReader(readers, skiplist.BytesComparator{})
+	defer closeReader(t, reader)
+
+	offset, err := reader.ApproximateOffsetOf(intToByteSlice(1000))
+	require.Nil(t, err)
+	olderOffset, err := readers[0].ApproximateOffsetOf(intToByteSlice(1000))
+	require.Nil(t, err)
+	newerOffset, err := readers[1].ApproximateOffsetOf(intToByteSlice(1000))
+	require.Nil(t, err)
+	assert.Equal(t, olderOffset+newerOffset, offset)
+
+	size, err := reader.ApproximateSizeOfRange(intToByteSlice(0), intToByteSlice(200))
+	require.Nil(t, err)
+	olderSize, err := readers[0].ApproximateSizeOfRange(intToByteSlice(0), intToByteSlice(200))
+	require.Nil(t, err)
+	assert.Equal(t, olderSize, size)
+
+	samples, err := reader.SampleKeys(8)
+	require.Nil(t, err)
+	assert.Equal(t, 8, len(samples))
+	assert.True(t, isSortedAndUnique(samples))
+
+	// the keys from 200 on carry half of all data, so the median lands there within the granularity of the candidates
+	quantiles, err := reader.KeyQuantiles(2)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(quantiles))
+	assert.InDelta(t, 200, binary.BigEndian.Uint32(quantiles[0]), 25)
+
+	quantiles, err = reader.KeyQuantiles(4)
+	require.Nil(t, err)
+	assert.Equal(t, 3, len(quantiles))
+	assert.True(t, isSortedAndUnique(quantiles))
+}
+
+func isSortedAndUnique(keys [][]byte) bool {
+	for i := 1; i < len(keys); i++ {
+		if bytes.Compare(keys[i-1], keys[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,778 @@
+package sstables
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+
+	"github.com/golang/snappy"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+	"golang.org/x/exp/slices"
+)
+
+// LevelDBTableMagicNumber is stored at the end of every LevelDB table file, and of RocksDB files in the legacy format.
+const LevelDBTableMagicNumber = uint64(0xdb4775248b80fb57)
+
+// RocksDBBlockBasedTableMagicNumber is stored at the end of RocksDB files in the block based format, which is not
+// supported by the LevelDBTableReader.
+const RocksDBBlockBasedTableMagicNumber = uint64(0x88e241b785f4cff7)
+
+// levelDBFooterSizeBytes is the size of two padded block handles and the magic number.
+const levelDBFooterSizeBytes = 48
+
+// levelDBBlockTrailerSizeBytes is the size of the compression type and the masked crc32c checksum after each block.
+const levelDBBlockTrailerSizeBytes = 5
+
+const (
+	levelDBNoCompression     = 0
+	levelDBSnappyCompression = 1
+)
+
+const (
+	levelDBTypeDeletion = 0
+	levelDBTypeValue    = 1
+)
+
+// levelDBCrcMaskDelta is added to the rotated crc32c checksums, since LevelDB also stores checksums of data with checksums.
+const levelDBCrcMaskDelta = uint32(0xa282ead8)
+
+var levelDBCrcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type levelDBEntry struct {
+	key   []byte
+	value []byte
+}
+
+type levelDBIndexEntry struct {
+	// the user key of the separator, it's greater or equal to all keys of the block and less than the keys of the next
+	// block, unless the versions of that key continue in the next block
+	lastKey []byte
+	handle  levelDBBlockHandle
+}
+
+// LevelDBTableReader reads a single LevelDB table file, usually named "*.ldb" or "*.sst" in older versions.
+// A table can contain multiple versions of a key, only the latest one is returned. Deleted keys are returned with an
+// empty value, just like tombstones of the sstables in simpledb.
+// Only the index block is kept in memory, the data blocks are read and decoded on every lookup and by the iterators.
+// When the table is opened, all data blocks are read once to compute the metadata and to verify their checksums.
+type LevelDBTableReader struct {
+	opts     *LevelDBReaderOptions
+	file     *os.File
+	fileSize uint64
+	index    []levelDBIndexEntry
+	metaData *proto.MetaData
+	// the offset after the last data block
+	dataSize uint64
+}
+
+// searchIndex returns the index of the first block that can contain the key, which is len(reader.index) if the key is
+// greater than all keys in the table.
+func (reader *LevelDBTableReader) searchIndex(key []byte) int {
+	idx, _ := slices.BinarySearchFunc(reader.index, key, func(entry levelDBIndexEntry, k []byte) int {
+		return reader.opts.keyComparator.Compare(entry.lastKey, k)
+	})
+	return idx
+}
+
+func (reader *LevelDBTableReader) searchBlock(entries []levelDBEntry, key []byte) (int, bool) {
+	return slices.BinarySearchFunc(entries, key, func(entry levelDBEntry, k []byte) int {
+		return reader.opts.keyComparator.Compare(entry.key, k)
+	})
+}
+
+func (reader *LevelDBTableReader) Contains(key []byte) (bool, error) {
+	_, err := reader.Get(key)
+	if err != nil {
+		if errors.Is(err, NotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (reader *LevelDBTableReader) Get(key []byte) ([]byte, error) {
+	blockIdx := reader.searchIndex(key)
+	if blockIdx >= len(reader.index) {
+		return nil, NotFound
+	}
+
+	entries, err := reader.readDataBlock(blockIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	return reader.getFromBlock(entries, key)
+}
+
+func (reader *LevelDBTableReader) getFromBlock(entries []levelDBEntry, key []byte) ([]byte, error) {
+	idx, found := reader.searchBlock(entries, key)
+	if !found {
+		return nil, NotFound
+	}
+	return entries[idx].value, nil
+}
+
+func (reader *LevelDBTableReader) GetAsOf(userKey []byte, sequence uint64) ([]byte, error) {
+	version, err := getVersionAsOf(reader, userKey, sequence)
+	if err != nil {
+		return nil, err
+	}
+
+	return versionValue(version)
+}
+
+// MultiGet decodes every data block only once for consecutive keys that are stored in the same block.
+func (reader *LevelDBTableReader) MultiGet(keys [][]byte) ([][]byte, []error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+
+	lastBlockIdx := -1
+	var entries []levelDBEntry
+	var blockErr error
+	for i, key := range keys {
+		blockIdx := reader.searchIndex(key)
+		if blockIdx >= len(reader.index) {
+			errs[i] = NotFound
+			continue
+		}
+
+		if blockIdx != lastBlockIdx {
+			entries, blockErr = reader.readDataBlock(blockIdx)
+			lastBlockIdx = blockIdx
+		}
+
+		if blockErr != nil {
+			errs[i] = blockErr
+			continue
+		}
+		values[i], errs[i] = reader.getFromBlock(entries, key)
+	}
+	return values, errs
+}
+
+// ApproximateOffsetOf returns the offset of the data block that contains the key, just like LevelDB does.
+func (reader *LevelDBTableReader) ApproximateOffsetOf(key []byte) (uint64, error) {
+	blockIdx := reader.searchIndex(key)
+	if blockIdx >= len(reader.index) {
+		return reader.dataSize, nil
+	}
+	return reader.index[blockIdx].handle.offset, nil
+}
+
+func (reader *LevelDBTableReader) ApproximateSizeOfRange(keyLower []byte, keyHigher []byte) (uint64, error) {
+	lower, err := reader.ApproximateOffsetOf(keyLower)
+	if err != nil {
+		return 0, err
+	}
+
+	higher, err := reader.ApproximateOffsetOf(keyHigher)
+	if err != nil {
+		return 0, err
+	}
+
+	if higher < lower {
+		return 0, nil
+	}
+	return higher - lower, nil
+}
+
+// SampleKeys picks every NumRecords/n-th key, which requires to read all data blocks.
+func (reader *LevelDBTableReader) SampleKeys(n int) ([][]byte, error) {
+	if n <= 0 || reader.metaData.NumRecords == 0 {
+		return nil, nil
+	}
+
+	it, err := reader.Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	step := float64(reader.metaData.NumRecords) / float64(n)
+	var samples [][]byte
+	for i := 0; len(samples) < n; i++ {
+		k, _, err := it.Next()
+		if err != nil {
+			if errors.Is(err, Done) {
+				break
+			}
+			return nil, err
+		}
+
+		if float64(i) >= float64(len(samples))*step {
+			samples = append(samples, append([]byte{}, k...))
+		}
+	}
+	return samples, nil
+}
+
+// KeyQuantiles picks the keys at which the data block offsets cross the n-th parts of the data blocks. Only the
+// blocks the quantiles are picked from are read.
+func (reader *LevelDBTableReader) KeyQuantiles(n int) ([][]byte, error) {
+	if n <= 1 || len(reader.index) == 0 {
+		return nil, nil
+	}
+
+	var quantiles [][]byte
+	firstOffset := reader.index[0].handle.offset
+	target := func() uint64 {
+		return firstOffset + (reader.dataSize-firstOffset)*uint64(len(quantiles)+1)/uint64(n)
+	}
+	for blockIdx := 1; blockIdx < len(reader.index) && len(quantiles) < n-1; blockIdx++ {
+		offset := reader.index[blockIdx].handle.offset
+		if offset < target() {
+			continue
+		}
+
+		entries, err := reader.readDataBlock(blockIdx)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < len(entries) && len(quantiles) < n-1 && offset >= target(); i++ {
+			quantiles = append(quantiles, append([]byte{}, entries[i].key...))
+		}
+	}
+	return quantiles, nil
+}
+
+func (reader *LevelDBTableReader) Scan() (SSTableIteratorI, error) {
+	it := &LevelDBTableIterator{cursor: levelDBCursor{reader: reader}}
+	err := it.cursor.seekToFirst()
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+func (reader *LevelDBTableReader) ScanStartingAt(key []byte) (SSTableIteratorI, error) {
+	it := &LevelDBTableIterator{cursor: levelDBCursor{reader: reader}}
+	err := it.cursor.seek(key)
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+func (reader *LevelDBTableReader) ScanRange(keyLower []byte, keyHigher []byte) (SSTableIteratorI, error) {
+	if reader.opts.keyComparator.Compare(keyLower, keyHigher) > 0 {
+		return nil, fmt.Errorf("error in leveldb table '%s' in ScanRange: keyHigher is lower than keyLower", reader.opts.path)
+	}
+
+	// the range includes keyHigher
+	it := &LevelDBTableIterator{cursor: levelDBCursor{reader: reader}, keyHigher: keyHigher}
+	err := it.cursor.seek(keyLower)
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+func (reader *LevelDBTableReader) ScanPrefix(prefix []byte) (SSTableIteratorI, error) {
+	it, err := reader.ScanStartingAt(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return newPrefixIterator(prefix, it), nil
+}
+
+func (reader *LevelDBTableReader) SeekableIterator() (SSTableSeekableIteratorI, error) {
+	it := &LevelDBTableSeekableIterator{cursor: levelDBCursor{reader: reader}}
+	err := it.cursor.seekToFirst()
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+func (reader *LevelDBTableReader) RangeTombstones() RangeTombstones {
+	return nil
+}
+
+func (reader *LevelDBTableReader) Close() error {
+	reader.index = nil
+	return reader.file.Close()
+}
+
+func (reader *LevelDBTableReader) MetaData() *proto.MetaData {
+	return reader.metaData
+}
+
+// Properties returns nil, LevelDB tables don't have any properties.
+func (reader *LevelDBTableReader) Properties() map[string][]byte {
+	return nil
+}
+
+// BasePath returns the path of the table file.
+func (reader *LevelDBTableReader) BasePath() string {
+	return reader.opts.path
+}
+
+// load decodes the footer and the index block, afterwards it reads all data blocks once to compute the metadata.
+func (reader *LevelDBTableReader) load() error {
+	if reader.fileSize < levelDBFooterSizeBytes {
+		return fmt.Errorf("leveldb table '%s' is too small with %d bytes", reader.opts.path, reader.fileSize)
+	}
+
+	footer := make([]byte, levelDBFooterSizeBytes)
+	_, err := reader.file.ReadAt(footer, int64(reader.fileSize-levelDBFooterSizeBytes))
+	if err != nil {
+		return fmt.Errorf("error while reading footer of leveldb table '%s': %w", reader.opts.path, err)
+	}
+
+	magic := binary.LittleEndian.Uint64(footer[levelDBFooterSizeBytes-8:])
+	if magic == RocksDBBlockBasedTableMagicNumber {
+		return fmt.Errorf("'%s' is a rocksdb table in the block based format, which is not supported, "+
+			"only leveldb tables and rocksdb tables in the legacy format can be read", reader.opts.path)
+	}
+	if magic != LevelDBTableMagicNumber {
+		return fmt.Errorf("'%s' is not a leveldb table, unknown magic number %x", reader.opts.path, magic)
+	}
+
+	// the metaindex handle comes first, it only refers to the filter block that isn't needed here
+	_, n, err := decodeLevelDBBlockHandle(footer)
+	if err != nil {
+		return fmt.Errorf("error while decoding metaindex handle of leveldb table '%s': %w", reader.opts.path, err)
+	}
+	indexHandle, _, err := decodeLevelDBBlockHandle(footer[n:])
+	if err != nil {
+		return fmt.Errorf("error while decoding index handle of leveldb table '%s': %w", reader.opts.path, err)
+	}
+
+	indexBlock, err := reader.readBlock(indexHandle)
+	if err != nil {
+		return fmt.Errorf("error while reading index block of leveldb table '%s': %w", reader.opts.path, err)
+	}
+
+	err = iterateLevelDBBlock(indexBlock, func(internalKey []byte, value []byte) error {
+		if len(internalKey) < 8 {
+			return fmt.Errorf("internal key is too short with %d bytes", len(internalKey))
+		}
+
+		dataHandle, _, err := decodeLevelDBBlockHandle(value)
+		if err != nil {
+			return err
+		}
+
+		reader.index = append(reader.index, levelDBIndexEntry{lastKey: internalKey[:len(internalKey)-8], handle: dataHandle})
+		reader.dataSize = dataHandle.offset + dataHandle.size + levelDBBlockTrailerSizeBytes
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error while decoding index block of leveldb table '%s': %w", reader.opts.path, err)
+	}
+
+	md := &proto.MetaData{
+		ComparatorName: comparatorName(reader.opts.keyComparator),
+		IndexBytes:     indexHandle.size,
+		DataBytes:      reader.dataSize,
+		TotalBytes:     reader.fileSize,
+	}
+
+	for blockIdx := range reader.index {
+		entries, err := reader.readDataBlock(blockIdx)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			if md.NumRecords == 0 {
+				md.MinKey = e.key
+			}
+			md.MaxKey = e.key
+			md.NumRecords++
+			md.KeyBytes += uint64(len(e.key))
+			md.ValueBytes += uint64(len(e.value))
+			if len(e.value) == 0 {
+				md.NullValues++
+			}
+		}
+	}
+
+	reader.metaData = md
+	return nil
+}
+
+// readDataBlock decodes the latest version of every key in the block. Versions of the last key of the previous block
+// are skipped, since the latest version of a key is always stored in the first block that contains the key.
+func (reader *LevelDBTableReader) readDataBlock(blockIdx int) ([]levelDBEntry, error) {
+	handle := reader.index[blockIdx].handle
+	block, err := reader.readBlock(handle)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading data block of leveldb table '%s': %w", reader.opts.path, err)
+	}
+
+	var prevUserKey []byte
+	if blockIdx > 0 {
+		prevUserKey = reader.index[blockIdx-1].lastKey
+	}
+
+	var entries []levelDBEntry
+	err = iterateLevelDBBlock(block, func(internalKey []byte, value []byte) error {
+		if len(internalKey) < 8 {
+			return fmt.Errorf("internal key is too short with %d bytes", len(internalKey))
+		}
+		userKey := internalKey[:len(internalKey)-8]
+		valueType := internalKey[len(internalKey)-8]
+
+		// the versions of a key are sorted from the latest to the oldest
+		if prevUserKey != nil && reader.opts.keyComparator.Compare(prevUserKey, userKey) == 0 {
+			return nil
+		}
+		prevUserKey = userKey
+
+		switch valueType {
+		case levelDBTypeValue:
+		case levelDBTypeDeletion:
+			value = []byte{}
+		default:
+			return fmt.Errorf("unknown value type %d of key %v", valueType, userKey)
+		}
+
+		entries = append(entries, levelDBEntry{key: userKey, value: value})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while decoding data block at offset %d of leveldb table '%s': %w", handle.offset, reader.opts.path, err)
+	}
+
+	return entries, nil
+}
+
+// readBlock reads the block from the file, verifies its checksum and decompresses it.
+func (reader *LevelDBTableReader) readBlock(handle levelDBBlockHandle) ([]byte, error) {
+	end := handle.offset + handle.size + levelDBBlockTrailerSizeBytes
+	if end < handle.offset || end > reader.fileSize {
+		return nil, fmt.Errorf("block at offset %d with %d bytes is out of bounds", handle.offset, handle.size)
+	}
+
+	data := make([]byte, end-handle.offset)
+	_, err := reader.file.ReadAt(data, int64(handle.offset))
+	if err != nil {
+		return nil, fmt.Errorf("error while reading block at offset %d: %w", handle.offset, err)
+	}
+
+	// the checksum covers the block and its compression type
+	block := data[:handle.size]
+	compressionType := data[handle.size]
+	if !reader.opts.skipChecksums {
+		expected := binary.LittleEndian.Uint32(data[handle.size+1:])
+		crc := crc32.Update(crc32.Checksum(block, levelDBCrcTable), levelDBCrcTable, []byte{compressionType})
+		if maskLevelDBCrc(crc) != expected {
+			return nil, fmt.Errorf("block at offset %d: %w", handle.offset, ChecksumError{checksum: uint64(maskLevelDBCrc(crc)), expectedChecksum: uint64(expected)})
+		}
+	}
+
+	switch compressionType {
+	case levelDBNoCompression:
+		return block, nil
+	case levelDBSnappyCompression:
+		decompressed, err := snappy.Decode(nil, block)
+		if err != nil {
+			return nil, fmt.Errorf("error while decompressing block at offset %d: %w", handle.offset, err)
+		}
+		return decompressed, nil
+	default:
+		return nil, fmt.Errorf("block at offset %d has unsupported compression type %d", handle.offset, compressionType)
+	}
+}
+
+func maskLevelDBCrc(crc uint32) uint32 {
+	return ((crc >> 15) | (crc << 17)) + levelDBCrcMaskDelta
+}
+
+type levelDBBlockHandle struct {
+	offset uint64
+	size   uint64
+}
+
+// decodeLevelDBBlockHandle returns the handle and the number of bytes it was encoded with.
+func decodeLevelDBBlockHandle(b []byte) (levelDBBlockHandle, int, error) {
+	offset, n := binary.Uvarint(b)
+	if n <= 0 {
+		return levelDBBlockHandle{}, 0, errors.New("invalid block handle offset")
+	}
+	size, m := binary.Uvarint(b[n:])
+	if m <= 0 {
+		return levelDBBlockHandle{}, 0, errors.New("invalid block handle size")
+	}
+	return levelDBBlockHandle{offset: offset, size: size}, n + m, nil
+}
+
+// iterateLevelDBBlock calls fn for every entry of the block. The keys are prefix compressed against the previous key,
+// thus every key is a new slice, whereas the values point into the block.
+func iterateLevelDBBlock(block []byte, fn func(key []byte, value []byte) error) error {
+	if len(block) < 4 {
+		return fmt.Errorf("block is too small with %d bytes", len(block))
+	}
+	numRestarts := uint64(binary.LittleEndian.Uint32(block[len(block)-4:]))
+	if numRestarts*4+4 > uint64(len(block)) {
+		return fmt.Errorf("block has an invalid number of restart points %d", numRestarts)
+	}
+	entries := block[:uint64(len(block))-numRestarts*4-4]
+
+	var key []byte
+	for pos := 0; pos < len(entries); {
+		var header [3]uint64
+		for i := range header {
+			v, n := binary.Uvarint(entries[pos:])
+			if n <= 0 {
+				return fmt.Errorf("invalid entry header at block offset %d", pos)
+			}
+			header[i] = v
+			pos += n
+		}
+		shared, nonShared, valueLength := header[0], header[1], header[2]
+		if shared > uint64(len(key)) || nonShared+valueLength > uint64(len(entries)-pos) {
+			return fmt.Errorf("entry at block offset %d is out of bounds", pos)
+		}
+
+		key = append(append(make([]byte, 0, shared+nonShared), key[:shared]...), entries[pos:pos+int(nonShared)]...)
+		pos += int(nonShared)
+		value := entries[pos : pos+int(valueLength)]
+		pos += int(valueLength)
+
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// levelDBCursor points to an entry of the currently decoded data block, blockIdx is out of bounds of the index once
+// the cursor is exhausted in either direction.
+type levelDBCursor struct {
+	reader   *LevelDBTableReader
+	blockIdx int
+	entries  []levelDBEntry
+	entryIdx int
+}
+
+func (c *levelDBCursor) valid() bool {
+	return c.blockIdx >= 0 && c.blockIdx < len(c.reader.index) && c.entryIdx >= 0 && c.entryIdx < len(c.entries)
+}
+
+func (c *levelDBCursor) current() levelDBEntry {
+	return c.entries[c.entryIdx]
+}
+
+func (c *levelDBCursor) loadBlock(blockIdx int) error {
+	c.blockIdx = blockIdx
+	c.entries = nil
+	if blockIdx < 0 || blockIdx >= len(c.reader.index) {
+		return nil
+	}
+
+	entries, err := c.reader.readDataBlock(blockIdx)
+	if err != nil {
+		return err
+	}
+	c.entries = entries
+	return nil
+}
+
+// skipForward moves to the first entry of the next blocks, in case the cursor is after the last entry of its block.
+// Blocks can be empty when they only contain older versions of the last key of the previous block.
+func (c *levelDBCursor) skipForward() error {
+	for c.blockIdx < len(c.reader.index) && c.entryIdx >= len(c.entries) {
+		err := c.loadBlock(c.blockIdx + 1)
+		if err != nil {
+			return err
+		}
+		c.entryIdx = 0
+	}
+	return nil
+}
+
+// skipBackward moves to the last entry of the previous blocks, in case the cursor is before the first entry of its block.
+func (c *levelDBCursor) skipBackward() error {
+	for c.blockIdx >= 0 && c.entryIdx < 0 {
+		err := c.loadBlock(c.blockIdx - 1)
+		if err != nil {
+			return err
+		}
+		c.entryIdx = len(c.entries) - 1
+	}
+	return nil
+}
+
+func (c *levelDBCursor) next() error {
+	c.entryIdx++
+	return c.skipForward()
+}
+
+func (c *levelDBCursor) prev() error {
+	c.entryIdx--
+	return c.skipBackward()
+}
+
+// seek moves to the first entry that is greater or equal to the key.
+func (c *levelDBCursor) seek(key []byte) error {
+	err := c.loadBlock(c.reader.searchIndex(key))
+	if err != nil {
+		return err
+	}
+	c.entryIdx, _ = c.reader.searchBlock(c.entries, key)
+	return c.skipForward()
+}
+
+func (c *levelDBCursor) seekToFirst() error {
+	err := c.loadBlock(0)
+	if err != nil {
+		return err
+	}
+	c.entryIdx = 0
+	return c.skipForward()
+}
+
+func (c *levelDBCursor) seekToLast() error {
+	err := c.loadBlock(len(c.reader.index) - 1)
+	if err != nil {
+		return err
+	}
+	c.entryIdx = len(c.entries) - 1
+	return c.skipBackward()
+}
+
+type LevelDBTableIterator struct {
+	cursor levelDBCursor
+	// the inclusive upper bound of ScanRange, nil if there's none
+	keyHigher []byte
+}
+
+func (it *LevelDBTableIterator) Next() ([]byte, []byte, error) {
+	if !it.cursor.valid() {
+		return nil, nil, Done
+	}
+
+	entry := it.cursor.current()
+	if it.keyHigher != nil && it.cursor.reader.opts.keyComparator.Compare(entry.key, it.keyHigher) > 0 {
+		return nil, nil, Done
+	}
+
+	err := it.cursor.next()
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry.key, entry.value, nil
+}
+
+// LevelDBTableSeekableIterator is positioned at the entry of its cursor, which is out of bounds once exhausted in
+// either direction.
+type LevelDBTableSeekableIterator struct {
+	cursor levelDBCursor
+}
+
+func (it *LevelDBTableSeekableIterator) Next() ([]byte, []byte, error) {
+	if !it.Valid() {
+		return nil, nil, Done
+	}
+	entry := it.cursor.current()
+	err := it.cursor.next()
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry.key, entry.value, nil
+}
+
+func (it *LevelDBTableSeekableIterator) Prev() ([]byte, []byte, error) {
+	if !it.Valid() {
+		return nil, nil, Done
+	}
+	entry := it.cursor.current()
+	err := it.cursor.prev()
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry.key, entry.value, nil
+}
+
+func (it *LevelDBTableSeekableIterator) Seek(key []byte) error {
+	return it.cursor.seek(key)
+}
+
+func (it *LevelDBTableSeekableIterator) SeekForPrev(key []byte) error {
+	err := it.cursor.seek(key)
+	if err != nil {
+		return err
+	}
+
+	if !it.cursor.valid() {
+		return it.cursor.seekToLast()
+	}
+
+	if it.cursor.reader.opts.keyComparator.Compare(it.cursor.current().key, key) != 0 {
+		return it.cursor.prev()
+	}
+	return nil
+}
+
+func (it *LevelDBTableSeekableIterator) SeekToFirst() error {
+	return it.cursor.seekToFirst()
+}
+
+func (it *LevelDBTableSeekableIterator) SeekToLast() error {
+	return it.cursor.seekToLast()
+}
+
+func (it *LevelDBTableSeekableIterator) Valid() bool {
+	return it.cursor.valid()
+}
+
+// NewLevelDBTableReader opens the LevelDB table file at the given path. The user keys need to be sorted with the
+// configured comparator, which is the bytewise comparator of LevelDB by default.
+func NewLevelDBTableReader(path string, readerOptions ...LevelDBReaderOption) (SSTableReaderI, error) {
+	opts := &LevelDBReaderOptions{
+		path:          path,
+		keyComparator: skiplist.BytesComparator{},
+	}
+
+	for _, readerOption := range readerOptions {
+		readerOption(opts)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening leveldb table '%s': %w", path, err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("error while reading leveldb table '%s': %w", path, err), file.Close())
+	}
+
+	reader := &LevelDBTableReader{opts: opts, file: file, fileSize: uint64(stat.Size())}
+	err = reader.load()
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+	return reader, nil
+}
+
+// options
+
+type LevelDBReaderOptions struct {
+	path          string
+	keyComparator skiplist.Comparator[[]byte]
+	skipChecksums bool
+}
+
+type LevelDBReaderOption func(*LevelDBReaderOptions)
+
+// LevelDBReadWithKeyComparator configures the comparator the user keys are sorted with, when the LevelDB database
+// used a custom comparator instead of the default bytewise comparator.
+func LevelDBReadWithKeyComparator(cmp skiplist.Comparator[[]byte]) LevelDBReaderOption {
+	return func(args *LevelDBReaderOptions) {
+		args.keyComparator = cmp
+	}
+}
+
+// LevelDBSkipChecksums skips the verification of the crc32c checksums of the blocks.
+func LevelDBSkipChecksums() LevelDBReaderOption {
+	return func(args *LevelDBReaderOptions) {
+		args.skipChecksums = true
+	}
+}
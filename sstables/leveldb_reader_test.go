@@ -0,0 +1,440 @@
+package sstables
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+type testLevelDBEntry struct {
+	key       string
+	sequence  uint64
+	valueType byte
+	value     string
+}
+
+// testLevelDBTableBuilder writes tables in the format of the LevelDB table builder, the entries need to be added in the
+// order of their internal keys.
+type testLevelDBTableBuilder struct {
+	snappy          bool
+	entriesPerBlock int
+	buf             []byte
+	index           [][2][]byte
+	block           [][2][]byte
+}
+
+func (b *testLevelDBTableBuilder) add(e testLevelDBEntry) {
+	tag := e.sequence<<8 | uint64(e.valueType)
+	internalKey := binary.LittleEndian.AppendUint64([]byte(e.key), tag)
+	b.block = append(b.block, [2][]byte{internalKey, []byte(e.value)})
+	if len(b.block) == b.entriesPerBlock {
+		b.flush()
+	}
+}
+
+func (b *testLevelDBTableBuilder) flush() {
+	if len(b.block) == 0 {
+		return
+	}
+	lastKey := b.block[len(b.block)-1][0]
+	handle := b.writeBlock(encodeTestLevelDBBlock(b.block), b.snappy)
+	b.index = append(b.index, [2][]byte{lastKey, handle})
+	b.block = nil
+}
+
+func (b *testLevelDBTableBuilder) writeBlock(block []byte, compress bool) []byte {
+	compressionType := byte(levelDBNoCompression)
+	if compress {
+		block = snappy.Encode(nil, block)
+		compressionType = levelDBSnappyCompression
+	}
+	handle := binary.AppendUvarint(nil, uint64(len(b.buf)))
+	handle = binary.AppendUvarint(handle, uint64(len(block)))
+	crc := crc32.Update(crc32.Checksum(block, levelDBCrcTable), levelDBCrcTable, []byte{compressionType})
+	b.buf = append(b.buf, block...)
+	b.buf = append(b.buf, compressionType)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, maskLevelDBCrc(crc))
+	return handle
+}
+
+func (b *testLevelDBTableBuilder) finish() []byte {
+	b.flush()
+	metaIndexHandle := b.writeBlock(encodeTestLevelDBBlock(nil), false)
+	indexHandle := b.writeBlock(encodeTestLevelDBBlock(b.index), false)
+	footer := append(metaIndexHandle, indexHandle...)
+	footer = append(footer, make([]byte, levelDBFooterSizeBytes-8-len(footer))...)
+	footer = binary.LittleEndian.AppendUint64(footer, LevelDBTableMagicNumber)
+	return append(b.buf, footer...)
+}
+
+// encodeTestLevelDBBlock prefix compresses the keys with a restart point every four entries.
+func encodeTestLevelDBBlock(entries [][2][]byte) []byte {
+	const restartInterval = 4
+	var buf []byte
+	restarts := []uint32{0}
+	var prevKey []byte
+	for i, e := range entries {
+		shared := 0
+		if i%restartInterval == 0 {
+			if i > 0 {
+				restarts = append(restarts, uint32(len(buf)))
+			}
+		} else {
+			for shared < len(prevKey) && shared < len(e[0]) && prevKey[shared] == e[0][shared] {
+				shared++
+			}
+		}
+		buf = binary.AppendUvarint(buf, uint64(shared))
+		buf = binary.AppendUvarint(buf, uint64(len(e[0])-shared))
+		buf = binary.AppendUvarint(buf, uint64(len(e[1])))
+		buf = append(buf, e[0][shared:]...)
+		buf = append(buf, e[1]...)
+		prevKey = e[0]
+	}
+	for _, r := range restarts {
+		buf = binary.LittleEndian.AppendUint32(buf, r)
+	}
+	return binary.LittleEndian.AppendUint32(buf, uint32(len(restarts)))
+}
+
+// testLevelDBEntries returns keys "key000" to "key099", every third key has an older version and every fifth key
+// is deleted in its latest version.
+func testLevelDBEntries() []testLevelDBEntry {
+	var entries []testLevelDBEntry
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%03d", i)
+		if i%5 == 0 {
+			entries = append(entries, testLevelDBEntry{key: key, sequence: uint64(1000 + i), valueType: levelDBTypeDeletion})
+		} else {
+			entries = append(entries, testLevelDBEntry{key: key, sequence: uint64(1000 + i), valueType: levelDBTypeValue, value: fmt.Sprintf("val%03d", i)})
+		}
+		if i%3 == 0 {
+			entries = append(entries, testLevelDBEntry{key: key, sequence: uint64(i), valueType: levelDBTypeValue, value: "old"})
+		}
+	}
+	return entries
+}
+
+func writeTestLevelDBTable(t *testing.T, compress bool) string {
+	return writeTestLevelDBTableWithBlockSize(t, compress, 10)
+}
+
+func writeTestLevelDBTableWithBlockSize(t *testing.T, compress bool, entriesPerBlock int) string {
+	b := &testLevelDBTableBuilder{snappy: compress, entriesPerBlock: entriesPerBlock}
+	for _, e := range testLevelDBEntries() {
+		b.add(e)
+	}
+	path := filepath.Join(t.TempDir(), "000005.ldb")
+	require.NoError(t, os.WriteFile(path, b.finish(), 0644))
+	return path
+}
+
+func expectedLevelDBValue(i int) []byte {
+	if i%5 == 0 {
+		return []byte{}
+	}
+	return []byte(fmt.Sprintf("val%03d", i))
+}
+
+func TestLevelDBTableReaderGet(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("snappy=%v", compress), func(t *testing.T) {
+			reader, err := NewLevelDBTableReader(writeTestLevelDBTable(t, compress))
+			require.NoError(t, err)
+			defer closeReader(t, reader)
+
+			for i := 0; i < 100; i++ {
+				key := []byte(fmt.Sprintf("key%03d", i))
+				v, err := reader.Get(key)
+				require.NoError(t, err)
+				require.Equal(t, expectedLevelDBValue(i), v)
+				contains, err := reader.Contains(key)
+				require.NoError(t, err)
+				require.True(t, contains)
+			}
+
+			_, err = reader.Get([]byte("key100"))
+			require.ErrorIs(t, err, NotFound)
+			contains, err := reader.Contains([]byte("a"))
+			require.NoError(t, err)
+			require.False(t, contains)
+
+			values, errs := reader.MultiGet([][]byte{[]byte("key001"), []byte("nope")})
+			require.Equal(t, []byte("val001"), values[0])
+			require.NoError(t, errs[0])
+			require.ErrorIs(t, errs[1], NotFound)
+		})
+	}
+}
+
+func TestLevelDBTableReaderMetaData(t *testing.T) {
+	path := writeTestLevelDBTable(t, false)
+	reader, err := NewLevelDBTableReader(path)
+	require.NoError(t, err)
+	defer closeReader(t, reader)
+
+	md := reader.MetaData()
+	require.Equal(t, uint64(100), md.NumRecords)
+	require.Equal(t, uint64(20), md.NullValues)
+	require.Equal(t, []byte("key000"), md.MinKey)
+	require.Equal(t, []byte("key099"), md.MaxKey)
+	require.Equal(t, uint64(600), md.KeyBytes)
+	require.Equal(t, uint64(80*6), md.ValueBytes)
+	require.Equal(t, skiplist.ComparatorName[[]byte](skiplist.BytesComparator{}), md.ComparatorName)
+	require.False(t, md.InternalKeys)
+	require.Equal(t, path, reader.BasePath())
+	require.Nil(t, reader.Properties())
+	require.Nil(t, reader.RangeTombstones())
+
+	_, err = reader.GetAsOf([]byte("key001"), 1)
+	require.Error(t, err)
+
+	offset, err := reader.ApproximateOffsetOf([]byte("key000"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), offset)
+	offset, err = reader.ApproximateOffsetOf([]byte("zzz"))
+	require.NoError(t, err)
+	require.Equal(t, md.DataBytes, offset)
+	size, err := reader.ApproximateSizeOfRange([]byte("key000"), []byte("key050"))
+	require.NoError(t, err)
+	require.Greater(t, size, uint64(0))
+	require.Less(t, size, md.DataBytes)
+
+	samples, err := reader.SampleKeys(4)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("key000"), []byte("key025"), []byte("key050"), []byte("key075")}, samples)
+	quantiles, err := reader.KeyQuantiles(2)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(quantiles))
+}
+
+func TestLevelDBTableReaderScans(t *testing.T) {
+	reader, err := NewLevelDBTableReader(writeTestLevelDBTable(t, true))
+	require.NoError(t, err)
+	defer closeReader(t, reader)
+
+	it, err := reader.Scan()
+	require.NoError(t, err)
+	require.Equal(t, 100, assertLevelDBIterator(t, it, 0))
+
+	it, err = reader.ScanStartingAt([]byte("key0905"))
+	require.NoError(t, err)
+	require.Equal(t, 9, assertLevelDBIterator(t, it, 91))
+
+	it, err = reader.ScanRange([]byte("key010"), []byte("key019"))
+	require.NoError(t, err)
+	require.Equal(t, 10, assertLevelDBIterator(t, it, 10))
+
+	_, err = reader.ScanRange([]byte("key019"), []byte("key010"))
+	require.Error(t, err)
+
+	it, err = reader.ScanPrefix([]byte("key04"))
+	require.NoError(t, err)
+	require.Equal(t, 10, assertLevelDBIterator(t, it, 40))
+}
+
+// assertLevelDBIterator checks that the iterator returns the consecutive keys from start on and returns their number.
+func assertLevelDBIterator(t *testing.T, it SSTableIteratorI, start int) int {
+	n := 0
+	for {
+		k, v, err := it.Next()
+		if err == Done {
+			return n
+		}
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("key%03d", start+n)), k)
+		require.Equal(t, expectedLevelDBValue(start+n), v)
+		n++
+	}
+}
+
+func TestLevelDBTableReaderSeekableIterator(t *testing.T) {
+	reader, err := NewLevelDBTableReader(writeTestLevelDBTable(t, false))
+	require.NoError(t, err)
+	defer closeReader(t, reader)
+
+	it, err := reader.SeekableIterator()
+	require.NoError(t, err)
+	require.NoError(t, it.SeekForPrev([]byte("key0505")))
+	k, _, err := it.Prev()
+	require.NoError(t, err)
+	require.Equal(t, []byte("key050"), k)
+	k, v, err := it.Prev()
+	require.NoError(t, err)
+	require.Equal(t, []byte("key049"), k)
+	require.Equal(t, []byte("val049"), v)
+
+	require.NoError(t, it.Seek([]byte("key098")))
+	_, _, err = it.Next()
+	require.NoError(t, err)
+	_, _, err = it.Next()
+	require.NoError(t, err)
+	require.False(t, it.Valid())
+	_, _, err = it.Next()
+	require.ErrorIs(t, err, Done)
+
+	require.NoError(t, it.SeekToLast())
+	k, _, err = it.Prev()
+	require.NoError(t, err)
+	require.Equal(t, []byte("key099"), k)
+	require.NoError(t, it.SeekToFirst())
+	k, _, err = it.Next()
+	require.NoError(t, err)
+	require.Equal(t, []byte("key000"), k)
+}
+
+func TestLevelDBTableReaderVersionsAcrossBlocks(t *testing.T) {
+	// the older versions of a key are stored in the following blocks, which are even empty when there's one entry per block
+	for _, entriesPerBlock := range []int{1, 2, 3} {
+		t.Run(fmt.Sprintf("entriesPerBlock=%d", entriesPerBlock), func(t *testing.T) {
+			reader, err := NewLevelDBTableReader(writeTestLevelDBTableWithBlockSize(t, false, entriesPerBlock))
+			require.NoError(t, err)
+			defer closeReader(t, reader)
+			require.Equal(t, uint64(100), reader.MetaData().NumRecords)
+
+			var keys [][]byte
+			for i := 0; i < 100; i++ {
+				keys = append(keys, []byte(fmt.Sprintf("key%03d", i)))
+				v, err := reader.Get(keys[i])
+				require.NoError(t, err)
+				require.Equal(t, expectedLevelDBValue(i), v)
+			}
+
+			values, errs := reader.MultiGet(keys)
+			for i := range keys {
+				require.NoError(t, errs[i])
+				require.Equal(t, expectedLevelDBValue(i), values[i])
+			}
+
+			it, err := reader.Scan()
+			require.NoError(t, err)
+			require.Equal(t, 100, assertLevelDBIterator(t, it, 0))
+
+			it, err = reader.ScanRange([]byte("key003"), []byte("key006"))
+			require.NoError(t, err)
+			require.Equal(t, 4, assertLevelDBIterator(t, it, 3))
+
+			seekable, err := reader.SeekableIterator()
+			require.NoError(t, err)
+			require.NoError(t, seekable.SeekToLast())
+			for i := 99; i >= 0; i-- {
+				k, v, err := seekable.Prev()
+				require.NoError(t, err)
+				require.Equal(t, keys[i], k)
+				require.Equal(t, expectedLevelDBValue(i), v)
+			}
+			require.False(t, seekable.Valid())
+		})
+	}
+}
+
+func TestLevelDBTableReaderReadsBlocksLazily(t *testing.T) {
+	path := writeTestLevelDBTable(t, false)
+	reader, err := NewLevelDBTableReader(path)
+	require.NoError(t, err)
+	defer closeReader(t, reader)
+
+	// corrupting the first data block after opening only fails the reads of its keys
+	file, err := os.OpenFile(path, os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteAt([]byte{0xff}, 3)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	_, err = reader.Get([]byte("key000"))
+	require.ErrorIs(t, err, ChecksumError{})
+	v, err := reader.Get([]byte("key099"))
+	require.NoError(t, err)
+	require.Equal(t, expectedLevelDBValue(99), v)
+
+	it, err := reader.ScanStartingAt([]byte("key050"))
+	require.NoError(t, err)
+	require.Equal(t, 50, assertLevelDBIterator(t, it, 50))
+	_, err = reader.Scan()
+	require.ErrorIs(t, err, ChecksumError{})
+}
+
+func TestLevelDBTableReaderChecksumMismatch(t *testing.T) {
+	path := writeTestLevelDBTable(t, false)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	// the first data block starts with the first key
+	data[3] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	_, err = NewLevelDBTableReader(path)
+	require.ErrorIs(t, err, ChecksumError{})
+
+	reader, err := NewLevelDBTableReader(path, LevelDBSkipChecksums())
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+}
+
+func TestLevelDBTableReaderUnknownMagicNumber(t *testing.T) {
+	path := writeTestLevelDBTable(t, false)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	binary.LittleEndian.PutUint64(data[len(data)-8:], RocksDBBlockBasedTableMagicNumber)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	_, err = NewLevelDBTableReader(path)
+	require.ErrorContains(t, err, "rocksdb table in the block based format, which is not supported")
+
+	binary.LittleEndian.PutUint64(data[len(data)-8:], 42)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	_, err = NewLevelDBTableReader(path)
+	require.ErrorContains(t, err, "unknown magic number")
+
+	_, err = NewLevelDBTableReader(filepath.Join(t.TempDir(), "missing.ldb"))
+	require.Error(t, err)
+}
+
+func TestLevelDBTableReaderMergeIntoSSTable(t *testing.T) {
+	levelDBReader, err := NewLevelDBTableReader(writeTestLevelDBTable(t, true))
+	require.NoError(t, err)
+	defer closeReader(t, levelDBReader)
+
+	writer, err := newTestSSTableStreamWriter()
+	require.NoError(t, err)
+	defer cleanWriterDir(t, writer)
+	require.NoError(t, writer.Open())
+	for i := 100; i < 150; i++ {
+		require.NoError(t, writer.WriteNext([]byte(fmt.Sprintf("key%03d", i)), expectedLevelDBValue(i)))
+	}
+	require.NoError(t, writer.Close())
+	nativeReader, err := NewSSTableReader(ReadBasePath(writer.opts.basePath), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.NoError(t, err)
+	defer closeReader(t, nativeReader)
+
+	levelDBIterator, err := levelDBReader.Scan()
+	require.NoError(t, err)
+	nativeIterator, err := nativeReader.Scan()
+	require.NoError(t, err)
+
+	outWriter, err := newTestSSTableStreamWriter()
+	require.NoError(t, err)
+	defer cleanWriterDir(t, outWriter)
+	require.NoError(t, outWriter.Open())
+	merger := NewSSTableMerger(skiplist.BytesComparator{})
+	require.NoError(t, merger.Merge([]SSTableMergeIteratorContext{
+		NewReaderMergeIteratorContext(0, levelDBIterator, levelDBReader),
+		NewReaderMergeIteratorContext(1, nativeIterator, nativeReader),
+	}, outWriter))
+	require.NoError(t, outWriter.Close())
+
+	merged, err := NewSSTableReader(ReadBasePath(outWriter.opts.basePath), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.NoError(t, err)
+	defer closeReader(t, merged)
+	require.Equal(t, uint64(150), merged.MetaData().NumRecords)
+	it, err := merged.Scan()
+	require.NoError(t, err)
+	require.Equal(t, 150, assertLevelDBIterator(t, it, 0))
+}
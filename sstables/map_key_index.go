@@ -76,6 +76,24 @@ func (s *MapKeyIndexLoader[T]) Load(indexPath string, metadata *proto.MetaData)
 		return nil, fmt.Errorf("error while creating index reader of sstable in '%s': %w", indexPath, err)
 	}
 
+	return s.load(reader, indexPath, metadata)
+}
+
+// LoadFromSource loads the index from a table that is opened with ReadFromSource.
+func (s *MapKeyIndexLoader[T]) LoadFromSource(source TableSource, metadata *proto.MetaData) (SortedKeyIndex, error) {
+	if s.Mapper == nil {
+		return nil, errors.New("error loader need a Mapper for sstable from source")
+	}
+
+	reader, err := newSourceProtoReader(source, IndexFileName, s.ReadBufferSize)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating index reader of sstable from source: %w", err)
+	}
+
+	return s.load(reader, IndexFileName, metadata)
+}
+
+func (s *MapKeyIndexLoader[T]) load(reader rProto.ReaderI, indexPath string, metadata *proto.MetaData) (_ SortedKeyIndex, err error) {
 	err = reader.Open()
 	if err != nil {
 		return nil, fmt.Errorf("error while opening index reader of sstable in '%s': %w", indexPath, err)
@@ -0,0 +1,151 @@
+package sstables
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MergeOperator combines merge operands with the existing value of a key, which allows read-modify-write updates such
+// as incrementing counters or appending to lists without reading the value first.
+type MergeOperator interface {
+	// FullMerge applies the operands, ordered from the oldest to the newest, to the existing value of the key.
+	// The existing value is nil when the key doesn't exist or was deleted.
+	FullMerge(key []byte, existing []byte, operands [][]byte) ([]byte, error)
+	// PartialMerge combines the operands, ordered from the oldest to the newest, into a single operand. It returns false
+	// when the operands can't be combined without the existing value, in which case they are kept as they are.
+	PartialMerge(key []byte, operands [][]byte) ([]byte, bool)
+}
+
+// MergeValue is a decoded value of a table that was written WithMergeOperands.
+type MergeValue struct {
+	Kind ValueKind
+	// Value is only set for ValueKindPut
+	Value []byte
+	// Operands are only set for ValueKindMerge, ordered from the oldest to the newest
+	Operands [][]byte
+}
+
+// Encode returns the value as it's stored in tables that are written WithMergeOperands. A delete is encoded as an
+// empty value, a put as its kind followed by the value and merge operands as their kind followed by every operand
+// prefixed with its length as an uvarint.
+func (v MergeValue) Encode() []byte {
+	switch v.Kind {
+	case ValueKindPut:
+		encoded := make([]byte, 0, len(v.Value)+1)
+		return append(append(encoded, byte(ValueKindPut)), v.Value...)
+	case ValueKindMerge:
+		encoded := []byte{byte(ValueKindMerge)}
+		for _, operand := range v.Operands {
+			encoded = binary.AppendUvarint(encoded, uint64(len(operand)))
+			encoded = append(encoded, operand...)
+		}
+		return encoded
+	default:
+		return []byte{}
+	}
+}
+
+// DecodeMergeValue is the inverse to MergeValue.Encode, the returned value and operands point into the given slice.
+func DecodeMergeValue(encoded []byte) (MergeValue, error) {
+	if len(encoded) == 0 {
+		return MergeValue{Kind: ValueKindDelete}, nil
+	}
+
+	switch ValueKind(encoded[0]) {
+	case ValueKindPut:
+		return MergeValue{Kind: ValueKindPut, Value: encoded[1:]}, nil
+	case ValueKindMerge:
+		var operands [][]byte
+		for rest := encoded[1:]; len(rest) > 0; {
+			length, n := binary.Uvarint(rest)
+			if n <= 0 || uint64(len(rest)-n) < length {
+				return MergeValue{}, fmt.Errorf("invalid merge operands, truncated operand: %v", encoded)
+			}
+			operands = append(operands, rest[n:n+int(length)])
+			rest = rest[n+int(length):]
+		}
+		return MergeValue{Kind: ValueKindMerge, Operands: operands}, nil
+	default:
+		return MergeValue{}, fmt.Errorf("unknown value kind %d", encoded[0])
+	}
+}
+
+// mergeFolder folds the values of a single key from the newest to the oldest, until a put or a delete was found.
+type mergeFolder struct {
+	key []byte
+	// operands are ordered from the oldest to the newest
+	operands [][]byte
+	// done is set when a put or a delete was found, which is stored in base
+	done bool
+	base MergeValue
+}
+
+// add folds the next older value, it returns true when no older value needs to be added anymore.
+func (f *mergeFolder) add(value MergeValue) bool {
+	if f.done {
+		return true
+	}
+
+	if value.Kind == ValueKindMerge {
+		f.operands = append(append([][]byte{}, value.Operands...), f.operands...)
+		return false
+	}
+
+	f.done = true
+	f.base = value
+	return true
+}
+
+// merge returns the folded value. Without a put or a delete, the operands are partially merged, since older values
+// might still exist that weren't added to the folder.
+func (f *mergeFolder) merge(operator MergeOperator) (MergeValue, error) {
+	if len(f.operands) == 0 {
+		if !f.done {
+			return MergeValue{Kind: ValueKindDelete}, nil
+		}
+		return f.base, nil
+	}
+
+	if !f.done {
+		if operator != nil && len(f.operands) > 1 {
+			operand, ok := operator.PartialMerge(f.key, f.operands)
+			if ok {
+				return MergeValue{Kind: ValueKindMerge, Operands: [][]byte{operand}}, nil
+			}
+		}
+		return MergeValue{Kind: ValueKindMerge, Operands: f.operands}, nil
+	}
+
+	merged, err := f.fullMerge(operator)
+	if err != nil {
+		return MergeValue{}, err
+	}
+	return MergeValue{Kind: ValueKindPut, Value: merged}, nil
+}
+
+// fullMerge applies all operands to the base, which is treated as non-existent when no put was found.
+func (f *mergeFolder) fullMerge(operator MergeOperator) ([]byte, error) {
+	if operator == nil {
+		return nil, MergeOperandFound
+	}
+
+	var existing []byte
+	if f.base.Kind == ValueKindPut {
+		existing = f.base.Value
+	}
+
+	merged, err := operator.FullMerge(f.key, existing, f.operands)
+	if err != nil {
+		return nil, fmt.Errorf("error while merging %d operands of key %v: %w", len(f.operands), f.key, err)
+	}
+	return merged, nil
+}
+
+// plainMergeValue interprets a value of a table without merge operands, an empty value is treated as a delete
+// just like in ScanReduceLatestWinsSkipTombstones.
+func plainMergeValue(value []byte) MergeValue {
+	if len(value) == 0 {
+		return MergeValue{Kind: ValueKindDelete}
+	}
+	return MergeValue{Kind: ValueKindPut, Value: value}
+}
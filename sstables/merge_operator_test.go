@@ -0,0 +1,337 @@
+package sstables
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+// counterMergeOperator adds up big endian integers, just like intToByteSlice encodes them.
+type counterMergeOperator struct {
+	partialMerges int
+}
+
+func (c *counterMergeOperator) FullMerge(_ []byte, existing []byte, operands [][]byte) ([]byte, error) {
+	sum := uint32(0)
+	if existing != nil {
+		sum = binary.BigEndian.Uint32(existing)
+	}
+	for _, operand := range operands {
+		sum += binary.BigEndian.Uint32(operand)
+	}
+	return intToByteSlice(int(sum)), nil
+}
+
+func (c *counterMergeOperator) PartialMerge(key []byte, operands [][]byte) ([]byte, bool) {
+	c.partialMerges++
+	merged, _ := c.FullMerge(key, nil, operands)
+	return merged, true
+}
+
+type mergeTestRecord struct {
+	key   int
+	value MergeValue
+}
+
+func putValue(v int) MergeValue {
+	return MergeValue{Kind: ValueKindPut, Value: intToByteSlice(v)}
+}
+
+func mergeOperands(operands ...int) MergeValue {
+	value := MergeValue{Kind: ValueKindMerge}
+	for _, o := range operands {
+		value.Operands = append(value.Operands, intToByteSlice(o))
+	}
+	return value
+}
+
+// writeMergeTestTable writes the records WithMergeOperands and returns the opened reader.
+func writeMergeTestTable(t *testing.T, records []mergeTestRecord, tombstones RangeTombstones) (SSTableReaderI, func()) {
+	tmpDir, err := os.MkdirTemp("", "sstables_MergeOperands")
+	require.Nil(t, err)
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}),
+		WithMergeOperands())
+	require.Nil(t, err)
+	require.NoError(t, writer.Open())
+	for _, tombstone := range tombstones {
+		require.NoError(t, writer.WriteRangeTombstone(tombstone.Start, tombstone.End))
+	}
+	for _, r := range records {
+		require.NoError(t, writer.WriteNext(intToByteSlice(r.key), r.value.Encode()))
+	}
+	require.NoError(t, writer.Close())
+
+	reader, err := NewSSTableReader(ReadBasePath(tmpDir), ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	return reader, func() {
+		closeReader(t, reader)
+		cleanWriterDir(t, writer)
+	}
+}
+
+func TestMergeValueEncodeDecode(t *testing.T) {
+	for _, v := range []MergeValue{
+		{Kind: ValueKindDelete},
+		{Kind: ValueKindPut, Value: []byte{}},
+		putValue(13),
+		mergeOperands(1),
+		mergeOperands(1, 2, 3),
+		{Kind: ValueKindMerge, Operands: [][]byte{{}, {1}}},
+	} {
+		decoded, err := DecodeMergeValue(v.Encode())
+		require.Nil(t, err)
+		assert.Equal(t, v, decoded)
+	}
+
+	assert.Equal(t, []byte{}, MergeValue{Kind: ValueKindDelete}.Encode())
+
+	_, err := DecodeMergeValue([]byte{42})
+	assert.Error(t, err)
+	_, err = DecodeMergeValue([]byte{byte(ValueKindMerge), 5, 1})
+	assert.Error(t, err)
+}
+
+func TestMergeOperandsWriterRejectsUnencodedValues(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sstables_MergeOperands")
+	require.Nil(t, err)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}),
+		WithMergeOperands())
+	require.Nil(t, err)
+	require.NoError(t, writer.Open())
+	assert.Error(t, writer.WriteNext([]byte{1}, []byte{42}))
+	assert.NoError(t, writer.WriteNext([]byte{2}, []byte{}))
+	require.NoError(t, writer.Close())
+}
+
+func TestSuperReaderFoldsMergeOperands(t *testing.T) {
+	plainWriter, err := newTestSSTableStreamWriter()
+	require.Nil(t, err)
+	defer cleanWriterDir(t, plainWriter)
+	require.NoError(t, plainWriter.Open())
+	require.NoError(t, plainWriter.WriteNext(intToByteSlice(1), intToByteSlice(10)))
+	require.NoError(t, plainWriter.WriteNext(intToByteSlice(2), intToByteSlice(20)))
+	require.NoError(t, plainWriter.WriteNext(intToByteSlice(3), intToByteSlice(30)))
+	require.NoError(t, plainWriter.WriteNext(intToByteSlice(6), intToByteSlice(60)))
+	require.NoError(t, plainWriter.Close())
+	plain, err := NewSSTableReader(ReadBasePath(plainWriter.opts.basePath))
+	require.Nil(t, err)
+	defer closeReader(t, plain)
+
+	older, cleanupOlder := writeMergeTestTable(t, []mergeTestRecord{
+		{1, mergeOperands(1, 2)},
+		{2, MergeValue{Kind: ValueKindDelete}},
+		{3, putValue(100)},
+		{4, mergeOperands(5)},
+	}, nil)
+	defer cleanupOlder()
+	newer, cleanupNewer := writeMergeTestTable(t, []mergeTestRecord{
+		{1, mergeOperands(3)},
+		{2, mergeOperands(7)},
+		{3, mergeOperands(1)},
+		{4, mergeOperands(1, 1)},
+		{5, MergeValue{Kind: ValueKindDelete}},
+		{6, mergeOperands(4)},
+	}, RangeTombstones{{Start: intToByteSlice(6), End: intToByteSlice(7)}})
+	defer cleanupNewer()
+
+	readers := []SSTableReaderI{plain, older, newer}
+	reader := NewSuperSSTableReader(readers, skiplist.BytesComparator{},
+		SuperReadWithMergeOperator(&counterMergeOperator{}))
+
+	expected := map[int][]byte{
+		1: intToByteSlice(16),
+		2: intToByteSlice(7),
+		3: intToByteSlice(101),
+		4: intToByteSlice(7),
+		5: {},
+		6: intToByteSlice(4),
+	}
+	var keys [][]byte
+	for k, v := range expected {
+		actual, err := reader.Get(intToByteSlice(k))
+		require.Nil(t, err)
+		assert.Equal(t, v, actual, "key %d", k)
+		keys = append(keys, intToByteSlice(k))
+	}
+	_, err = reader.Get(intToByteSlice(7))
+	assert.ErrorIs(t, err, NotFound)
+
+	values, errs := reader.MultiGet(keys)
+	for i, k := range keys {
+		require.Nil(t, errs[i])
+		assert.Equal(t, expected[int(binary.BigEndian.Uint32(k))], values[i])
+	}
+
+	// scans fully merge the operands, since they contain all tables
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	for i := 1; i <= 6; i++ {
+		k, v, err := it.Next()
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(i), k)
+		assert.Equal(t, expected[i], v, "key %d", i)
+	}
+	_, _, err = it.Next()
+	assert.ErrorIs(t, err, Done)
+
+	withoutOperator := NewSuperSSTableReader(readers, skiplist.BytesComparator{})
+	_, err = withoutOperator.Get(intToByteSlice(1))
+	assert.ErrorIs(t, err, MergeOperandFound)
+	v, err := withoutOperator.Get(intToByteSlice(5))
+	require.Nil(t, err)
+	assert.Equal(t, []byte{}, v)
+}
+
+// multiGetOnlyReader counts the calls to MultiGet and fails on single lookups.
+type multiGetOnlyReader struct {
+	SSTableReaderI
+	multiGets int
+	keys      int
+}
+
+func (r *multiGetOnlyReader) Get(_ []byte) ([]byte, error) {
+	return nil, errors.New("unexpected Get")
+}
+
+func (r *multiGetOnlyReader) MultiGet(keys [][]byte) ([][]byte, []error) {
+	r.multiGets++
+	r.keys += len(keys)
+	return r.SSTableReaderI.MultiGet(keys)
+}
+
+func TestSuperReaderMultiGetBatchesMergeOperands(t *testing.T) {
+	oldest, cleanupOldest := writeMergeTestTable(t, []mergeTestRecord{
+		{1, putValue(10)},
+		{2, putValue(20)},
+		{3, putValue(30)},
+	}, nil)
+	defer cleanupOldest()
+	older, cleanupOlder := writeMergeTestTable(t, []mergeTestRecord{
+		{1, mergeOperands(1)},
+		{2, putValue(200)},
+		{4, mergeOperands(4)},
+	}, nil)
+	defer cleanupOlder()
+	newer, cleanupNewer := writeMergeTestTable(t, []mergeTestRecord{
+		{1, mergeOperands(2, 3)},
+		{3, MergeValue{Kind: ValueKindDelete}},
+		{4, mergeOperands(1)},
+	}, nil)
+	defer cleanupNewer()
+
+	counting := []*multiGetOnlyReader{{SSTableReaderI: oldest}, {SSTableReaderI: older}, {SSTableReaderI: newer}}
+	reader := NewSuperSSTableReader([]SSTableReaderI{counting[0], counting[1], counting[2]}, skiplist.BytesComparator{},
+		SuperReadWithMergeOperator(&counterMergeOperator{}))
+
+	var keys [][]byte
+	for i := 5; i >= 0; i-- {
+		keys = append(keys, intToByteSlice(i))
+	}
+	values, errs := reader.MultiGet(keys)
+
+	expected := NewSuperSSTableReader([]SSTableReaderI{oldest, older, newer}, skiplist.BytesComparator{},
+		SuperReadWithMergeOperator(&counterMergeOperator{}))
+	for i, key := range keys {
+		expectedValue, expectedErr := expected.Get(key)
+		assert.Equal(t, expectedErr, errs[i], "key %v", key)
+		assert.Equal(t, expectedValue, values[i], "key %v", key)
+	}
+	assert.Equal(t, intToByteSlice(16), values[4])
+	assert.Equal(t, intToByteSlice(200), values[3])
+	assert.Equal(t, []byte{}, values[2])
+	assert.Equal(t, intToByteSlice(5), values[1])
+
+	// every reader is asked once, the keys that found a put or a delete aren't looked up in the older readers anymore
+	for _, r := range counting {
+		assert.Equal(t, 1, r.multiGets)
+	}
+	assert.Equal(t, 6, counting[2].keys)
+	assert.Equal(t, 5, counting[1].keys)
+	assert.Equal(t, 4, counting[0].keys)
+}
+
+func TestMergeCompactCollapsesMergeOperands(t *testing.T) {
+	oldest, cleanupOldest := writeMergeTestTable(t, []mergeTestRecord{
+		{1, putValue(10)},
+		{2, putValue(20)},
+	}, nil)
+	defer cleanupOldest()
+	older, cleanupOlder := writeMergeTestTable(t, []mergeTestRecord{
+		{1, mergeOperands(1, 2)},
+		{2, MergeValue{Kind: ValueKindDelete}},
+		{3, mergeOperands(3)},
+	}, nil)
+	defer cleanupOlder()
+	newer, cleanupNewer := writeMergeTestTable(t, []mergeTestRecord{
+		{1, mergeOperands(3)},
+		{2, mergeOperands(5)},
+		{3, mergeOperands(4)},
+	}, nil)
+	defer cleanupNewer()
+
+	// only the two newer tables are compacted, thus the operands can only be partially merged
+	operator := &counterMergeOperator{}
+	var iterators []SSTableMergeIteratorContext
+	for i, reader := range []SSTableReaderI{older, newer} {
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		iterators = append(iterators, NewReaderMergeIteratorContext(i, it, reader))
+	}
+	it, err := NewSSTableMerger(skiplist.BytesComparator{}, WithMergeOperator(operator)).
+		MergeCompactIterator(iterators, ScanReduceLatestWins)
+	require.Nil(t, err)
+
+	expected := []MergeValue{mergeOperands(6), putValue(5), mergeOperands(7)}
+	var compacted []mergeTestRecord
+	for i, e := range expected {
+		k, v, err := it.Next()
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(i+1), k)
+		decoded, err := DecodeMergeValue(v)
+		require.Nil(t, err)
+		assert.Equal(t, e, decoded)
+		compacted = append(compacted, mergeTestRecord{key: i + 1, value: decoded})
+	}
+	_, _, err = it.Next()
+	assert.ErrorIs(t, err, Done)
+	assert.Equal(t, 2, operator.partialMerges)
+
+	result, cleanupResult := writeMergeTestTable(t, compacted, nil)
+	defer cleanupResult()
+	reader := NewSuperSSTableReader([]SSTableReaderI{oldest, result}, skiplist.BytesComparator{},
+		SuperReadWithMergeOperator(operator))
+	for k, v := range map[int]int{1: 16, 2: 5, 3: 7} {
+		actual, err := reader.Get(intToByteSlice(k))
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(v), actual)
+	}
+}
+
+func TestMergeCompactWithoutOperatorKeepsOperands(t *testing.T) {
+	older, cleanupOlder := writeMergeTestTable(t, []mergeTestRecord{{1, mergeOperands(1)}}, nil)
+	defer cleanupOlder()
+	newer, cleanupNewer := writeMergeTestTable(t, []mergeTestRecord{{1, mergeOperands(2)}}, nil)
+	defer cleanupNewer()
+
+	var iterators []SSTableMergeIteratorContext
+	for i, reader := range []SSTableReaderI{older, newer} {
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		iterators = append(iterators, NewReaderMergeIteratorContext(i, it, reader))
+	}
+	it, err := NewSSTableMerger(skiplist.BytesComparator{}).MergeCompactIterator(iterators, ScanReduceLatestWins)
+	require.Nil(t, err)
+	_, v, err := it.Next()
+	require.Nil(t, err)
+	decoded, err := DecodeMergeValue(v)
+	require.Nil(t, err)
+	assert.Equal(t, mergeOperands(1, 2), decoded)
+	_, _, err = it.Next()
+	assert.True(t, errors.Is(err, Done))
+}
@@ -0,0 +1,131 @@
+package sstables
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func writeMultiGetTestTable(t *testing.T, keys []int, tombstones ...[2]int) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_MultiGet")
+	require.Nil(t, err)
+
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, k := range keys {
+		require.Nil(t, writer.WriteNext(intToByteSlice(k), intToByteSlice(k+1)))
+	}
+	for _, tombstone := range tombstones {
+		require.Nil(t, writer.WriteRangeTombstone(intToByteSlice(tombstone[0]), intToByteSlice(tombstone[1])))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+func TestMultiGetMatchesGet(t *testing.T) {
+	var keys []int
+	for i := 0; i < 500; i += 2 {
+		keys = append(keys, i)
+	}
+	path := writeMultiGetTestTable(t, keys)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	loaders := map[string]IndexLoader{
+		"slice":    &SliceKeyIndexLoader{ReadBufferSize: 4096},
+		"skiplist": &SkipListIndexLoader{KeyComparator: skiplist.BytesComparator{}, ReadBufferSize: 4096},
+		"disk":     &DiskIndexLoader{},
+	}
+
+	// unsorted, with duplicates and keys that don't exist
+	lookups := [][]byte{intToByteSlice(498), intToByteSlice(3), intToByteSlice(0), intToByteSlice(250),
+		intToByteSlice(1000), intToByteSlice(0), intToByteSlice(42)}
+
+	for name, loader := range loaders {
+		t.Run(name, func(t *testing.T) {
+			reader, err := NewSSTableReader(ReadBasePath(path), ReadIndexLoader(loader))
+			require.Nil(t, err)
+			defer closeReader(t, reader)
+
+			values, errs := reader.MultiGet(lookups)
+			require.Equal(t, len(lookups), len(values))
+			require.Equal(t, len(lookups), len(errs))
+			for i, key := range lookups {
+				expectedValue, expectedErr := reader.Get(key)
+				assert.Equal(t, expectedErr, errs[i])
+				assert.Equal(t, expectedValue, values[i])
+			}
+			assert.ErrorIs(t, errs[1], NotFound)
+			assert.ErrorIs(t, errs[4], NotFound)
+			assert.Equal(t, intToByteSlice(499), values[0])
+		})
+	}
+}
+
+func TestMultiGetEmpty(t *testing.T) {
+	path := writeMultiGetTestTable(t, []int{1})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	values, errs := reader.MultiGet(nil)
+	assert.Empty(t, values)
+	assert.Empty(t, errs)
+
+	values, errs = EmptySStableReader{}.MultiGet([][]byte{{1}})
+	assert.Equal(t, [][]byte{nil}, values)
+	assert.Equal(t, []error{NotFound}, errs)
+}
+
+func TestSliceKeyIndexGetAll(t *testing.T) {
+	index := &SliceKeyIndex{index: []sliceKey{
+		{IndexVal{Offset: 1}, []byte{1}},
+		{IndexVal{Offset: 3}, []byte{3}},
+		{IndexVal{Offset: 5}, []byte{5}},
+	}}
+
+	vals, errs := index.GetAll([][]byte{{1}, {2}, {5}, {3}, {6}, {0}})
+	assert.Equal(t, []IndexVal{{Offset: 1}, {}, {Offset: 5}, {Offset: 3}, {}, {}}, vals)
+	assert.Equal(t, []error{nil, skiplist.NotFound, nil, nil, skiplist.NotFound, skiplist.NotFound}, errs)
+}
+
+func TestSuperMultiGet(t *testing.T) {
+	older := writeMultiGetTestTable(t, []int{1, 2, 3, 4, 5})
+	defer func() { require.Nil(t, os.RemoveAll(older)) }()
+	newer := writeMultiGetTestTable(t, []int{5, 6}, [2]int{2, 4})
+	defer func() { require.Nil(t, os.RemoveAll(newer)) }()
+
+	var readers []SSTableReaderI
+	for _, p := range []string{older, newer} {
+		reader, err := NewSSTableReader(ReadBasePath(p))
+		require.Nil(t, err)
+		readers = append(readers, reader)
+	}
+	reader := NewSuperSSTableReader(readers, skiplist.BytesComparator{})
+	defer closeReader(t, reader)
+
+	var lookups [][]byte
+	for i := 7; i >= 0; i-- {
+		lookups = append(lookups, intToByteSlice(i))
+	}
+
+	values, errs := reader.MultiGet(lookups)
+	for i, key := range lookups {
+		expectedValue, expectedErr := reader.Get(key)
+		assert.Equal(t, expectedErr, errs[i], "key %v", key)
+		assert.Equal(t, expectedValue, values[i], "key %v", key)
+	}
+
+	// 2 and 3 are range deleted, 0 and 7 don't exist
+	for _, i := range []int{0, 4, 5, 7} {
+		assert.ErrorIs(t, errs[i], NotFound)
+	}
+	assert.Equal(t, intToByteSlice(7), values[1])
+	assert.Equal(t, intToByteSlice(5), values[3])
+	assert.Equal(t, intToByteSlice(2), values[6])
+}
@@ -0,0 +1,294 @@
+package sstables
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/thomasjungblut/go-sstables/pq"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+// mergeBatch is a consecutive run of records that is handed between the goroutines of the ParallelSSTableMerger.
+type mergeBatch struct {
+	keys   [][]byte
+	values [][]byte
+	// err is set on the last batch of an input that failed, it never contains Done
+	err error
+}
+
+// prefetchingIterator reads the batches that a goroutine prefetched from a merge input.
+type prefetchingIterator struct {
+	ctx     int
+	batches <-chan mergeBatch
+	current mergeBatch
+	pos     int
+}
+
+func (it *prefetchingIterator) Next() ([]byte, []byte, error) {
+	for it.pos >= len(it.current.keys) {
+		if it.current.err != nil {
+			return nil, nil, it.current.err
+		}
+
+		batch, ok := <-it.batches
+		if !ok {
+			return nil, nil, pq.Done
+		}
+		it.current = batch
+		it.pos = 0
+	}
+
+	k, v := it.current.keys[it.pos], it.current.values[it.pos]
+	it.pos++
+	return k, v, nil
+}
+
+func (it *prefetchingIterator) Context() int {
+	return it.ctx
+}
+
+// prefetch reads the iterator in batches until it's exhausted, fails or the merge is stopped. Reading the values
+// includes decompressing them, which therefore happens concurrently for all inputs.
+func prefetch(iterator SSTableIteratorI, batchSize int, out chan<- mergeBatch, stop <-chan struct{}) {
+	defer close(out)
+
+	for {
+		batch := mergeBatch{keys: make([][]byte, 0, batchSize), values: make([][]byte, 0, batchSize)}
+		done := false
+		for len(batch.keys) < batchSize {
+			k, v, err := iterator.Next()
+			if err != nil {
+				if !errors.Is(err, Done) {
+					batch.err = err
+				}
+				done = true
+				break
+			}
+			batch.keys = append(batch.keys, k)
+			batch.values = append(batch.values, v)
+		}
+
+		if len(batch.keys) > 0 || batch.err != nil {
+			select {
+			case out <- batch:
+			case <-stop:
+				return
+			}
+		}
+
+		if done {
+			return
+		}
+	}
+}
+
+// pqIterator exposes a priority queue as an SSTableIteratorI.
+type pqIterator struct {
+	pq pq.PriorityQueueI[[]byte, []byte, int]
+}
+
+func (it pqIterator) Next() ([]byte, []byte, error) {
+	k, v, _, err := it.pq.Next()
+	if err != nil {
+		if errors.Is(err, pq.Done) {
+			return nil, nil, Done
+		}
+		return nil, nil, err
+	}
+	return k, v, nil
+}
+
+// ParallelSSTableMerger merges like the SSTableMerger, but spreads the work over multiple goroutines: every input is
+// read ahead and decompressed by its own goroutine, the records are merged with a pq.LoserTree and written by another
+// goroutine. The ReduceFunc is called on the merging goroutine only, exactly like in SSTableMerger.MergeCompact.
+// Since the inputs are read concurrently, each iterator must not share any state with the others.
+type ParallelSSTableMerger struct {
+	comp skiplist.Comparator[[]byte]
+	opts *ParallelMergerOptions
+}
+
+func (m ParallelSSTableMerger) checkComparatorNames(iterators []SSTableMergeIteratorContext) error {
+	return m.sequential().checkComparatorNames(iterators)
+}
+
+// sequential returns the SSTableMerger with the same comparator, merge operator and bottommost setting.
+func (m ParallelSSTableMerger) sequential() SSTableMerger {
+	return SSTableMerger{comp: m.comp, mergeOperator: m.opts.mergeOperator, bottommost: m.opts.bottommost}
+}
+
+// run merges the prefetched iterators via newIterator into the writer. It waits for all goroutines before returning,
+// so the caller can safely close the readers of the iterators afterwards.
+func (m ParallelSSTableMerger) run(iterators []SSTableMergeIteratorContext, writer SSTableStreamWriterI,
+	newIterator func(pq.PriorityQueueI[[]byte, []byte, int]) SSTableIteratorI) (err error) {
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	var inputs []pq.IteratorWithContext[[]byte, []byte, int]
+	for _, iterator := range iterators {
+		batches := make(chan mergeBatch, m.opts.readAheadBatches)
+		wg.Add(1)
+		go func(iterator SSTableIteratorI) {
+			defer wg.Done()
+			prefetch(iterator, m.opts.batchSize, batches, stop)
+		}(iterator.iterator)
+		inputs = append(inputs, &prefetchingIterator{ctx: iterator.ctx, batches: batches})
+	}
+
+	tree, err := pq.NewLoserTree[[]byte, []byte, int](m.comp, inputs)
+	if err != nil {
+		return fmt.Errorf("parallel merge error while initializing the loser tree: %w", err)
+	}
+	iterator := newIterator(tree)
+
+	writes := make(chan mergeBatch, m.opts.readAheadBatches)
+	writeErr := make(chan error, 1)
+	writeFailed := make(chan struct{})
+	go func() {
+		var wErr error
+		for batch := range writes {
+			for i := 0; i < len(batch.keys) && wErr == nil; i++ {
+				wErr = writer.WriteNext(batch.keys[i], batch.values[i])
+				if wErr != nil {
+					wErr = fmt.Errorf("parallel merge error while writing next record: %w", wErr)
+					close(writeFailed)
+				}
+			}
+		}
+		writeErr <- wErr
+	}()
+
+	var mergeErr error
+	batch := mergeBatch{}
+	for mergeErr == nil {
+		k, v, err := iterator.Next()
+		if err != nil {
+			if !errors.Is(err, Done) {
+				mergeErr = fmt.Errorf("parallel merge error while iterating: %w", err)
+			}
+			break
+		}
+
+		batch.keys = append(batch.keys, k)
+		batch.values = append(batch.values, v)
+		if len(batch.keys) >= m.opts.batchSize {
+			select {
+			case writes <- batch:
+			case <-writeFailed:
+				mergeErr = errors.New("parallel merge stopped, since the writer failed")
+			}
+			batch = mergeBatch{}
+		}
+	}
+
+	if mergeErr == nil && len(batch.keys) > 0 {
+		select {
+		case writes <- batch:
+		case <-writeFailed:
+		}
+	}
+
+	close(writes)
+	wErr := <-writeErr
+	if wErr != nil {
+		// the writer's error is the cause of the merge stopping early
+		return wErr
+	}
+
+	return mergeErr
+}
+
+// Merge accepts a slice of sstable iterators to merge into an already opened writer. The caller needs to close the writer.
+func (m ParallelSSTableMerger) Merge(iterators []SSTableMergeIteratorContext, writer SSTableStreamWriterI) error {
+	err := m.checkComparatorNames(iterators)
+	if err != nil {
+		return err
+	}
+
+	return m.run(iterators, writer, func(tree pq.PriorityQueueI[[]byte, []byte, int]) SSTableIteratorI {
+		return pqIterator{pq: tree}
+	})
+}
+
+// MergeCompact is like SSTableMerger.MergeCompact, the values of the same key are reduced with the ReduceFunc and
+// keys that are deleted by range tombstones of newer iterators are dropped. The caller needs to close the writer.
+func (m ParallelSSTableMerger) MergeCompact(iterators []SSTableMergeIteratorContext, writer SSTableStreamWriterI, reduce ReduceFunc) error {
+	err := m.checkComparatorNames(iterators)
+	if err != nil {
+		return err
+	}
+
+	err = writeMergedRangeTombstones(iterators, writer)
+	if err != nil {
+		return err
+	}
+
+	return m.run(iterators, writer, func(tree pq.PriorityQueueI[[]byte, []byte, int]) SSTableIteratorI {
+		return m.sequential().newMergeCompactionIterator(tree, reduce, iterators)
+	})
+}
+
+func NewParallelSSTableMerger(comp skiplist.Comparator[[]byte], opts ...ParallelMergerOption) ParallelSSTableMerger {
+	options := &ParallelMergerOptions{
+		batchSize:        1024,
+		readAheadBatches: 4,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.batchSize <= 0 {
+		options.batchSize = 1
+	}
+	if options.readAheadBatches < 0 {
+		options.readAheadBatches = 0
+	}
+
+	return ParallelSSTableMerger{comp: comp, opts: options}
+}
+
+// options
+
+type ParallelMergerOptions struct {
+	batchSize        int
+	readAheadBatches int
+	mergeOperator    MergeOperator
+	bottommost       bool
+}
+
+type ParallelMergerOption func(*ParallelMergerOptions)
+
+// MergeBatchSize sets the number of records that are handed between the goroutines at once, defaults to 1024.
+func MergeBatchSize(n int) ParallelMergerOption {
+	return func(args *ParallelMergerOptions) {
+		args.batchSize = n
+	}
+}
+
+// MergeReadAheadBatches sets how many batches of each input are read ahead, and how many merged batches can wait
+// for the writer, defaults to 4.
+func MergeReadAheadBatches(n int) ParallelMergerOption {
+	return func(args *ParallelMergerOptions) {
+		args.readAheadBatches = n
+	}
+}
+
+// ParallelMergeOperator collapses the merge operands in MergeCompact, just like WithMergeOperator for the SSTableMerger.
+func ParallelMergeOperator(operator MergeOperator) ParallelMergerOption {
+	return func(args *ParallelMergerOptions) {
+		args.mergeOperator = operator
+	}
+}
+
+// ParallelBottommostMerge drops the keys whose values have all expired in MergeCompact, just like WithBottommostMerge
+// for the SSTableMerger.
+func ParallelBottommostMerge() ParallelMergerOption {
+	return func(args *ParallelMergerOptions) {
+		args.bottommost = true
+	}
+}
@@ -0,0 +1,172 @@
+package sstables
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+// failingMergeIterator returns ascending integers until n records were read, then it returns err.
+type failingMergeIterator struct {
+	i   int
+	n   int
+	err error
+}
+
+func (it *failingMergeIterator) Next() ([]byte, []byte, error) {
+	if it.i >= it.n {
+		return nil, nil, it.err
+	}
+	it.i++
+	return intToByteSlice(it.i), intToByteSlice(it.i), nil
+}
+
+type failingMergeWriter struct {
+	SSTableStreamWriterI
+	remaining int
+	err       error
+}
+
+func (w *failingMergeWriter) WriteNext(_ []byte, _ []byte) error {
+	if w.remaining == 0 {
+		return w.err
+	}
+	w.remaining--
+	return nil
+}
+
+// overlappingMergeInputs writes numFiles tables with overlapping ranges of integers and returns their iterators.
+func overlappingMergeInputs(t *testing.T, numFiles int, tombstones map[int]RangeTombstones) ([]SSTableMergeIteratorContext, func()) {
+	var cleanups []func()
+	var iterators []SSTableMergeIteratorContext
+	for i := 0; i < numFiles; i++ {
+		writer, err := newTestSSTableStreamWriter()
+		require.Nil(t, err)
+		streamedWriteAscendingIntegersWithStart(t, writer, i*25, 250)
+		reader, iterator := getFullScanIterator(t, writer.opts.basePath)
+		cleanups = append(cleanups, func() {
+			closeReader(t, reader)
+			cleanWriterDir(t, writer)
+		})
+		iterators = append(iterators, NewMergeIteratorContextWithRangeTombstones(i, iterator, tombstones[i]))
+	}
+
+	return iterators, func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
+}
+
+func TestParallelMergeEndToEnd(t *testing.T) {
+	for _, numFiles := range []int{1, 2, 5, 9} {
+		var expectedNumbers []int
+		var iterators []SSTableMergeIteratorContext
+		for i := 0; i < numFiles; i++ {
+			writer, err := newTestSSTableStreamWriter()
+			require.Nil(t, err)
+			defer cleanWriterDir(t, writer)
+			expectedNumbers = append(expectedNumbers, streamedWriteElements(t, writer, 1000/numFiles)...)
+			reader, iterator := getFullScanIterator(t, writer.opts.basePath)
+			defer closeReader(t, reader)
+			iterators = append(iterators, NewMergeIteratorContext(i, iterator))
+		}
+
+		outWriter, err := newTestSSTableStreamWriter()
+		require.Nil(t, err)
+		require.NoError(t, outWriter.Open())
+		defer cleanWriterDir(t, outWriter)
+
+		merger := NewParallelSSTableMerger(skiplist.BytesComparator{}, MergeBatchSize(7), MergeReadAheadBatches(2))
+		require.Nil(t, merger.Merge(iterators, outWriter))
+		require.NoError(t, outWriter.Close())
+		sort.Ints(expectedNumbers)
+		assertRandomAndSequentialRead(t, outWriter.opts.basePath, expectedNumbers)
+	}
+}
+
+func TestParallelMergeCompactMatchesSequentialMerge(t *testing.T) {
+	tombstones := map[int]RangeTombstones{
+		2: {{Start: intToByteSlice(10), End: intToByteSlice(40)}},
+		4: {{Start: intToByteSlice(200), End: intToByteSlice(210)}},
+	}
+
+	// the reduce must be called with the same keys, values and contexts as with the sequential merger,
+	// the order of the values of the same key is not defined though
+	var reduced []map[int][]byte
+	reduce := func(key []byte, values [][]byte, context []int) ([]byte, []byte) {
+		valuesByContext := map[int][]byte{}
+		for i, c := range context {
+			valuesByContext[c] = values[i]
+		}
+		reduced = append(reduced, valuesByContext)
+		return ScanReduceLatestWins(key, values, context)
+	}
+
+	iterators, cleanup := overlappingMergeInputs(t, 5, tombstones)
+	defer cleanup()
+	expectedWriter, err := newTestSSTableStreamWriter()
+	require.Nil(t, err)
+	require.NoError(t, expectedWriter.Open())
+	defer cleanWriterDir(t, expectedWriter)
+	require.Nil(t, NewSSTableMerger(skiplist.BytesComparator{}).MergeCompact(iterators, expectedWriter, reduce))
+	require.NoError(t, expectedWriter.Close())
+	expectedReduced := reduced
+	reduced = nil
+
+	iterators, cleanup = overlappingMergeInputs(t, 5, tombstones)
+	defer cleanup()
+	actualWriter, err := newTestSSTableStreamWriter()
+	require.Nil(t, err)
+	require.NoError(t, actualWriter.Open())
+	defer cleanWriterDir(t, actualWriter)
+	merger := NewParallelSSTableMerger(skiplist.BytesComparator{}, MergeBatchSize(16))
+	require.Nil(t, merger.MergeCompact(iterators, actualWriter, reduce))
+	require.NoError(t, actualWriter.Close())
+
+	assert.Equal(t, expectedReduced, reduced)
+
+	expected, err := NewSSTableReader(ReadBasePath(expectedWriter.opts.basePath))
+	require.Nil(t, err)
+	defer closeReader(t, expected)
+	actual, err := NewSSTableReader(ReadBasePath(actualWriter.opts.basePath))
+	require.Nil(t, err)
+	defer closeReader(t, actual)
+
+	assert.Equal(t, expected.MetaData().NumRecords, actual.MetaData().NumRecords)
+	assert.Equal(t, expected.RangeTombstones(), actual.RangeTombstones())
+	expectedIt, err := expected.Scan()
+	require.Nil(t, err)
+	actualIt, err := actual.Scan()
+	require.Nil(t, err)
+	assertIteratorsEqual(t, expectedIt, actualIt)
+}
+
+func TestParallelMergeInputError(t *testing.T) {
+	expectedErr := errors.New("read failure")
+	iterators := []SSTableMergeIteratorContext{
+		NewMergeIteratorContext(0, &failingMergeIterator{n: 100, err: Done}),
+		NewMergeIteratorContext(1, &failingMergeIterator{n: 50, err: expectedErr}),
+	}
+
+	writer := &failingMergeWriter{remaining: -1}
+	err := NewParallelSSTableMerger(skiplist.BytesComparator{}, MergeBatchSize(8)).Merge(iterators, writer)
+	assert.ErrorIs(t, err, expectedErr)
+}
+
+func TestParallelMergeWriterError(t *testing.T) {
+	expectedErr := errors.New("write failure")
+	iterators := []SSTableMergeIteratorContext{
+		NewMergeIteratorContext(0, &failingMergeIterator{n: 10000, err: Done}),
+		NewMergeIteratorContext(1, &failingMergeIterator{n: 10000, err: Done}),
+	}
+
+	writer := &failingMergeWriter{remaining: 100, err: expectedErr}
+	err := NewParallelSSTableMerger(skiplist.BytesComparator{}, MergeBatchSize(8)).MergeCompact(iterators, writer,
+		ScanReduceLatestWins)
+	assert.ErrorIs(t, err, expectedErr)
+}
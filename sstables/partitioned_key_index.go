@@ -0,0 +1,575 @@
+package sstables
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/thomasjungblut/go-sstables/recordio"
+	rProto "github.com/thomasjungblut/go-sstables/recordio/proto"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+	"golang.org/x/exp/slices"
+)
+
+// IndexPartitionsFileName is the top-level index of tables written WithPartitionedIndex. Every record is an IndexEntry
+// with the first key of a partition and the offset of the partition's first record in the index file as ValueOffset.
+var IndexPartitionsFileName = "index_partitions.rio"
+
+// DefaultIndexPartitionSizeBytes is used to partition the index of tables that were written without WithPartitionedIndex.
+const DefaultIndexPartitionSizeBytes uint64 = 4096
+
+type indexPartition struct {
+	firstKey    []byte
+	startOffset uint64
+}
+
+// appendIndexPartition starts a new partition at the given index record, once the current partition reached the size.
+func appendIndexPartition(partitions []indexPartition, key []byte, offset uint64, partitionSizeBytes uint64) []indexPartition {
+	if len(partitions) > 0 && offset-partitions[len(partitions)-1].startOffset < partitionSizeBytes {
+		return partitions
+	}
+	return append(partitions, indexPartition{firstKey: append([]byte{}, key...), startOffset: offset})
+}
+
+type cachedIndexPartition struct {
+	partition int
+	entries   []sliceKey
+}
+
+// PartitionedKeyIndex is a two-level index. Only the first key of every partition of the index file is kept in memory,
+// the partitions themselves are read on demand from the memory mapped index file and cached.
+type PartitionedKeyIndex struct {
+	reader     rProto.ReadAtI
+	partitions []indexPartition
+	// scanPartitionSizeBytes is set when the table has no top-level index, which is then created by scanning the index on Open
+	scanPartitionSizeBytes uint64
+
+	maxCachedPartitions int
+	cacheLock           sync.Mutex
+	cache               map[int]*list.Element
+	lru                 *list.List
+}
+
+func (s *PartitionedKeyIndex) Open() error {
+	err := s.reader.Open()
+	if err != nil {
+		return err
+	}
+
+	if s.scanPartitionSizeBytes > 0 {
+		cur := uint64(recordio.FileHeaderSizeBytes)
+		for {
+			entry := &proto.IndexEntry{}
+			offset, _, err := s.reader.SeekNext(entry, cur)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+			s.partitions = appendIndexPartition(s.partitions, entry.Key, offset, s.scanPartitionSizeBytes)
+			cur = offset + 1
+		}
+	}
+
+	return nil
+}
+
+func (s *PartitionedKeyIndex) Close() error {
+	return s.reader.Close()
+}
+
+// locate returns the partition that would contain the key, -1 if the key is lower than the first key of the index.
+func (s *PartitionedKeyIndex) locate(key []byte) int {
+	return sort.Search(len(s.partitions), func(i int) bool {
+		return bytes.Compare(s.partitions[i].firstKey, key) > 0
+	}) - 1
+}
+
+// partition returns the entries of the i-th partition, either from the cache or by reading them from the index file.
+func (s *PartitionedKeyIndex) partition(i int) ([]sliceKey, error) {
+	s.cacheLock.Lock()
+	if e, ok := s.cache[i]; ok {
+		s.lru.MoveToFront(e)
+		s.cacheLock.Unlock()
+		return e.Value.(*cachedIndexPartition).entries, nil
+	}
+	s.cacheLock.Unlock()
+
+	endOffset := s.reader.Size()
+	if i+1 < len(s.partitions) {
+		endOffset = s.partitions[i+1].startOffset
+	}
+
+	var entries []sliceKey
+	cur := s.partitions[i].startOffset
+	for cur < endOffset {
+		entry := &proto.IndexEntry{}
+		offset, _, err := s.reader.SeekNext(entry, cur)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("error while reading index partition %d: %w", i, err)
+		}
+		if offset >= endOffset {
+			break
+		}
+		entries = append(entries, sliceKey{IndexVal{Offset: entry.ValueOffset, Checksum: entry.Checksum}, entry.Key})
+		cur = offset + 1
+	}
+
+	s.cacheLock.Lock()
+	defer s.cacheLock.Unlock()
+	if _, ok := s.cache[i]; !ok {
+		s.cache[i] = s.lru.PushFront(&cachedIndexPartition{partition: i, entries: entries})
+		if s.lru.Len() > s.maxCachedPartitions {
+			oldest := s.lru.Back()
+			s.lru.Remove(oldest)
+			delete(s.cache, oldest.Value.(*cachedIndexPartition).partition)
+		}
+	}
+
+	return entries, nil
+}
+
+func searchSliceKeys(entries []sliceKey, key []byte) (int, bool) {
+	return slices.BinarySearchFunc(entries, key, func(entry sliceKey, k []byte) int {
+		return bytes.Compare(entry.key, k)
+	})
+}
+
+func (s *PartitionedKeyIndex) Get(key []byte) (IndexVal, error) {
+	p := s.locate(key)
+	if p < 0 {
+		return IndexVal{}, skiplist.NotFound
+	}
+
+	entries, err := s.partition(p)
+	if err != nil {
+		return IndexVal{}, err
+	}
+
+	idx, found := searchSliceKeys(entries, key)
+	if !found {
+		return IndexVal{}, skiplist.NotFound
+	}
+	return entries[idx].IndexVal, nil
+}
+
+// GetAll looks up sorted keys with the same partition without going through the cache again.
+func (s *PartitionedKeyIndex) GetAll(keys [][]byte) ([]IndexVal, []error) {
+	vals := make([]IndexVal, len(keys))
+	errs := make([]error, len(keys))
+
+	lastPartition := -1
+	var entries []sliceKey
+	for i, key := range keys {
+		p := s.locate(key)
+		if p < 0 {
+			errs[i] = skiplist.NotFound
+			continue
+		}
+
+		if p != lastPartition {
+			var err error
+			entries, err = s.partition(p)
+			if err != nil {
+				lastPartition = -1
+				errs[i] = err
+				continue
+			}
+			lastPartition = p
+		}
+
+		idx, found := searchSliceKeys(entries, key)
+		if found {
+			vals[i] = entries[idx].IndexVal
+		} else {
+			errs[i] = skiplist.NotFound
+		}
+	}
+
+	return vals, errs
+}
+
+func (s *PartitionedKeyIndex) Contains(key []byte) (bool, error) {
+	_, err := s.Get(key)
+	if err != nil {
+		if errors.Is(err, skiplist.NotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *PartitionedKeyIndex) Iterator() (skiplist.IteratorI[[]byte, IndexVal], error) {
+	return &PartitionedKeyIndexIterator{index: s, partition: -1}, nil
+}
+
+func (s *PartitionedKeyIndex) IteratorStartingAt(key []byte) (skiplist.IteratorI[[]byte, IndexVal], error) {
+	return s.newIteratorStartingAt(key, nil)
+}
+
+func (s *PartitionedKeyIndex) IteratorBetween(keyLower []byte, keyHigher []byte) (skiplist.IteratorI[[]byte, IndexVal], error) {
+	if bytes.Compare(keyLower, keyHigher) > 0 {
+		return nil, errors.New("keyHigher is lower than keyLower")
+	}
+
+	return s.newIteratorStartingAt(keyLower, keyHigher)
+}
+
+func (s *PartitionedKeyIndex) newIteratorStartingAt(key []byte, keyHigher []byte) (*PartitionedKeyIndexIterator, error) {
+	p := s.locate(key)
+	if p < 0 {
+		return &PartitionedKeyIndexIterator{index: s, partition: -1, keyHigher: keyHigher}, nil
+	}
+
+	entries, err := s.partition(p)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, _ := searchSliceKeys(entries, key)
+	return &PartitionedKeyIndexIterator{index: s, partition: p, entries: entries, currentIndex: idx, keyHigher: keyHigher}, nil
+}
+
+func (s *PartitionedKeyIndex) SeekableIterator() (skiplist.SeekableIteratorI[[]byte, IndexVal], error) {
+	it := &PartitionedKeyIndexSeekableIterator{index: s}
+	if err := it.SeekToFirst(); err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+// PartitionedKeyIndexIterator iterates the entries of a partition and moves on to the next partition once exhausted.
+type PartitionedKeyIndexIterator struct {
+	index        *PartitionedKeyIndex
+	partition    int
+	entries      []sliceKey
+	currentIndex int
+	// keyHigher is the inclusive upper bound of the iterator, nil if unbounded
+	keyHigher []byte
+}
+
+func (s *PartitionedKeyIndexIterator) Next() ([]byte, IndexVal, error) {
+	for s.currentIndex >= len(s.entries) {
+		if s.partition+1 >= len(s.index.partitions) {
+			return nil, IndexVal{}, skiplist.Done
+		}
+
+		entries, err := s.index.partition(s.partition + 1)
+		if err != nil {
+			return nil, IndexVal{}, err
+		}
+		s.partition++
+		s.entries = entries
+		s.currentIndex = 0
+	}
+
+	cx := s.entries[s.currentIndex]
+	if s.keyHigher != nil && bytes.Compare(cx.key, s.keyHigher) > 0 {
+		return nil, IndexVal{}, skiplist.Done
+	}
+
+	s.currentIndex++
+	return cx.key, cx.IndexVal, nil
+}
+
+// PartitionedKeyIndexSeekableIterator is positioned at currentIndex of a partition, as long as valid is true.
+type PartitionedKeyIndexSeekableIterator struct {
+	index        *PartitionedKeyIndex
+	partition    int
+	entries      []sliceKey
+	currentIndex int
+	valid        bool
+}
+
+func (s *PartitionedKeyIndexSeekableIterator) Next() ([]byte, IndexVal, error) {
+	if !s.valid {
+		return nil, IndexVal{}, skiplist.Done
+	}
+
+	cx := s.entries[s.currentIndex]
+	s.currentIndex++
+	return cx.key, cx.IndexVal, s.forward()
+}
+
+func (s *PartitionedKeyIndexSeekableIterator) Prev() ([]byte, IndexVal, error) {
+	if !s.valid {
+		return nil, IndexVal{}, skiplist.Done
+	}
+
+	cx := s.entries[s.currentIndex]
+	s.currentIndex--
+	return cx.key, cx.IndexVal, s.backward()
+}
+
+func (s *PartitionedKeyIndexSeekableIterator) Seek(key []byte) error {
+	p := s.index.locate(key)
+	if p < 0 {
+		return s.SeekToFirst()
+	}
+
+	err := s.load(p)
+	if err != nil {
+		return err
+	}
+	s.currentIndex, _ = searchSliceKeys(s.entries, key)
+	return s.forward()
+}
+
+func (s *PartitionedKeyIndexSeekableIterator) SeekForPrev(key []byte) error {
+	p := s.index.locate(key)
+	if p < 0 {
+		s.valid = false
+		return nil
+	}
+
+	err := s.load(p)
+	if err != nil {
+		return err
+	}
+	idx, found := searchSliceKeys(s.entries, key)
+	if !found {
+		idx = idx - 1
+	}
+	s.currentIndex = idx
+	return s.backward()
+}
+
+func (s *PartitionedKeyIndexSeekableIterator) SeekToFirst() error {
+	if len(s.index.partitions) == 0 {
+		s.valid = false
+		return nil
+	}
+
+	err := s.load(0)
+	if err != nil {
+		return err
+	}
+	s.currentIndex = 0
+	return s.forward()
+}
+
+func (s *PartitionedKeyIndexSeekableIterator) SeekToLast() error {
+	if len(s.index.partitions) == 0 {
+		s.valid = false
+		return nil
+	}
+
+	err := s.load(len(s.index.partitions) - 1)
+	if err != nil {
+		return err
+	}
+	s.currentIndex = len(s.entries) - 1
+	return s.backward()
+}
+
+func (s *PartitionedKeyIndexSeekableIterator) Valid() bool {
+	return s.valid
+}
+
+func (s *PartitionedKeyIndexSeekableIterator) load(partition int) error {
+	entries, err := s.index.partition(partition)
+	if err != nil {
+		return err
+	}
+	s.partition = partition
+	s.entries = entries
+	return nil
+}
+
+// forward moves into the following partitions until currentIndex points to an entry.
+func (s *PartitionedKeyIndexSeekableIterator) forward() error {
+	for s.currentIndex >= len(s.entries) {
+		if s.partition+1 >= len(s.index.partitions) {
+			s.valid = false
+			return nil
+		}
+
+		err := s.load(s.partition + 1)
+		if err != nil {
+			return err
+		}
+		s.currentIndex = 0
+	}
+
+	s.valid = true
+	return nil
+}
+
+// backward moves into the preceding partitions until currentIndex points to an entry.
+func (s *PartitionedKeyIndexSeekableIterator) backward() error {
+	for s.currentIndex < 0 {
+		if s.partition == 0 {
+			s.valid = false
+			return nil
+		}
+
+		err := s.load(s.partition - 1)
+		if err != nil {
+			return err
+		}
+		s.currentIndex = len(s.entries) - 1
+	}
+
+	s.valid = true
+	return nil
+}
+
+// PartitionedIndexLoader loads the top-level index of tables written WithPartitionedIndex. For other tables the top-level
+// index is created by scanning the index file once.
+type PartitionedIndexLoader struct {
+	// MaxCachedPartitions defines how many partitions are kept in memory, defaults to 64.
+	MaxCachedPartitions int
+	// ScanPartitionSizeBytes is the partition size for tables without a top-level index, defaults to DefaultIndexPartitionSizeBytes.
+	ScanPartitionSizeBytes uint64
+}
+
+func (l *PartitionedIndexLoader) Load(indexPath string, _ *proto.MetaData) (SortedKeyIndex, error) {
+	partitions, exists, err := readIndexPartitionsIfExists(filepath.Join(filepath.Dir(indexPath), IndexPartitionsFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := rProto.NewMMapProtoReaderWithPath(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating index reader of sstable in '%s': %w", indexPath, err)
+	}
+
+	return l.newIndex(reader, partitions, exists), nil
+}
+
+// LoadFromSource loads the index from a table that is opened with ReadFromSource, only the top-level index and the
+// partitions that are needed for a lookup are read.
+func (l *PartitionedIndexLoader) LoadFromSource(source TableSource, _ *proto.MetaData) (SortedKeyIndex, error) {
+	var partitions []indexPartition
+	r, size, exists, err := sourceFileIfExists(source, IndexPartitionsFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		reader, err := rProto.NewReader(rProto.ReaderPath(IndexPartitionsFileName), rProto.ReaderFromReaderAt(r, size),
+			rProto.ReadBufferSizeBytes(4096))
+		if err != nil {
+			return nil, fmt.Errorf("error while creating index partitions reader from source: %w", err)
+		}
+
+		partitions, err = readIndexPartitions(reader, IndexPartitionsFileName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reader, err := newSourceProtoReadAtReader(source, IndexFileName)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating index reader of sstable from source: %w", err)
+	}
+
+	return l.newIndex(reader, partitions, exists), nil
+}
+
+func (l *PartitionedIndexLoader) newIndex(reader rProto.ReadAtI, partitions []indexPartition, partitionsExist bool) *PartitionedKeyIndex {
+	maxCachedPartitions := l.MaxCachedPartitions
+	if maxCachedPartitions <= 0 {
+		maxCachedPartitions = 64
+	}
+
+	index := &PartitionedKeyIndex{
+		reader:              reader,
+		partitions:          partitions,
+		maxCachedPartitions: maxCachedPartitions,
+		cache:               map[int]*list.Element{},
+		lru:                 list.New(),
+	}
+
+	if !partitionsExist {
+		index.scanPartitionSizeBytes = l.ScanPartitionSizeBytes
+		if index.scanPartitionSizeBytes == 0 {
+			index.scanPartitionSizeBytes = DefaultIndexPartitionSizeBytes
+		}
+	}
+
+	return index
+}
+
+func writeIndexPartitions(path string, partitions []indexPartition) (err error) {
+	writer, err := rProto.NewWriter(rProto.Path(path), rProto.WriteBufferSizeBytes(4096))
+	if err != nil {
+		return fmt.Errorf("error while creating index partitions writer in '%s': %w", path, err)
+	}
+
+	err = writer.Open()
+	if err != nil {
+		return fmt.Errorf("error while opening index partitions writer in '%s': %w", path, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, writer.Close())
+	}()
+
+	for _, p := range partitions {
+		_, err = writer.Write(&proto.IndexEntry{Key: p.firstKey, ValueOffset: p.startOffset})
+		if err != nil {
+			return fmt.Errorf("error while writing index partition in '%s': %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func readIndexPartitionsIfExists(path string) (partitions []indexPartition, exists bool, err error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, false, nil
+	}
+
+	reader, err := rProto.NewReader(rProto.ReaderPath(path), rProto.ReadBufferSizeBytes(4096))
+	if err != nil {
+		return nil, false, fmt.Errorf("error while creating index partitions reader in '%s': %w", path, err)
+	}
+
+	partitions, err = readIndexPartitions(reader, path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return partitions, true, nil
+}
+
+func readIndexPartitions(reader rProto.ReaderI, path string) (partitions []indexPartition, err error) {
+	err = reader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error while opening index partitions reader in '%s': %w", path, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, reader.Close())
+	}()
+
+	for {
+		record := &proto.IndexEntry{}
+		_, err := reader.ReadNext(record)
+		// io.EOF signals that no records are left to be read
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error while reading index partitions in '%s': %w", path, err)
+		}
+
+		partitions = append(partitions, indexPartition{firstKey: record.Key, startOffset: record.ValueOffset})
+	}
+
+	return partitions, nil
+}
@@ -0,0 +1,107 @@
+package sstables
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func writePartitionedIndexTestTable(t *testing.T, keys []int) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_PartitionedIndex")
+	require.Nil(t, err)
+
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}),
+		WithPartitionedIndex(128))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, k := range keys {
+		require.Nil(t, writer.WriteNext(intToByteSlice(k), intToByteSlice(k+1)))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+func TestPartitionedIndexWrittenByWriter(t *testing.T) {
+	var keys []int
+	for i := 0; i < 1000; i += 2 {
+		keys = append(keys, i)
+	}
+	path := writePartitionedIndexTestTable(t, keys)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	partitions, exists, err := readIndexPartitionsIfExists(filepath.Join(path, IndexPartitionsFileName))
+	require.Nil(t, err)
+	require.True(t, exists)
+	require.Greater(t, len(partitions), 10)
+	assert.Equal(t, intToByteSlice(0), partitions[0].firstKey)
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadIndexLoader(&PartitionedIndexLoader{MaxCachedPartitions: 4}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	index := reader.(*SSTableReader).index.(*PartitionedKeyIndex)
+	assert.Equal(t, partitions, index.partitions)
+
+	for _, k := range keys {
+		v, err := reader.Get(intToByteSlice(k))
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(k+1), v)
+
+		_, err = reader.Get(intToByteSlice(k + 1))
+		assert.ErrorIs(t, err, NotFound)
+	}
+	assert.LessOrEqual(t, index.lru.Len(), 4)
+
+	it, err := reader.ScanRange(intToByteSlice(101), intToByteSlice(700))
+	require.Nil(t, err)
+	var scanned [][]byte
+	for {
+		k, _, err := it.Next()
+		if errors.Is(err, Done) {
+			break
+		}
+		require.Nil(t, err)
+		scanned = append(scanned, k)
+	}
+	var expected [][]byte
+	for i := 102; i <= 700; i += 2 {
+		expected = append(expected, intToByteSlice(i))
+	}
+	assert.Equal(t, expected, scanned)
+
+	seekable, err := reader.SeekableIterator()
+	require.Nil(t, err)
+	require.Nil(t, seekable.SeekForPrev(intToByteSlice(1001)))
+	for i := len(keys) - 1; i >= 0; i-- {
+		k, _, err := seekable.Prev()
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(keys[i]), k)
+	}
+	assert.False(t, seekable.Valid())
+}
+
+func TestPartitionedIndexEmptyTable(t *testing.T) {
+	path := writePartitionedIndexTestTable(t, nil)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadIndexLoader(&PartitionedIndexLoader{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	_, err = reader.Get(intToByteSlice(1))
+	assert.ErrorIs(t, err, NotFound)
+
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	_, _, err = it.Next()
+	assert.ErrorIs(t, err, Done)
+
+	seekable, err := reader.SeekableIterator()
+	require.Nil(t, err)
+	assert.False(t, seekable.Valid())
+}
@@ -0,0 +1,180 @@
+package sstables
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+// MergePartition is the key range [Lower, Upper) of a single sub-merge of SSTableMerger.MergeCompactPartitioned.
+// A nil Lower or Upper means the range is unbounded on that side.
+type MergePartition struct {
+	Lower []byte
+	Upper []byte
+}
+
+// Contains returns true if the given key is within [Lower, Upper).
+func (p MergePartition) Contains(comp skiplist.Comparator[[]byte], key []byte) bool {
+	return (p.Lower == nil || comp.Compare(p.Lower, key) <= 0) && (p.Upper == nil || comp.Compare(key, p.Upper) < 0)
+}
+
+// clip returns the parts of the tombstones that are within the partition.
+func (p MergePartition) clip(comp skiplist.Comparator[[]byte], tombstones RangeTombstones) RangeTombstones {
+	var clipped RangeTombstones
+	for _, t := range tombstones {
+		start, end := t.Start, t.End
+		if p.Lower != nil && comp.Compare(start, p.Lower) < 0 {
+			start = p.Lower
+		}
+		if p.Upper != nil && comp.Compare(end, p.Upper) > 0 {
+			end = p.Upper
+		}
+		if comp.Compare(start, end) < 0 {
+			clipped = append(clipped, RangeTombstone{Start: start, End: end})
+		}
+	}
+	return clipped
+}
+
+// scan returns an iterator over all keys of the reader within the partition.
+func (p MergePartition) scan(comp skiplist.Comparator[[]byte], reader SSTableReaderI) (SSTableIteratorI, error) {
+	var iterator SSTableIteratorI
+	var err error
+	if p.Lower == nil {
+		iterator, err = reader.Scan()
+	} else {
+		iterator, err = reader.ScanStartingAt(p.Lower)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Upper == nil {
+		return iterator, nil
+	}
+	return &upperBoundIterator{iterator: iterator, comp: comp, upper: p.Upper}, nil
+}
+
+// upperBoundIterator stops the underlying iterator at the first key that is not lower than the upper bound.
+type upperBoundIterator struct {
+	iterator SSTableIteratorI
+	comp     skiplist.Comparator[[]byte]
+	upper    []byte
+	done     bool
+}
+
+func (it *upperBoundIterator) Next() ([]byte, []byte, error) {
+	if it.done {
+		return nil, nil, Done
+	}
+
+	k, v, err := it.iterator.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if it.comp.Compare(k, it.upper) >= 0 {
+		it.done = true
+		return nil, nil, Done
+	}
+	return k, v, nil
+}
+
+// MergePartitions samples the readers and splits their keys into up to n partitions of roughly equal data size,
+// the partitions are returned in the order of their key ranges. At least a single unbounded partition is returned.
+func MergePartitions(comp skiplist.Comparator[[]byte], readers []SSTableReaderI, n int) ([]MergePartition, error) {
+	superReader, err := NewCheckedSuperSSTableReader(readers, comp)
+	if err != nil {
+		return nil, err
+	}
+
+	boundaries, err := superReader.KeyQuantiles(n)
+	if err != nil {
+		return nil, fmt.Errorf("error while sampling the partition boundaries: %w", err)
+	}
+
+	var partitions []MergePartition
+	var lower []byte
+	for _, boundary := range boundaries {
+		// an empty key can't be the upper bound of a non-empty partition
+		if len(boundary) == 0 || (lower != nil && comp.Compare(lower, boundary) >= 0) {
+			continue
+		}
+		partitions = append(partitions, MergePartition{Lower: lower, Upper: boundary})
+		lower = boundary
+	}
+
+	return append(partitions, MergePartition{Lower: lower}), nil
+}
+
+// MergeCompactPartitioned is MergeCompact split by key range: the keys of the readers are partitioned by MergePartitions
+// into up to len(writers) partitions, which are merged concurrently, each into its own already opened writer.
+// The context of each reader is its index, so the readers need to be ordered from the oldest to the newest table.
+// The range tombstones of the readers are clipped to the key range of each partition, so every part of them ends up in
+// exactly one writer. Writers without a partition stay empty, the caller needs to close all writers.
+// Since the partitions are merged concurrently, the ReduceFunc must be safe to be called from multiple goroutines.
+func (m SSTableMerger) MergeCompactPartitioned(readers []SSTableReaderI, writers []SSTableStreamWriterI, reduce ReduceFunc) error {
+	return mergeCompactPartitioned(m.comp, readers, writers, func(iterators []SSTableMergeIteratorContext, writer SSTableStreamWriterI) error {
+		return m.MergeCompact(iterators, writer, reduce)
+	})
+}
+
+// MergeCompactPartitioned is like SSTableMerger.MergeCompactPartitioned, but every partition is merged by the
+// ParallelSSTableMerger. Thus, all readers are read ahead concurrently within every partition.
+func (m ParallelSSTableMerger) MergeCompactPartitioned(readers []SSTableReaderI, writers []SSTableStreamWriterI, reduce ReduceFunc) error {
+	return mergeCompactPartitioned(m.comp, readers, writers, func(iterators []SSTableMergeIteratorContext, writer SSTableStreamWriterI) error {
+		return m.MergeCompact(iterators, writer, reduce)
+	})
+}
+
+// mergeCompactPartitioned partitions the readers and calls mergeCompact for the iterators of every partition
+// concurrently, the i-th partition is written into the i-th writer.
+func mergeCompactPartitioned(comp skiplist.Comparator[[]byte], readers []SSTableReaderI, writers []SSTableStreamWriterI,
+	mergeCompact func([]SSTableMergeIteratorContext, SSTableStreamWriterI) error) error {
+	if len(writers) == 0 {
+		return errors.New("partitioned merge compact requires at least one writer")
+	}
+
+	partitions, err := MergePartitions(comp, readers, len(writers))
+	if err != nil {
+		return fmt.Errorf("partitioned merge compact error while partitioning: %w", err)
+	}
+
+	var partitionIterators [][]SSTableMergeIteratorContext
+	for _, partition := range partitions {
+		var iterators []SSTableMergeIteratorContext
+		for i, reader := range readers {
+			iterator, err := partition.scan(comp, reader)
+			if err != nil {
+				return fmt.Errorf("partitioned merge compact error while scanning reader %d: %w", i, err)
+			}
+			iterators = append(iterators, SSTableMergeIteratorContext{
+				ctx:             i,
+				iterator:        iterator,
+				rangeTombstones: partition.clip(comp, reader.RangeTombstones()),
+				comparatorName:  reader.MetaData().ComparatorName,
+				mergeOperands:   reader.MetaData().MergeOperands,
+				expiringValues:  readsEncodedExpiry(reader),
+			})
+		}
+		partitionIterators = append(partitionIterators, iterators)
+	}
+
+	errs := make([]error, len(partitions))
+	var wg sync.WaitGroup
+	for i := range partitions {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := mergeCompact(partitionIterators[i], writers[i])
+			if err != nil {
+				errs[i] = fmt.Errorf("partition %d: %w", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,188 @@
+package sstables
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+// writePartitionTestTables writes numTables tables with overlapping ranges of integers, the tombstones are written
+// into the table with the same index.
+func writePartitionTestTables(t *testing.T, numTables int, tombstones map[int]RangeTombstones) ([]SSTableReaderI, func()) {
+	var readers []SSTableReaderI
+	var writers []*SSTableStreamWriter
+	for i := 0; i < numTables; i++ {
+		writer, err := newTestSSTableStreamWriter()
+		require.Nil(t, err)
+		writers = append(writers, writer)
+		require.NoError(t, writer.Open())
+		for _, tombstone := range tombstones[i] {
+			require.NoError(t, writer.WriteRangeTombstone(tombstone.Start, tombstone.End))
+		}
+		for k := i * 100; k < i*100+500; k++ {
+			require.NoError(t, writer.WriteNext(intToByteSlice(k), intToByteSlice(k+i)))
+		}
+		require.NoError(t, writer.Close())
+
+		reader, err := NewSSTableReader(ReadBasePath(writer.opts.basePath), ReadWithKeyComparator(skiplist.BytesComparator{}))
+		require.Nil(t, err)
+		readers = append(readers, reader)
+	}
+
+	return readers, func() {
+		for i := range readers {
+			closeReader(t, readers[i])
+			cleanWriterDir(t, writers[i])
+		}
+	}
+}
+
+func TestMergePartitionsCoverAllKeys(t *testing.T) {
+	readers, cleanup := writePartitionTestTables(t, 3, nil)
+	defer cleanup()
+
+	partitions, err := MergePartitions(skiplist.BytesComparator{}, readers, 4)
+	require.Nil(t, err)
+	require.Equal(t, 4, len(partitions))
+	assert.Nil(t, partitions[0].Lower)
+	assert.Nil(t, partitions[len(partitions)-1].Upper)
+	for i := 1; i < len(partitions); i++ {
+		assert.Equal(t, partitions[i-1].Upper, partitions[i].Lower)
+		if i > 1 {
+			assert.Less(t, skiplist.BytesComparator{}.Compare(partitions[i-1].Lower, partitions[i].Lower), 0)
+		}
+	}
+
+	partitions, err = MergePartitions(skiplist.BytesComparator{}, readers, 1)
+	require.Nil(t, err)
+	assert.Equal(t, []MergePartition{{}}, partitions)
+}
+
+func TestMergePartitionClipsTombstones(t *testing.T) {
+	comp := skiplist.BytesComparator{}
+	p := MergePartition{Lower: []byte("c"), Upper: []byte("f")}
+	tombstones := RangeTombstones{
+		{Start: []byte("a"), End: []byte("b")},
+		{Start: []byte("a"), End: []byte("d")},
+		{Start: []byte("d"), End: []byte("e")},
+		{Start: []byte("e"), End: []byte("z")},
+		{Start: []byte("f"), End: []byte("z")},
+	}
+
+	assert.Equal(t, RangeTombstones{
+		{Start: []byte("c"), End: []byte("d")},
+		{Start: []byte("d"), End: []byte("e")},
+		{Start: []byte("e"), End: []byte("f")},
+	}, p.clip(comp, tombstones))
+	assert.Equal(t, tombstones, MergePartition{}.clip(comp, tombstones))
+
+	assert.True(t, p.Contains(comp, []byte("c")))
+	assert.True(t, p.Contains(comp, []byte("ezz")))
+	assert.False(t, p.Contains(comp, []byte("f")))
+	assert.False(t, p.Contains(comp, []byte("b")))
+}
+
+func TestMergeCompactPartitionedMatchesMergeCompact(t *testing.T) {
+	assertMergeCompactPartitionedMatchesMergeCompact(t, NewSSTableMerger(skiplist.BytesComparator{}).MergeCompactPartitioned)
+}
+
+func TestParallelMergeCompactPartitionedMatchesMergeCompact(t *testing.T) {
+	merger := NewParallelSSTableMerger(skiplist.BytesComparator{}, MergeBatchSize(7))
+	assertMergeCompactPartitionedMatchesMergeCompact(t, merger.MergeCompactPartitioned)
+}
+
+func assertMergeCompactPartitionedMatchesMergeCompact(t *testing.T,
+	mergeCompactPartitioned func([]SSTableReaderI, []SSTableStreamWriterI, ReduceFunc) error) {
+	tombstones := map[int]RangeTombstones{
+		1: {{Start: intToByteSlice(10), End: intToByteSlice(40)}},
+		3: {{Start: intToByteSlice(150), End: intToByteSlice(420)}},
+	}
+	readers, cleanup := writePartitionTestTables(t, 4, tombstones)
+	defer cleanup()
+
+	merger := NewSSTableMerger(skiplist.BytesComparator{})
+
+	var iterators []SSTableMergeIteratorContext
+	for i, reader := range readers {
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		iterators = append(iterators, NewReaderMergeIteratorContext(i, it, reader))
+	}
+	expectedWriter, err := newTestSSTableStreamWriter()
+	require.Nil(t, err)
+	defer cleanWriterDir(t, expectedWriter)
+	require.NoError(t, expectedWriter.Open())
+	require.NoError(t, merger.MergeCompact(iterators, expectedWriter, ScanReduceLatestWins))
+	require.NoError(t, expectedWriter.Close())
+
+	var writers []SSTableStreamWriterI
+	var streamWriters []*SSTableStreamWriter
+	for i := 0; i < 5; i++ {
+		writer, err := newTestSSTableStreamWriter()
+		require.Nil(t, err)
+		defer cleanWriterDir(t, writer)
+		require.NoError(t, writer.Open())
+		writers = append(writers, writer)
+		streamWriters = append(streamWriters, writer)
+	}
+	require.NoError(t, mergeCompactPartitioned(readers, writers, ScanReduceLatestWins))
+
+	var actualKeys, actualValues [][]byte
+	var actualTombstones []RangeTombstone
+	nonEmpty := 0
+	for _, writer := range streamWriters {
+		require.NoError(t, writer.Close())
+		reader, err := NewSSTableReader(ReadBasePath(writer.opts.basePath))
+		require.Nil(t, err)
+		if reader.MetaData().NumRecords > 0 {
+			nonEmpty++
+		}
+		actualTombstones = append(actualTombstones, reader.RangeTombstones()...)
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		for {
+			k, v, err := it.Next()
+			if errors.Is(err, Done) {
+				break
+			}
+			require.Nil(t, err)
+			actualKeys = append(actualKeys, k)
+			actualValues = append(actualValues, v)
+		}
+		closeReader(t, reader)
+	}
+	assert.Greater(t, nonEmpty, 1)
+
+	expected, err := NewSSTableReader(ReadBasePath(expectedWriter.opts.basePath))
+	require.Nil(t, err)
+	defer closeReader(t, expected)
+	assert.Equal(t, expected.RangeTombstones(), mergeRangeTombstones(skiplist.BytesComparator{}, actualTombstones))
+
+	it, err := expected.Scan()
+	require.Nil(t, err)
+	var expectedKeys, expectedValues [][]byte
+	for {
+		k, v, err := it.Next()
+		if errors.Is(err, Done) {
+			break
+		}
+		require.Nil(t, err)
+		expectedKeys = append(expectedKeys, k)
+		expectedValues = append(expectedValues, v)
+	}
+	assert.Equal(t, expectedKeys, actualKeys)
+	assert.Equal(t, expectedValues, actualValues)
+}
+
+func TestMergeCompactPartitionedRequiresWriters(t *testing.T) {
+	readers, cleanup := writePartitionTestTables(t, 1, nil)
+	defer cleanup()
+
+	err := NewSSTableMerger(skiplist.BytesComparator{}).MergeCompactPartitioned(readers, nil, ScanReduceLatestWins)
+	assert.Error(t, err)
+	err = NewParallelSSTableMerger(skiplist.BytesComparator{}).MergeCompactPartitioned(readers, nil, ScanReduceLatestWins)
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package sstables
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// PrefixExtractor derives a prefix from a key, which is additionally added to the bloom filter of a sstable.
+// This allows ScanPrefix to skip tables that can not contain any key with a given prefix.
+type PrefixExtractor interface {
+	// Name identifies the extractor, it is persisted in the metadata to ensure readers are using the same extractor.
+	Name() string
+	// InDomain returns true if a prefix can be extracted from the given key.
+	InDomain(key []byte) bool
+	// Transform returns the prefix of the given key, only called when InDomain returned true.
+	Transform(key []byte) []byte
+}
+
+// FixedPrefixExtractor uses the first Length bytes of a key as the prefix. Keys shorter than that are not in its domain.
+type FixedPrefixExtractor struct {
+	Length int
+}
+
+func (f FixedPrefixExtractor) Name() string {
+	return fmt.Sprintf("fixed:%d", f.Length)
+}
+
+func (f FixedPrefixExtractor) InDomain(key []byte) bool {
+	return len(key) >= f.Length
+}
+
+func (f FixedPrefixExtractor) Transform(key []byte) []byte {
+	return key[:f.Length]
+}
+
+// isExtractedPrefix returns true if the given prefix is exactly what the extractor produces for it,
+// only then the bloom filter can be consulted for it.
+func isExtractedPrefix(extractor PrefixExtractor, prefix []byte) bool {
+	return extractor.InDomain(prefix) && bytes.Equal(extractor.Transform(prefix), prefix)
+}
+
+// PrefixIterator wraps an iterator and stops once a key does not share the given prefix anymore.
+// This assumes a comparator that keeps all keys with the same prefix adjacent, like skiplist.BytesComparator.
+type PrefixIterator struct {
+	prefix   []byte
+	iterator SSTableIteratorI
+	done     bool
+}
+
+func (it *PrefixIterator) Next() ([]byte, []byte, error) {
+	if it.done {
+		return nil, nil, Done
+	}
+
+	k, v, err := it.iterator.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !bytes.HasPrefix(k, it.prefix) {
+		it.done = true
+		return nil, nil, Done
+	}
+
+	return k, v, nil
+}
+
+func newPrefixIterator(prefix []byte, iterator SSTableIteratorI) SSTableIteratorI {
+	return &PrefixIterator{prefix: prefix, iterator: iterator}
+}
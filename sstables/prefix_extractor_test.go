@@ -0,0 +1,171 @@
+package sstables
+
+import (
+	"os"
+	"testing"
+
+	"github.com/steakknife/bloomfilter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func TestFixedPrefixExtractor(t *testing.T) {
+	extractor := FixedPrefixExtractor{Length: 2}
+	assert.Equal(t, "fixed:2", extractor.Name())
+	assert.False(t, extractor.InDomain([]byte{1}))
+	assert.True(t, extractor.InDomain([]byte{1, 2}))
+	assert.Equal(t, []byte{1, 2}, extractor.Transform([]byte{1, 2, 3}))
+
+	assert.True(t, isExtractedPrefix(extractor, []byte{1, 2}))
+	assert.False(t, isExtractedPrefix(extractor, []byte{1}))
+	assert.False(t, isExtractedPrefix(extractor, []byte{1, 2, 3}))
+}
+
+func TestScanPrefixWithExtractor(t *testing.T) {
+	path := writePrefixTestTable(t, WithPrefixExtractor(FixedPrefixExtractor{Length: 1}))
+	defer func() { require.NoError(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(
+		ReadBasePath(path),
+		ReadWithPrefixExtractor(FixedPrefixExtractor{Length: 1}))
+	require.NoError(t, err)
+	defer closeReader(t, reader)
+	assert.Equal(t, "fixed:1", reader.MetaData().PrefixExtractor)
+
+	it, err := reader.ScanPrefix([]byte("b"))
+	require.NoError(t, err)
+	assertIteratorMatchesKeys(t, it, []string{"ba", "bb", "bc"})
+
+	it, err = reader.ScanPrefix([]byte("bb"))
+	require.NoError(t, err)
+	assertIteratorMatchesKeys(t, it, []string{"bb"})
+
+	// the bloom filter rules out the prefix entirely
+	it, err = reader.ScanPrefix([]byte("x"))
+	require.NoError(t, err)
+	assert.Equal(t, EmptySSTableIterator{}, it)
+
+	it, err = reader.ScanPrefix([]byte{})
+	require.NoError(t, err)
+	assertIteratorMatchesKeys(t, it, []string{"aa", "ab", "ba", "bb", "bc", "d"})
+}
+
+func TestPrefixExtractorSizesBloomFilterForPrefixes(t *testing.T) {
+	newWriter := func(writerOptions ...WriterOption) *SSTableStreamWriter {
+		path, err := os.MkdirTemp("", "sstables_PrefixBloomSize")
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, os.RemoveAll(path)) })
+
+		writerOptions = append(writerOptions, WriteBasePath(path), WithKeyComparator(skiplist.BytesComparator{}),
+			BloomExpectedNumberOfElements(1000))
+		writer, err := NewSSTableStreamWriter(writerOptions...)
+		require.NoError(t, err)
+		require.NoError(t, writer.Open())
+		require.NoError(t, writer.Close())
+		return writer
+	}
+
+	expected, err := bloomfilter.NewOptimal(2000, 0.01)
+	require.NoError(t, err)
+	assert.Equal(t, expected.M(), newWriter(WithPrefixExtractor(FixedPrefixExtractor{Length: 1})).bloomFilter.M())
+	assert.Less(t, newWriter().bloomFilter.M(), expected.M())
+}
+
+func TestScanPrefixWithoutExtractor(t *testing.T) {
+	path := writePrefixTestTable(t)
+	defer func() { require.NoError(t, os.RemoveAll(path)) }()
+
+	// the reader's extractor is ignored, since the table was not written with it
+	reader, err := NewSSTableReader(
+		ReadBasePath(path),
+		ReadWithPrefixExtractor(FixedPrefixExtractor{Length: 1}))
+	require.NoError(t, err)
+	defer closeReader(t, reader)
+	assert.Equal(t, "", reader.MetaData().PrefixExtractor)
+
+	it, err := reader.ScanPrefix([]byte("a"))
+	require.NoError(t, err)
+	assertIteratorMatchesKeys(t, it, []string{"aa", "ab"})
+
+	it, err = reader.ScanPrefix([]byte("x"))
+	require.NoError(t, err)
+	assertIteratorMatchesKeys(t, it, []string{})
+
+	it, err = reader.ScanPrefix([]byte("c"))
+	require.NoError(t, err)
+	assertIteratorMatchesKeys(t, it, []string{})
+}
+
+func TestSuperScanPrefix(t *testing.T) {
+	extractor := FixedPrefixExtractor{Length: 1}
+	path := writePrefixTestTable(t, WithPrefixExtractor(extractor))
+	defer func() { require.NoError(t, os.RemoveAll(path)) }()
+
+	otherPath, err := os.MkdirTemp("", "sstables_PrefixScan")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(otherPath)) }()
+	writer, err := NewSSTableStreamWriter(
+		WriteBasePath(otherPath),
+		WithKeyComparator(skiplist.BytesComparator{}),
+		WithPrefixExtractor(extractor))
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	require.NoError(t, writer.WriteNext([]byte("bb"), []byte("newer")))
+	require.NoError(t, writer.WriteNext([]byte("bd"), []byte("newer")))
+	require.NoError(t, writer.Close())
+
+	r1, err := NewSSTableReader(ReadBasePath(path), ReadWithPrefixExtractor(extractor))
+	require.NoError(t, err)
+	r2, err := NewSSTableReader(ReadBasePath(otherPath), ReadWithPrefixExtractor(extractor))
+	require.NoError(t, err)
+	reader := NewSuperSSTableReader([]SSTableReaderI{r1, r2}, skiplist.BytesComparator{})
+	defer closeReader(t, reader)
+
+	it, err := reader.ScanPrefix([]byte("b"))
+	require.NoError(t, err)
+	assertIteratorMatchesKeys(t, it, []string{"ba", "bb", "bc", "bd"})
+
+	v, err := reader.Get([]byte("bb"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("newer"), v)
+
+	it, err = reader.ScanPrefix([]byte("a"))
+	require.NoError(t, err)
+	assertIteratorMatchesKeys(t, it, []string{"aa", "ab"})
+
+	it, err = reader.ScanPrefix([]byte("x"))
+	require.NoError(t, err)
+	assert.Equal(t, EmptySSTableIterator{}, it)
+}
+
+func writePrefixTestTable(t *testing.T, opts ...WriterOption) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_PrefixScan")
+	require.NoError(t, err)
+
+	opts = append(opts, WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}))
+	writer, err := NewSSTableStreamWriter(opts...)
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	for _, k := range []string{"aa", "ab", "ba", "bb", "bc", "d"} {
+		require.NoError(t, writer.WriteNext([]byte(k), []byte(k)))
+	}
+	require.NoError(t, writer.Close())
+	return tmpDir
+}
+
+func assertIteratorMatchesKeys(t *testing.T, it SSTableIteratorI, expectedKeys []string) {
+	var actual []string
+	for {
+		k, _, err := it.Next()
+		if err == Done {
+			break
+		}
+		require.NoError(t, err)
+		actual = append(actual, string(k))
+	}
+	assert.Equal(t, len(expectedKeys), len(actual))
+	for i, k := range expectedKeys {
+		assert.Equal(t, k, actual[i])
+	}
+}
@@ -0,0 +1,39 @@
+package sstables
+
+// PropertiesCollector gathers user-defined properties while a table is written, the result is stored in the metadata
+// and can be retrieved with SSTableReaderI.Properties.
+type PropertiesCollector interface {
+	// Add is called for every key and value in the order they are passed to WriteNext.
+	Add(key []byte, value []byte) error
+	// Finish is called when the writer is closed and returns the properties to store.
+	Finish() (map[string][]byte, error)
+}
+
+// PropertiesCollectorFactory creates a new collector for every table that is written, that allows the same writer
+// options to be used for multiple tables, for example with the RollingSSTableWriter.
+type PropertiesCollectorFactory func() PropertiesCollector
+
+// collectProperties merges the static properties with the results of all collectors.
+// Collectors take precedence over static properties and later collectors over earlier ones.
+func collectProperties(static map[string][]byte, collectors []PropertiesCollector) (map[string][]byte, error) {
+	if len(static) == 0 && len(collectors) == 0 {
+		return nil, nil
+	}
+
+	properties := make(map[string][]byte, len(static))
+	for k, v := range static {
+		properties[k] = v
+	}
+
+	for _, collector := range collectors {
+		collected, err := collector.Finish()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range collected {
+			properties[k] = v
+		}
+	}
+
+	return properties, nil
+}
@@ -0,0 +1,165 @@
+package sstables
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+// maxValueSizeCollector records the size of the largest value of a table.
+type maxValueSizeCollector struct {
+	maxSize uint64
+	err     error
+}
+
+func (c *maxValueSizeCollector) Add(_ []byte, value []byte) error {
+	if uint64(len(value)) > c.maxSize {
+		c.maxSize = uint64(len(value))
+	}
+	return c.err
+}
+
+func (c *maxValueSizeCollector) Finish() (map[string][]byte, error) {
+	return map[string][]byte{"max_value_size": binary.BigEndian.AppendUint64(nil, c.maxSize)}, nil
+}
+
+func newMaxValueSizeCollector() PropertiesCollector {
+	return &maxValueSizeCollector{}
+}
+
+func writePropertiesTestTable(t *testing.T, keys [][]byte, values [][]byte, opts ...WriterOption) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_Properties")
+	require.Nil(t, err)
+
+	writer, err := NewSSTableStreamWriter(append(opts, WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}))...)
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for i := range keys {
+		require.Nil(t, writer.WriteNext(keys[i], values[i]))
+	}
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+func TestPropertiesAndStatistics(t *testing.T) {
+	before := time.Now().UnixMilli()
+	path := writePropertiesTestTable(t,
+		[][]byte{[]byte("a"), []byte("bb"), []byte("ccc")},
+		[][]byte{[]byte("1"), []byte("1234"), nil},
+		WithProperties(map[string][]byte{"job": []byte("42"), "max_value_size": []byte("overwritten")}),
+		WithPropertiesCollector(newMaxValueSizeCollector))
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	assert.Equal(t, map[string][]byte{
+		"job":            []byte("42"),
+		"max_value_size": binary.BigEndian.AppendUint64(nil, 4),
+	}, reader.Properties())
+
+	metaData := reader.MetaData()
+	assert.Equal(t, uint64(6), metaData.KeyBytes)
+	assert.Equal(t, uint64(5), metaData.ValueBytes)
+	assert.Equal(t, uint64(0), metaData.MinSequence)
+	assert.Equal(t, uint64(0), metaData.MaxSequence)
+	assert.GreaterOrEqual(t, metaData.CreationTime, before)
+	assert.LessOrEqual(t, metaData.CreationTime, time.Now().UnixMilli())
+}
+
+func TestPropertiesWithoutAnyProperties(t *testing.T) {
+	path := writePropertiesTestTable(t, [][]byte{[]byte("a")}, [][]byte{[]byte("1")})
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.Nil(t, reader.Properties())
+}
+
+func TestPropertiesSequenceRange(t *testing.T) {
+	path := writePropertiesTestTable(t,
+		[][]byte{
+			EncodeInternalKey([]byte("a"), 7, ValueKindPut),
+			EncodeInternalKey([]byte("a"), 3, ValueKindPut),
+			EncodeInternalKey([]byte("b"), 5, ValueKindDelete),
+		},
+		[][]byte{[]byte("1"), []byte("2"), nil},
+		WithInternalKeys())
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.Equal(t, uint64(3), reader.MetaData().MinSequence)
+	assert.Equal(t, uint64(7), reader.MetaData().MaxSequence)
+}
+
+func TestPropertiesCollectorError(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sstables_PropertiesError")
+	require.Nil(t, err)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+
+	expectedErr := errors.New("collector failure")
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}),
+		WithPropertiesCollector(func() PropertiesCollector { return &maxValueSizeCollector{err: expectedErr} }))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	assert.ErrorIs(t, writer.WriteNext([]byte("a"), []byte("1")), expectedErr)
+	require.Nil(t, writer.Close())
+}
+
+func TestSuperReaderMergesProperties(t *testing.T) {
+	older := writePropertiesTestTable(t, [][]byte{[]byte("a")}, [][]byte{[]byte("1")},
+		WithProperties(map[string][]byte{"job": []byte("1"), "schema": []byte("v1")}))
+	defer func() { require.Nil(t, os.RemoveAll(older)) }()
+	newer := writePropertiesTestTable(t, [][]byte{[]byte("bb")}, [][]byte{[]byte("22")},
+		WithProperties(map[string][]byte{"job": []byte("2")}))
+	defer func() { require.Nil(t, os.RemoveAll(newer)) }()
+
+	var readers []SSTableReaderI
+	for _, p := range []string{older, newer} {
+		reader, err := NewSSTableReader(ReadBasePath(p))
+		require.Nil(t, err)
+		readers = append(readers, reader)
+	}
+	reader := NewSuperSSTableReader(readers, skiplist.BytesComparator{})
+	defer closeReader(t, reader)
+
+	expected := map[string][]byte{"job": []byte("2"), "schema": []byte("v1")}
+	assert.Equal(t, expected, reader.Properties())
+	assert.Equal(t, expected, reader.MetaData().Properties)
+	assert.Equal(t, uint64(3), reader.MetaData().KeyBytes)
+	assert.Equal(t, uint64(3), reader.MetaData().ValueBytes)
+}
+
+func TestRollingWriterCollectsPropertiesPerTable(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sstables_RollingProperties")
+	require.Nil(t, err)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+
+	writer, err := NewRollingSSTableWriter(RollingBasePath(tmpDir), RollAtKeyBoundaries([]byte("b")),
+		RollingTableOptions(WithKeyComparator(skiplist.BytesComparator{}), WithPropertiesCollector(newMaxValueSizeCollector)))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	require.Nil(t, writer.WriteNext([]byte("a"), []byte("12345")))
+	require.Nil(t, writer.WriteNext([]byte("b"), []byte("1")))
+	require.Nil(t, writer.Close())
+
+	tables, err := writer.Tables()
+	require.Nil(t, err)
+	require.Equal(t, 2, len(tables))
+	for i, expectedSize := range []uint64{5, 1} {
+		reader, err := NewSSTableReader(ReadBasePath(tables[i].BasePath))
+		require.Nil(t, err)
+		assert.Equal(t, binary.BigEndian.AppendUint64(nil, expectedSize), reader.Properties()["max_value_size"])
+		closeReader(t, reader)
+	}
+}
@@ -127,18 +127,34 @@ func (x *DataEntry) GetValue() []byte {
 }
 
 type MetaData struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	NumRecords     uint64                 `protobuf:"varint,1,opt,name=numRecords,proto3" json:"numRecords,omitempty"`
-	MinKey         []byte                 `protobuf:"bytes,2,opt,name=minKey,proto3" json:"minKey,omitempty"`
-	MaxKey         []byte                 `protobuf:"bytes,3,opt,name=maxKey,proto3" json:"maxKey,omitempty"`
-	DataBytes      uint64                 `protobuf:"varint,4,opt,name=dataBytes,proto3" json:"dataBytes,omitempty"`
-	IndexBytes     uint64                 `protobuf:"varint,5,opt,name=indexBytes,proto3" json:"indexBytes,omitempty"`
-	TotalBytes     uint64                 `protobuf:"varint,6,opt,name=totalBytes,proto3" json:"totalBytes,omitempty"`
-	Version        uint32                 `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"` // currently version 1, the default is version 0 with protos as values
-	SkippedRecords uint64                 `protobuf:"varint,8,opt,name=skippedRecords,proto3" json:"skippedRecords,omitempty"`
-	NullValues     uint64                 `protobuf:"varint,9,opt,name=nullValues,proto3" json:"nullValues,omitempty"` // in simpleDB that corresponds to the number of tombstones
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	NumRecords         uint64                 `protobuf:"varint,1,opt,name=numRecords,proto3" json:"numRecords,omitempty"`
+	MinKey             []byte                 `protobuf:"bytes,2,opt,name=minKey,proto3" json:"minKey,omitempty"`
+	MaxKey             []byte                 `protobuf:"bytes,3,opt,name=maxKey,proto3" json:"maxKey,omitempty"`
+	DataBytes          uint64                 `protobuf:"varint,4,opt,name=dataBytes,proto3" json:"dataBytes,omitempty"`
+	IndexBytes         uint64                 `protobuf:"varint,5,opt,name=indexBytes,proto3" json:"indexBytes,omitempty"`
+	TotalBytes         uint64                 `protobuf:"varint,6,opt,name=totalBytes,proto3" json:"totalBytes,omitempty"`
+	Version            uint32                 `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"` // currently version 1, the default is version 0 with protos as values
+	SkippedRecords     uint64                 `protobuf:"varint,8,opt,name=skippedRecords,proto3" json:"skippedRecords,omitempty"`
+	NullValues         uint64                 `protobuf:"varint,9,opt,name=nullValues,proto3" json:"nullValues,omitempty"`           // in simpleDB that corresponds to the number of tombstones
+	PrefixExtractor    string                 `protobuf:"bytes,10,opt,name=prefixExtractor,proto3" json:"prefixExtractor,omitempty"` // name of the prefix extractor whose prefixes were added to the bloom filter
+	NumRangeTombstones uint64                 `protobuf:"varint,11,opt,name=numRangeTombstones,proto3" json:"numRangeTombstones,omitempty"`
+	ValueSeparation    bool                   `protobuf:"varint,12,opt,name=valueSeparation,proto3" json:"valueSeparation,omitempty"`                                                                        // true if the values are encoded with a tag whether they are inline or a blob reference
+	BlobFileBytes      map[uint64]uint64      `protobuf:"bytes,13,rep,name=blobFileBytes,proto3" json:"blobFileBytes,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // blob file id to the number of value bytes that are referenced in it
+	InternalKeys       bool                   `protobuf:"varint,14,opt,name=internalKeys,proto3" json:"internalKeys,omitempty"`                                                                              // true if the keys are encoded as internal keys with sequence number and value kind
+	ComparatorName     string                 `protobuf:"bytes,15,opt,name=comparatorName,proto3" json:"comparatorName,omitempty"`                                                                           // name of the key comparator the keys are sorted with, its type name if it wasn't named
+	Properties         map[string][]byte      `protobuf:"bytes,16,rep,name=properties,proto3" json:"properties,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`         // user-defined properties, see WithProperties and WithPropertiesCollector
+	KeyBytes           uint64                 `protobuf:"varint,17,opt,name=keyBytes,proto3" json:"keyBytes,omitempty"`                                                                                      // sum of the sizes of all keys
+	ValueBytes         uint64                 `protobuf:"varint,18,opt,name=valueBytes,proto3" json:"valueBytes,omitempty"`                                                                                  // sum of the sizes of all values as they were written, before compression or value separation
+	MinSequence        uint64                 `protobuf:"varint,19,opt,name=minSequence,proto3" json:"minSequence,omitempty"`                                                                                // lowest sequence number of all internal keys, only set when internalKeys is true
+	MaxSequence        uint64                 `protobuf:"varint,20,opt,name=maxSequence,proto3" json:"maxSequence,omitempty"`                                                                                // highest sequence number of all internal keys, only set when internalKeys is true
+	CreationTime       int64                  `protobuf:"varint,21,opt,name=creationTime,proto3" json:"creationTime,omitempty"`                                                                              // unix timestamp in milliseconds when the table was opened for writing
+	FileChecksums      map[string]uint64      `protobuf:"bytes,22,rep,name=fileChecksums,proto3" json:"fileChecksums,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`  // crc-64 checksums of the files of the table by their name, except the metadata itself
+	MetaDataChecksum   uint64                 `protobuf:"fixed64,23,opt,name=metaDataChecksum,proto3" json:"metaDataChecksum,omitempty"`                                                                     // crc-64 checksum of all bytes of the metadata file before this field, which is always written last
+	MergeOperands      bool                   `protobuf:"varint,24,opt,name=mergeOperands,proto3" json:"mergeOperands,omitempty"`                                                                            // true if the values are encoded as MergeValue with their kind, see WithMergeOperands
+	ExpiringValues     bool                   `protobuf:"varint,25,opt,name=expiringValues,proto3" json:"expiringValues,omitempty"`                                                                          // true if the values are prefixed with their expiry time, see WithExpiringValues
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *MetaData) Reset() {
@@ -234,6 +250,171 @@ func (x *MetaData) GetNullValues() uint64 {
 	return 0
 }
 
+func (x *MetaData) GetPrefixExtractor() string {
+	if x != nil {
+		return x.PrefixExtractor
+	}
+	return ""
+}
+
+func (x *MetaData) GetNumRangeTombstones() uint64 {
+	if x != nil {
+		return x.NumRangeTombstones
+	}
+	return 0
+}
+
+func (x *MetaData) GetValueSeparation() bool {
+	if x != nil {
+		return x.ValueSeparation
+	}
+	return false
+}
+
+func (x *MetaData) GetBlobFileBytes() map[uint64]uint64 {
+	if x != nil {
+		return x.BlobFileBytes
+	}
+	return nil
+}
+
+func (x *MetaData) GetInternalKeys() bool {
+	if x != nil {
+		return x.InternalKeys
+	}
+	return false
+}
+
+func (x *MetaData) GetComparatorName() string {
+	if x != nil {
+		return x.ComparatorName
+	}
+	return ""
+}
+
+func (x *MetaData) GetProperties() map[string][]byte {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+func (x *MetaData) GetKeyBytes() uint64 {
+	if x != nil {
+		return x.KeyBytes
+	}
+	return 0
+}
+
+func (x *MetaData) GetValueBytes() uint64 {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return 0
+}
+
+func (x *MetaData) GetMinSequence() uint64 {
+	if x != nil {
+		return x.MinSequence
+	}
+	return 0
+}
+
+func (x *MetaData) GetMaxSequence() uint64 {
+	if x != nil {
+		return x.MaxSequence
+	}
+	return 0
+}
+
+func (x *MetaData) GetCreationTime() int64 {
+	if x != nil {
+		return x.CreationTime
+	}
+	return 0
+}
+
+func (x *MetaData) GetFileChecksums() map[string]uint64 {
+	if x != nil {
+		return x.FileChecksums
+	}
+	return nil
+}
+
+func (x *MetaData) GetMetaDataChecksum() uint64 {
+	if x != nil {
+		return x.MetaDataChecksum
+	}
+	return 0
+}
+
+func (x *MetaData) GetMergeOperands() bool {
+	if x != nil {
+		return x.MergeOperands
+	}
+	return false
+}
+
+func (x *MetaData) GetExpiringValues() bool {
+	if x != nil {
+		return x.ExpiringValues
+	}
+	return false
+}
+
+// deletes all keys in the range [start, end) of older sstables
+type RangeTombstone struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         []byte                 `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           []byte                 `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeTombstone) Reset() {
+	*x = RangeTombstone{}
+	mi := &file_sstables_proto_sstable_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeTombstone) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeTombstone) ProtoMessage() {}
+
+func (x *RangeTombstone) ProtoReflect() protoreflect.Message {
+	mi := &file_sstables_proto_sstable_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeTombstone.ProtoReflect.Descriptor instead.
+func (*RangeTombstone) Descriptor() ([]byte, []int) {
+	return file_sstables_proto_sstable_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RangeTombstone) GetStart() []byte {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *RangeTombstone) GetEnd() []byte {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
 var File_sstables_proto_sstable_proto protoreflect.FileDescriptor
 
 var file_sstables_proto_sstable_proto_rawDesc = string([]byte{
@@ -247,7 +428,7 @@ var file_sstables_proto_sstable_proto_rawDesc = string([]byte{
 	0x73, 0x75, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b,
 	0x73, 0x75, 0x6d, 0x22, 0x21, 0x0a, 0x09, 0x44, 0x61, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79,
 	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x9a, 0x02, 0x0a, 0x08, 0x4d, 0x65, 0x74, 0x61, 0x44,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0xa0, 0x09, 0x0a, 0x08, 0x4d, 0x65, 0x74, 0x61, 0x44,
 	0x61, 0x74, 0x61, 0x12, 0x1e, 0x0a, 0x0a, 0x6e, 0x75, 0x6d, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
 	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x6e, 0x75, 0x6d, 0x52, 0x65, 0x63, 0x6f,
 	0x72, 0x64, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x69, 0x6e, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20,
@@ -265,7 +446,67 @@ var file_sstables_proto_sstable_proto_rawDesc = string([]byte{
 	0x01, 0x28, 0x04, 0x52, 0x0e, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x52, 0x65, 0x63, 0x6f,
 	0x72, 0x64, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6e, 0x75, 0x6c, 0x6c, 0x56, 0x61, 0x6c, 0x75, 0x65,
 	0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x6e, 0x75, 0x6c, 0x6c, 0x56, 0x61, 0x6c,
-	0x75, 0x65, 0x73, 0x42, 0x36, 0x5a, 0x34, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x75, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x0f, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x45, 0x78, 0x74,
+	0x72, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x70, 0x72,
+	0x65, 0x66, 0x69, 0x78, 0x45, 0x78, 0x74, 0x72, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x2e, 0x0a,
+	0x12, 0x6e, 0x75, 0x6d, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f,
+	0x6e, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52, 0x12, 0x6e, 0x75, 0x6d, 0x52, 0x61,
+	0x6e, 0x67, 0x65, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x73, 0x12, 0x28, 0x0a,
+	0x0f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x53, 0x65, 0x70, 0x61, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x53, 0x65, 0x70,
+	0x61, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x48, 0x0a, 0x0d, 0x62, 0x6c, 0x6f, 0x62, 0x46,
+	0x69, 0x6c, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61, 0x2e,
+	0x42, 0x6c, 0x6f, 0x62, 0x46, 0x69, 0x6c, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x0d, 0x62, 0x6c, 0x6f, 0x62, 0x46, 0x69, 0x6c, 0x65, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x12, 0x22, 0x0a, 0x0c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x4b, 0x65, 0x79,
+	0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61,
+	0x6c, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x26, 0x0a, 0x0e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x61,
+	0x74, 0x6f, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63,
+	0x6f, 0x6d, 0x70, 0x61, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x3f, 0x0a,
+	0x0a, 0x70, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x69, 0x65, 0x73, 0x18, 0x10, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61,
+	0x74, 0x61, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x69, 0x65, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x69, 0x65, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x08, 0x6b, 0x65, 0x79, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x12, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x6d, 0x69,
+	0x6e, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x0b, 0x6d, 0x69, 0x6e, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x0b,
+	0x6d, 0x61, 0x78, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x14, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x22,
+	0x0a, 0x0c, 0x63, 0x72, 0x65, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x15,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x63, 0x72, 0x65, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x69,
+	0x6d, 0x65, 0x12, 0x48, 0x0a, 0x0d, 0x66, 0x69, 0x6c, 0x65, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x73,
+	0x75, 0x6d, 0x73, 0x18, 0x16, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x43,
+	0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d, 0x66,
+	0x69, 0x6c, 0x65, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x73, 0x12, 0x2a, 0x0a, 0x10,
+	0x6d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d,
+	0x18, 0x17, 0x20, 0x01, 0x28, 0x06, 0x52, 0x10, 0x6d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61,
+	0x43, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x12, 0x24, 0x0a, 0x0d, 0x6d, 0x65, 0x72, 0x67,
+	0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x6e, 0x64, 0x73, 0x18, 0x18, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0d, 0x6d, 0x65, 0x72, 0x67, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x6e, 0x64, 0x73, 0x12, 0x26,
+	0x0a, 0x0e, 0x65, 0x78, 0x70, 0x69, 0x72, 0x69, 0x6e, 0x67, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73,
+	0x18, 0x19, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x65, 0x78, 0x70, 0x69, 0x72, 0x69, 0x6e, 0x67,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x1a, 0x40, 0x0a, 0x12, 0x42, 0x6c, 0x6f, 0x62, 0x46, 0x69,
+	0x6c, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3d, 0x0a, 0x0f, 0x50, 0x72, 0x6f, 0x70,
+	0x65, 0x72, 0x74, 0x69, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x40, 0x0a, 0x12, 0x46, 0x69, 0x6c, 0x65, 0x43,
+	0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x38, 0x0a, 0x0e, 0x52, 0x61, 0x6e,
+	0x67, 0x65, 0x54, 0x6f, 0x6d, 0x62, 0x73, 0x74, 0x6f, 0x6e, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
+	0x65, 0x6e, 0x64, 0x42, 0x36, 0x5a, 0x34, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
 	0x6d, 0x2f, 0x74, 0x68, 0x6f, 0x6d, 0x61, 0x73, 0x6a, 0x75, 0x6e, 0x67, 0x62, 0x6c, 0x75, 0x74,
 	0x2f, 0x67, 0x6f, 0x2d, 0x73, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x2f, 0x73, 0x73, 0x74,
 	0x61, 0x62, 0x6c, 0x65, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f,
@@ -284,18 +525,25 @@ func file_sstables_proto_sstable_proto_rawDescGZIP() []byte {
 	return file_sstables_proto_sstable_proto_rawDescData
 }
 
-var file_sstables_proto_sstable_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_sstables_proto_sstable_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_sstables_proto_sstable_proto_goTypes = []any{
-	(*IndexEntry)(nil), // 0: proto.IndexEntry
-	(*DataEntry)(nil),  // 1: proto.DataEntry
-	(*MetaData)(nil),   // 2: proto.MetaData
+	(*IndexEntry)(nil),     // 0: proto.IndexEntry
+	(*DataEntry)(nil),      // 1: proto.DataEntry
+	(*MetaData)(nil),       // 2: proto.MetaData
+	(*RangeTombstone)(nil), // 3: proto.RangeTombstone
+	nil,                    // 4: proto.MetaData.BlobFileBytesEntry
+	nil,                    // 5: proto.MetaData.PropertiesEntry
+	nil,                    // 6: proto.MetaData.FileChecksumsEntry
 }
 var file_sstables_proto_sstable_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	4, // 0: proto.MetaData.blobFileBytes:type_name -> proto.MetaData.BlobFileBytesEntry
+	5, // 1: proto.MetaData.properties:type_name -> proto.MetaData.PropertiesEntry
+	6, // 2: proto.MetaData.fileChecksums:type_name -> proto.MetaData.FileChecksumsEntry
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_sstables_proto_sstable_proto_init() }
@@ -309,7 +557,7 @@ func file_sstables_proto_sstable_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sstables_proto_sstable_proto_rawDesc), len(file_sstables_proto_sstable_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
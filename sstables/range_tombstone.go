@@ -0,0 +1,152 @@
+package sstables
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	rProto "github.com/thomasjungblut/go-sstables/recordio/proto"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+)
+
+var RangeTombstoneFileName = "range_tombstones.rio"
+
+// RangeTombstone deletes all keys in the range [Start, End). A tombstone only deletes the keys of older sstables,
+// the keys within the same sstable as the tombstone are considered to be newer and thus take precedence.
+type RangeTombstone struct {
+	Start []byte
+	End   []byte
+}
+
+// Covers returns true if the given key is within [Start, End).
+func (r RangeTombstone) Covers(comp skiplist.Comparator[[]byte], key []byte) bool {
+	return comp.Compare(r.Start, key) <= 0 && comp.Compare(key, r.End) < 0
+}
+
+// RangeTombstones is a sorted list of non-overlapping range tombstones.
+type RangeTombstones []RangeTombstone
+
+// Covers returns true if the given key is deleted by any of the tombstones.
+func (r RangeTombstones) Covers(comp skiplist.Comparator[[]byte], key []byte) bool {
+	// find the first tombstone that starts after the key, the one before it is the only candidate
+	i := sort.Search(len(r), func(i int) bool {
+		return comp.Compare(r[i].Start, key) > 0
+	})
+	if i == 0 {
+		return false
+	}
+	return r[i-1].Covers(comp, key)
+}
+
+// mergeRangeTombstones sorts the given tombstones and merges overlapping or adjacent ones into a single tombstone.
+func mergeRangeTombstones(comp skiplist.Comparator[[]byte], tombstones []RangeTombstone) RangeTombstones {
+	if len(tombstones) == 0 {
+		return nil
+	}
+
+	sorted := make([]RangeTombstone, len(tombstones))
+	copy(sorted, tombstones)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return comp.Compare(sorted[i].Start, sorted[j].Start) < 0
+	})
+
+	merged := RangeTombstones{sorted[0]}
+	for _, t := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if comp.Compare(t.Start, last.End) <= 0 {
+			if comp.Compare(t.End, last.End) > 0 {
+				last.End = t.End
+			}
+			continue
+		}
+		merged = append(merged, t)
+	}
+
+	return merged
+}
+
+func writeRangeTombstones(path string, tombstones RangeTombstones) (err error) {
+	writer, err := rProto.NewWriter(rProto.Path(path), rProto.WriteBufferSizeBytes(4096))
+	if err != nil {
+		return fmt.Errorf("error while creating range tombstone writer in '%s': %w", path, err)
+	}
+
+	err = writer.Open()
+	if err != nil {
+		return fmt.Errorf("error while opening range tombstone writer in '%s': %w", path, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, writer.Close())
+	}()
+
+	for _, t := range tombstones {
+		_, err = writer.Write(&proto.RangeTombstone{Start: t.Start, End: t.End})
+		if err != nil {
+			return fmt.Errorf("error while writing range tombstone in '%s': %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func readRangeTombstonesIfExists(path string) (tombstones RangeTombstones, err error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	reader, err := rProto.NewReader(rProto.ReaderPath(path), rProto.ReadBufferSizeBytes(4096))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating range tombstone reader in '%s': %w", path, err)
+	}
+
+	return readRangeTombstones(reader, path)
+}
+
+func readRangeTombstones(reader rProto.ReaderI, path string) (tombstones RangeTombstones, err error) {
+	err = reader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error while opening range tombstone reader in '%s': %w", path, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, reader.Close())
+	}()
+
+	for {
+		record := &proto.RangeTombstone{}
+		_, err := reader.ReadNext(record)
+		// io.EOF signals that no records are left to be read
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error while reading range tombstones in '%s': %w", path, err)
+		}
+
+		tombstones = append(tombstones, RangeTombstone{Start: record.Start, End: record.End})
+	}
+
+	return tombstones, nil
+}
+
+// contextRangeTombstones are the range tombstones of a merge context, e.g. the index of a reader in the SuperSSTableReader.
+type contextRangeTombstones struct {
+	ctx        int
+	tombstones RangeTombstones
+}
+
+// isRangeDeleted returns true if the key, which was read from the given context, is deleted by a range tombstone
+// of a newer (higher) context.
+func isRangeDeleted(comp skiplist.Comparator[[]byte], tombstones []contextRangeTombstones, key []byte, ctx int) bool {
+	for _, t := range tombstones {
+		if t.ctx > ctx && t.tombstones.Covers(comp, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package sstables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func TestMergeRangeTombstones(t *testing.T) {
+	comp := skiplist.BytesComparator{}
+	assert.Nil(t, mergeRangeTombstones(comp, nil))
+
+	merged := mergeRangeTombstones(comp, []RangeTombstone{
+		{Start: intToByteSlice(10), End: intToByteSlice(12)},
+		{Start: intToByteSlice(1), End: intToByteSlice(3)},
+		{Start: intToByteSlice(2), End: intToByteSlice(5)},
+		{Start: intToByteSlice(5), End: intToByteSlice(6)},
+		{Start: intToByteSlice(10), End: intToByteSlice(11)},
+	})
+	assert.Equal(t, RangeTombstones{
+		{Start: intToByteSlice(1), End: intToByteSlice(6)},
+		{Start: intToByteSlice(10), End: intToByteSlice(12)},
+	}, merged)
+
+	for i := 0; i < 15; i++ {
+		expected := (i >= 1 && i < 6) || (i >= 10 && i < 12)
+		assert.Equal(t, expected, merged.Covers(comp, intToByteSlice(i)), "key %d", i)
+	}
+
+	assert.False(t, RangeTombstones(nil).Covers(comp, intToByteSlice(1)))
+}
+
+func TestWriteRangeTombstoneInvalidRange(t *testing.T) {
+	writer, err := newTestSSTableStreamWriter()
+	require.Nil(t, err)
+	defer cleanWriterDir(t, writer)
+
+	// not opened yet
+	assert.Error(t, writer.WriteRangeTombstone(intToByteSlice(1), intToByteSlice(2)))
+
+	require.Nil(t, writer.Open())
+	assert.Error(t, writer.WriteRangeTombstone(intToByteSlice(2), intToByteSlice(2)))
+	assert.Error(t, writer.WriteRangeTombstone(intToByteSlice(3), intToByteSlice(2)))
+	require.Nil(t, writer.Close())
+}
+
+func TestReadRangeTombstones(t *testing.T) {
+	writer := writeRangeTombstoneTestTable(t, []int{4, 8}, [][2]int{{3, 6}, {20, 25}})
+	defer cleanWriterDir(t, writer)
+
+	reader, err := NewSSTableReader(ReadBasePath(writer.opts.basePath))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	assert.Equal(t, 2, int(reader.MetaData().NumRecords))
+	assert.Equal(t, 2, int(reader.MetaData().NumRangeTombstones))
+	assert.Equal(t, RangeTombstones{
+		{Start: intToByteSlice(3), End: intToByteSlice(6)},
+		{Start: intToByteSlice(20), End: intToByteSlice(25)},
+	}, reader.RangeTombstones())
+
+	// the keys within the same table are not deleted by its own tombstones
+	v, err := reader.Get(intToByteSlice(4))
+	require.Nil(t, err)
+	assert.Equal(t, intToByteSlice(5), v)
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	assertIteratorMatchesSlice(t, it, []int{4, 8})
+}
+
+func TestReadWithoutRangeTombstones(t *testing.T) {
+	reader, err := NewSSTableReader(ReadBasePath("test_files/SimpleWriteHappyPathSSTableWithMetaData"))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	assert.Nil(t, reader.RangeTombstones())
+	assert.Equal(t, 0, int(reader.MetaData().NumRangeTombstones))
+}
+
+func TestSuperReaderRangeTombstones(t *testing.T) {
+	newer := writeRangeTombstoneTestTable(t, []int{4, 8}, [][2]int{{3, 6}})
+	defer cleanWriterDir(t, newer)
+
+	reader := openRangeTombstoneTestSuperReader(t, "test_files/SimpleWriteHappyPathSSTableWithMetaData", newer.opts.basePath)
+	defer closeReader(t, reader)
+
+	for _, k := range []int{3, 5} {
+		_, err := reader.Get(intToByteSlice(k))
+		assert.Equal(t, NotFound, err)
+		contains, err := reader.Contains(intToByteSlice(k))
+		require.Nil(t, err)
+		assert.False(t, contains)
+	}
+
+	for _, k := range []int{1, 2, 4, 6, 7, 8} {
+		v, err := reader.Get(intToByteSlice(k))
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(k+1), v)
+		contains, err := reader.Contains(intToByteSlice(k))
+		require.Nil(t, err)
+		assert.True(t, contains)
+	}
+
+	expected := []int{1, 2, 4, 6, 7, 8}
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	assertIteratorMatchesSlice(t, it, expected)
+
+	it, err = reader.ScanStartingAt(intToByteSlice(3))
+	require.Nil(t, err)
+	assertIteratorMatchesSlice(t, it, []int{4, 6, 7, 8})
+
+	it, err = reader.ScanRange(intToByteSlice(2), intToByteSlice(5))
+	require.Nil(t, err)
+	assertIteratorMatchesSlice(t, it, []int{2, 4})
+
+	sit, err := reader.SeekableIterator()
+	require.Nil(t, err)
+	assertIteratorMatchesSlice(t, sit, expected)
+	require.Nil(t, sit.SeekForPrev(intToByteSlice(5)))
+	assertReverseIteratorMatchesSlice(t, sit, intToByteSlice(0), []int{1, 2, 4})
+	require.Nil(t, sit.Seek(intToByteSlice(5)))
+	assertIteratorMatchesSlice(t, sit, []int{6, 7, 8})
+
+	assert.Equal(t, RangeTombstones{{Start: intToByteSlice(3), End: intToByteSlice(6)}}, reader.RangeTombstones())
+	assert.Equal(t, 1, int(reader.MetaData().NumRangeTombstones))
+}
+
+func TestMergeCompactRangeTombstones(t *testing.T) {
+	oldest := writeRangeTombstoneTestTable(t, []int{3, 5, 30}, nil)
+	defer cleanWriterDir(t, oldest)
+	older := writeRangeTombstoneTestTable(t, []int{1, 2, 3, 4, 5, 6, 7, 21}, [][2]int{{29, 31}})
+	defer cleanWriterDir(t, older)
+	newer := writeRangeTombstoneTestTable(t, []int{4, 8}, [][2]int{{3, 6}, {20, 25}})
+	defer cleanWriterDir(t, newer)
+
+	var iterators []SSTableMergeIteratorContext
+	for i, path := range []string{older.opts.basePath, newer.opts.basePath} {
+		reader, err := NewSSTableReader(ReadBasePath(path))
+		require.Nil(t, err)
+		defer closeReader(t, reader)
+		it, err := reader.Scan()
+		require.Nil(t, err)
+		iterators = append(iterators, NewMergeIteratorContextWithRangeTombstones(i, it, reader.RangeTombstones()))
+	}
+
+	out, err := newTestSSTableStreamWriter()
+	require.Nil(t, err)
+	defer cleanWriterDir(t, out)
+	require.Nil(t, out.Open())
+	require.Nil(t, NewSSTableMerger(skiplist.BytesComparator{}).MergeCompact(iterators, out, ScanReduceLatestWins))
+	require.Nil(t, out.Close())
+
+	merged, err := NewSSTableReader(ReadBasePath(out.opts.basePath))
+	require.Nil(t, err)
+	defer closeReader(t, merged)
+
+	it, err := merged.Scan()
+	require.Nil(t, err)
+	assertIteratorMatchesSlice(t, it, []int{1, 2, 4, 6, 7, 8})
+	assert.Equal(t, RangeTombstones{
+		{Start: intToByteSlice(3), End: intToByteSlice(6)},
+		{Start: intToByteSlice(20), End: intToByteSlice(25)},
+		{Start: intToByteSlice(29), End: intToByteSlice(31)},
+	}, merged.RangeTombstones())
+
+	// the carried forward tombstones still delete the keys of the table that was not part of the compaction
+	reader := openRangeTombstoneTestSuperReader(t, oldest.opts.basePath, out.opts.basePath)
+	defer closeReader(t, reader)
+	it, err = reader.Scan()
+	require.Nil(t, err)
+	assertIteratorMatchesSlice(t, it, []int{1, 2, 4, 6, 7, 8})
+}
+
+func writeRangeTombstoneTestTable(t *testing.T, keys []int, tombstones [][2]int) *SSTableStreamWriter {
+	writer, err := newTestSSTableStreamWriter()
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, k := range keys {
+		require.Nil(t, writer.WriteNext(intToByteSlice(k), intToByteSlice(k+1)))
+	}
+	for _, tombstone := range tombstones {
+		require.Nil(t, writer.WriteRangeTombstone(intToByteSlice(tombstone[0]), intToByteSlice(tombstone[1])))
+	}
+	require.Nil(t, writer.Close())
+	return writer
+}
+
+func openRangeTombstoneTestSuperReader(t *testing.T, paths ...string) SSTableReaderI {
+	var readers []SSTableReaderI
+	for _, path := range paths {
+		reader, err := NewSSTableReader(ReadBasePath(path))
+		require.Nil(t, err)
+		readers = append(readers, reader)
+	}
+	return NewSuperSSTableReader(readers, skiplist.BytesComparator{})
+}
@@ -0,0 +1,325 @@
+package sstables
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+// RollingTableNamePattern is the directory name of every table written by a RollingSSTableWriter, the placeholder
+// is the zero-based index of the table in the order of writing.
+var RollingTableNamePattern = "table_%06d"
+
+// RolledSSTable describes a single table that was written by a RollingSSTableWriter.
+type RolledSSTable struct {
+	BasePath   string
+	MinKey     []byte
+	MaxKey     []byte
+	NumRecords uint64
+	TotalBytes uint64
+}
+
+// RollingSSTableWriter writes a sorted stream of records into multiple sstables with disjoint key ranges.
+// A new table is started in a new directory below the base path whenever the current table reached the target size,
+// or when a key reaches the next of the supplied key boundaries. All versions of the same user key
+// (see WithInternalKeys) are always written into the same table.
+//
+// Range tombstones are clipped to the key range of each table, so they never delete keys of a sibling table.
+// Since tables are finished while rolling, a range tombstone can't start before the first key of the current table
+// once a table was rolled. MergeCompact writes all range tombstones before the first record, which is always safe.
+type RollingSSTableWriter struct {
+	opts         *RollingSSTableWriterOptions
+	comp         skiplist.Comparator[[]byte]
+	internalKeys bool
+
+	opened bool
+	closed bool
+
+	current *SSTableStreamWriter
+	tables  []RolledSSTable
+
+	// the first key of the current table, all lower keys were written into previous tables already
+	currentLowerBound []byte
+	lastUserKey       []byte
+	nextBoundary      int
+
+	rangeTombstones []RangeTombstone
+}
+
+func (w *RollingSSTableWriter) Open() error {
+	if w.opened {
+		return fmt.Errorf("rolling sstable writer in '%s' is already opened", w.opts.basePath)
+	}
+
+	err := os.MkdirAll(w.opts.basePath, 0700)
+	if err != nil {
+		return fmt.Errorf("error while creating rolling sstable writer directory '%s': %w", w.opts.basePath, err)
+	}
+
+	w.opened = true
+	return nil
+}
+
+func (w *RollingSSTableWriter) WriteNext(key []byte, value []byte) error {
+	if !w.opened || w.closed {
+		return fmt.Errorf("sstables.WriteNext '%s': rolling sstable writer is not opened", w.opts.basePath)
+	}
+
+	userKey := key
+	if w.internalKeys {
+		internalKey, err := DecodeInternalKey(key)
+		if err != nil {
+			return fmt.Errorf("sstables.WriteNext '%s': %w", w.opts.basePath, err)
+		}
+		userKey = internalKey.UserKey
+	}
+
+	if w.current != nil && w.shouldRoll(userKey) {
+		err := w.roll(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.current == nil {
+		err := w.openNextTable()
+		if err != nil {
+			return err
+		}
+	}
+
+	for w.nextBoundary < len(w.opts.keyBoundaries) && w.comp.Compare(userKey, w.opts.keyBoundaries[w.nextBoundary]) >= 0 {
+		w.nextBoundary++
+	}
+	w.lastUserKey = append(w.lastUserKey[:0], userKey...)
+
+	return w.current.WriteNext(key, value)
+}
+
+// shouldRoll decides whether the given user key should be written into a new table. This is never the case for
+// another version of the previously written user key.
+func (w *RollingSSTableWriter) shouldRoll(userKey []byte) bool {
+	if w.comp.Compare(w.lastUserKey, userKey) == 0 {
+		return false
+	}
+
+	if w.nextBoundary < len(w.opts.keyBoundaries) && w.comp.Compare(userKey, w.opts.keyBoundaries[w.nextBoundary]) >= 0 {
+		return true
+	}
+
+	if w.opts.targetSizeBytes == 0 {
+		return false
+	}
+
+	return w.current.dataWriter.Size()+w.current.indexWriter.Size() >= w.opts.targetSizeBytes
+}
+
+// roll finishes the current table, the given key is the first key of the next table.
+func (w *RollingSSTableWriter) roll(nextKey []byte) error {
+	var remaining []RangeTombstone
+	for _, t := range w.rangeTombstones {
+		if w.comp.Compare(t.Start, nextKey) >= 0 {
+			remaining = append(remaining, t)
+			continue
+		}
+
+		end := t.End
+		if w.comp.Compare(end, nextKey) > 0 {
+			end = nextKey
+			remaining = append(remaining, RangeTombstone{Start: append([]byte{}, nextKey...), End: t.End})
+		}
+
+		err := w.current.WriteRangeTombstone(t.Start, end)
+		if err != nil {
+			return err
+		}
+	}
+	w.rangeTombstones = remaining
+
+	err := w.closeCurrent()
+	if err != nil {
+		return err
+	}
+
+	w.currentLowerBound = append([]byte{}, nextKey...)
+	return nil
+}
+
+func (w *RollingSSTableWriter) openNextTable() error {
+	path := filepath.Join(w.opts.basePath, fmt.Sprintf(RollingTableNamePattern, len(w.tables)))
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		return fmt.Errorf("error while creating rolled sstable directory '%s': %w", path, err)
+	}
+
+	writerOptions := append(append([]WriterOption{}, w.opts.writerOptions...), WriteBasePath(path))
+	writer, err := NewSSTableStreamWriter(writerOptions...)
+	if err != nil {
+		return fmt.Errorf("error while creating rolled sstable writer in '%s': %w", path, err)
+	}
+
+	err = writer.Open()
+	if err != nil {
+		return err
+	}
+
+	w.current = writer
+	return nil
+}
+
+func (w *RollingSSTableWriter) closeCurrent() error {
+	writer := w.current
+	w.current = nil
+
+	err := writer.Close()
+	if err != nil {
+		return err
+	}
+
+	w.tables = append(w.tables, RolledSSTable{
+		BasePath:   writer.opts.basePath,
+		MinKey:     writer.metaData.MinKey,
+		MaxKey:     writer.metaData.MaxKey,
+		NumRecords: writer.metaData.NumRecords,
+		TotalBytes: writer.metaData.TotalBytes,
+	})
+	return nil
+}
+
+// WriteRangeTombstone deletes all keys in [start, end) of older sstables. Once a table was rolled,
+// the start must not be lower than the first key of the current table.
+func (w *RollingSSTableWriter) WriteRangeTombstone(start []byte, end []byte) error {
+	if !w.opened || w.closed {
+		return fmt.Errorf("sstables.WriteRangeTombstone '%s': rolling sstable writer is not opened", w.opts.basePath)
+	}
+
+	if w.comp.Compare(start, end) >= 0 {
+		return fmt.Errorf("sstables.WriteRangeTombstone '%s': start key must be lower than the end key", w.opts.basePath)
+	}
+
+	if w.currentLowerBound != nil && w.comp.Compare(start, w.currentLowerBound) < 0 {
+		return fmt.Errorf("sstables.WriteRangeTombstone '%s': range tombstone starts in an already rolled table", w.opts.basePath)
+	}
+
+	w.rangeTombstones = append(w.rangeTombstones, RangeTombstone{
+		Start: append([]byte{}, start...),
+		End:   append([]byte{}, end...),
+	})
+
+	return nil
+}
+
+// Close finishes the last table, the written tables are only returned by Tables afterwards.
+// No table is written at all when neither records nor range tombstones were written.
+func (w *RollingSSTableWriter) Close() (err error) {
+	if !w.opened || w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if w.current == nil && len(w.rangeTombstones) > 0 {
+		err = w.openNextTable()
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.current == nil {
+		return nil
+	}
+
+	for _, t := range w.rangeTombstones {
+		err = errors.Join(err, w.current.WriteRangeTombstone(t.Start, t.End))
+	}
+	w.rangeTombstones = nil
+
+	return errors.Join(err, w.closeCurrent())
+}
+
+// Tables returns all tables that were written in the order of their key ranges. Since the last table is only
+// finished by Close, calling it on a writer that wasn't closed yet returns an error.
+func (w *RollingSSTableWriter) Tables() ([]RolledSSTable, error) {
+	if !w.closed {
+		return nil, fmt.Errorf("sstables.Tables '%s': rolling sstable writer is not closed yet", w.opts.basePath)
+	}
+
+	return w.tables, nil
+}
+
+// NewRollingSSTableWriter creates a new rolling writer, the minimum options required are the base path and the
+// writer options for each table, which must contain the comparator but no base path:
+// > sstables.NewRollingSSTableWriter(sstables.RollingBasePath("some_folder"), sstables.RollAtSizeBytes(1024*1024*64),
+// >     sstables.RollingTableOptions(sstables.WithKeyComparator(some_comparator)))
+func NewRollingSSTableWriter(rollingOptions ...RollingWriterOption) (*RollingSSTableWriter, error) {
+	opts := &RollingSSTableWriterOptions{}
+	for _, rollingOption := range rollingOptions {
+		rollingOption(opts)
+	}
+
+	if opts.basePath == "" {
+		return nil, errors.New("basePath was not supplied")
+	}
+
+	// validates the table options upfront and resolves the comparator the tables are written with
+	probe, err := NewSSTableStreamWriter(append(append([]WriterOption{}, opts.writerOptions...), WriteBasePath(opts.basePath))...)
+	if err != nil {
+		return nil, err
+	}
+
+	comp := probe.opts.keyComparator
+	for i := 1; i < len(opts.keyBoundaries); i++ {
+		if comp.Compare(opts.keyBoundaries[i-1], opts.keyBoundaries[i]) >= 0 {
+			return nil, errors.New("key boundaries must be strictly ascending")
+		}
+	}
+
+	return &RollingSSTableWriter{
+		opts:         opts,
+		comp:         comp,
+		internalKeys: probe.opts.internalKeys,
+	}, nil
+}
+
+// options
+
+type RollingSSTableWriterOptions struct {
+	basePath        string
+	targetSizeBytes uint64
+	keyBoundaries   [][]byte
+	writerOptions   []WriterOption
+}
+
+type RollingWriterOption func(*RollingSSTableWriterOptions)
+
+// RollingBasePath is the directory in which every table is written into its own directory, see RollingTableNamePattern.
+func RollingBasePath(p string) RollingWriterOption {
+	return func(args *RollingSSTableWriterOptions) {
+		args.basePath = p
+	}
+}
+
+// RollAtSizeBytes starts a new table once the data and index files of the current table reach the given size.
+// As tables are never split within a key, tables may grow beyond that size by one user key.
+func RollAtSizeBytes(n uint64) RollingWriterOption {
+	return func(args *RollingSSTableWriterOptions) {
+		args.targetSizeBytes = n
+	}
+}
+
+// RollAtKeyBoundaries starts a new table with the first key that is greater or equal to each of the given,
+// strictly ascending boundaries. With internal keys, the boundaries are user keys.
+func RollAtKeyBoundaries(boundaries ...[]byte) RollingWriterOption {
+	return func(args *RollingSSTableWriterOptions) {
+		args.keyBoundaries = boundaries
+	}
+}
+
+// RollingTableOptions are the options every table is written with, the base path is set by the rolling writer.
+func RollingTableOptions(writerOptions ...WriterOption) RollingWriterOption {
+	return func(args *RollingSSTableWriterOptions) {
+		args.writerOptions = append(args.writerOptions, writerOptions...)
+	}
+}
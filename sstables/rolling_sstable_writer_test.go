@@ -0,0 +1,204 @@
+package sstables
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func TestRollingWriterRollsAtSize(t *testing.T) {
+	writer := newTestRollingWriter(t, RollAtSizeBytes(1024))
+	defer func() { require.Nil(t, os.RemoveAll(writer.opts.basePath)) }()
+
+	var keys []int
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, i)
+	}
+	writeRollingTestRecords(t, writer, keys)
+
+	tables, err := writer.Tables()
+	require.Nil(t, err)
+	require.Greater(t, len(tables), 5)
+	var readKeys []int
+	for i, table := range tables {
+		assert.Equal(t, filepath.Join(writer.opts.basePath, fmt.Sprintf(RollingTableNamePattern, i)), table.BasePath)
+		assert.True(t, table.NumRecords > 0)
+		if i > 0 {
+			assert.Equal(t, 1, skiplist.BytesComparator{}.Compare(table.MinKey, tables[i-1].MaxKey))
+		}
+		if i < len(tables)-1 {
+			// rolling only happens once the size was reached
+			assert.GreaterOrEqual(t, table.TotalBytes, uint64(1024))
+		}
+
+		readKeys = append(readKeys, readRolledTable(t, table)...)
+	}
+	assert.Equal(t, keys, readKeys)
+}
+
+func TestRollingWriterRollsAtKeyBoundaries(t *testing.T) {
+	writer := newTestRollingWriter(t, RollAtKeyBoundaries(intToByteSlice(5), intToByteSlice(6), intToByteSlice(8), intToByteSlice(20)))
+	defer func() { require.Nil(t, os.RemoveAll(writer.opts.basePath)) }()
+
+	writeRollingTestRecords(t, writer, []int{1, 2, 4, 7, 8, 9, 21})
+
+	tables, err := writer.Tables()
+	require.Nil(t, err)
+	require.Equal(t, 4, len(tables))
+	for i, expected := range [][]int{{1, 2, 4}, {7}, {8, 9}, {21}} {
+		assert.Equal(t, intToByteSlice(expected[0]), tables[i].MinKey)
+		assert.Equal(t, intToByteSlice(expected[len(expected)-1]), tables[i].MaxKey)
+		assert.Equal(t, uint64(len(expected)), tables[i].NumRecords)
+		assert.Equal(t, expected, readRolledTable(t, tables[i]))
+	}
+
+	_, err = NewRollingSSTableWriter(
+		RollingBasePath(writer.opts.basePath),
+		RollAtKeyBoundaries(intToByteSlice(5), intToByteSlice(5)),
+		RollingTableOptions(WithKeyComparator(skiplist.BytesComparator{})))
+	assert.Error(t, err)
+}
+
+func TestRollingWriterNeverSplitsVersionsOfKey(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sstables_RollingWriter")
+	require.Nil(t, err)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+
+	writer, err := NewRollingSSTableWriter(
+		RollingBasePath(tmpDir),
+		RollAtSizeBytes(1),
+		RollAtKeyBoundaries([]byte("b")),
+		RollingTableOptions(WithInternalKeys()))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for _, e := range []versionTestEntry{
+		{"a", 3, ValueKindPut, "a3"},
+		{"a", 2, ValueKindPut, "a2"},
+		{"b", 5, ValueKindPut, "b5"},
+		{"b", 1, ValueKindDelete, ""},
+		{"c", 1, ValueKindPut, "c1"},
+	} {
+		require.Nil(t, writer.WriteNext(EncodeInternalKey([]byte(e.key), e.sequence, e.kind), []byte(e.value)))
+	}
+	require.Nil(t, writer.Close())
+
+	tables, err := writer.Tables()
+	require.Nil(t, err)
+	require.Equal(t, 3, len(tables))
+	for i, expected := range []string{"a", "b", "c"} {
+		minKey, err := DecodeInternalKey(tables[i].MinKey)
+		require.Nil(t, err)
+		maxKey, err := DecodeInternalKey(tables[i].MaxKey)
+		require.Nil(t, err)
+		assert.Equal(t, expected, string(minKey.UserKey))
+		assert.Equal(t, expected, string(maxKey.UserKey))
+	}
+	assert.Equal(t, uint64(2), tables[0].NumRecords)
+	assert.Equal(t, uint64(2), tables[1].NumRecords)
+	assert.Equal(t, uint64(1), tables[2].NumRecords)
+}
+
+func TestRollingWriterClipsRangeTombstones(t *testing.T) {
+	writer := newTestRollingWriter(t, RollAtKeyBoundaries(intToByteSlice(10), intToByteSlice(20)))
+	defer func() { require.Nil(t, os.RemoveAll(writer.opts.basePath)) }()
+
+	require.Nil(t, writer.WriteRangeTombstone(intToByteSlice(2), intToByteSlice(25)))
+	require.Nil(t, writer.WriteRangeTombstone(intToByteSlice(30), intToByteSlice(40)))
+	require.Nil(t, writer.WriteNext(intToByteSlice(1), intToByteSlice(2)))
+	require.Nil(t, writer.WriteNext(intToByteSlice(12), intToByteSlice(13)))
+	// the tombstone would reach into the already rolled table
+	assert.Error(t, writer.WriteRangeTombstone(intToByteSlice(5), intToByteSlice(15)))
+	require.Nil(t, writer.WriteRangeTombstone(intToByteSlice(15), intToByteSlice(16)))
+	require.Nil(t, writer.WriteNext(intToByteSlice(22), intToByteSlice(23)))
+	require.Nil(t, writer.Close())
+
+	tables, err := writer.Tables()
+	require.Nil(t, err)
+	require.Equal(t, 3, len(tables))
+	expectedTombstones := []RangeTombstones{
+		{{Start: intToByteSlice(2), End: intToByteSlice(12)}},
+		{{Start: intToByteSlice(12), End: intToByteSlice(22)}},
+		{{Start: intToByteSlice(22), End: intToByteSlice(25)}, {Start: intToByteSlice(30), End: intToByteSlice(40)}},
+	}
+	var readers []SSTableReaderI
+	for i, table := range tables {
+		reader, err := NewSSTableReader(ReadBasePath(table.BasePath))
+		require.Nil(t, err)
+		assert.Equal(t, expectedTombstones[i], reader.RangeTombstones())
+		readers = append(readers, reader)
+	}
+
+	// the siblings don't delete each other's keys
+	reader := NewSuperSSTableReader(readers, skiplist.BytesComparator{})
+	defer closeReader(t, reader)
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	assertIteratorMatchesSlice(t, it, []int{1, 12, 22})
+}
+
+func TestRollingWriterEmpty(t *testing.T) {
+	writer := newTestRollingWriter(t, RollAtSizeBytes(1024))
+	defer func() { require.Nil(t, os.RemoveAll(writer.opts.basePath)) }()
+
+	_, err := writer.Tables()
+	assert.Error(t, err)
+	require.Nil(t, writer.Close())
+	tables, err := writer.Tables()
+	require.Nil(t, err)
+	assert.Empty(t, tables)
+
+	tombstoneOnly := newTestRollingWriter(t, RollAtSizeBytes(1024))
+	defer func() { require.Nil(t, os.RemoveAll(tombstoneOnly.opts.basePath)) }()
+	require.Nil(t, tombstoneOnly.WriteRangeTombstone(intToByteSlice(1), intToByteSlice(2)))
+	require.Nil(t, tombstoneOnly.Close())
+	tables, err = tombstoneOnly.Tables()
+	require.Nil(t, err)
+	require.Equal(t, 1, len(tables))
+	assert.Equal(t, uint64(0), tables[0].NumRecords)
+}
+
+func newTestRollingWriter(t *testing.T, opts ...RollingWriterOption) *RollingSSTableWriter {
+	tmpDir, err := os.MkdirTemp("", "sstables_RollingWriter")
+	require.Nil(t, err)
+
+	opts = append(opts, RollingBasePath(tmpDir), RollingTableOptions(WithKeyComparator(skiplist.BytesComparator{})))
+	writer, err := NewRollingSSTableWriter(opts...)
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	return writer
+}
+
+func writeRollingTestRecords(t *testing.T, writer *RollingSSTableWriter, keys []int) {
+	for _, k := range keys {
+		require.Nil(t, writer.WriteNext(intToByteSlice(k), intToByteSlice(k+1)))
+	}
+	require.Nil(t, writer.Close())
+}
+
+func readRolledTable(t *testing.T, table RolledSSTable) []int {
+	reader, err := NewSSTableReader(ReadBasePath(table.BasePath))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	var keys []int
+	it, err := reader.Scan()
+	require.Nil(t, err)
+	for {
+		k, v, err := it.Next()
+		if errors.Is(err, Done) {
+			break
+		}
+		require.Nil(t, err)
+		key := int(binary.BigEndian.Uint32(k))
+		assert.Equal(t, intToByteSlice(key+1), v)
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,237 @@
+package sstables
+
+import (
+	"errors"
+
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+const (
+	directionForward  = iota
+	directionBackward = iota
+)
+
+type seekableMergeHead struct {
+	key   []byte
+	value []byte
+	valid bool
+}
+
+// SeekableMergeIterator merges several bidirectional iterators into a single bidirectional iterator.
+// For keys that exist in multiple iterators, the value of the iterator with the highest index wins, similar to how
+// ScanReduceLatestWins treats the context.
+//
+// Every child iterator is read ahead by one entry (the head) in the current direction. When the direction changes,
+// all children are re-positioned around the current key.
+type SeekableMergeIterator struct {
+	comp      skiplist.Comparator[[]byte]
+	iterators []SSTableSeekableIteratorI
+	heads     []seekableMergeHead
+	direction int
+	// the range tombstones of the iterators, where the context is the index in iterators
+	tombstones []contextRangeTombstones
+}
+
+func (m *SeekableMergeIterator) Next() ([]byte, []byte, error) {
+	if m.direction != directionForward {
+		if err := m.switchDirection(directionForward); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return m.advance(func(c int) bool { return c < 0 }, func(it SSTableSeekableIteratorI) ([]byte, []byte, error) {
+		return it.Next()
+	})
+}
+
+func (m *SeekableMergeIterator) Prev() ([]byte, []byte, error) {
+	if m.direction != directionBackward {
+		if err := m.switchDirection(directionBackward); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return m.advance(func(c int) bool { return c > 0 }, func(it SSTableSeekableIteratorI) ([]byte, []byte, error) {
+		return it.Prev()
+	})
+}
+
+// advance returns the current entry, which is the head that wins by the given comparison, and moves all
+// children positioned at the current key onwards using the move function.
+func (m *SeekableMergeIterator) advance(wins func(int) bool,
+	move func(SSTableSeekableIteratorI) ([]byte, []byte, error)) ([]byte, []byte, error) {
+	current := m.currentHead()
+	if current < 0 {
+		return nil, nil, Done
+	}
+
+	key := m.heads[current].key
+	value := m.heads[current].value
+	if err := m.moveHeadsAt(key, move); err != nil {
+		return nil, nil, err
+	}
+
+	if err := m.skipRangeDeleted(move); err != nil {
+		return nil, nil, err
+	}
+
+	return key, value, nil
+}
+
+// moveHeadsAt moves all children positioned at the given key onwards using the move function.
+func (m *SeekableMergeIterator) moveHeadsAt(key []byte, move func(SSTableSeekableIteratorI) ([]byte, []byte, error)) error {
+	for i, h := range m.heads {
+		if h.valid && m.comp.Compare(h.key, key) == 0 {
+			if err := m.fillHead(i, move); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipRangeDeleted moves onwards until the current key is not deleted by a range tombstone of a newer iterator.
+func (m *SeekableMergeIterator) skipRangeDeleted(move func(SSTableSeekableIteratorI) ([]byte, []byte, error)) error {
+	if len(m.tombstones) == 0 {
+		return nil
+	}
+
+	for {
+		current := m.currentHead()
+		if current < 0 || !isRangeDeleted(m.comp, m.tombstones, m.heads[current].key, current) {
+			return nil
+		}
+
+		if err := m.moveHeadsAt(m.heads[current].key, move); err != nil {
+			return err
+		}
+	}
+}
+
+// currentHead returns the index of the head that the iterator is positioned at, -1 if exhausted.
+// On equal keys, the head from the later iterator wins.
+func (m *SeekableMergeIterator) currentHead() int {
+	current := -1
+	for i, h := range m.heads {
+		if !h.valid {
+			continue
+		}
+		if current < 0 {
+			current = i
+			continue
+		}
+
+		c := m.comp.Compare(h.key, m.heads[current].key)
+		if c == 0 || (m.direction == directionForward && c < 0) || (m.direction == directionBackward && c > 0) {
+			current = i
+		}
+	}
+	return current
+}
+
+func (m *SeekableMergeIterator) fillHead(i int, move func(SSTableSeekableIteratorI) ([]byte, []byte, error)) error {
+	k, v, err := move(m.iterators[i])
+	if err != nil {
+		if errors.Is(err, Done) {
+			m.heads[i] = seekableMergeHead{}
+			return nil
+		}
+		return err
+	}
+	m.heads[i] = seekableMergeHead{key: k, value: v, valid: true}
+	return nil
+}
+
+func (m *SeekableMergeIterator) fillHeads(direction int) error {
+	m.direction = direction
+	move := func(it SSTableSeekableIteratorI) ([]byte, []byte, error) { return it.Next() }
+	if direction == directionBackward {
+		move = func(it SSTableSeekableIteratorI) ([]byte, []byte, error) { return it.Prev() }
+	}
+
+	for i := range m.iterators {
+		if err := m.fillHead(i, move); err != nil {
+			return err
+		}
+	}
+
+	return m.skipRangeDeleted(move)
+}
+
+// switchDirection keeps the iterator positioned at the current key, but reads the children in the other direction.
+func (m *SeekableMergeIterator) switchDirection(direction int) error {
+	current := m.currentHead()
+	if current < 0 {
+		// exhausted iterators stay exhausted, regardless of their direction
+		m.direction = direction
+		return nil
+	}
+
+	key := m.heads[current].key
+	if direction == directionForward {
+		return m.Seek(key)
+	}
+	return m.SeekForPrev(key)
+}
+
+func (m *SeekableMergeIterator) Seek(key []byte) error {
+	for _, it := range m.iterators {
+		if err := it.Seek(key); err != nil {
+			return err
+		}
+	}
+	return m.fillHeads(directionForward)
+}
+
+func (m *SeekableMergeIterator) SeekForPrev(key []byte) error {
+	for _, it := range m.iterators {
+		if err := it.SeekForPrev(key); err != nil {
+			return err
+		}
+	}
+	return m.fillHeads(directionBackward)
+}
+
+func (m *SeekableMergeIterator) SeekToFirst() error {
+	for _, it := range m.iterators {
+		if err := it.SeekToFirst(); err != nil {
+			return err
+		}
+	}
+	return m.fillHeads(directionForward)
+}
+
+func (m *SeekableMergeIterator) SeekToLast() error {
+	for _, it := range m.iterators {
+		if err := it.SeekToLast(); err != nil {
+			return err
+		}
+	}
+	return m.fillHeads(directionBackward)
+}
+
+func (m *SeekableMergeIterator) Valid() bool {
+	return m.currentHead() >= 0
+}
+
+// NewSeekableMergeIterator creates a merged iterator that is positioned at the first key of all given iterators.
+// The ordering of the iterators matters, it is assumed the older iterator comes before the newer (ascending order).
+func NewSeekableMergeIterator(comp skiplist.Comparator[[]byte], iterators []SSTableSeekableIteratorI) (*SeekableMergeIterator, error) {
+	return newSeekableMergeIterator(comp, iterators, nil)
+}
+
+func newSeekableMergeIterator(comp skiplist.Comparator[[]byte], iterators []SSTableSeekableIteratorI,
+	tombstones []contextRangeTombstones) (*SeekableMergeIterator, error) {
+	m := &SeekableMergeIterator{
+		comp:       comp,
+		iterators:  iterators,
+		heads:      make([]seekableMergeHead, len(iterators)),
+		tombstones: tombstones,
+	}
+
+	if err := m.SeekToFirst(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -23,7 +23,7 @@ type SkipListIndexLoader struct {
 	ReadBufferSize int
 }
 
-func (l *SkipListIndexLoader) Load(indexPath string, _ *proto.MetaData) (_ SortedKeyIndex, err error) {
+func (l *SkipListIndexLoader) Load(indexPath string, metadata *proto.MetaData) (SortedKeyIndex, error) {
 	reader, err := rProto.NewReader(
 		rProto.ReaderPath(indexPath),
 		rProto.ReadBufferSizeBytes(l.ReadBufferSize),
@@ -32,6 +32,20 @@ func (l *SkipListIndexLoader) Load(indexPath string, _ *proto.MetaData) (_ Sorte
 		return nil, fmt.Errorf("error while creating index reader of sstable in '%s': %w", indexPath, err)
 	}
 
+	return l.load(reader, indexPath, metadata)
+}
+
+// LoadFromSource loads the index from a table that is opened with ReadFromSource.
+func (l *SkipListIndexLoader) LoadFromSource(source TableSource, metadata *proto.MetaData) (SortedKeyIndex, error) {
+	reader, err := newSourceProtoReader(source, IndexFileName, l.ReadBufferSize)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating index reader of sstable from source: %w", err)
+	}
+
+	return l.load(reader, IndexFileName, metadata)
+}
+
+func (l *SkipListIndexLoader) load(reader rProto.ReaderI, indexPath string, _ *proto.MetaData) (_ SortedKeyIndex, err error) {
 	err = reader.Open()
 	if err != nil {
 		return nil, fmt.Errorf("error while opening index reader of sstable in '%s': %w", indexPath, err)
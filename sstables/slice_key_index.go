@@ -37,6 +37,30 @@ func (s *SliceKeyIndex) Get(key []byte) (IndexVal, error) {
 	return IndexVal{}, skiplist.NotFound
 }
 
+// GetAll narrows the binary search for each key to the part of the index after the previous key.
+func (s *SliceKeyIndex) GetAll(keys [][]byte) ([]IndexVal, []error) {
+	vals := make([]IndexVal, len(keys))
+	errs := make([]error, len(keys))
+	lower := 0
+	for i, key := range keys {
+		// unsorted keys need to search the whole index again
+		if i > 0 && bytes.Compare(key, keys[i-1]) < 0 {
+			lower = 0
+		}
+
+		idx, found := slices.BinarySearchFunc(s.index[lower:], key, func(entry sliceKey, k []byte) int {
+			return bytes.Compare(entry.key, k)
+		})
+		lower += idx
+		if found {
+			vals[i] = s.index[lower].IndexVal
+		} else {
+			errs[i] = skiplist.NotFound
+		}
+	}
+	return vals, errs
+}
+
 func (s *SliceKeyIndex) Contains(key []byte) (bool, error) {
 	_, found := s.search(key)
 	return found, nil
@@ -69,6 +93,10 @@ func (s *SliceKeyIndex) IteratorBetween(keyLower []byte, keyHigher []byte) (skip
 	return &SliceKeyIndexIterator{index: s.index, currentIndex: startIdx, endIndexExcl: endIdx}, nil
 }
 
+func (s *SliceKeyIndex) SeekableIterator() (skiplist.SeekableIteratorI[[]byte, IndexVal], error) {
+	return &SliceKeyIndexSeekableIterator{index: s}, nil
+}
+
 type SliceKeyIndexIterator struct {
 	index        []sliceKey
 	endIndexExcl int
@@ -84,6 +112,58 @@ func (s *SliceKeyIndexIterator) Next() ([]byte, IndexVal, error) {
 	return cx.key, cx.IndexVal, nil
 }
 
+// SliceKeyIndexSeekableIterator is positioned at currentIndex, which is out of bounds once exhausted in either direction.
+type SliceKeyIndexSeekableIterator struct {
+	index        *SliceKeyIndex
+	currentIndex int
+}
+
+func (s *SliceKeyIndexSeekableIterator) Next() ([]byte, IndexVal, error) {
+	if !s.Valid() {
+		return nil, IndexVal{}, skiplist.Done
+	}
+	cx := s.index.index[s.currentIndex]
+	s.currentIndex += 1
+	return cx.key, cx.IndexVal, nil
+}
+
+func (s *SliceKeyIndexSeekableIterator) Prev() ([]byte, IndexVal, error) {
+	if !s.Valid() {
+		return nil, IndexVal{}, skiplist.Done
+	}
+	cx := s.index.index[s.currentIndex]
+	s.currentIndex -= 1
+	return cx.key, cx.IndexVal, nil
+}
+
+func (s *SliceKeyIndexSeekableIterator) Seek(key []byte) error {
+	s.currentIndex, _ = s.index.search(key)
+	return nil
+}
+
+func (s *SliceKeyIndexSeekableIterator) SeekForPrev(key []byte) error {
+	idx, found := s.index.search(key)
+	if !found {
+		idx = idx - 1
+	}
+	s.currentIndex = idx
+	return nil
+}
+
+func (s *SliceKeyIndexSeekableIterator) SeekToFirst() error {
+	s.currentIndex = 0
+	return nil
+}
+
+func (s *SliceKeyIndexSeekableIterator) SeekToLast() error {
+	s.currentIndex = len(s.index.index) - 1
+	return nil
+}
+
+func (s *SliceKeyIndexSeekableIterator) Valid() bool {
+	return s.currentIndex >= 0 && s.currentIndex < len(s.index.index)
+}
+
 type SliceKeyIndexLoader struct {
 	ReadBufferSize int
 }
@@ -97,6 +177,20 @@ func (s *SliceKeyIndexLoader) Load(indexPath string, metadata *proto.MetaData) (
 		return nil, fmt.Errorf("error while creating index reader of sstable in '%s': %w", indexPath, err)
 	}
 
+	return s.load(reader, indexPath, metadata)
+}
+
+// LoadFromSource loads the index from a table that is opened with ReadFromSource.
+func (s *SliceKeyIndexLoader) LoadFromSource(source TableSource, metadata *proto.MetaData) (SortedKeyIndex, error) {
+	reader, err := newSourceProtoReader(source, IndexFileName, s.ReadBufferSize)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating index reader of sstable from source: %w", err)
+	}
+
+	return s.load(reader, IndexFileName, metadata)
+}
+
+func (s *SliceKeyIndexLoader) load(reader rProto.ReaderI, indexPath string, metadata *proto.MetaData) (_ SortedKeyIndex, err error) {
 	err = reader.Open()
 	if err != nil {
 		return nil, fmt.Errorf("error while opening index reader of sstable in '%s': %w", indexPath, err)
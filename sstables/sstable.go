@@ -2,6 +2,7 @@ package sstables
 
 import (
 	"errors"
+	"fmt"
 	"github.com/thomasjungblut/go-sstables/skiplist"
 	"github.com/thomasjungblut/go-sstables/sstables/proto"
 )
@@ -18,17 +19,79 @@ var Version = uint32(1)
 var Done = errors.New("no more items in iterator")
 var NotFound = errors.New("key was not found")
 
+// ComparatorMismatch indicates that an sstable is read with a different key comparator than it was written with.
+var ComparatorMismatch = errors.New("key comparator mismatch")
+
+// comparatorName returns the name of a skiplist.NamedComparator, or the type name of an unnamed comparator. It is
+// persisted in the metadata of an sstable and never empty, so two different unnamed comparators can be told apart.
+func comparatorName(cmp skiplist.Comparator[[]byte]) string {
+	if name := skiplist.ComparatorName(cmp); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%T", cmp)
+}
+
+// checkComparatorName compares the name of the comparator with the one persisted in an sstable's metadata. Tables that
+// were written before the name was persisted can't be checked and are always accepted.
+func checkComparatorName(cmp skiplist.Comparator[[]byte], tableComparatorName string) error {
+	if tableComparatorName == "" {
+		return nil
+	}
+
+	name := comparatorName(cmp)
+	if name != tableComparatorName {
+		return fmt.Errorf("%w: sstable was written with '%s', but is read with '%s'", ComparatorMismatch, tableComparatorName, name)
+	}
+	return nil
+}
+
 type SSTableIteratorI interface {
 	// Next returns the next key, value in sequence.
 	// Returns Done as the error when the iterator is exhausted
 	Next() ([]byte, []byte, error)
 }
 
+// SSTableSeekableIteratorI is a bidirectional iterator that can be re-positioned without creating a new iterator.
+// The iterator is positioned at an entry, Next and Prev return the entry at the current position and
+// move the position forward or backward respectively.
+type SSTableSeekableIteratorI interface {
+	SSTableIteratorI
+	// Prev returns the current key, value and moves the iterator backwards.
+	// Returns Done as the error when the iterator is exhausted
+	Prev() ([]byte, []byte, error)
+	// Seek positions the iterator at the first key that is greater or equal to the given key.
+	Seek(key []byte) error
+	// SeekForPrev positions the iterator at the last key that is less or equal to the given key.
+	SeekForPrev(key []byte) error
+	// SeekToFirst positions the iterator at the first key of the sequence.
+	SeekToFirst() error
+	// SeekToLast positions the iterator at the last key of the sequence.
+	SeekToLast() error
+	// Valid returns true when the iterator is positioned at an entry, false when it was exhausted in either direction.
+	Valid() bool
+}
+
 type SSTableReaderI interface {
 	// Contains returns true when the given key exists, false otherwise
 	Contains(key []byte) (bool, error)
 	// Get returns the value associated with the given key, NotFound as the error otherwise
 	Get(key []byte) ([]byte, error)
+	// GetAsOf returns the value of the latest version of the user key with a sequence number lower or equal to the given
+	// sequence, for tables that were written WithInternalKeys. NotFound is returned when the version was deleted,
+	// MergeOperandFound when the version is a merge operand.
+	GetAsOf(userKey []byte, sequence uint64) ([]byte, error)
+	// ApproximateOffsetOf returns the approximate offset in the data file at which the given key is or would be stored.
+	ApproximateOffsetOf(key []byte) (uint64, error)
+	// ApproximateSizeOfRange returns the approximate number of data file bytes the keys in [keyLower, keyHigher) occupy.
+	ApproximateSizeOfRange(keyLower []byte, keyHigher []byte) (uint64, error)
+	// SampleKeys returns up to n keys that are evenly spaced by their position in the index, starting with the first key.
+	SampleKeys(n int) ([][]byte, error)
+	// KeyQuantiles returns up to n-1 ascending keys that split the table into n ranges of approximately equal data size.
+	// Every key is the inclusive start of the next range.
+	KeyQuantiles(n int) ([][]byte, error)
+	// MultiGet looks up all the given keys at once, which is more efficient than calling Get for each of them.
+	// The values and errors are returned in the same order as the keys, the error of a key is NotFound if it doesn't exist.
+	MultiGet(keys [][]byte) ([][]byte, []error)
 	// Scan returns an iterator over the whole sorted sequence. Scan uses a more optimized version that iterates the
 	// data file sequentially, whereas the other Scan* functions use the index and random access using mmap.
 	Scan() (SSTableIteratorI, error)
@@ -40,10 +103,21 @@ type SSTableReaderI interface {
 	// Using keys that are out of the sequence range will result in either an empty iterator or the full sequence.
 	// If keyHigher is lower than keyLower an error will be returned.
 	ScanRange(keyLower []byte, keyHigher []byte) (SSTableIteratorI, error)
+	// ScanPrefix returns an iterator over the sorted sequence of all keys that start with the given prefix.
+	// When the table was written with a PrefixExtractor, the bloom filter is used to skip tables that can't contain the prefix.
+	ScanPrefix(prefix []byte) (SSTableIteratorI, error)
+	// SeekableIterator returns a bidirectional iterator that is positioned at the first key of the sequence.
+	// Reverse range scans can be done by seeking with SeekForPrev and iterating using Prev.
+	SeekableIterator() (SSTableSeekableIteratorI, error)
+	// RangeTombstones returns the sorted and non-overlapping range tombstones of this sstable. The tombstones only delete
+	// keys of older sstables, the keys of this sstable always take precedence over them.
+	RangeTombstones() RangeTombstones
 	// Close closes this sstable reader
 	Close() error
 	// MetaData returns the metadata of this sstable
 	MetaData() *proto.MetaData
+	// Properties returns the user-defined properties that were stored with WithProperties and WithPropertiesCollector.
+	Properties() map[string][]byte
 	// BasePath returns the base path / root path of this sstable that contains all the files.
 	BasePath() string
 }
@@ -58,6 +132,8 @@ type SSTableStreamWriterI interface {
 	Open() error
 	// WriteNext writes the next record to a sstable disk structure, expects keys to be ordered.
 	WriteNext(key []byte, value []byte) error
+	// WriteRangeTombstone deletes all keys in [start, end) of older sstables, can be called in any order until Close.
+	WriteRangeTombstone(start []byte, end []byte) error
 	// Close closes the sstable files.
 	Close() error
 }
@@ -39,9 +39,26 @@ type SortedKeyIndex interface {
 	// Using keys that are out of the sequence range will result in either an empty iterator or the full sequence.
 	// If keyHigher is lower than keyLower an error will be returned
 	IteratorBetween(keyLower []byte, keyHigher []byte) (skiplist.IteratorI[[]byte, IndexVal], error)
+	// SeekableIterator returns a bidirectional iterator that is positioned at the first key of the index.
+	SeekableIterator() (skiplist.SeekableIteratorI[[]byte, IndexVal], error)
+}
+
+// BatchSortedKeyIndex is implemented by indices that can look up multiple keys more efficiently than calling Get for each.
+type BatchSortedKeyIndex interface {
+	SortedKeyIndex
+	// GetAll returns the IndexVal for each of the given keys, which should be sorted ascending for the best performance.
+	// The error of a key is skiplist.NotFound if it does not exist.
+	GetAll(keys [][]byte) ([]IndexVal, []error)
 }
 
 type IndexLoader interface {
 	// Load is creating a SortedKeyIndex from the given path.
 	Load(path string, metadata *proto.MetaData) (SortedKeyIndex, error)
 }
+
+// SourceIndexLoader is implemented by index loaders that can load the index of a table that is opened with ReadFromSource.
+type SourceIndexLoader interface {
+	IndexLoader
+	// LoadFromSource is creating a SortedKeyIndex from the IndexFileName of the given source.
+	LoadFromSource(source TableSource, metadata *proto.MetaData) (SortedKeyIndex, error)
+}
@@ -23,6 +23,16 @@ var indexLoaders = []func() IndexLoader{
 	func() IndexLoader {
 		return &DiskIndexLoader{}
 	},
+	func() IndexLoader {
+		return &PartitionedIndexLoader{}
+	},
+	func() IndexLoader {
+		// every partition holds two entries, only two of them are cached
+		return &PartitionedIndexLoader{MaxCachedPartitions: 2, ScanPartitionSizeBytes: 40}
+	},
+	func() IndexLoader {
+		return &HashIndexLoader{}
+	},
 	func() IndexLoader {
 		return &MapKeyIndexLoader[[4]byte]{
 			ReadBufferSize: 4096,
@@ -281,6 +291,85 @@ func TestIndexIteratorBetweenHoles(t *testing.T) {
 	}
 }
 
+func TestIndexSeekableIterator(t *testing.T) {
+	writer, err := newTestSSTableSimpleWriter()
+	require.Nil(t, err)
+	defer cleanWriterDir(t, writer.streamWriter)
+
+	elements := []int{0, 1, 2, 4, 8, 9, 10}
+	err = writer.WriteSkipListMap(TEST_ONLY_NewSkipListMapWithElements(elements))
+	require.Nil(t, err)
+
+	for _, loaderFunc := range indexLoaders {
+		loader := loaderFunc()
+		t.Run(reflect.TypeOf(loader).String(), func(t *testing.T) {
+			idx, err := loader.Load(writer.streamWriter.indexFilePath, &proto.MetaData{})
+			require.NoError(t, err)
+
+			require.NoError(t, idx.Open())
+			defer func() {
+				require.NoError(t, idx.Close())
+			}()
+
+			it, err := idx.SeekableIterator()
+			require.NoError(t, err)
+			assert.True(t, it.Valid())
+			assertIndexIteratorMatchesSlice(t, it, elements)
+			assert.False(t, it.Valid())
+
+			require.NoError(t, it.SeekToLast())
+			assertIndexReverseIteratorMatchesSlice(t, it, []int{10, 9, 8, 4, 2, 1, 0})
+
+			require.NoError(t, it.SeekToFirst())
+			assertIndexIteratorMatchesSlice(t, it, elements)
+
+			require.NoError(t, it.Seek(intToByteSlice(5)))
+			assertIndexIteratorMatchesSlice(t, it, []int{8, 9, 10})
+			require.NoError(t, it.Seek(intToByteSlice(4)))
+			assertIndexReverseIteratorMatchesSlice(t, it, []int{4, 2, 1, 0})
+			require.NoError(t, it.Seek(intToByteSlice(11)))
+			assert.False(t, it.Valid())
+
+			require.NoError(t, it.SeekForPrev(intToByteSlice(7)))
+			assertIndexReverseIteratorMatchesSlice(t, it, []int{4, 2, 1, 0})
+			require.NoError(t, it.SeekForPrev(intToByteSlice(8)))
+			assertIndexReverseIteratorMatchesSlice(t, it, []int{8, 4, 2, 1, 0})
+			require.NoError(t, it.SeekForPrev(intToByteSlice(100)))
+			assertIndexReverseIteratorMatchesSlice(t, it, []int{10, 9, 8, 4, 2, 1, 0})
+			require.NoError(t, it.SeekForPrev([]byte{}))
+			assert.False(t, it.Valid())
+
+			// switching the direction returns the current entry again
+			require.NoError(t, it.Seek(intToByteSlice(2)))
+			k, _, err := it.Next()
+			require.NoError(t, err)
+			assert.Equal(t, intToByteSlice(2), k)
+			k, _, err = it.Prev()
+			require.NoError(t, err)
+			assert.Equal(t, intToByteSlice(4), k)
+			k, _, err = it.Prev()
+			require.NoError(t, err)
+			assert.Equal(t, intToByteSlice(2), k)
+		})
+	}
+}
+
+func assertIndexReverseIteratorMatchesSlice(t *testing.T, it skiplist.SeekableIteratorI[[]byte, IndexVal], expectedSlice []int) {
+	numRead := 0
+	for _, e := range expectedSlice {
+		actualKey, _, err := it.Prev()
+		require.Nil(t, err)
+		assert.Equal(t, e, int(binary.BigEndian.Uint32(actualKey)))
+		numRead++
+	}
+	assert.Equal(t, len(expectedSlice), numRead)
+	assert.False(t, it.Valid())
+	k, v, err := it.Prev()
+	assert.Equal(t, Done, err)
+	require.Nil(t, k)
+	require.Equal(t, IndexVal{}, v)
+}
+
 func assertIndexIteratorMatchesSlice(t *testing.T, it skiplist.IteratorI[[]byte, IndexVal], expectedSlice []int) {
 	numRead := 0
 	for _, e := range expectedSlice {
@@ -23,7 +23,7 @@ func (it *SSTableIterator) Next() ([]byte, []byte, error) {
 		}
 	}
 
-	valBytes, err := it.reader.getValueAtOffset(iv, it.reader.opts.skipHashCheckOnRead)
+	valBytes, err := it.reader.getScannedValueAtOffset(iv)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -31,6 +31,77 @@ func (it *SSTableIterator) Next() ([]byte, []byte, error) {
 	return key, valBytes, nil
 }
 
+// SSTableSeekableIterator is a bidirectional iterator over the index that reads the values using random access via mmap.
+type SSTableSeekableIterator struct {
+	reader      *SSTableReader
+	keyIterator skiplist.SeekableIteratorI[[]byte, IndexVal]
+}
+
+func (it *SSTableSeekableIterator) Next() ([]byte, []byte, error) {
+	return it.readValue(it.keyIterator.Next())
+}
+
+func (it *SSTableSeekableIterator) Prev() ([]byte, []byte, error) {
+	return it.readValue(it.keyIterator.Prev())
+}
+
+func (it *SSTableSeekableIterator) readValue(key []byte, iv IndexVal, err error) ([]byte, []byte, error) {
+	if err != nil {
+		if errors.Is(err, skiplist.Done) {
+			return nil, nil, Done
+		} else {
+			return nil, nil, err
+		}
+	}
+
+	valBytes, err := it.reader.getScannedValueAtOffset(iv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, valBytes, nil
+}
+
+func (it *SSTableSeekableIterator) Seek(key []byte) error {
+	return it.keyIterator.Seek(key)
+}
+
+func (it *SSTableSeekableIterator) SeekForPrev(key []byte) error {
+	return it.keyIterator.SeekForPrev(key)
+}
+
+func (it *SSTableSeekableIterator) SeekToFirst() error {
+	return it.keyIterator.SeekToFirst()
+}
+
+func (it *SSTableSeekableIterator) SeekToLast() error {
+	return it.keyIterator.SeekToLast()
+}
+
+func (it *SSTableSeekableIterator) Valid() bool {
+	return it.keyIterator.Valid()
+}
+
+// valueDecodingIterator decodes the values of the underlying iterator, e.g. to resolve blob references.
+type valueDecodingIterator struct {
+	iterator SSTableIteratorI
+	decode   func([]byte) ([]byte, error)
+}
+
+func (it *valueDecodingIterator) Next() ([]byte, []byte, error) {
+	key, value, err := it.iterator.Next()
+	if err != nil {
+		return key, value, err
+	}
+
+	value, err = it.decode(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, value, nil
+}
+
 // V0SSTableFullScanIterator deprecated, since this is for the v0 protobuf based sstables.
 // this is an optimized iterator that does a sequential read over the index+data files instead of a
 // sequential read on the index with a random access lookup on the data file via mmap
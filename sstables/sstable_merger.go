@@ -1,8 +1,12 @@
 package sstables
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
+
 	"github.com/thomasjungblut/go-sstables/pq"
 	"github.com/thomasjungblut/go-sstables/skiplist"
 )
@@ -10,8 +14,14 @@ import (
 type ReduceFunc func([]byte, [][]byte, []int) ([]byte, []byte)
 
 type SSTableMergeIteratorContext struct {
-	ctx      int
-	iterator SSTableIteratorI
+	ctx             int
+	iterator        SSTableIteratorI
+	rangeTombstones RangeTombstones
+	comparatorName  string
+	// mergeOperands is true when the values are encoded MergeValues
+	mergeOperands bool
+	// expiringValues is true when the values are prefixed with their expiry time
+	expiringValues bool
 }
 
 func (s SSTableMergeIteratorContext) Next() ([]byte, []byte, error) {
@@ -19,6 +29,9 @@ func (s SSTableMergeIteratorContext) Next() ([]byte, []byte, error) {
 	if errors.Is(err, Done) {
 		return nil, nil, pq.Done
 	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error in iterator with context %d: %w", s.ctx, err)
+	}
 	return k, v, nil
 }
 
@@ -33,12 +46,75 @@ func NewMergeIteratorContext(context int, iterator SSTableIteratorI) SSTableMerg
 	}
 }
 
+// NewMergeIteratorContextWithRangeTombstones creates a merge context whose range tombstones delete the keys of all
+// iterators with a lower context. That assumes the context denotes the age, where a higher context is newer.
+func NewMergeIteratorContextWithRangeTombstones(context int, iterator SSTableIteratorI, tombstones RangeTombstones) SSTableMergeIteratorContext {
+	return SSTableMergeIteratorContext{
+		ctx:             context,
+		iterator:        iterator,
+		rangeTombstones: tombstones,
+	}
+}
+
+// NewReaderMergeIteratorContext creates a merge context for an iterator over the given reader. Alongside the range
+// tombstones of the reader, it carries the name of the comparator the reader's table was written with, so the merger
+// can refuse to merge tables that are sorted differently, and whether the table contains merge operands or values
+// with their expiry time, when the reader was opened with ReadEncodedExpiry.
+func NewReaderMergeIteratorContext(context int, iterator SSTableIteratorI, reader SSTableReaderI) SSTableMergeIteratorContext {
+	return SSTableMergeIteratorContext{
+		ctx:             context,
+		iterator:        iterator,
+		rangeTombstones: reader.RangeTombstones(),
+		comparatorName:  reader.MetaData().ComparatorName,
+		mergeOperands:   reader.MetaData().MergeOperands,
+		expiringValues:  readsEncodedExpiry(reader),
+	}
+}
+
+// readsEncodedExpiry is true if the values of the reader are returned with their expiry time.
+func readsEncodedExpiry(reader SSTableReaderI) bool {
+	r, ok := reader.(*SSTableReader)
+	return ok && r.metaData.ExpiringValues && r.opts.readEncodedExpiry && !r.opts.readEncodedValues
+}
+
+func collectRangeTombstones(iterators []SSTableMergeIteratorContext) []contextRangeTombstones {
+	var tombstones []contextRangeTombstones
+	for _, iterator := range iterators {
+		if len(iterator.rangeTombstones) > 0 {
+			tombstones = append(tombstones, contextRangeTombstones{ctx: iterator.ctx, tombstones: iterator.rangeTombstones})
+		}
+	}
+	return tombstones
+}
+
 type SSTableMerger struct {
-	comp skiplist.Comparator[[]byte]
+	comp          skiplist.Comparator[[]byte]
+	mergeOperator MergeOperator
+	// resolveMerges fully merges all operands and returns decoded values, which is only correct when the oldest
+	// table of a key is part of the merge
+	resolveMerges bool
+	// bottommost is true when the merge covers all tables that contain its keys
+	bottommost bool
+}
+
+// checkComparatorNames ensures that all iterators are sorted with the comparator of the merger.
+func (m SSTableMerger) checkComparatorNames(iterators []SSTableMergeIteratorContext) error {
+	for _, iterator := range iterators {
+		err := checkComparatorName(m.comp, iterator.comparatorName)
+		if err != nil {
+			return fmt.Errorf("iterator with context %d can't be merged: %w", iterator.ctx, err)
+		}
+	}
+	return nil
 }
 
 // Merge accepts a slice of sstable iterators to merge into an already opened writer. The caller needs to close the writer.
 func (m SSTableMerger) Merge(iterators []SSTableMergeIteratorContext, writer SSTableStreamWriterI) (err error) {
+	err = m.checkComparatorNames(iterators)
+	if err != nil {
+		return err
+	}
+
 	var iteratorWithContext []pq.IteratorWithContext[[]byte, []byte, int]
 	for _, iterator := range iterators {
 		iteratorWithContext = append(iteratorWithContext, iterator)
@@ -68,12 +144,136 @@ func (m SSTableMerger) Merge(iterators []SSTableMergeIteratorContext, writer SST
 }
 
 type MergeCompactionIterator struct {
-	comp    skiplist.Comparator[[]byte]
-	reduce  func([]byte, [][]byte, []int) ([]byte, []byte)
-	pq      pq.PriorityQueueI[[]byte, []byte, int]
-	prevKey []byte
-	valBuf  [][]byte
-	ctxBuf  []int
+	comp       skiplist.Comparator[[]byte]
+	reduce     func([]byte, [][]byte, []int) ([]byte, []byte)
+	pq         pq.PriorityQueueI[[]byte, []byte, int]
+	prevKey    []byte
+	valBuf     [][]byte
+	ctxBuf     []int
+	tombstones []contextRangeTombstones
+
+	mergeOperator MergeOperator
+	resolveMerges bool
+	// mergeContexts contains the contexts whose values are encoded MergeValues
+	mergeContexts map[int]bool
+	// expiringContexts contains the contexts whose values are prefixed with their expiry time, when it's not empty
+	// the expired values are replaced by tombstones and all values are returned with their expiry time
+	expiringContexts map[int]bool
+	// dropExpired removes the keys whose values have all expired, instead of reducing their tombstones
+	dropExpired bool
+}
+
+// reduceValues removes all values that were deleted by range tombstones and reduces the remainder.
+func (m *MergeCompactionIterator) reduceValues(key []byte, values [][]byte, context []int) ([]byte, []byte, error) {
+	if len(m.tombstones) > 0 {
+		var liveValues [][]byte
+		var liveContext []int
+		for i, c := range context {
+			if !isRangeDeleted(m.comp, m.tombstones, key, c) {
+				liveValues = append(liveValues, values[i])
+				liveContext = append(liveContext, c)
+			}
+		}
+
+		if len(liveValues) == 0 {
+			return nil, nil, nil
+		}
+		values, context = liveValues, liveContext
+	}
+
+	if len(m.expiringContexts) > 0 {
+		var numExpired int
+		var err error
+		values, numExpired, err = expireValues(values, context, m.expiringContexts, time.Now())
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while expiring values of key %v: %w", key, err)
+		}
+		// no older table can contain a value that the tombstones would need to delete
+		if m.dropExpired && numExpired == len(values) {
+			return nil, nil, nil
+		}
+	}
+
+	if len(m.mergeContexts) > 0 {
+		var err error
+		values, context, err = m.collapseMergeOperands(key, values, context)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	k, v := m.reduce(key, values, context)
+	return k, v, nil
+}
+
+// collapseMergeOperands folds the values from the newest to the oldest context into a single value. Merges of tables
+// with merge operands return the value encoded, so it can be written into a table WithMergeOperands again.
+func (m *MergeCompactionIterator) collapseMergeOperands(key []byte, values [][]byte, context []int) ([][]byte, []int, error) {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return context[order[i]] > context[order[j]]
+	})
+
+	folder := &mergeFolder{key: key}
+	var baseExpiry time.Time
+	for _, i := range order {
+		v := values[i]
+		var expiresAt time.Time
+		if len(m.expiringContexts) > 0 {
+			var err error
+			v, expiresAt, err = DecodeExpiringValue(v)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error while decoding expiry of key %v in context %d: %w", key, context[i], err)
+			}
+		}
+
+		value := plainMergeValue(v)
+		if m.mergeContexts[context[i]] {
+			var err error
+			value, err = DecodeMergeValue(v)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error while decoding value of key %v in context %d: %w", key, context[i], err)
+			}
+		}
+
+		if folder.add(value) {
+			baseExpiry = expiresAt
+			break
+		}
+		// the tombstones of a context delete the values of all older contexts
+		if isRangeDeleted(m.comp, m.tombstones, key, context[i]-1) {
+			folder.add(MergeValue{Kind: ValueKindDelete})
+			break
+		}
+	}
+
+	if m.resolveMerges && !folder.done {
+		folder.add(MergeValue{Kind: ValueKindDelete})
+	}
+
+	merged, err := folder.merge(m.mergeOperator)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newest := []int{context[order[0]]}
+	if !m.resolveMerges {
+		if len(m.expiringContexts) > 0 {
+			// only an unchanged put keeps its expiry, merged values are written anew
+			if len(folder.operands) > 0 {
+				baseExpiry = time.Time{}
+			}
+			return [][]byte{EncodeExpiringValue(merged.Encode(), baseExpiry)}, newest, nil
+		}
+		return [][]byte{merged.Encode()}, newest, nil
+	}
+	if merged.Kind == ValueKindPut {
+		return [][]byte{merged.Value}, newest, nil
+	}
+	return [][]byte{{}}, newest, nil
 }
 
 func (m *MergeCompactionIterator) Next() ([]byte, []byte, error) {
@@ -82,7 +282,10 @@ func (m *MergeCompactionIterator) Next() ([]byte, []byte, error) {
 		if err != nil {
 			if errors.Is(err, pq.Done) {
 				if len(m.valBuf) > 0 {
-					kReduced, vReduced := m.reduce(m.prevKey, m.valBuf, m.ctxBuf)
+					kReduced, vReduced, err := m.reduceValues(m.prevKey, m.valBuf, m.ctxBuf)
+					if err != nil {
+						return nil, nil, err
+					}
 					if kReduced != nil && vReduced != nil {
 						// clear the buffer, so we don't infinite loop on the last elements
 						m.valBuf = m.valBuf[:0]
@@ -98,7 +301,10 @@ func (m *MergeCompactionIterator) Next() ([]byte, []byte, error) {
 		var toReturnKey, toReturnVal []byte
 		//we have to accumulate the whole sequence
 		if m.prevKey != nil && m.comp.Compare(k, m.prevKey) != 0 {
-			kReduced, vReduced := m.reduce(m.prevKey, m.valBuf, m.ctxBuf)
+			kReduced, vReduced, err := m.reduceValues(m.prevKey, m.valBuf, m.ctxBuf)
+			if err != nil {
+				return nil, nil, err
+			}
 			if kReduced != nil && vReduced != nil {
 				toReturnKey = kReduced
 				toReturnVal = vReduced
@@ -118,6 +324,11 @@ func (m *MergeCompactionIterator) Next() ([]byte, []byte, error) {
 }
 
 func (m SSTableMerger) MergeCompactIterator(iterators []SSTableMergeIteratorContext, reduce ReduceFunc) (SSTableIteratorI, error) {
+	err := m.checkComparatorNames(iterators)
+	if err != nil {
+		return nil, err
+	}
+
 	var iteratorWithContext []pq.IteratorWithContext[[]byte, []byte, int]
 	for _, iterator := range iterators {
 		iteratorWithContext = append(iteratorWithContext, iterator)
@@ -127,28 +338,74 @@ func (m SSTableMerger) MergeCompactIterator(iterators []SSTableMergeIteratorCont
 		return nil, fmt.Errorf("merge compact error while initializing the heap: %w", err)
 	}
 
+	return m.newMergeCompactionIterator(pqq, reduce, iterators), nil
+}
+
+func (m SSTableMerger) newMergeCompactionIterator(pqq pq.PriorityQueueI[[]byte, []byte, int],
+	reduce ReduceFunc, iterators []SSTableMergeIteratorContext) *MergeCompactionIterator {
 	var prevKey []byte
 	valBuf := make([][]byte, 0)
 	ctxBuf := make([]int, 0)
 
+	mergeContexts := map[int]bool{}
+	expiringContexts := map[int]bool{}
+	for _, iterator := range iterators {
+		if iterator.mergeOperands {
+			mergeContexts[iterator.ctx] = true
+		}
+		if iterator.expiringValues {
+			expiringContexts[iterator.ctx] = true
+		}
+	}
+
 	return &MergeCompactionIterator{
-		comp:    m.comp,
-		reduce:  reduce,
-		pq:      pqq,
-		prevKey: prevKey,
-		valBuf:  valBuf,
-		ctxBuf:  ctxBuf,
-	}, nil
+		comp:             m.comp,
+		reduce:           reduce,
+		pq:               pqq,
+		prevKey:          prevKey,
+		valBuf:           valBuf,
+		ctxBuf:           ctxBuf,
+		tombstones:       collectRangeTombstones(iterators),
+		mergeOperator:    m.mergeOperator,
+		resolveMerges:    m.resolveMerges,
+		mergeContexts:    mergeContexts,
+		expiringContexts: expiringContexts,
+		dropExpired:      m.bottommost,
+	}
+}
 
+// writeMergedRangeTombstones carries the range tombstones of all iterators forward into the writer.
+func writeMergedRangeTombstones(iterators []SSTableMergeIteratorContext, writer SSTableStreamWriterI) error {
+	for _, iterator := range iterators {
+		for _, t := range iterator.rangeTombstones {
+			err := writer.WriteRangeTombstone(t.Start, t.End)
+			if err != nil {
+				return fmt.Errorf("merge compact error while writing range tombstone: %w", err)
+			}
+		}
+	}
+	return nil
 }
 
 // MergeCompact accepts a slice of sstable iterators to merge into an already opened writer. The caller needs to close the writer.
+// Keys that are deleted by range tombstones of newer iterators are dropped, the range tombstones themselves are carried
+// forward into the writer, since they might still delete keys in sstables that are older than all the given iterators.
+// When any of the iterators contains merge operands, the values of each key are collapsed with the MergeOperator into
+// a single encoded MergeValue before they are reduced, which requires the writer to be opened WithMergeOperands.
+// Operands without an older put or delete are only partially merged, since they may apply to older sstables.
+// Readers of tables WithExpiringValues that were opened with ReadEncodedExpiry have their expired values replaced by
+// tombstones, the remaining values are returned with their expiry time, which requires a writer WithExpiringValues.
 func (m SSTableMerger) MergeCompact(iterators []SSTableMergeIteratorContext, writer SSTableStreamWriterI, reduce ReduceFunc) (err error) {
 	iterator, err := m.MergeCompactIterator(iterators, reduce)
 	if err != nil {
 		return fmt.Errorf("merge compact error while initializing the iterator: %w", err)
 	}
 
+	err = writeMergedRangeTombstones(iterators, writer)
+	if err != nil {
+		return err
+	}
+
 	for {
 		k, v, err := iterator.Next()
 		if err != nil {
@@ -159,11 +416,175 @@ func (m SSTableMerger) MergeCompact(iterators []SSTableMergeIteratorContext, wri
 			}
 		}
 		err = writer.WriteNext(k, v)
+		if err != nil {
+			return fmt.Errorf("merge compact error while writing next record: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// VersionCompactionIterator merges iterators over internal keys, which groups all versions of a user key and
+// reduces them via a VersionReduceFunc.
+type VersionCompactionIterator struct {
+	pq     pq.PriorityQueueI[[]byte, []byte, int]
+	reduce VersionReduceFunc
+
+	peekKey   *InternalKey
+	peekValue []byte
+	peekCtx   int
+
+	userKey []byte
+	output  []KeyVersion
+}
+
+func (m *VersionCompactionIterator) Next() ([]byte, []byte, error) {
+	for len(m.output) == 0 {
+		err := m.reduceNextUserKey()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	version := m.output[0]
+	m.output = m.output[1:]
+	return EncodeInternalKey(m.userKey, version.Sequence, version.Kind), version.Value, nil
+}
+
+func (m *VersionCompactionIterator) fetch() error {
+	k, v, c, err := m.pq.Next()
+	if err != nil {
+		if errors.Is(err, pq.Done) {
+			m.peekKey = nil
+			return nil
+		}
+		return err
+	}
+
+	internalKey, err := DecodeInternalKey(k)
+	if err != nil {
+		return err
+	}
+
+	m.peekKey = &internalKey
+	m.peekValue = v
+	m.peekCtx = c
+	return nil
+}
+
+func (m *VersionCompactionIterator) reduceNextUserKey() error {
+	if m.peekKey == nil {
+		return Done
 	}
 
+	userKey := m.peekKey.UserKey
+	var versions []KeyVersion
+	var contexts []int
+	for m.peekKey != nil && bytes.Equal(m.peekKey.UserKey, userKey) {
+		last := len(versions) - 1
+		if last >= 0 && versions[last].Sequence == m.peekKey.Sequence && versions[last].Kind == m.peekKey.Kind {
+			// the very same version exists in multiple iterators, the latest context wins
+			if m.peekCtx > contexts[last] {
+				versions[last].Value = m.peekValue
+				contexts[last] = m.peekCtx
+			}
+		} else {
+			versions = append(versions, KeyVersion{Sequence: m.peekKey.Sequence, Kind: m.peekKey.Kind, Value: m.peekValue})
+			contexts = append(contexts, m.peekCtx)
+		}
+
+		if err := m.fetch(); err != nil {
+			return err
+		}
+	}
+
+	m.userKey = userKey
+	m.output = m.reduce(userKey, versions)
 	return nil
 }
 
-func NewSSTableMerger(comp skiplist.Comparator[[]byte]) SSTableMerger {
-	return SSTableMerger{comp}
+// MergeCompactVersionsIterator merges iterators over internal keys (see EncodeInternalKey). All versions of a user key
+// are presented to the reduce function at once, which then decides which of the versions are kept.
+func (m SSTableMerger) MergeCompactVersionsIterator(iterators []SSTableMergeIteratorContext, reduce VersionReduceFunc) (SSTableIteratorI, error) {
+	err := m.checkComparatorNames(iterators)
+	if err != nil {
+		return nil, err
+	}
+
+	var iteratorWithContext []pq.IteratorWithContext[[]byte, []byte, int]
+	for _, iterator := range iterators {
+		iteratorWithContext = append(iteratorWithContext, iterator)
+	}
+	pqq, err := pq.NewPriorityQueue[[]byte, []byte, int](m.comp, iteratorWithContext)
+	if err != nil {
+		return nil, fmt.Errorf("merge compact versions error while initializing the heap: %w", err)
+	}
+
+	it := &VersionCompactionIterator{pq: pqq, reduce: reduce}
+	err = it.fetch()
+	if err != nil {
+		return nil, fmt.Errorf("merge compact versions error while initializing the iterator: %w", err)
+	}
+
+	return it, nil
+}
+
+// MergeCompactVersions is like MergeCompact, but for tables with internal keys. The caller needs to close the writer.
+func (m SSTableMerger) MergeCompactVersions(iterators []SSTableMergeIteratorContext, writer SSTableStreamWriterI, reduce VersionReduceFunc) error {
+	iterator, err := m.MergeCompactVersionsIterator(iterators, reduce)
+	if err != nil {
+		return err
+	}
+
+	for {
+		k, v, err := iterator.Next()
+		if err != nil {
+			if errors.Is(err, Done) {
+				break
+			} else {
+				return fmt.Errorf("merge compact versions error while iterating: %w", err)
+			}
+		}
+
+		err = writer.WriteNext(k, v)
+		if err != nil {
+			return fmt.Errorf("merge compact versions error while writing next record: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func NewSSTableMerger(comp skiplist.Comparator[[]byte], opts ...MergerOption) SSTableMerger {
+	options := &SSTableMergerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return SSTableMerger{comp: comp, mergeOperator: options.mergeOperator, bottommost: options.bottommost}
+}
+
+// options
+
+type SSTableMergerOptions struct {
+	mergeOperator MergeOperator
+	bottommost    bool
+}
+
+type MergerOption func(*SSTableMergerOptions)
+
+// WithMergeOperator collapses the merge operands of iterators over tables that were written WithMergeOperands.
+func WithMergeOperator(operator MergeOperator) MergerOption {
+	return func(args *SSTableMergerOptions) {
+		args.mergeOperator = operator
+	}
+}
+
+// WithBottommostMerge declares that the merged tables are the only ones that contain their keys, for example in a full
+// compaction. Keys whose values have all expired are then dropped by MergeCompact instead of being reduced as
+// tombstones, as there is no older value left that they would need to delete.
+func WithBottommostMerge() MergerOption {
+	return func(args *SSTableMergerOptions) {
+		args.bottommost = true
+	}
 }
@@ -1,6 +1,7 @@
 package sstables
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thomasjungblut/go-sstables/skiplist"
@@ -193,3 +194,22 @@ func TestMergeAndCompactEmptyResult(t *testing.T) {
 	require.Nil(t, outWriter.Close())
 	assertRandomAndSequentialRead(t, outWriter.opts.basePath, []int{})
 }
+
+func TestMergeAndCompactPropagatesIteratorErrors(t *testing.T) {
+	iteratorErr := errors.New("iterator failed")
+	merger := NewSSTableMerger(skiplist.BytesComparator{})
+	for _, n := range []int{0, 10} {
+		writer := &failingMergeWriter{remaining: 100}
+		err := merger.MergeCompact([]SSTableMergeIteratorContext{
+			NewMergeIteratorContext(0, &failingMergeIterator{n: n, err: iteratorErr}),
+		}, writer, ScanReduceLatestWins)
+		assert.ErrorIs(t, err, iteratorErr)
+		assert.ErrorContains(t, err, "iterator with context 0")
+
+		writer = &failingMergeWriter{remaining: 100}
+		err = merger.Merge([]SSTableMergeIteratorContext{
+			NewMergeIteratorContext(0, &failingMergeIterator{n: n, err: iteratorErr}),
+		}, writer)
+		assert.ErrorIs(t, err, iteratorErr)
+	}
+}
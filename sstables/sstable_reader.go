@@ -10,6 +10,8 @@ import (
 	"hash/fnv"
 
 	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/steakknife/bloomfilter"
 	"github.com/thomasjungblut/go-sstables/recordio"
@@ -44,26 +46,31 @@ type SSTableReader struct {
 	dataReader   recordio.ReadAtI
 	metaData     *proto.MetaData
 	miscClosers  []recordio.CloseableI
+
+	rangeTombstones RangeTombstones
 }
 
 func (reader *SSTableReader) Contains(key []byte) (bool, error) {
 	// short-cut for the bloom filter to tell whether it's not in the set (if available)
-	if reader.bloomFilter != nil {
-		fnvHash := fnv.New64()
-		_, err := fnvHash.Write(key)
-		if err != nil {
-			return false, err
-		}
-
-		if !reader.bloomFilter.Contains(fnvHash) {
-			return false, nil
-		}
+	if !reader.mightContain(key) {
+		return false, nil
 	}
 
 	// go back to the index/disk to see if the key is available
 	return reader.index.Contains(key)
 }
 
+// mightContain returns false if the bloom filter rules out the key, true if it might exist or there's no bloom filter.
+func (reader *SSTableReader) mightContain(key []byte) bool {
+	if reader.bloomFilter == nil {
+		return true
+	}
+
+	fnvHash := fnv.New64()
+	_, _ = fnvHash.Write(key)
+	return reader.bloomFilter.Contains(fnvHash)
+}
+
 func (reader *SSTableReader) Get(key []byte) ([]byte, error) {
 	iVal, err := reader.index.Get(key)
 	if err != nil {
@@ -73,7 +80,283 @@ func (reader *SSTableReader) Get(key []byte) ([]byte, error) {
 		return nil, fmt.Errorf("error in sstable '%s' on getting key from index: %w", reader.opts.basePath, err)
 	}
 
-	return reader.getValueAtOffset(iVal, reader.opts.skipHashCheckOnRead)
+	return reader.getDecodedValueAtOffset(iVal)
+}
+
+// MultiGet probes the bloom filter for all keys in sorted order, looks up the remaining keys in the index at once and then
+// reads the values in the order of their offset in the data file.
+func (reader *SSTableReader) MultiGet(keys [][]byte) ([][]byte, []error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+
+	var lookupKeys [][]byte
+	var lookupPositions []int
+	for _, i := range sortedKeyPositions(reader.opts.keyComparator, keys) {
+		if !reader.mightContain(keys[i]) {
+			errs[i] = NotFound
+			continue
+		}
+		lookupKeys = append(lookupKeys, keys[i])
+		lookupPositions = append(lookupPositions, i)
+	}
+
+	indexVals, indexErrs := getAllFromIndex(reader.index, lookupKeys)
+
+	type valueRead struct {
+		position int
+		iVal     IndexVal
+	}
+	var reads []valueRead
+	for j, i := range lookupPositions {
+		if indexErrs[j] != nil {
+			if errors.Is(indexErrs[j], skiplist.NotFound) {
+				errs[i] = NotFound
+			} else {
+				errs[i] = fmt.Errorf("error in sstable '%s' on getting key from index: %w", reader.opts.basePath, indexErrs[j])
+			}
+			continue
+		}
+		reads = append(reads, valueRead{position: i, iVal: indexVals[j]})
+	}
+
+	sort.SliceStable(reads, func(a, b int) bool {
+		return reads[a].iVal.Offset < reads[b].iVal.Offset
+	})
+
+	for _, r := range reads {
+		values[r.position], errs[r.position] = reader.getDecodedValueAtOffset(r.iVal)
+	}
+
+	return values, errs
+}
+
+func (reader *SSTableReader) ApproximateOffsetOf(key []byte) (uint64, error) {
+	it, err := reader.index.IteratorStartingAt(key)
+	if err != nil {
+		return 0, fmt.Errorf("error in sstable '%s' while approximating offset: %w", reader.opts.basePath, err)
+	}
+
+	_, iVal, err := it.Next()
+	if err != nil {
+		if errors.Is(err, skiplist.Done) {
+			return reader.dataSize(), nil
+		}
+		return 0, fmt.Errorf("error in sstable '%s' while approximating offset: %w", reader.opts.basePath, err)
+	}
+
+	return iVal.Offset, nil
+}
+
+func (reader *SSTableReader) ApproximateSizeOfRange(keyLower []byte, keyHigher []byte) (uint64, error) {
+	lower, err := reader.ApproximateOffsetOf(keyLower)
+	if err != nil {
+		return 0, err
+	}
+
+	higher, err := reader.ApproximateOffsetOf(keyHigher)
+	if err != nil {
+		return 0, err
+	}
+
+	if higher < lower {
+		return 0, nil
+	}
+	return higher - lower, nil
+}
+
+// SampleKeys iterates the whole index and picks every NumRecords/n-th key.
+func (reader *SSTableReader) SampleKeys(n int) ([][]byte, error) {
+	if n <= 0 || reader.metaData.NumRecords == 0 {
+		return nil, nil
+	}
+
+	step := float64(reader.metaData.NumRecords) / float64(n)
+	var samples [][]byte
+	err := reader.iterateIndex(func(i uint64, key []byte, _ IndexVal) bool {
+		if float64(i) >= float64(len(samples))*step {
+			samples = append(samples, append([]byte{}, key...))
+		}
+		return len(samples) < n
+	})
+	return samples, err
+}
+
+// KeyQuantiles iterates the whole index and picks the keys at which the value offsets cross the n-th parts of the data file.
+func (reader *SSTableReader) KeyQuantiles(n int) ([][]byte, error) {
+	if n <= 1 || reader.metaData.NumRecords == 0 {
+		return nil, nil
+	}
+
+	var quantiles [][]byte
+	var firstOffset uint64
+	size := reader.dataSize()
+	err := reader.iterateIndex(func(i uint64, key []byte, iVal IndexVal) bool {
+		if i == 0 {
+			firstOffset = iVal.Offset
+			return true
+		}
+
+		target := firstOffset + (size-firstOffset)*uint64(len(quantiles)+1)/uint64(n)
+		if iVal.Offset >= target {
+			quantiles = append(quantiles, append([]byte{}, key...))
+		}
+		return len(quantiles) < n-1
+	})
+	return quantiles, err
+}
+
+// iterateIndex calls fn for each index entry in order until it returns false.
+func (reader *SSTableReader) iterateIndex(fn func(i uint64, key []byte, iVal IndexVal) bool) error {
+	it, err := reader.index.Iterator()
+	if err != nil {
+		return fmt.Errorf("error in sstable '%s' while iterating index: %w", reader.opts.basePath, err)
+	}
+
+	for i := uint64(0); ; i++ {
+		k, iVal, err := it.Next()
+		if err != nil {
+			if errors.Is(err, skiplist.Done) {
+				return nil
+			}
+			return fmt.Errorf("error in sstable '%s' while iterating index: %w", reader.opts.basePath, err)
+		}
+
+		if !fn(i, k, iVal) {
+			return nil
+		}
+	}
+}
+
+func (reader *SSTableReader) dataSize() uint64 {
+	if reader.v0DataReader != nil {
+		return reader.v0DataReader.Size()
+	}
+	return reader.dataReader.Size()
+}
+
+// sortedKeyPositions returns the positions of the keys in ascending key order.
+func sortedKeyPositions(cmp skiplist.Comparator[[]byte], keys [][]byte) []int {
+	positions := make([]int, len(keys))
+	for i := range positions {
+		positions[i] = i
+	}
+	sort.SliceStable(positions, func(a, b int) bool {
+		return cmp.Compare(keys[positions[a]], keys[positions[b]]) < 0
+	})
+	return positions
+}
+
+// getAllFromIndex uses BatchSortedKeyIndex if the index supports it, otherwise looks up every key separately.
+func getAllFromIndex(index SortedKeyIndex, keys [][]byte) ([]IndexVal, []error) {
+	if batchIndex, ok := index.(BatchSortedKeyIndex); ok {
+		return batchIndex.GetAll(keys)
+	}
+
+	vals := make([]IndexVal, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		vals[i], errs[i] = index.Get(key)
+	}
+	return vals, errs
+}
+
+func (reader *SSTableReader) GetAsOf(userKey []byte, sequence uint64) ([]byte, error) {
+	version, err := getVersionAsOf(reader, userKey, sequence)
+	if err != nil {
+		return nil, err
+	}
+
+	return versionValue(version)
+}
+
+// getDecodedValueAtOffset returns the value at the given offset, with its blob reference already resolved.
+// Expired values return ExpiredValue.
+func (reader *SSTableReader) getDecodedValueAtOffset(iVal IndexVal) ([]byte, error) {
+	v, err := reader.getValueAtOffset(iVal, reader.opts.skipHashCheckOnRead)
+	if err != nil {
+		// the raw value is still returned alongside checksum errors
+		return v, err
+	}
+
+	return reader.decodeValue(v)
+}
+
+// getScannedValueAtOffset is getDecodedValueAtOffset for the iterators, see scanValue.
+func (reader *SSTableReader) getScannedValueAtOffset(iVal IndexVal) ([]byte, error) {
+	v, err := reader.getValueAtOffset(iVal, reader.opts.skipHashCheckOnRead)
+	if err != nil {
+		return v, err
+	}
+
+	return reader.scanValue(v)
+}
+
+// decodeValue decodes the stored value and removes its expiry, it returns ExpiredValue if the value has expired.
+func (reader *SSTableReader) decodeValue(v []byte) ([]byte, error) {
+	v, err := reader.decodeStoredValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reader.decodesExpiry() {
+		return v, nil
+	}
+
+	v, err = decodeUnexpiredValue(v, time.Now())
+	if err != nil && !errors.Is(err, ExpiredValue) {
+		return nil, fmt.Errorf("error in sstable '%s' while decoding value: %w", reader.opts.basePath, err)
+	}
+	return v, err
+}
+
+// scanValue is decodeValue for the iterators, which return expired values as empty values just like tombstones.
+// That way they still take precedence over the values of older tables when they are merged.
+func (reader *SSTableReader) scanValue(v []byte) ([]byte, error) {
+	v, err := reader.decodeValue(v)
+	if errors.Is(err, ExpiredValue) {
+		return []byte{}, nil
+	}
+	return v, err
+}
+
+// decodesExpiry is true when the values of the table are stored with their expiry, and it's not read encoded.
+func (reader *SSTableReader) decodesExpiry() bool {
+	return reader.metaData.ExpiringValues && !reader.opts.readEncodedExpiry && !reader.opts.readEncodedValues
+}
+
+// decodeStoredValue turns the value as stored in the data file into the value returned to the caller. That is either
+// resolving the blob reference for tables with value separation, or encoding the value when ReadEncodedValues is set.
+func (reader *SSTableReader) decodeStoredValue(v []byte) ([]byte, error) {
+	if reader.opts.readEncodedValues {
+		if reader.metaData.ValueSeparation {
+			return v, nil
+		}
+		return encodeInlineValue(v), nil
+	}
+
+	if !reader.metaData.ValueSeparation {
+		return v, nil
+	}
+
+	value, ref, isReference, err := decodeValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("error in sstable '%s' while decoding value: %w", reader.opts.basePath, err)
+	}
+
+	if !isReference {
+		return value, nil
+	}
+
+	value, err = reader.opts.blobStore.Get(ref)
+	if err != nil {
+		return nil, fmt.Errorf("error in sstable '%s' while resolving blob reference: %w", reader.opts.basePath, err)
+	}
+
+	return value, nil
+}
+
+func (reader *SSTableReader) needsValueDecoding() bool {
+	return reader.opts.readEncodedValues || reader.metaData.ValueSeparation || reader.decodesExpiry()
 }
 
 func (reader *SSTableReader) getValueAtOffset(iVal IndexVal, skipHashCheck bool) (v []byte, err error) {
@@ -118,7 +401,13 @@ func (reader *SSTableReader) getValueAtOffset(iVal IndexVal, skipHashCheck bool)
 
 func (reader *SSTableReader) Scan() (SSTableIteratorI, error) {
 	if reader.v0DataReader != nil {
-		dataReader, err := rProto.NewReader(rProto.ReaderPath(filepath.Join(reader.opts.basePath, DataFileName)))
+		var dataReader rProto.ReaderI
+		var err error
+		if reader.opts.source != nil {
+			dataReader, err = newSourceProtoReader(reader.opts.source, DataFileName, reader.opts.readBufferSizeBytes)
+		} else {
+			dataReader, err = rProto.NewReader(rProto.ReaderPath(filepath.Join(reader.opts.basePath, DataFileName)))
+		}
 		if err != nil {
 			return nil, fmt.Errorf("error in sstable '%s' while creating a scanner: %w", reader.opts.basePath, err)
 		}
@@ -134,12 +423,22 @@ func (reader *SSTableReader) Scan() (SSTableIteratorI, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error in sstable '%s' while creating a scanner iterator: %w", reader.opts.basePath, err)
 		}
-		return newV0SStableFullScanIterator(it, dataReader)
+		scanner, err := newV0SStableFullScanIterator(it, dataReader)
+		if err != nil || !reader.needsValueDecoding() {
+			return scanner, err
+		}
+		return &valueDecodingIterator{iterator: scanner, decode: reader.scanValue}, nil
 	} else {
-		dataReader, err := recordio.NewFileReader(
-			recordio.ReaderPath(filepath.Join(reader.opts.basePath, DataFileName)),
-			recordio.ReaderBufferSizeBytes(reader.opts.readBufferSizeBytes),
-		)
+		var dataReader recordio.ReaderI
+		var err error
+		if reader.opts.source != nil {
+			dataReader, err = newSourceReader(reader.opts.source, DataFileName, reader.opts.readBufferSizeBytes)
+		} else {
+			dataReader, err = recordio.NewFileReader(
+				recordio.ReaderPath(filepath.Join(reader.opts.basePath, DataFileName)),
+				recordio.ReaderBufferSizeBytes(reader.opts.readBufferSizeBytes),
+			)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("error in sstable '%s' while creating a scanner: %w", reader.opts.basePath, err)
 		}
@@ -154,7 +453,11 @@ func (reader *SSTableReader) Scan() (SSTableIteratorI, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error in sstable '%s' while creating a scanner iterator: %w", reader.opts.basePath, err)
 		}
-		return newSStableFullScanIterator(it, dataReader, reader.opts.skipHashCheckOnRead)
+		scanner, err := newSStableFullScanIterator(it, dataReader, reader.opts.skipHashCheckOnRead)
+		if err != nil || !reader.needsValueDecoding() {
+			return scanner, err
+		}
+		return &valueDecodingIterator{iterator: scanner, decode: reader.scanValue}, nil
 	}
 }
 
@@ -174,6 +477,52 @@ func (reader *SSTableReader) ScanRange(keyLower []byte, keyHigher []byte) (SSTab
 	return &SSTableIterator{reader: reader, keyIterator: it}, nil
 }
 
+func (reader *SSTableReader) ScanPrefix(prefix []byte) (SSTableIteratorI, error) {
+	mayContain, err := reader.mayContainPrefix(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("error in sstable '%s' in ScanPrefix: %w", reader.opts.basePath, err)
+	}
+
+	if !mayContain {
+		return EmptySSTableIterator{}, nil
+	}
+
+	it, err := reader.index.IteratorStartingAt(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("error in sstable '%s' in ScanPrefix: %w", reader.opts.basePath, err)
+	}
+	return newPrefixIterator(prefix, &SSTableIterator{reader: reader, keyIterator: it}), nil
+}
+
+func (reader *SSTableReader) SeekableIterator() (SSTableSeekableIteratorI, error) {
+	it, err := reader.index.SeekableIterator()
+	if err != nil {
+		return nil, fmt.Errorf("error in sstable '%s' in SeekableIterator: %w", reader.opts.basePath, err)
+	}
+	return &SSTableSeekableIterator{reader: reader, keyIterator: it}, nil
+}
+
+// mayContainPrefix returns false if the bloom filter can rule out that any key starts with the given prefix.
+// That is only possible if the table was written with the same PrefixExtractor that is configured on this reader.
+func (reader *SSTableReader) mayContainPrefix(prefix []byte) (bool, error) {
+	extractor := reader.opts.prefixExtractor
+	if reader.bloomFilter == nil || extractor == nil || reader.metaData.PrefixExtractor != extractor.Name() {
+		return true, nil
+	}
+
+	if !isExtractedPrefix(extractor, prefix) {
+		return true, nil
+	}
+
+	fnvHash := fnv.New64()
+	_, err := fnvHash.Write(prefix)
+	if err != nil {
+		return false, err
+	}
+
+	return reader.bloomFilter.Contains(fnvHash), nil
+}
+
 func (reader *SSTableReader) Close() (err error) {
 	for _, e := range reader.miscClosers {
 		err = errors.Join(err, e.Close())
@@ -194,10 +543,18 @@ func (reader *SSTableReader) Close() (err error) {
 	return err
 }
 
+func (reader *SSTableReader) RangeTombstones() RangeTombstones {
+	return reader.rangeTombstones
+}
+
 func (reader *SSTableReader) MetaData() *proto.MetaData {
 	return reader.metaData
 }
 
+func (reader *SSTableReader) Properties() map[string][]byte {
+	return reader.metaData.Properties
+}
+
 func (reader *SSTableReader) BasePath() string {
 	return reader.opts.basePath
 }
@@ -250,7 +607,7 @@ func checksumValue(value []byte) (uint64, error) {
 // NewSSTableReader creates a new reader. The sstable base path is mandatory:
 // > sstables.NewSSTableReader(sstables.ReadBasePath("some_path"))
 // This function will check hashes and validity of the datafile matching the index file.
-func NewSSTableReader(readerOptions ...ReadOption) (SSTableReaderI, error) {
+func NewSSTableReader(readerOptions ...ReadOption) (_ SSTableReaderI, err error) {
 	opts := &SSTableReaderOptions{
 		basePath: "",
 		// by default, we validate the integrity on loading and never checking when reading.
@@ -264,7 +621,7 @@ func NewSSTableReader(readerOptions ...ReadOption) (SSTableReaderI, error) {
 		readOption(opts)
 	}
 
-	if opts.basePath == "" {
+	if opts.basePath == "" && opts.source == nil {
 		return nil, errors.New("SSTableReader: basePath was not supplied")
 	}
 
@@ -278,33 +635,92 @@ func NewSSTableReader(readerOptions ...ReadOption) (SSTableReaderI, error) {
 		}
 	}
 
-	metaData, err := readMetaDataIfExists(filepath.Join(opts.basePath, MetaFileName))
+	var metaData *proto.MetaData
+	if opts.source != nil {
+		metaData, err = readMetaDataFromSource(opts.source)
+	} else {
+		err = checkTableComplete(opts.basePath)
+		if err != nil {
+			return nil, err
+		}
+		metaData, err = readMetaDataIfExists(filepath.Join(opts.basePath, MetaFileName))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error while reading metadata of sstable in '%s': %w", opts.basePath, err)
 	}
 
-	index, err := opts.indexLoader.Load(filepath.Join(opts.basePath, IndexFileName), metaData)
+	err = checkComparatorName(opts.keyComparator, metaData.ComparatorName)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening sstable in '%s': %w", opts.basePath, err)
+	}
+
+	var index SortedKeyIndex
+	if opts.source != nil {
+		sourceLoader, ok := opts.indexLoader.(SourceIndexLoader)
+		if !ok {
+			return nil, fmt.Errorf("index loader %T of sstable in '%s' can't load from a source", opts.indexLoader, opts.basePath)
+		}
+		index, err = sourceLoader.LoadFromSource(opts.source, metaData)
+	} else {
+		index, err = opts.indexLoader.Load(filepath.Join(opts.basePath, IndexFileName), metaData)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error while reading index of sstable in '%s': %w", opts.basePath, err)
 	}
 
+	// the index and data readers hold open files, which need to be closed again when the table is rejected
+	opened := []recordio.CloseableI{index}
+	defer func() {
+		if err != nil {
+			for _, closer := range opened {
+				err = errors.Join(err, closer.Close())
+			}
+		}
+	}()
+
 	err = index.Open()
 	if err != nil {
 		return nil, fmt.Errorf("error while opening index of sstable in '%s': %w", opts.basePath, err)
 	}
 
-	filter, err := readFilterIfExists(filepath.Join(opts.basePath, BloomFileName))
+	var filter *bloomfilter.Filter
+	if opts.source != nil {
+		filter, err = readFilterFromSource(opts.source)
+	} else {
+		filter, err = readFilterIfExists(filepath.Join(opts.basePath, BloomFileName))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error while reading filter of sstable in '%s': %w", opts.basePath, err)
 	}
 
-	reader := &SSTableReader{opts: opts, bloomFilter: filter, index: index, metaData: metaData}
+	if metaData.ValueSeparation && len(metaData.BlobFileBytes) > 0 && opts.blobStore == nil && !opts.readEncodedValues {
+		return nil, fmt.Errorf("sstable in '%s' references blob files, but no blob store was supplied", opts.basePath)
+	}
+
+	var tombstones RangeTombstones
+	if opts.source != nil {
+		tombstones, err = readRangeTombstonesFromSource(opts.source)
+	} else {
+		tombstones, err = readRangeTombstonesIfExists(filepath.Join(opts.basePath, RangeTombstoneFileName))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while reading range tombstones of sstable in '%s': %w", opts.basePath, err)
+	}
+
+	reader := &SSTableReader{opts: opts, bloomFilter: filter, index: index, metaData: metaData,
+		rangeTombstones: mergeRangeTombstones(opts.keyComparator, tombstones)}
 
 	if metaData.Version == 0 {
-		v0DataReader, err := rProto.NewMMapProtoReaderWithPath(filepath.Join(opts.basePath, DataFileName))
+		var v0DataReader rProto.ReadAtI
+		if opts.source != nil {
+			v0DataReader, err = newSourceProtoReadAtReader(opts.source, DataFileName)
+		} else {
+			v0DataReader, err = rProto.NewMMapProtoReaderWithPath(filepath.Join(opts.basePath, DataFileName))
+		}
 		if err != nil {
 			return nil, fmt.Errorf("error while creating proto data reader of sstable in '%s': %w", opts.basePath, err)
 		}
+		opened = append(opened, v0DataReader)
 
 		err = v0DataReader.Open()
 		if err != nil {
@@ -313,10 +729,16 @@ func NewSSTableReader(readerOptions ...ReadOption) (SSTableReaderI, error) {
 
 		reader.v0DataReader = v0DataReader
 	} else {
-		dataReader, err := recordio.NewMemoryMappedReaderWithPath(filepath.Join(opts.basePath, DataFileName))
+		var dataReader recordio.ReadAtI
+		if opts.source != nil {
+			dataReader, err = newSourceReadAtReader(opts.source, DataFileName)
+		} else {
+			dataReader, err = recordio.NewMemoryMappedReaderWithPath(filepath.Join(opts.basePath, DataFileName))
+		}
 		if err != nil {
 			return nil, fmt.Errorf("error while creating data reader of sstable in '%s': %w", opts.basePath, err)
 		}
+		opened = append(opened, dataReader)
 
 		err = dataReader.Open()
 		if err != nil {
@@ -328,12 +750,6 @@ func NewSSTableReader(readerOptions ...ReadOption) (SSTableReaderI, error) {
 
 	err = reader.validateDataFile()
 	if err != nil {
-		if reader.v0DataReader != nil {
-			err = errors.Join(err, reader.v0DataReader.Close())
-		}
-		if reader.dataReader != nil {
-			err = errors.Join(err, reader.dataReader.Close())
-		}
 		return nil, err
 	}
 
@@ -379,6 +795,11 @@ func readMetaDataIfExists(metaPath string) (md *proto.MetaData, err error) {
 		return nil, fmt.Errorf("error while parsing metadata in '%s': %w", metaPath, err)
 	}
 
+	err = verifyMetaDataChecksum(content, md)
+	if err != nil {
+		return nil, fmt.Errorf("error while verifying metadata in '%s': %w", metaPath, err)
+	}
+
 	return
 }
 
@@ -389,6 +810,11 @@ type SSTableReaderOptions struct {
 	basePath            string
 	readBufferSizeBytes int
 	indexLoader         IndexLoader
+	prefixExtractor     PrefixExtractor
+	blobStore           *BlobStore
+	readEncodedValues   bool
+	readEncodedExpiry   bool
+	source              TableSource
 
 	// TODO(thomas): this is a special case of the skiplist index, which could go into the loader implementation
 	keyComparator skiplist.Comparator[[]byte]
@@ -439,3 +865,44 @@ func ReadIndexLoader(il IndexLoader) ReadOption {
 		args.indexLoader = il
 	}
 }
+
+// ReadWithPrefixExtractor enables ScanPrefix to use the bloom filter, given the table was written with the same extractor.
+func ReadWithPrefixExtractor(extractor PrefixExtractor) ReadOption {
+	return func(args *SSTableReaderOptions) {
+		args.prefixExtractor = extractor
+	}
+}
+
+// ReadWithBlobStore resolves the blob references of tables that were written using WithValueSeparation.
+func ReadWithBlobStore(store *BlobStore) ReadOption {
+	return func(args *SSTableReaderOptions) {
+		args.blobStore = store
+	}
+}
+
+// ReadEncodedValues returns all values in their encoded form, without resolving any blob references.
+// This is meant to be used together with WriteEncodedValues, to merge tables without rewriting the separated values.
+func ReadEncodedValues() ReadOption {
+	return func(args *SSTableReaderOptions) {
+		args.readEncodedValues = true
+	}
+}
+
+// ReadEncodedExpiry returns the values of tables written WithExpiringValues with their encoded expiry time, including
+// the values that have expired already. Merges use it to carry the expiry forward, see SSTableMerger.MergeCompact.
+func ReadEncodedExpiry() ReadOption {
+	return func(args *SSTableReaderOptions) {
+		args.readEncodedExpiry = true
+	}
+}
+
+// ReadFromSource opens the table from the given source instead of the base path, which is then only used in error
+// messages. Only the byte ranges that are needed for the lookups and scans are read from the source. Since validating
+// the data file on load would read it entirely, the hash check on load is skipped, EnableHashCheckOnReads checks
+// every value as it is read instead. The index loader must implement SourceIndexLoader.
+func ReadFromSource(source TableSource) ReadOption {
+	return func(args *SSTableReaderOptions) {
+		args.source = source
+		args.skipHashCheckOnLoad = true
+	}
+}
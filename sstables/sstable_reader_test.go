@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
 	"testing"
 )
 
@@ -95,6 +96,42 @@ func TestCRCHashMismatchError(t *testing.T) {
 	require.Nil(t, reader)
 }
 
+func TestCRCHashMismatchErrorClosesIndex(t *testing.T) {
+	loader := &closeTrackingIndexLoader{delegate: &SliceKeyIndexLoader{ReadBufferSize: 4096}}
+	reader, err := NewSSTableReader(
+		ReadBasePath("test_files/SimpleWriteHappyPathSSTableWithCRCHashesMismatch"),
+		ReadWithKeyComparator(skiplist.BytesComparator{}),
+		ReadIndexLoader(loader))
+	require.ErrorContains(t, err, "Checksum mismatch")
+	require.Nil(t, reader)
+	require.NotNil(t, loader.index)
+	require.True(t, loader.index.closed)
+}
+
+type closeTrackingIndexLoader struct {
+	delegate IndexLoader
+	index    *closeTrackingIndex
+}
+
+func (l *closeTrackingIndexLoader) Load(path string, metadata *proto.MetaData) (SortedKeyIndex, error) {
+	index, err := l.delegate.Load(path, metadata)
+	if err != nil {
+		return nil, err
+	}
+	l.index = &closeTrackingIndex{SortedKeyIndex: index}
+	return l.index, nil
+}
+
+type closeTrackingIndex struct {
+	SortedKeyIndex
+	closed bool
+}
+
+func (i *closeTrackingIndex) Close() error {
+	i.closed = true
+	return i.SortedKeyIndex.Close()
+}
+
 func TestCRCHashMismatchErrorSkipEntirelyReadChecks(t *testing.T) {
 	reader, err := NewSSTableReader(
 		ReadBasePath("test_files/SimpleWriteHappyPathSSTableWithCRCHashesMismatch"),
@@ -115,6 +115,15 @@ func TestReadStreamedWriteEndToEndForRangeTesting(t *testing.T) {
 	assertExhaustiveRangeReads(t, writer.opts.basePath, expectedNumbers)
 }
 
+func TestReadStreamedWriteEndToEndForReverseRangeTesting(t *testing.T) {
+	writer, err := newTestSSTableStreamWriter()
+	require.Nil(t, err)
+	defer cleanWriterDir(t, writer)
+
+	expectedNumbers := streamedWriteElements(t, writer, 50)
+	assertExhaustiveReverseRangeReads(t, writer.opts.basePath, expectedNumbers)
+}
+
 func TestNilEmptyReadAndWrites(t *testing.T) {
 	writer, err := newTestSSTableStreamWriter()
 	require.Nil(t, err)
@@ -383,6 +392,46 @@ func assertExhaustiveRangeReads(t *testing.T, sstablePath string, expectedNumber
 	}
 }
 
+func assertExhaustiveReverseRangeReads(t *testing.T, sstablePath string, expectedNumbers []int) {
+	for _, loaderFunc := range indexLoaders {
+		loader := loaderFunc()
+		t.Run(fmt.Sprintf("reverserangereader_%s", reflect.TypeOf(loader).String()), func(t *testing.T) {
+			reader, err := NewSSTableReader(
+				ReadBasePath(sstablePath),
+				ReadWithKeyComparator(skiplist.BytesComparator{}),
+				ReadIndexLoader(loader))
+			require.NoError(t, err)
+			defer closeReader(t, reader)
+
+			sort.Ints(expectedNumbers)
+			it, err := reader.SeekableIterator()
+			require.NoError(t, err)
+			for i := 0; i < len(expectedNumbers); i++ {
+				for j := i; j < len(expectedNumbers); j++ {
+					require.NoError(t, it.SeekForPrev(intToByteSlice(expectedNumbers[j])))
+					assertReverseIteratorMatchesSlice(t, it, intToByteSlice(expectedNumbers[i]), expectedNumbers[i:j+1])
+				}
+			}
+		})
+	}
+}
+
+// assertReverseIteratorMatchesSlice iterates backwards until keyLower was reached and expects the reverse of expectedSlice
+func assertReverseIteratorMatchesSlice(t *testing.T, it SSTableSeekableIteratorI, keyLower []byte, expectedSlice []int) {
+	numRead := 0
+	for i := len(expectedSlice) - 1; i >= 0; i-- {
+		actualKey, actualValue, err := it.Prev()
+		require.Nil(t, err)
+		assert.Equal(t, expectedSlice[i], int(binary.BigEndian.Uint32(actualKey)))
+		assert.Equal(t, expectedSlice[i]+1, int(binary.BigEndian.Uint32(actualValue)))
+		numRead++
+		if skiplist.BytesComparator.Compare(skiplist.BytesComparator{}, actualKey, keyLower) <= 0 {
+			break
+		}
+	}
+	assert.Equal(t, len(expectedSlice), numRead)
+}
+
 func closeWriter(t *testing.T, writer *SSTableStreamWriter) {
 	func() { require.Nil(t, writer.Close()) }()
 }
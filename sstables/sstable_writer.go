@@ -1,12 +1,14 @@
 package sstables
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"hash/crc64"
 	"hash/fnv"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/steakknife/bloomfilter"
 	"github.com/thomasjungblut/go-sstables/recordio"
@@ -19,6 +21,9 @@ import (
 type SSTableStreamWriter struct {
 	opts *SSTableWriterOptions
 
+	// writePath is the basePath, or the staging directory when writing atomically
+	writePath string
+
 	indexFilePath string
 	dataFilePath  string
 	metaFilePath  string
@@ -30,11 +35,32 @@ type SSTableStreamWriter struct {
 	bloomFilter *bloomfilter.Filter
 	metaData    *sProto.MetaData
 
-	lastKey []byte
+	lastKey    []byte
+	lastPrefix []byte
+
+	rangeTombstones []RangeTombstone
+
+	blobFileId     uint64
+	blobFileWriter recordio.WriterI
+
+	propertiesCollectors []PropertiesCollector
+
+	indexPartitions []indexPartition
+
+	hashIndexEntries []hashIndexEntry
 }
 
 func (writer *SSTableStreamWriter) Open() error {
-	writer.indexFilePath = filepath.Join(writer.opts.basePath, IndexFileName)
+	writer.writePath = writer.opts.basePath
+	if writer.opts.writeAtomically {
+		stagingPath, err := createStagingDir(writer.opts.basePath)
+		if err != nil {
+			return err
+		}
+		writer.writePath = stagingPath
+	}
+
+	writer.indexFilePath = filepath.Join(writer.writePath, IndexFileName)
 	iWriter, err := rProto.NewWriter(
 		rProto.Path(writer.indexFilePath),
 		rProto.CompressionType(writer.opts.indexCompressionType),
@@ -49,7 +75,7 @@ func (writer *SSTableStreamWriter) Open() error {
 		return fmt.Errorf("error while opening index writer in '%s': %w", writer.opts.basePath, err)
 	}
 
-	writer.dataFilePath = filepath.Join(writer.opts.basePath, DataFileName)
+	writer.dataFilePath = filepath.Join(writer.writePath, DataFileName)
 	dWriter, err := recordio.NewFileWriter(
 		recordio.Path(writer.dataFilePath),
 		recordio.CompressionType(writer.opts.dataCompressionType),
@@ -65,18 +91,44 @@ func (writer *SSTableStreamWriter) Open() error {
 		return fmt.Errorf("error while opening data writer in '%s': %w", writer.opts.basePath, err)
 	}
 
-	writer.metaFilePath = filepath.Join(writer.opts.basePath, MetaFileName)
+	writer.metaFilePath = filepath.Join(writer.writePath, MetaFileName)
 	metaFile, err := os.OpenFile(writer.metaFilePath, os.O_WRONLY|os.O_CREATE, 0666)
 	if err != nil {
 		return fmt.Errorf("error while opening metadata file in '%s': %w", writer.opts.basePath, err)
 	}
 	writer.metaDataFile = metaFile
 	writer.metaData = &sProto.MetaData{
-		Version: Version,
+		Version:        Version,
+		ComparatorName: comparatorName(writer.opts.keyComparator),
+		CreationTime:   time.Now().UnixMilli(),
+	}
+
+	writer.propertiesCollectors = nil
+	for _, factory := range writer.opts.propertiesCollectorFactories {
+		writer.propertiesCollectors = append(writer.propertiesCollectors, factory())
+	}
+
+	if writer.opts.prefixExtractor != nil {
+		writer.metaData.PrefixExtractor = writer.opts.prefixExtractor.Name()
+	}
+
+	writer.metaData.InternalKeys = writer.opts.internalKeys
+	writer.metaData.MergeOperands = writer.opts.mergeOperands
+	writer.metaData.ExpiringValues = writer.opts.expiringValues
+
+	if writer.opts.blobStore != nil {
+		writer.metaData.ValueSeparation = true
+		writer.metaData.BlobFileBytes = map[uint64]uint64{}
 	}
 
 	if writer.opts.enableBloomFilter {
-		bf, err := bloomfilter.NewOptimal(writer.opts.bloomExpectedNumberOfElements, writer.opts.bloomFpProbability)
+		// every key can add its prefix to the filter as well, which would otherwise raise the false positive probability
+		expectedNumberOfElements := writer.opts.bloomExpectedNumberOfElements
+		if writer.opts.prefixExtractor != nil {
+			expectedNumberOfElements *= 2
+		}
+
+		bf, err := bloomfilter.NewOptimal(expectedNumberOfElements, writer.opts.bloomFpProbability)
 		if err != nil {
 			return fmt.Errorf("error while creating bloomfilter in '%s': %w", writer.opts.basePath, err)
 		}
@@ -87,6 +139,124 @@ func (writer *SSTableStreamWriter) Open() error {
 }
 
 func (writer *SSTableStreamWriter) WriteNext(key []byte, value []byte) error {
+	sequence, err := writer.keySequence(key)
+	if err != nil {
+		return err
+	}
+
+	// values that are written encoded are prefixed by their value separation, so their expiry and kind are inside it
+	if !writer.opts.writeEncodedValues {
+		innerValue := value
+		if writer.opts.expiringValues {
+			innerValue, _, err = DecodeExpiringValue(value)
+			if err != nil {
+				return fmt.Errorf("sstables.WriteNext '%s': %w", writer.opts.basePath, err)
+			}
+		}
+
+		if writer.opts.mergeOperands && len(innerValue) > 0 && innerValue[0] != byte(ValueKindPut) && innerValue[0] != byte(ValueKindMerge) {
+			return fmt.Errorf("sstables.WriteNext '%s': value is not an encoded MergeValue, unknown kind %d", writer.opts.basePath, innerValue[0])
+		}
+	}
+
+	err = writer.addKey(key)
+	if err != nil {
+		return err
+	}
+
+	storedValue := value
+	if writer.opts.blobStore != nil {
+		storedValue, err = writer.separateValue(value)
+		if err != nil {
+			return fmt.Errorf("error writeNext while separating value in '%s': %w", writer.opts.basePath, err)
+		}
+	}
+
+	crc := crc64.New(crc64.MakeTable(crc64.ISO))
+	_, err = crc.Write(storedValue)
+	if err != nil {
+		return fmt.Errorf("error while writing crc64 hash in '%s': %w", writer.opts.basePath, err)
+	}
+
+	preWriteOffset := writer.dataWriter.Size()
+	recordOffset, err := writer.dataWriter.Write(storedValue)
+	if err != nil {
+		return fmt.Errorf("error writeNext data writer error in '%s': %w", writer.opts.basePath, err)
+	}
+
+	err = writer.addIndexEntry(key, sequence, recordOffset, crc.Sum64(), preWriteOffset)
+	if err != nil {
+		return err
+	}
+
+	writer.metaData.ValueBytes += uint64(len(value))
+	if value == nil {
+		writer.metaData.NullValues += 1
+	}
+
+	for _, collector := range writer.propertiesCollectors {
+		err = collector.Add(key, value)
+		if err != nil {
+			return fmt.Errorf("error writeNext while collecting properties in '%s': %w", writer.opts.basePath, err)
+		}
+	}
+
+	return nil
+}
+
+// writeNextRaw is WriteNext for a record that was read from the data file of another table, which is copied without
+// compressing it again. The checksum is the one of the value in the index of the other table. This can't be used with
+// value separation or properties collectors, which both need the value itself.
+func (writer *SSTableStreamWriter) writeNextRaw(key []byte, record recordio.RawRecord, checksum uint64) error {
+	rawWriter, ok := writer.dataWriter.(recordio.RawWriterI)
+	if !ok {
+		return fmt.Errorf("sstables.writeNextRaw '%s': data writer %T can't write raw records", writer.opts.basePath, writer.dataWriter)
+	}
+
+	sequence, err := writer.keySequence(key)
+	if err != nil {
+		return err
+	}
+
+	err = writer.addKey(key)
+	if err != nil {
+		return err
+	}
+
+	preWriteOffset := rawWriter.Size()
+	recordOffset, err := rawWriter.WriteRaw(record)
+	if err != nil {
+		return fmt.Errorf("error writeNextRaw data writer error in '%s': %w", writer.opts.basePath, err)
+	}
+
+	err = writer.addIndexEntry(key, sequence, recordOffset, checksum, preWriteOffset)
+	if err != nil {
+		return err
+	}
+
+	writer.metaData.ValueBytes += record.PayloadSizeUncompressed
+	if record.Nil {
+		writer.metaData.NullValues += 1
+	}
+
+	return nil
+}
+
+// keySequence returns the sequence number of the key when writing internal keys.
+func (writer *SSTableStreamWriter) keySequence(key []byte) (uint64, error) {
+	if !writer.opts.internalKeys {
+		return 0, nil
+	}
+
+	internalKey, err := DecodeInternalKey(key)
+	if err != nil {
+		return 0, fmt.Errorf("sstables.WriteNext '%s': %w", writer.opts.basePath, err)
+	}
+	return internalKey.Sequence, nil
+}
+
+// addKey checks that the key is ascending and adds it to the bloom filter.
+func (writer *SSTableStreamWriter) addKey(key []byte) error {
 	if writer.lastKey != nil {
 		cmpResult := writer.opts.keyComparator.Compare(writer.lastKey, key)
 		if cmpResult == 0 {
@@ -115,40 +285,176 @@ func (writer *SSTableStreamWriter) WriteNext(key []byte, value []byte) error {
 		fnvHash := fnv.New64()
 		_, _ = fnvHash.Write(key)
 		writer.bloomFilter.Add(fnvHash)
-	}
 
-	crc := crc64.New(crc64.MakeTable(crc64.ISO))
-	_, err := crc.Write(value)
-	if err != nil {
-		return fmt.Errorf("error while writing crc64 hash in '%s': %w", writer.opts.basePath, err)
+		// keys are sorted, so we only need to add a prefix when it changes between two consecutive keys
+		if writer.opts.prefixExtractor != nil && writer.opts.prefixExtractor.InDomain(key) {
+			prefix := writer.opts.prefixExtractor.Transform(key)
+			if writer.lastPrefix == nil || !bytes.Equal(writer.lastPrefix, prefix) {
+				prefixHash := fnv.New64()
+				_, _ = prefixHash.Write(prefix)
+				writer.bloomFilter.Add(prefixHash)
+				writer.lastPrefix = append(writer.lastPrefix[:0], prefix...)
+			}
+		}
 	}
 
-	preWriteOffset := writer.dataWriter.Size()
-	recordOffset, err := writer.dataWriter.Write(value)
-	if err != nil {
-		return fmt.Errorf("error writeNext data writer error in '%s': %w", writer.opts.basePath, err)
-	}
+	return nil
+}
 
-	_, err = writer.indexWriter.Write(&sProto.IndexEntry{Key: key, ValueOffset: recordOffset, Checksum: crc.Sum64()})
+// addIndexEntry writes the index entry of a value that was written at recordOffset and updates the metadata of the key.
+func (writer *SSTableStreamWriter) addIndexEntry(key []byte, sequence uint64, recordOffset uint64, checksum uint64, preWriteOffset uint64) error {
+	indexOffset, err := writer.indexWriter.Write(&sProto.IndexEntry{Key: key, ValueOffset: recordOffset, Checksum: checksum})
 	if err != nil {
 		// in case of failures we need to try to rewind the data writer's offset to preWriteOffset
 		seekErr := writer.dataWriter.Seek(preWriteOffset)
 		return fmt.Errorf("error writeNext index writer/seeker error in '%s': %w", writer.opts.basePath, errors.Join(err, seekErr))
 	}
 
+	if writer.opts.indexPartitionSizeBytes > 0 {
+		writer.indexPartitions = appendIndexPartition(writer.indexPartitions, key, indexOffset, writer.opts.indexPartitionSizeBytes)
+	}
+
+	if writer.opts.hashIndex {
+		writer.hashIndexEntries = append(writer.hashIndexEntries, hashIndexEntry{hash: hashKey(key), indexOffset: indexOffset})
+	}
+
+	if writer.opts.internalKeys {
+		if writer.metaData.NumRecords == 0 || sequence < writer.metaData.MinSequence {
+			writer.metaData.MinSequence = sequence
+		}
+		if sequence > writer.metaData.MaxSequence {
+			writer.metaData.MaxSequence = sequence
+		}
+	}
+
 	writer.metaData.NumRecords += 1
-	if value == nil {
-		writer.metaData.NullValues += 1
+	writer.metaData.KeyBytes += uint64(len(key))
+	return nil
+}
+
+// separateValue returns the encoded value to store in the data file. Values of at least the threshold size are appended
+// to a blob file, in which case the encoded value is a reference to it.
+func (writer *SSTableStreamWriter) separateValue(value []byte) ([]byte, error) {
+	if len(value) == 0 {
+		return value, nil
 	}
 
+	if writer.opts.writeEncodedValues {
+		decoded, ref, isReference, err := decodeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		// references are copied as they are, which saves rewriting the blob during merges and compactions
+		_, rewrite := writer.opts.rewriteBlobFiles[ref.FileId]
+		if isReference && !rewrite {
+			writer.metaData.BlobFileBytes[ref.FileId] += ref.Length
+			return value, nil
+		}
+
+		if isReference {
+			decoded, err = writer.opts.blobStore.Get(ref)
+			if err != nil {
+				return nil, err
+			}
+		}
+		value = decoded
+	}
+
+	if len(value) < writer.opts.blobThresholdBytes {
+		return encodeInlineValue(value), nil
+	}
+
+	if writer.blobFileWriter == nil {
+		fileId, blobWriter, err := writer.opts.blobStore.newFileWriter(writer.opts.dataCompressionType, writer.opts.writeBufferSizeBytes)
+		if err != nil {
+			return nil, err
+		}
+		writer.blobFileId = fileId
+		writer.blobFileWriter = blobWriter
+	}
+
+	offset, err := writer.blobFileWriter.Write(value)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := BlobReference{FileId: writer.blobFileId, Offset: offset, Length: uint64(len(value))}
+	writer.metaData.BlobFileBytes[ref.FileId] += ref.Length
+	return encodeBlobReference(ref), nil
+}
+
+func (writer *SSTableStreamWriter) WriteRangeTombstone(start []byte, end []byte) error {
+	if writer.metaData == nil {
+		return fmt.Errorf("sstables.WriteRangeTombstone '%s': no metadata available to write into, table might not be opened yet", writer.opts.basePath)
+	}
+
+	if writer.opts.keyComparator.Compare(start, end) >= 0 {
+		return fmt.Errorf("sstables.WriteRangeTombstone '%s': start key must be lower than the end key", writer.opts.basePath)
+	}
+
+	// the caller might re-use the buffers, so we have to copy until the tombstones are written on Close
+	writer.rangeTombstones = append(writer.rangeTombstones, RangeTombstone{
+		Start: append([]byte{}, start...),
+		End:   append([]byte{}, end...),
+	})
+
 	return nil
 }
 
-func (writer *SSTableStreamWriter) Close() (err error) {
+func (writer *SSTableStreamWriter) Close() error {
+	err := writer.close()
+	if !writer.opts.writeAtomically || writer.writePath == writer.opts.basePath {
+		return err
+	}
+
+	if err != nil {
+		return errors.Join(err, os.RemoveAll(writer.writePath))
+	}
+
+	err = commitStagingDir(writer.writePath, writer.opts.basePath)
+	if err != nil {
+		return errors.Join(err, os.RemoveAll(writer.writePath))
+	}
+
+	return nil
+}
+
+func (writer *SSTableStreamWriter) close() (err error) {
 	err = errors.Join(writer.indexWriter.Close(), writer.dataWriter.Close())
 
+	if writer.blobFileWriter != nil {
+		// the values have to be durable before the metadata marks the table as complete
+		err = errors.Join(err, writer.blobFileWriter.Close(), writer.opts.blobStore.syncFile(writer.blobFileId))
+		writer.opts.blobStore.releaseFile(writer.blobFileId, writer.metaData.BlobFileBytes[writer.blobFileId])
+	}
+
+	if len(writer.rangeTombstones) > 0 {
+		merged := mergeRangeTombstones(writer.opts.keyComparator, writer.rangeTombstones)
+		tErr := writeRangeTombstones(filepath.Join(writer.writePath, RangeTombstoneFileName), merged)
+		if tErr != nil {
+			err = errors.Join(err, tErr)
+		}
+		if writer.metaData != nil {
+			writer.metaData.NumRangeTombstones = uint64(len(merged))
+		}
+	}
+
+	if writer.opts.indexPartitionSizeBytes > 0 && writer.metaData != nil {
+		pErr := writeIndexPartitions(filepath.Join(writer.writePath, IndexPartitionsFileName), writer.indexPartitions)
+		if pErr != nil {
+			err = errors.Join(err, pErr)
+		}
+	}
+
+	if writer.opts.hashIndex && writer.metaData != nil {
+		hErr := writeHashIndex(filepath.Join(writer.writePath, HashIndexFileName), writer.hashIndexEntries)
+		if hErr != nil {
+			err = errors.Join(err, hErr)
+		}
+	}
+
 	if writer.opts.enableBloomFilter && writer.bloomFilter != nil {
-		_, bErr := writer.bloomFilter.WriteFile(filepath.Join(writer.opts.basePath, BloomFileName))
+		_, bErr := writer.bloomFilter.WriteFile(filepath.Join(writer.writePath, BloomFileName))
 		if bErr != nil {
 			err = errors.Join(err, fmt.Errorf("error in writing bloom filter  in '%s': %w", writer.opts.basePath, bErr))
 		}
@@ -163,10 +469,25 @@ func (writer *SSTableStreamWriter) Close() (err error) {
 		writer.metaData.DataBytes = writer.dataWriter.Size()
 		writer.metaData.IndexBytes = writer.indexWriter.Size()
 		writer.metaData.TotalBytes = writer.metaData.DataBytes + writer.metaData.IndexBytes
+
+		properties, pErr := collectProperties(writer.opts.properties, writer.propertiesCollectors)
+		if pErr != nil {
+			return errors.Join(err, fmt.Errorf("error in collecting properties in '%s': %w", writer.opts.basePath, pErr))
+		}
+		writer.metaData.Properties = properties
+
+		// all other files are closed at this point
+		checksums, cErr := checksumComponentFiles(writer.writePath)
+		if cErr != nil {
+			return errors.Join(err, fmt.Errorf("error in checksumming files in '%s': %w", writer.opts.basePath, cErr))
+		}
+		writer.metaData.FileChecksums = checksums
+
 		bytes, mErr := proto.Marshal(writer.metaData)
 		if mErr != nil {
 			return errors.Join(err, fmt.Errorf("error in serializing metadata in '%s': %w", writer.opts.basePath, mErr))
 		}
+		bytes = appendMetaDataChecksum(bytes)
 
 		_, wErr := writer.metaDataFile.Write(bytes)
 		if wErr != nil {
@@ -232,10 +553,28 @@ func NewSSTableStreamWriter(writerOptions ...WriterOption) (*SSTableStreamWriter
 		return nil, errors.New("basePath was not supplied")
 	}
 
+	if opts.internalKeys {
+		if opts.keyComparator == nil {
+			opts.keyComparator = skiplist.BytesComparator{}
+		}
+
+		if _, ok := opts.keyComparator.(skiplist.BytesComparator); !ok {
+			return nil, errors.New("internal keys can only be written with skiplist.BytesComparator")
+		}
+	}
+
 	if opts.keyComparator == nil {
 		return nil, errors.New("no key comparator supplied")
 	}
 
+	if opts.writeEncodedValues && opts.blobStore == nil {
+		return nil, errors.New("writing encoded values requires value separation to be enabled")
+	}
+
+	if len(opts.rewriteBlobFiles) > 0 && !opts.writeEncodedValues {
+		return nil, errors.New("rewriting blob files requires writing encoded values")
+	}
+
 	if opts.bloomExpectedNumberOfElements <= 0 {
 		return nil, fmt.Errorf("unexpected number of bloom filter elements, was: %d",
 			opts.bloomExpectedNumberOfElements)
@@ -264,6 +603,19 @@ type SSTableWriterOptions struct {
 	bloomFpProbability            float64
 	writeBufferSizeBytes          int
 	keyComparator                 skiplist.Comparator[[]byte]
+	prefixExtractor               PrefixExtractor
+	blobStore                     *BlobStore
+	blobThresholdBytes            int
+	writeEncodedValues            bool
+	rewriteBlobFiles              map[uint64]struct{}
+	internalKeys                  bool
+	mergeOperands                 bool
+	expiringValues                bool
+	properties                    map[string][]byte
+	propertiesCollectorFactories  []PropertiesCollectorFactory
+	indexPartitionSizeBytes       uint64
+	hashIndex                     bool
+	writeAtomically               bool
 }
 
 type WriterOption func(*SSTableWriterOptions)
@@ -315,3 +667,115 @@ func WithKeyComparator(cmp skiplist.Comparator[[]byte]) WriterOption {
 		args.keyComparator = cmp
 	}
 }
+
+// WithPrefixExtractor adds the prefixes of all keys, as extracted by the given PrefixExtractor, into the bloom filter.
+// Readers using the same extractor can then skip tables in ScanPrefix. The bloom filter is sized for twice the
+// BloomExpectedNumberOfElements, since every key can add a prefix.
+func WithPrefixExtractor(extractor PrefixExtractor) WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.prefixExtractor = extractor
+	}
+}
+
+// WithValueSeparation stores all values with at least thresholdBytes into a blob file of the given store,
+// the sstable itself only contains a reference to the value. Readers need to be supplied with the same store using
+// ReadWithBlobStore to resolve the references.
+func WithValueSeparation(store *BlobStore, thresholdBytes int) WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.blobStore = store
+		args.blobThresholdBytes = thresholdBytes
+	}
+}
+
+// WriteEncodedValues expects the values to be encoded, as returned by a reader with ReadEncodedValues.
+// Blob references are copied without rewriting the blob, which is what merges and compactions should use.
+// This requires WithValueSeparation to be set as well.
+func WriteEncodedValues() WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.writeEncodedValues = true
+	}
+}
+
+// RewriteBlobFiles copies the values of the given blob files into a new blob file instead of copying their references,
+// see BlobStore.FilesToRewrite. This requires WriteEncodedValues to be set as well.
+func RewriteBlobFiles(fileIds ...uint64) WriterOption {
+	return func(args *SSTableWriterOptions) {
+		if args.rewriteBlobFiles == nil {
+			args.rewriteBlobFiles = map[uint64]struct{}{}
+		}
+		for _, fileId := range fileIds {
+			args.rewriteBlobFiles[fileId] = struct{}{}
+		}
+	}
+}
+
+// WithInternalKeys expects all keys to be encoded using EncodeInternalKey, which allows to write multiple versions of
+// the same user key. Those tables can be read at a certain sequence number using GetAsOf.
+func WithInternalKeys() WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.internalKeys = true
+	}
+}
+
+// WithMergeOperands expects all values to be encoded using MergeValue.Encode, which allows to store merge operands
+// alongside puts and deletes. Readers return the values as they were written, the SuperSSTableReader and the
+// SSTableMerger fold them with a MergeOperator.
+func WithMergeOperands() WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.mergeOperands = true
+	}
+}
+
+// WithExpiringValues expects all values to be encoded using EncodeExpiringValue, which stores an expiry time with
+// every value. Readers treat expired values as not found. When combined with WithMergeOperands, the encoded MergeValue
+// is prefixed with the expiry time.
+func WithExpiringValues() WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.expiringValues = true
+	}
+}
+
+// WithProperties stores the given user-defined properties in the metadata, e.g. the id of the job that wrote the table.
+func WithProperties(properties map[string][]byte) WriterOption {
+	return func(args *SSTableWriterOptions) {
+		if args.properties == nil {
+			args.properties = map[string][]byte{}
+		}
+		for k, v := range properties {
+			args.properties[k] = v
+		}
+	}
+}
+
+// WithPropertiesCollector creates a new PropertiesCollector for each written table, which is called for every key and
+// value. Its properties are stored in the metadata on Close and take precedence over the ones from WithProperties.
+func WithPropertiesCollector(factory PropertiesCollectorFactory) WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.propertiesCollectorFactories = append(args.propertiesCollectorFactories, factory)
+	}
+}
+
+// WithPartitionedIndex additionally writes a top-level index with the first key of every index partition of the given
+// size, which the PartitionedIndexLoader keeps in memory instead of the whole index.
+func WithPartitionedIndex(partitionSizeBytes uint64) WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.indexPartitionSizeBytes = partitionSizeBytes
+	}
+}
+
+// WithHashIndex additionally writes a hash table over all keys, which the HashIndexLoader uses for point lookups in
+// constant time without loading the index into memory.
+func WithHashIndex() WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.hashIndex = true
+	}
+}
+
+// WriteAtomically writes the table into a hidden staging directory next to the basePath, which is synced and renamed
+// to the basePath on Close. Readers therefore never see a partially written table, the basePath must not exist yet or
+// be an empty directory. Staging directories of crashed writers can be found with IsStagingDir.
+func WriteAtomically() WriterOption {
+	return func(args *SSTableWriterOptions) {
+		args.writeAtomically = true
+	}
+}
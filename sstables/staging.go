@@ -0,0 +1,117 @@
+package sstables
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IncompleteTable indicates that an sstable is still being written, or that its writer never finished.
+var IncompleteTable = errors.New("sstable is incomplete")
+
+// StagingDirInfix is part of the name of the temporary sibling directory a table is written into WriteAtomically,
+// for example ".sstable_42.staging-1234" for the base path "sstable_42".
+var StagingDirInfix = ".staging-"
+
+// IsStagingDir returns true if the directory name belongs to a table that is written atomically, but that wasn't
+// renamed to its final path yet. Such directories are left behind by crashed writers and can be removed safely.
+func IsStagingDir(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, StagingDirInfix)
+}
+
+// createStagingDir creates the hidden sibling directory that a table is written into before it's renamed to the
+// basePath. The basePath must not exist yet or be an empty directory, so that the rename can replace it atomically.
+func createStagingDir(basePath string) (string, error) {
+	entries, err := os.ReadDir(basePath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("error while checking sstable path '%s': %w", basePath, err)
+	}
+
+	if len(entries) > 0 {
+		return "", fmt.Errorf("sstable path '%s' must not exist or be empty to write atomically", basePath)
+	}
+
+	cleanPath := filepath.Clean(basePath)
+	stagingPath, err := os.MkdirTemp(filepath.Dir(cleanPath), "."+filepath.Base(cleanPath)+StagingDirInfix)
+	if err != nil {
+		return "", fmt.Errorf("error while creating staging directory for sstable in '%s': %w", basePath, err)
+	}
+
+	return stagingPath, nil
+}
+
+// commitStagingDir makes all files in the staging directory durable and renames it to the basePath.
+func commitStagingDir(stagingPath string, basePath string) error {
+	entries, err := os.ReadDir(stagingPath)
+	if err != nil {
+		return fmt.Errorf("error while listing staging directory '%s': %w", stagingPath, err)
+	}
+
+	for _, e := range entries {
+		err = syncPath(filepath.Join(stagingPath, e.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = syncPath(stagingPath)
+	if err != nil {
+		return err
+	}
+
+	// an empty directory at the basePath was created by the caller, the rename can't replace it on all platforms
+	err = os.Remove(basePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error while replacing sstable path '%s': %w", basePath, err)
+	}
+
+	err = os.Rename(stagingPath, basePath)
+	if err != nil {
+		return fmt.Errorf("error while renaming staging directory '%s' to '%s': %w", stagingPath, basePath, err)
+	}
+
+	return syncPath(filepath.Dir(filepath.Clean(basePath)))
+}
+
+func syncPath(path string) (err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error while opening '%s' for syncing: %w", path, err)
+	}
+
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	err = f.Sync()
+	if err != nil {
+		return fmt.Errorf("error while syncing '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// checkTableComplete rejects staging directories and tables whose metadata was never written. The metadata is
+// created empty when the writer is opened and only written when it's closed.
+func checkTableComplete(basePath string) error {
+	if IsStagingDir(filepath.Base(filepath.Clean(basePath))) {
+		return fmt.Errorf("sstable in '%s' is a staging directory: %w", basePath, IncompleteTable)
+	}
+
+	stat, err := os.Stat(filepath.Join(basePath, MetaFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// tables written before the metadata existed don't have it at all
+			return nil
+		}
+		return fmt.Errorf("error while checking metadata of sstable in '%s': %w", basePath, err)
+	}
+
+	if stat.Size() == 0 {
+		return fmt.Errorf("sstable in '%s' has no metadata: %w", basePath, IncompleteTable)
+	}
+
+	return nil
+}
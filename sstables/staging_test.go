@@ -0,0 +1,133 @@
+package sstables
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func newStagingTestDir(t *testing.T) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_Staging")
+	require.Nil(t, err)
+	return tmpDir
+}
+
+func stagingDirs(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+
+	var result []string
+	for _, e := range entries {
+		if IsStagingDir(e.Name()) {
+			result = append(result, e.Name())
+		}
+	}
+	return result
+}
+
+func TestWriteAtomically(t *testing.T) {
+	tmpDir := newStagingTestDir(t)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+	path := filepath.Join(tmpDir, "table")
+
+	writer, err := NewSSTableStreamWriter(WriteBasePath(path), WithKeyComparator(skiplist.BytesComparator{}),
+		WriteAtomically(), WithHashIndex())
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for i := 0; i < 100; i++ {
+		require.Nil(t, writer.WriteNext(intToByteSlice(i), intToByteSlice(i+1)))
+	}
+
+	// nothing is visible under the final path while writing
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	staging := stagingDirs(t, tmpDir)
+	require.Len(t, staging, 1)
+
+	_, err = NewSSTableReader(ReadBasePath(filepath.Join(tmpDir, staging[0])))
+	assert.ErrorIs(t, err, IncompleteTable)
+
+	require.Nil(t, writer.Close())
+	assert.Empty(t, stagingDirs(t, tmpDir))
+
+	reader, err := NewSSTableReader(ReadBasePath(path), ReadIndexLoader(&HashIndexLoader{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.Equal(t, uint64(100), reader.MetaData().NumRecords)
+
+	for i := 0; i < 100; i++ {
+		v, err := reader.Get(intToByteSlice(i))
+		require.Nil(t, err)
+		assert.Equal(t, intToByteSlice(i+1), v)
+	}
+}
+
+func TestWriteAtomicallyReplacesEmptyDirectory(t *testing.T) {
+	tmpDir := newStagingTestDir(t)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+
+	writer, err := newAtomicTestWriter(tmpDir)
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	require.Nil(t, writer.WriteNext([]byte("a"), []byte("b")))
+	require.Nil(t, writer.Close())
+
+	reader, err := NewSSTableReader(ReadBasePath(tmpDir))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	v, err := reader.Get([]byte("a"))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("b"), v)
+	for _, d := range stagingDirs(t, filepath.Dir(tmpDir)) {
+		assert.NotContains(t, d, filepath.Base(tmpDir))
+	}
+}
+
+func TestWriteAtomicallyRejectsExistingTable(t *testing.T) {
+	tmpDir := newStagingTestDir(t)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+	require.Nil(t, os.WriteFile(filepath.Join(tmpDir, DataFileName), []byte{}, 0666))
+
+	writer, err := newAtomicTestWriter(tmpDir)
+	require.Nil(t, err)
+	require.ErrorContains(t, writer.Open(), "must not exist or be empty")
+}
+
+func TestReaderRejectsUnfinishedTable(t *testing.T) {
+	tmpDir := newStagingTestDir(t)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	require.Nil(t, writer.WriteNext([]byte("a"), []byte("b")))
+
+	_, err = NewSSTableReader(ReadBasePath(tmpDir))
+	assert.ErrorIs(t, err, IncompleteTable)
+
+	require.Nil(t, writer.Close())
+	reader, err := NewSSTableReader(ReadBasePath(tmpDir))
+	require.Nil(t, err)
+	closeReader(t, reader)
+}
+
+func TestReaderRejectsUnfinishedTableFromSource(t *testing.T) {
+	path := writeSourceTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	source := readerAtSourceOf(t, path)
+	source[MetaFileName] = ReaderAtFile{ReaderAt: bytes.NewReader(nil), Size: 0}
+
+	_, err := NewSSTableReader(ReadFromSource(source))
+	assert.ErrorIs(t, err, IncompleteTable)
+}
+
+func newAtomicTestWriter(path string) (*SSTableStreamWriter, error) {
+	return NewSSTableStreamWriter(WriteBasePath(path), WithKeyComparator(skiplist.BytesComparator{}), WriteAtomically())
+}
@@ -2,6 +2,8 @@ package sstables
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/thomasjungblut/go-sstables/skiplist"
@@ -10,9 +12,12 @@ import (
 
 // SuperSSTableReader unifies several sstables under one single reader with the same interface.
 // The ordering of the readers matters, it is assumed the older reader comes before the newer (ascending order).
+// Tables that were written WithMergeOperands are folded with the MergeOperator, all values are returned decoded.
 type SuperSSTableReader struct {
-	readers []SSTableReaderI
-	comp    skiplist.Comparator[[]byte]
+	readers       []SSTableReaderI
+	comp          skiplist.Comparator[[]byte]
+	mergeOperator MergeOperator
+	mergeOperands bool
 }
 
 func (s SuperSSTableReader) Contains(key []byte) (bool, error) {
@@ -26,17 +31,33 @@ func (s SuperSSTableReader) Contains(key []byte) (bool, error) {
 		if keyExist {
 			return true, nil
 		}
+		// older readers can't contain the key anymore when it was range deleted
+		if s.readers[i].RangeTombstones().Covers(s.comp, key) {
+			return false, nil
+		}
 	}
 
 	return false, nil
 }
 
 func (s SuperSSTableReader) Get(key []byte) ([]byte, error) {
+	if s.mergeOperands {
+		return s.getMerged(key)
+	}
+
 	// scanning from back to front to get the latest definitive answer
 	for i := len(s.readers) - 1; i >= 0; i-- {
 		res, err := s.readers[i].Get(key)
 		if err != nil {
+			// an expired value takes precedence over the older readers, just like a tombstone
+			if errors.Is(err, ExpiredValue) {
+				return nil, NotFound
+			}
 			if errors.Is(err, NotFound) {
+				// older readers can't contain the key anymore when it was range deleted
+				if s.readers[i].RangeTombstones().Covers(s.comp, key) {
+					return nil, NotFound
+				}
 				continue
 			}
 			return nil, err
@@ -48,6 +69,327 @@ func (s SuperSSTableReader) Get(key []byte) ([]byte, error) {
 	return nil, NotFound
 }
 
+// getMerged folds the values of the key from the newest to the oldest reader, until a put or a delete was found.
+// A delete is returned as an empty value, just like a tombstone in a table without merge operands.
+func (s SuperSSTableReader) getMerged(key []byte) ([]byte, error) {
+	folder := &mergeFolder{key: key}
+	found := false
+	for i := len(s.readers) - 1; i >= 0 && !folder.done; i-- {
+		res, err := s.readers[i].Get(key)
+		foundInReader, err := s.addToFolder(folder, s.readers[i], res, err)
+		if err != nil {
+			return nil, err
+		}
+		found = found || foundInReader
+	}
+
+	return s.foldedValue(folder, found)
+}
+
+// addToFolder adds the result of looking up the folder's key in the reader, it returns true if the key was found.
+func (s SuperSSTableReader) addToFolder(folder *mergeFolder, reader SSTableReaderI, res []byte, err error) (bool, error) {
+	if err != nil && !errors.Is(err, NotFound) {
+		return false, err
+	}
+
+	found := false
+	if errors.Is(err, ExpiredValue) {
+		found = true
+		folder.add(MergeValue{Kind: ValueKindDelete})
+	} else if err == nil {
+		found = true
+		value := plainMergeValue(res)
+		if reader.MetaData().MergeOperands {
+			value, err = DecodeMergeValue(res)
+			if err != nil {
+				return false, fmt.Errorf("error in sstable '%s' while decoding value: %w", reader.BasePath(), err)
+			}
+		}
+		folder.add(value)
+	}
+
+	// older readers can't contain the key anymore when it was range deleted
+	if reader.RangeTombstones().Covers(s.comp, folder.key) {
+		folder.add(MergeValue{Kind: ValueKindDelete})
+	}
+
+	return found, nil
+}
+
+// foldedValue fully merges the operands of the folder onto its base.
+func (s SuperSSTableReader) foldedValue(folder *mergeFolder, found bool) ([]byte, error) {
+	if !found {
+		return nil, NotFound
+	}
+
+	if len(folder.operands) == 0 {
+		if folder.base.Kind == ValueKindPut {
+			return folder.base.Value, nil
+		}
+		return []byte{}, nil
+	}
+
+	return folder.fullMerge(s.mergeOperator)
+}
+
+// MultiGet looks up the keys in the newest reader first, only the keys that weren't found are looked up in the older ones.
+func (s SuperSSTableReader) MultiGet(keys [][]byte) ([][]byte, []error) {
+	if s.mergeOperands {
+		return s.multiGetMerged(keys)
+	}
+
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	pending := allPositions(len(keys))
+	for i := len(s.readers) - 1; i >= 0 && len(pending) > 0; i-- {
+		readerValues, readerErrs := s.readers[i].MultiGet(pendingKeys(keys, pending))
+		var notFound []int
+		for j, position := range pending {
+			err := readerErrs[j]
+			if err == nil {
+				values[position] = readerValues[j]
+				continue
+			}
+
+			if !errors.Is(err, NotFound) {
+				errs[position] = err
+				continue
+			}
+
+			// older readers can't contain the key anymore when it has expired or was range deleted
+			if errors.Is(err, ExpiredValue) || s.readers[i].RangeTombstones().Covers(s.comp, keys[position]) {
+				errs[position] = NotFound
+				continue
+			}
+			notFound = append(notFound, position)
+		}
+		pending = notFound
+	}
+
+	for _, position := range pending {
+		errs[position] = NotFound
+	}
+
+	return values, errs
+}
+
+// multiGetMerged collects the values of the keys with one MultiGet per reader, from the newest to the oldest reader.
+// Only the keys whose folder still needs older values are looked up in the older readers.
+func (s SuperSSTableReader) multiGetMerged(keys [][]byte) ([][]byte, []error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	folders := make([]*mergeFolder, len(keys))
+	found := make([]bool, len(keys))
+	for i, key := range keys {
+		folders[i] = &mergeFolder{key: key}
+	}
+
+	pending := allPositions(len(keys))
+	for i := len(s.readers) - 1; i >= 0 && len(pending) > 0; i-- {
+		readerValues, readerErrs := s.readers[i].MultiGet(pendingKeys(keys, pending))
+		var notDone []int
+		for j, position := range pending {
+			foundInReader, err := s.addToFolder(folders[position], s.readers[i], readerValues[j], readerErrs[j])
+			if err != nil {
+				errs[position] = err
+				continue
+			}
+
+			found[position] = found[position] || foundInReader
+			if !folders[position].done {
+				notDone = append(notDone, position)
+			}
+		}
+		pending = notDone
+	}
+
+	for i := range keys {
+		if errs[i] == nil {
+			values[i], errs[i] = s.foldedValue(folders[i], found[i])
+		}
+	}
+
+	return values, errs
+}
+
+func allPositions(n int) []int {
+	positions := make([]int, n)
+	for i := range positions {
+		positions[i] = i
+	}
+	return positions
+}
+
+func pendingKeys(keys [][]byte, pending []int) [][]byte {
+	result := make([][]byte, len(pending))
+	for j, position := range pending {
+		result[j] = keys[position]
+	}
+	return result
+}
+
+// ApproximateOffsetOf returns the sum of the offsets of the key in all readers, that is the approximate number of
+// data bytes of all tables that come before the key.
+func (s SuperSSTableReader) ApproximateOffsetOf(key []byte) (uint64, error) {
+	sum := uint64(0)
+	for _, reader := range s.readers {
+		offset, err := reader.ApproximateOffsetOf(key)
+		if err != nil {
+			return 0, err
+		}
+		sum += offset
+	}
+	return sum, nil
+}
+
+func (s SuperSSTableReader) ApproximateSizeOfRange(keyLower []byte, keyHigher []byte) (uint64, error) {
+	sum := uint64(0)
+	for _, reader := range s.readers {
+		size, err := reader.ApproximateSizeOfRange(keyLower, keyHigher)
+		if err != nil {
+			return 0, err
+		}
+		sum += size
+	}
+	return sum, nil
+}
+
+// SampleKeys samples every reader proportional to its number of records and evenly picks n of the merged samples.
+func (s SuperSSTableReader) SampleKeys(n int) ([][]byte, error) {
+	numRecords := s.MetaData().NumRecords
+	if n <= 0 || numRecords == 0 {
+		return nil, nil
+	}
+
+	var candidates [][]byte
+	for _, reader := range s.readers {
+		m := reader.MetaData().NumRecords
+		if m == 0 {
+			continue
+		}
+		// oversampling leaves enough candidates when the readers overlap
+		samples, err := reader.SampleKeys(int((2*uint64(n)*m + numRecords - 1) / numRecords))
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, samples...)
+	}
+
+	candidates = s.sortedUniqueKeys(candidates)
+	if len(candidates) <= n {
+		return candidates, nil
+	}
+
+	samples := make([][]byte, n)
+	for i := range samples {
+		samples[i] = candidates[i*len(candidates)/n]
+	}
+	return samples, nil
+}
+
+// KeyQuantiles takes the quantiles of every reader as candidates and picks those whose aggregated offset is closest
+// to the n-th parts of the data of all readers.
+func (s SuperSSTableReader) KeyQuantiles(n int) ([][]byte, error) {
+	if n <= 1 {
+		return nil, nil
+	}
+
+	var candidates [][]byte
+	for _, reader := range s.readers {
+		quantiles, err := reader.KeyQuantiles(4 * n)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, quantiles...)
+	}
+
+	candidates = s.sortedUniqueKeys(candidates)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	offsets := make([]uint64, len(candidates))
+	for i, candidate := range candidates {
+		offset, err := s.ApproximateOffsetOf(candidate)
+		if err != nil {
+			return nil, err
+		}
+		offsets[i] = offset
+	}
+
+	lower, err := s.ApproximateOffsetOf([]byte{})
+	if err != nil {
+		return nil, err
+	}
+	higher := uint64(0)
+	for _, reader := range s.readers {
+		// the index is always sorted by bytes, so any key after the largest one resolves to the end of the data
+		offset, err := reader.ApproximateOffsetOf(append(append([]byte{}, reader.MetaData().MaxKey...), 0))
+		if err != nil {
+			return nil, err
+		}
+		higher += offset
+	}
+
+	var quantiles [][]byte
+	next := 0
+	for i := 1; i < n && next < len(candidates); i++ {
+		target := lower + (higher-lower)*uint64(i)/uint64(n)
+		for next < len(candidates)-1 && absDiff(offsets[next+1], target) <= absDiff(offsets[next], target) {
+			next++
+		}
+		quantiles = append(quantiles, candidates[next])
+		next++
+	}
+	return quantiles, nil
+}
+
+func (s SuperSSTableReader) sortedUniqueKeys(keys [][]byte) [][]byte {
+	sort.Slice(keys, func(i, j int) bool {
+		return s.comp.Compare(keys[i], keys[j]) < 0
+	})
+
+	var unique [][]byte
+	for _, k := range keys {
+		if len(unique) == 0 || s.comp.Compare(unique[len(unique)-1], k) != 0 {
+			unique = append(unique, k)
+		}
+	}
+	return unique
+}
+
+func absDiff(a uint64, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// GetAsOf looks up the latest version in all readers, since the sequence numbers of the tables might overlap.
+func (s SuperSSTableReader) GetAsOf(userKey []byte, sequence uint64) ([]byte, error) {
+	var latest *KeyVersion
+	for _, reader := range s.readers {
+		version, err := getVersionAsOf(reader, userKey, sequence)
+		if err != nil {
+			if errors.Is(err, NotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		// on equal versions the later reader wins
+		if latest == nil || version.Sequence >= latest.Sequence {
+			latest = &version
+		}
+	}
+
+	if latest == nil {
+		return nil, NotFound
+	}
+
+	return versionValue(*latest)
+}
+
 func (s SuperSSTableReader) Scan() (SSTableIteratorI, error) {
 	var iterators []SSTableMergeIteratorContext
 	for i, reader := range s.readers {
@@ -55,10 +397,10 @@ func (s SuperSSTableReader) Scan() (SSTableIteratorI, error) {
 		if err != nil {
 			return nil, err
 		}
-		iterators = append(iterators, NewMergeIteratorContext(i, scanner))
+		iterators = append(iterators, NewReaderMergeIteratorContext(i, scanner, reader))
 	}
 
-	iterator, err := NewSSTableMerger(s.comp).MergeCompactIterator(iterators, ScanReduceLatestWins)
+	iterator, err := s.merger().MergeCompactIterator(iterators, ScanReduceLatestWins)
 	if err != nil {
 		return nil, err
 	}
@@ -74,10 +416,10 @@ func (s SuperSSTableReader) ScanStartingAt(key []byte) (SSTableIteratorI, error)
 		if err != nil {
 			return nil, err
 		}
-		iterators = append(iterators, NewMergeIteratorContext(i, scanner))
+		iterators = append(iterators, NewReaderMergeIteratorContext(i, scanner, reader))
 	}
 
-	iterator, err := NewSSTableMerger(s.comp).MergeCompactIterator(iterators, ScanReduceLatestWins)
+	iterator, err := s.merger().MergeCompactIterator(iterators, ScanReduceLatestWins)
 	if err != nil {
 		return nil, err
 	}
@@ -93,10 +435,10 @@ func (s SuperSSTableReader) ScanRange(keyLower []byte, keyHigher []byte) (SSTabl
 		if err != nil {
 			return nil, err
 		}
-		iterators = append(iterators, NewMergeIteratorContext(i, scanner))
+		iterators = append(iterators, NewReaderMergeIteratorContext(i, scanner, reader))
 	}
 
-	iterator, err := NewSSTableMerger(s.comp).MergeCompactIterator(iterators, ScanReduceLatestWins)
+	iterator, err := s.merger().MergeCompactIterator(iterators, ScanReduceLatestWins)
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +446,65 @@ func (s SuperSSTableReader) ScanRange(keyLower []byte, keyHigher []byte) (SSTabl
 	return iterator, nil
 }
 
+// ScanPrefix merges the prefix scans of all readers, tables whose bloom filter rules out the prefix are skipped entirely.
+func (s SuperSSTableReader) ScanPrefix(prefix []byte) (SSTableIteratorI, error) {
+	var iterators []SSTableMergeIteratorContext
+
+	for i, reader := range s.readers {
+		scanner, err := reader.ScanPrefix(prefix)
+		if err != nil {
+			return nil, err
+		}
+		if _, empty := scanner.(EmptySSTableIterator); empty && len(reader.RangeTombstones()) == 0 {
+			continue
+		}
+		iterators = append(iterators, NewReaderMergeIteratorContext(i, scanner, reader))
+	}
+
+	if len(iterators) == 0 {
+		return EmptySSTableIterator{}, nil
+	}
+
+	iterator, err := s.merger().MergeCompactIterator(iterators, ScanReduceLatestWins)
+	if err != nil {
+		return nil, err
+	}
+
+	return iterator, nil
+}
+
+// SeekableIterator merges the bidirectional iterators of all readers, the latest reader wins on equal keys.
+func (s SuperSSTableReader) SeekableIterator() (SSTableSeekableIteratorI, error) {
+	var iterators []SSTableSeekableIteratorI
+	var tombstones []contextRangeTombstones
+	for i, reader := range s.readers {
+		it, err := reader.SeekableIterator()
+		if err != nil {
+			return nil, err
+		}
+		iterators = append(iterators, it)
+		if len(reader.RangeTombstones()) > 0 {
+			tombstones = append(tombstones, contextRangeTombstones{ctx: i, tombstones: reader.RangeTombstones()})
+		}
+	}
+
+	return newSeekableMergeIterator(s.comp, iterators, tombstones)
+}
+
+// RangeTombstones returns the union of all range tombstones of the underlying readers.
+func (s SuperSSTableReader) RangeTombstones() RangeTombstones {
+	var tombstones []RangeTombstone
+	for _, reader := range s.readers {
+		tombstones = append(tombstones, reader.RangeTombstones()...)
+	}
+	return mergeRangeTombstones(s.comp, tombstones)
+}
+
+// merger returns the SSTableMerger for the scans, which fully merges the operands since all tables are part of the scan.
+func (s SuperSSTableReader) merger() SSTableMerger {
+	return SSTableMerger{comp: s.comp, mergeOperator: s.mergeOperator, resolveMerges: true}
+}
+
 // ScanReduceLatestWins is a simple version of a merge where the latest value always wins. Latest is determined
 // by looping the context and finding the biggest value denoted by integers (assuming context is actually []int).
 func ScanReduceLatestWins(key []byte, values [][]byte, context []int) ([]byte, []byte) {
@@ -148,14 +549,40 @@ func (s SuperSSTableReader) MetaData() *proto.MetaData {
 		IndexBytes: 0,
 		TotalBytes: 0,
 		Version:    0,
+		// the readers are expected to agree with the comparator
+		ComparatorName: comparatorName(s.comp),
 	}
 
+	hasSequences := false
 	for _, reader := range s.readers {
 		m := reader.MetaData()
 		sum.NumRecords += m.NumRecords
 		sum.DataBytes += m.DataBytes
 		sum.IndexBytes += m.IndexBytes
 		sum.TotalBytes += m.TotalBytes
+		sum.NumRangeTombstones += m.NumRangeTombstones
+		sum.KeyBytes += m.KeyBytes
+		sum.ValueBytes += m.ValueBytes
+		if m.InternalKeys && m.NumRecords > 0 {
+			if !hasSequences || m.MinSequence < sum.MinSequence {
+				sum.MinSequence = m.MinSequence
+			}
+			hasSequences = true
+			if m.MaxSequence > sum.MaxSequence {
+				sum.MaxSequence = m.MaxSequence
+			}
+		}
+		if m.CreationTime > sum.CreationTime {
+			sum.CreationTime = m.CreationTime
+		}
+		sum.ValueSeparation = sum.ValueSeparation || m.ValueSeparation
+		sum.InternalKeys = sum.InternalKeys || m.InternalKeys
+		for fileId, numBytes := range m.BlobFileBytes {
+			if sum.BlobFileBytes == nil {
+				sum.BlobFileBytes = map[uint64]uint64{}
+			}
+			sum.BlobFileBytes[fileId] += numBytes
+		}
 		sum.Version = m.Version // assuming all have the same version anyway
 		if s.comp.Compare(sum.MinKey, m.MinKey) < 0 {
 			sum.MinKey = m.MinKey
@@ -165,9 +592,24 @@ func (s SuperSSTableReader) MetaData() *proto.MetaData {
 			sum.MaxKey = m.MaxKey
 		}
 	}
+	sum.Properties = s.Properties()
 	return sum
 }
 
+// Properties merges the properties of all readers, newer readers take precedence.
+func (s SuperSSTableReader) Properties() map[string][]byte {
+	var properties map[string][]byte
+	for _, reader := range s.readers {
+		for k, v := range reader.Properties() {
+			if properties == nil {
+				properties = map[string][]byte{}
+			}
+			properties[k] = v
+		}
+	}
+	return properties
+}
+
 func (s SuperSSTableReader) BasePath() string {
 	// the usefulness here is also debatable, but we return a joined string of all sub files
 	var paths []string
@@ -177,6 +619,50 @@ func (s SuperSSTableReader) BasePath() string {
 	return strings.Join(paths, ",")
 }
 
-func NewSuperSSTableReader(readers []SSTableReaderI, comp skiplist.Comparator[[]byte]) *SuperSSTableReader {
-	return &SuperSSTableReader{readers: readers, comp: comp}
+// NewSuperSSTableReader combines the readers without checking their comparators, which must all sort the keys like
+// comp. Use NewCheckedSuperSSTableReader to verify this against the comparator names persisted in the readers' metadata.
+func NewSuperSSTableReader(readers []SSTableReaderI, comp skiplist.Comparator[[]byte], opts ...SuperReaderOption) *SuperSSTableReader {
+	options := &SuperSSTableReaderOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	mergeOperands := false
+	for _, reader := range readers {
+		mergeOperands = mergeOperands || reader.MetaData().MergeOperands
+	}
+	return &SuperSSTableReader{
+		readers:       readers,
+		comp:          comp,
+		mergeOperator: options.mergeOperator,
+		mergeOperands: mergeOperands,
+	}
+}
+
+// NewCheckedSuperSSTableReader works like NewSuperSSTableReader, but returns ComparatorMismatch if any of the readers
+// was written with a different comparator than comp.
+func NewCheckedSuperSSTableReader(readers []SSTableReaderI, comp skiplist.Comparator[[]byte], opts ...SuperReaderOption) (*SuperSSTableReader, error) {
+	for _, reader := range readers {
+		err := checkComparatorName(comp, reader.MetaData().ComparatorName)
+		if err != nil {
+			return nil, fmt.Errorf("error while adding '%s' to the super sstable reader: %w", reader.BasePath(), err)
+		}
+	}
+	return NewSuperSSTableReader(readers, comp, opts...), nil
+}
+
+// options
+
+type SuperSSTableReaderOptions struct {
+	mergeOperator MergeOperator
+}
+
+type SuperReaderOption func(*SuperSSTableReaderOptions)
+
+// SuperReadWithMergeOperator folds the merge operands of tables that were written WithMergeOperands. Without an
+// operator, reading a key that has merge operands returns MergeOperandFound.
+func SuperReadWithMergeOperator(operator MergeOperator) SuperReaderOption {
+	return func(args *SuperSSTableReaderOptions) {
+		args.mergeOperator = operator
+	}
 }
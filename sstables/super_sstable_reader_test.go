@@ -407,3 +407,73 @@ func TestSuperStaggeredAndOverlappingFull(t *testing.T) {
 	require.Nil(t, v)
 	assert.Equal(t, Done, err)
 }
+
+func TestSuperStaggeredAndOverlappingSeekable(t *testing.T) {
+	writer, err := newTestSSTableSimpleWriter()
+	require.Nil(t, err)
+	defer cleanWriterDir(t, writer.streamWriter)
+
+	err = writer.WriteSkipListMap(TEST_ONLY_NewSkipListMapWithElements([]int{0, 1, 2, 4, 8, 9, 10}))
+	require.Nil(t, err)
+
+	reader1, err := NewSSTableReader(
+		ReadBasePath(writer.streamWriter.opts.basePath),
+		ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader1)
+
+	reader2, err := NewSSTableReader(
+		ReadBasePath("test_files/SimpleWriteHappyPathSSTableRecordIOV2"),
+		ReadWithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader2)
+
+	reader := SuperSSTableReader{
+		readers: []SSTableReaderI{reader1, reader2},
+		comp:    skiplist.BytesComparator{},
+	}
+
+	expected := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	it, err := reader.SeekableIterator()
+	require.Nil(t, err)
+	assert.True(t, it.Valid())
+	assertIteratorMatchesSlice(t, it, expected)
+	assert.False(t, it.Valid())
+
+	require.Nil(t, it.SeekToLast())
+	assertReverseIteratorMatchesSlice(t, it, intToByteSlice(0), expected)
+	assert.False(t, it.Valid())
+
+	for i := 0; i < len(expected); i++ {
+		for j := i; j < len(expected); j++ {
+			require.Nil(t, it.SeekForPrev(intToByteSlice(expected[j])))
+			assertReverseIteratorMatchesSlice(t, it, intToByteSlice(expected[i]), expected[i:j+1])
+		}
+	}
+
+	// seeking in between and out of range keys
+	require.Nil(t, it.SeekForPrev(intToByteSlice(25)))
+	assertReverseIteratorMatchesSlice(t, it, intToByteSlice(0), expected)
+	require.Nil(t, it.Seek(intToByteSlice(11)))
+	assert.False(t, it.Valid())
+	_, _, err = it.Next()
+	assert.Equal(t, Done, err)
+
+	// switching directions returns the current key again
+	require.Nil(t, it.Seek(intToByteSlice(4)))
+	k, _, err := it.Next()
+	require.Nil(t, err)
+	assert.Equal(t, intToByteSlice(4), k)
+	k, _, err = it.Prev()
+	require.Nil(t, err)
+	assert.Equal(t, intToByteSlice(5), k)
+	k, _, err = it.Prev()
+	require.Nil(t, err)
+	assert.Equal(t, intToByteSlice(4), k)
+	k, _, err = it.Next()
+	require.Nil(t, err)
+	assert.Equal(t, intToByteSlice(3), k)
+	k, _, err = it.Next()
+	require.Nil(t, err)
+	assert.Equal(t, intToByteSlice(4), k)
+}
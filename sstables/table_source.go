@@ -0,0 +1,486 @@
+package sstables
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/steakknife/bloomfilter"
+	"github.com/thomasjungblut/go-sstables/recordio"
+	rProto "github.com/thomasjungblut/go-sstables/recordio/proto"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+	pb "google.golang.org/protobuf/proto"
+)
+
+// TableSource gives random access to the files of a single sstable, for example when they are stored in an object store.
+// Tables are opened from a source with ReadFromSource.
+type TableSource interface {
+	// File returns a reader and the size of the file with the given name, e.g. DataFileName. The reader must be safe
+	// for concurrent use. Optional files that don't exist must return an error that wraps os.ErrNotExist.
+	File(name string) (io.ReaderAt, int64, error)
+}
+
+// ReaderAtFile is a single file of a ReaderAtSource.
+type ReaderAtFile struct {
+	ReaderAt io.ReaderAt
+	Size     int64
+}
+
+// ReaderAtSource is a TableSource that maps the file names to readers, e.g. DataFileName to the data.
+type ReaderAtSource map[string]ReaderAtFile
+
+func (s ReaderAtSource) File(name string) (io.ReaderAt, int64, error) {
+	f, ok := s[name]
+	if !ok {
+		return nil, 0, fmt.Errorf("file '%s' not found in source: %w", name, os.ErrNotExist)
+	}
+	return f.ReaderAt, f.Size, nil
+}
+
+// RangeFetcher fetches byte ranges of the files of a single sstable, for example with HTTP range requests.
+type RangeFetcher interface {
+	// Size returns the size of the file with the given name, an error that wraps os.ErrNotExist if it doesn't exist.
+	Size(name string) (int64, error)
+	// Fetch returns length bytes of the file starting at offset. The range is always within the size of the file.
+	Fetch(name string, offset int64, length int64) ([]byte, error)
+}
+
+// FetcherSource is a TableSource that reads the files with a RangeFetcher. The fetched byte ranges are aligned to
+// blocks, so that small reads close to each other are served by one fetch, and the most recently used blocks are cached.
+type FetcherSource struct {
+	fetcher   RangeFetcher
+	blockSize int64
+	maxBlocks int
+	readAhead int64
+
+	lock  sync.Mutex
+	cache map[fetchedBlockKey]*list.Element
+	lru   *list.List
+}
+
+type fetchedBlockKey struct {
+	name  string
+	block int64
+}
+
+type fetchedBlock struct {
+	key  fetchedBlockKey
+	data []byte
+}
+
+func (s *FetcherSource) File(name string) (io.ReaderAt, int64, error) {
+	size, err := s.fetcher.Size(name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error while fetching the size of '%s': %w", name, err)
+	}
+	return &fetchingReaderAt{source: s, name: name, size: size, lastBlock: -1}, size, nil
+}
+
+// cached returns the block if it's in the cache and marks it as recently used.
+func (s *FetcherSource) cached(key fetchedBlockKey) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if e, ok := s.cache[key]; ok {
+		s.lru.MoveToFront(e)
+		return e.Value.(*fetchedBlock).data
+	}
+	return nil
+}
+
+func (s *FetcherSource) add(key fetchedBlockKey, data []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if e, ok := s.cache[key]; ok {
+		s.lru.MoveToFront(e)
+		return
+	}
+
+	s.cache[key] = s.lru.PushFront(&fetchedBlock{key: key, data: data})
+	for s.lru.Len() > s.maxBlocks {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.cache, oldest.Value.(*fetchedBlock).key)
+	}
+}
+
+// fetchingReaderAt reads a single file of a FetcherSource through its block cache.
+type fetchingReaderAt struct {
+	source *FetcherSource
+	name   string
+	size   int64
+
+	lock sync.Mutex
+	// lastBlock is the last block that had to be fetched, used to detect sequential reads
+	lastBlock int64
+}
+
+func (r *fetchingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("invalid offset %d in '%s'", off, r.name)
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	end := off + int64(len(p))
+	if end > r.size {
+		end = r.size
+	}
+
+	bs := r.source.blockSize
+	n := 0
+	for block := off / bs; block*bs < end; {
+		data := r.source.cached(fetchedBlockKey{r.name, block})
+		if data == nil {
+			var err error
+			data, err = r.fetch(block, (end-1)/bs)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		blockStart := block * bs
+		from := off + int64(n) - blockStart
+		n += copy(p[n:end-off], data[from:])
+		block++
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fetch reads the missing blocks from first up to last with a single fetch, the read-ahead extends the fetch when the
+// blocks are read sequentially. It returns the data of the first block.
+func (r *fetchingReaderAt) fetch(first int64, last int64) ([]byte, error) {
+	bs := r.source.blockSize
+	r.lock.Lock()
+	if r.source.readAhead > 0 && first == r.lastBlock+1 {
+		last += r.source.readAhead
+	}
+	r.lastBlock = last
+	r.lock.Unlock()
+
+	// don't fetch blocks again that are already cached at the end of the range
+	for last > first && r.source.cached(fetchedBlockKey{r.name, last}) != nil {
+		last--
+	}
+
+	start := first * bs
+	end := (last + 1) * bs
+	if end > r.size {
+		end = r.size
+	}
+
+	data, err := r.source.fetcher.Fetch(r.name, start, end-start)
+	if err != nil {
+		return nil, fmt.Errorf("error while fetching range [%d, %d) of '%s': %w", start, end, r.name, err)
+	}
+	if int64(len(data)) != end-start {
+		return nil, fmt.Errorf("fetching range [%d, %d) of '%s' returned %d bytes", start, end, r.name, len(data))
+	}
+
+	for block := first; block*bs < end; block++ {
+		blockEnd := (block + 1) * bs
+		if blockEnd > end {
+			blockEnd = end
+		}
+		r.source.add(fetchedBlockKey{r.name, block}, data[block*bs-start:blockEnd-start])
+	}
+
+	firstEnd := bs
+	if firstEnd > end-start {
+		firstEnd = end - start
+	}
+	return data[:firstEnd], nil
+}
+
+// NewFetcherSource creates a new TableSource that reads all files with the given fetcher.
+func NewFetcherSource(fetcher RangeFetcher, opts ...FetcherSourceOption) (*FetcherSource, error) {
+	options := &FetcherSourceOptions{
+		blockSizeBytes:  64 * 1024,
+		maxCachedBlocks: 256,
+		readAheadBlocks: 4,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if fetcher == nil {
+		return nil, errors.New("NewFetcherSource: fetcher was not supplied")
+	}
+
+	if options.blockSizeBytes <= 0 {
+		return nil, fmt.Errorf("NewFetcherSource: invalid block size %d", options.blockSizeBytes)
+	}
+
+	if options.maxCachedBlocks <= 0 {
+		return nil, fmt.Errorf("NewFetcherSource: invalid number of cached blocks %d", options.maxCachedBlocks)
+	}
+
+	return &FetcherSource{
+		fetcher:   fetcher,
+		blockSize: options.blockSizeBytes,
+		maxBlocks: options.maxCachedBlocks,
+		readAhead: int64(options.readAheadBlocks),
+		cache:     map[fetchedBlockKey]*list.Element{},
+		lru:       list.New(),
+	}, nil
+}
+
+// options
+
+type FetcherSourceOptions struct {
+	blockSizeBytes  int64
+	maxCachedBlocks int
+	readAheadBlocks int
+}
+
+type FetcherSourceOption func(*FetcherSourceOptions)
+
+// FetchBlockSizeBytes sets the granularity in which the byte ranges are fetched and cached, defaults to 64KiB.
+func FetchBlockSizeBytes(size int64) FetcherSourceOption {
+	return func(args *FetcherSourceOptions) {
+		args.blockSizeBytes = size
+	}
+}
+
+// FetchMaxCachedBlocks sets how many blocks are cached over all files of the source, defaults to 256.
+func FetchMaxCachedBlocks(n int) FetcherSourceOption {
+	return func(args *FetcherSourceOptions) {
+		args.maxCachedBlocks = n
+	}
+}
+
+// FetchReadAheadBlocks sets how many additional blocks are fetched when a file is read sequentially, for example
+// while scanning, defaults to 4. Zero disables the read-ahead.
+func FetchReadAheadBlocks(n int) FetcherSourceOption {
+	return func(args *FetcherSourceOptions) {
+		args.readAheadBlocks = n
+	}
+}
+
+// DirectoryFetcher is a RangeFetcher that reads the files of a table from a local directory.
+type DirectoryFetcher struct {
+	Path string
+}
+
+func (f DirectoryFetcher) Size(name string) (int64, error) {
+	stat, err := os.Stat(filepath.Join(f.Path, name))
+	if err != nil {
+		return 0, err
+	}
+	return stat.Size(), nil
+}
+
+func (f DirectoryFetcher) Fetch(name string, offset int64, length int64) (_ []byte, err error) {
+	file, err := os.Open(filepath.Join(f.Path, name))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	buf := make([]byte, length)
+	_, err = file.ReadAt(buf, offset)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// HTTPRangeFetcher is a RangeFetcher that reads the files of a table with HTTP range requests below a base url,
+// e.g. the data of "https://example.com/table" is read from "https://example.com/table/data.rio".
+type HTTPRangeFetcher struct {
+	BaseURL string
+	// Client is used for all requests, defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+func (f HTTPRangeFetcher) Size(name string) (int64, error) {
+	resp, err := f.client().Head(f.url(name))
+	if err != nil {
+		return 0, err
+	}
+
+	err = resp.Body.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, fmt.Errorf("'%s' returned %s: %w", f.url(name), resp.Status, os.ErrNotExist)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("'%s' returned %s", f.url(name), resp.Status)
+	}
+
+	if resp.ContentLength < 0 {
+		return 0, fmt.Errorf("'%s' did not return a content length", f.url(name))
+	}
+
+	return resp.ContentLength, nil
+}
+
+func (f HTTPRangeFetcher) Fetch(name string, offset int64, length int64) (_ []byte, err error) {
+	req, err := http.NewRequest(http.MethodGet, f.url(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("'%s' returned %s for range request", f.url(name), resp.Status)
+	}
+
+	buf := make([]byte, length)
+	_, err = io.ReadFull(resp.Body, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (f HTTPRangeFetcher) url(name string) string {
+	return strings.TrimSuffix(f.BaseURL, "/") + "/" + name
+}
+
+func (f HTTPRangeFetcher) client() *http.Client {
+	if f.Client == nil {
+		return http.DefaultClient
+	}
+	return f.Client
+}
+
+// sourceFileIfExists returns false if the source doesn't contain the file with the given name.
+func sourceFileIfExists(source TableSource, name string) (io.ReaderAt, int64, bool, error) {
+	r, size, err := source.File(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, 0, false, nil
+		}
+		return nil, 0, false, fmt.Errorf("error while opening '%s' from source: %w", name, err)
+	}
+	return r, size, true, nil
+}
+
+func readMetaDataFromSource(source TableSource) (*proto.MetaData, error) {
+	r, size, exists, err := sourceFileIfExists(source, MetaFileName)
+	if err != nil || !exists {
+		return &proto.MetaData{}, err
+	}
+
+	if size == 0 {
+		return nil, fmt.Errorf("sstable from source has no metadata: %w", IncompleteTable)
+	}
+
+	content := make([]byte, size)
+	_, err = r.ReadAt(content, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading metadata from source: %w", err)
+	}
+
+	md := &proto.MetaData{}
+	err = pb.Unmarshal(content, md)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing metadata from source: %w", err)
+	}
+
+	err = verifyMetaDataChecksum(content, md)
+	if err != nil {
+		return nil, fmt.Errorf("error while verifying metadata from source: %w", err)
+	}
+
+	return md, nil
+}
+
+func readFilterFromSource(source TableSource) (*bloomfilter.Filter, error) {
+	r, size, exists, err := sourceFileIfExists(source, BloomFileName)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	filter, _, err := bloomfilter.ReadFrom(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("error while reading bloom filter from source: %w", err)
+	}
+
+	return filter, nil
+}
+
+func readRangeTombstonesFromSource(source TableSource) (RangeTombstones, error) {
+	r, size, exists, err := sourceFileIfExists(source, RangeTombstoneFileName)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	reader, err := rProto.NewReader(rProto.ReaderPath(RangeTombstoneFileName), rProto.ReaderFromReaderAt(r, size),
+		rProto.ReadBufferSizeBytes(4096))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating range tombstone reader from source: %w", err)
+	}
+
+	return readRangeTombstones(reader, RangeTombstoneFileName)
+}
+
+// newSourceReader creates a sequential reader over the file with the given name, it fails if it doesn't exist.
+func newSourceReader(source TableSource, name string, bufSize int) (recordio.ReaderI, error) {
+	r, size, err := source.File(name)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening '%s' from source: %w", name, err)
+	}
+
+	return recordio.NewFileReader(recordio.ReaderFromReaderAt(r, size, name), recordio.ReaderBufferSizeBytes(bufSize))
+}
+
+// newSourceReadAtReader creates a random access reader over the file with the given name, it fails if it doesn't exist.
+func newSourceReadAtReader(source TableSource, name string) (recordio.ReadAtI, error) {
+	r, size, err := source.File(name)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening '%s' from source: %w", name, err)
+	}
+
+	return recordio.NewReaderAtReader(r, size, name)
+}
+
+// newSourceProtoReader creates a sequential reader over the file with the given name, it fails if it doesn't exist.
+func newSourceProtoReader(source TableSource, name string, bufSize int) (rProto.ReaderI, error) {
+	r, size, err := source.File(name)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening '%s' from source: %w", name, err)
+	}
+
+	return rProto.NewReader(rProto.ReaderPath(name), rProto.ReaderFromReaderAt(r, size), rProto.ReadBufferSizeBytes(bufSize))
+}
+
+// newSourceProtoReadAtReader creates a random access reader over the file with the given name, it fails if it doesn't exist.
+func newSourceProtoReadAtReader(source TableSource, name string) (rProto.ReadAtI, error) {
+	r, size, err := source.File(name)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening '%s' from source: %w", name, err)
+	}
+
+	return rProto.NewReaderAtProtoReader(r, size, name)
+}
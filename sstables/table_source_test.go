@@ -0,0 +1,315 @@
+package sstables
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+)
+
+// countingFetcher records every range that was fetched per file.
+type countingFetcher struct {
+	RangeFetcher
+	lock    sync.Mutex
+	fetches map[string]int
+	bytes   map[string]int64
+}
+
+func (f *countingFetcher) Fetch(name string, offset int64, length int64) ([]byte, error) {
+	f.lock.Lock()
+	f.fetches[name]++
+	f.bytes[name] += length
+	f.lock.Unlock()
+	return f.RangeFetcher.Fetch(name, offset, length)
+}
+
+func newCountingFetcher(fetcher RangeFetcher) *countingFetcher {
+	return &countingFetcher{RangeFetcher: fetcher, fetches: map[string]int{}, bytes: map[string]int64{}}
+}
+
+func writeSourceTestTable(t *testing.T, opts ...WriterOption) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_TableSource")
+	require.Nil(t, err)
+
+	writer, err := NewSSTableStreamWriter(append(opts, WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}))...)
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	for i := 0; i < 1000; i++ {
+		require.Nil(t, writer.WriteNext(intToByteSlice(i), bytes.Repeat(intToByteSlice(i), 25)))
+	}
+	require.Nil(t, writer.WriteRangeTombstone(intToByteSlice(100), intToByteSlice(110)))
+	require.Nil(t, writer.Close())
+	return tmpDir
+}
+
+func readerAtSourceOf(t *testing.T, path string) ReaderAtSource {
+	entries, err := os.ReadDir(path)
+	require.Nil(t, err)
+
+	source := ReaderAtSource{}
+	for _, e := range entries {
+		content, err := os.ReadFile(filepath.Join(path, e.Name()))
+		require.Nil(t, err)
+		source[e.Name()] = ReaderAtFile{ReaderAt: bytes.NewReader(content), Size: int64(len(content))}
+	}
+	return source
+}
+
+func assertReadersEqual(t *testing.T, expected SSTableReaderI, actual SSTableReaderI) {
+	assert.Equal(t, expected.MetaData().NumRecords, actual.MetaData().NumRecords)
+	assert.Equal(t, expected.RangeTombstones(), actual.RangeTombstones())
+
+	for _, i := range []int{0, 1, 105, 500, 999, 1000, 5000} {
+		expectedVal, expectedErr := expected.Get(intToByteSlice(i))
+		actualVal, actualErr := actual.Get(intToByteSlice(i))
+		assert.Equal(t, expectedErr, actualErr)
+		assert.Equal(t, expectedVal, actualVal)
+
+		expectedContains, err := expected.Contains(intToByteSlice(i))
+		require.Nil(t, err)
+		actualContains, err := actual.Contains(intToByteSlice(i))
+		require.Nil(t, err)
+		assert.Equal(t, expectedContains, actualContains)
+	}
+
+	expectedIt, err := expected.Scan()
+	require.Nil(t, err)
+	actualIt, err := actual.Scan()
+	require.Nil(t, err)
+	assertIteratorsEqual(t, expectedIt, actualIt)
+
+	expectedIt, err = expected.ScanRange(intToByteSlice(200), intToByteSlice(300))
+	require.Nil(t, err)
+	actualIt, err = actual.ScanRange(intToByteSlice(200), intToByteSlice(300))
+	require.Nil(t, err)
+	assertIteratorsEqual(t, expectedIt, actualIt)
+}
+
+func assertIteratorsEqual(t *testing.T, expected SSTableIteratorI, actual SSTableIteratorI) {
+	for {
+		expectedKey, expectedVal, expectedErr := expected.Next()
+		actualKey, actualVal, actualErr := actual.Next()
+		require.Equal(t, expectedErr, actualErr)
+		require.Equal(t, expectedKey, actualKey)
+		require.Equal(t, expectedVal, actualVal)
+		if expectedErr != nil {
+			return
+		}
+	}
+}
+
+func TestReadFromReaderAtSource(t *testing.T) {
+	path := writeSourceTestTable(t, WithPartitionedIndex(256), WithHashIndex())
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	local, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, local)
+
+	loaders := map[string]IndexLoader{
+		"slice":       &SliceKeyIndexLoader{ReadBufferSize: 4096},
+		"skiplist":    &SkipListIndexLoader{KeyComparator: skiplist.BytesComparator{}, ReadBufferSize: 4096},
+		"disk":        &DiskIndexLoader{},
+		"partitioned": &PartitionedIndexLoader{},
+		"hash":        &HashIndexLoader{},
+	}
+
+	for name, loader := range loaders {
+		t.Run(name, func(t *testing.T) {
+			reader, err := NewSSTableReader(ReadFromSource(readerAtSourceOf(t, path)), ReadIndexLoader(loader),
+				EnableHashCheckOnReads())
+			require.Nil(t, err)
+			defer closeReader(t, reader)
+			assertReadersEqual(t, local, reader)
+		})
+	}
+}
+
+func TestReadFromSourceWithoutOptionalFiles(t *testing.T) {
+	path := writeSourceTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	source := readerAtSourceOf(t, path)
+	delete(source, BloomFileName)
+	delete(source, RangeTombstoneFileName)
+
+	reader, err := NewSSTableReader(ReadFromSource(source))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	assert.Empty(t, reader.RangeTombstones())
+
+	v, err := reader.Get(intToByteSlice(105))
+	require.Nil(t, err)
+	assert.Equal(t, bytes.Repeat(intToByteSlice(105), 25), v)
+
+	delete(source, DataFileName)
+	_, err = NewSSTableReader(ReadFromSource(source))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestReadFromSourceUnsupportedIndexLoader(t *testing.T) {
+	path := writeSourceTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	_, err := NewSSTableReader(ReadFromSource(readerAtSourceOf(t, path)), ReadIndexLoader(unsupportedIndexLoader{}))
+	assert.ErrorContains(t, err, "can't load from a source")
+}
+
+// unsupportedIndexLoader can only load from paths.
+type unsupportedIndexLoader struct{}
+
+func (unsupportedIndexLoader) Load(path string, metadata *proto.MetaData) (SortedKeyIndex, error) {
+	return (&SliceKeyIndexLoader{ReadBufferSize: 4096}).Load(path, metadata)
+}
+
+// memoryFetcher serves the files from memory.
+type memoryFetcher map[string][]byte
+
+func (f memoryFetcher) Size(name string) (int64, error) {
+	content, ok := f[name]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	return int64(len(content)), nil
+}
+
+func (f memoryFetcher) Fetch(name string, offset int64, length int64) ([]byte, error) {
+	return append([]byte{}, f[name][offset:offset+length]...), nil
+}
+
+func TestPointLookupsOnlyFetchNeededRanges(t *testing.T) {
+	path := writeSourceTestTable(t, WithPartitionedIndex(256))
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	fetcher := newCountingFetcher(DirectoryFetcher{Path: path})
+	source, err := NewFetcherSource(fetcher, FetchBlockSizeBytes(512), FetchReadAheadBlocks(0))
+	require.Nil(t, err)
+
+	reader, err := NewSSTableReader(ReadFromSource(source), ReadIndexLoader(&PartitionedIndexLoader{}))
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+	// opening only reads the header of the data file
+	assert.Equal(t, 1, fetcher.fetches[DataFileName])
+
+	v, err := reader.Get(intToByteSlice(500))
+	require.Nil(t, err)
+	assert.Equal(t, bytes.Repeat(intToByteSlice(500), 25), v)
+
+	dataSize, err := fetcher.Size(DataFileName)
+	require.Nil(t, err)
+	indexSize, err := fetcher.Size(IndexFileName)
+	require.Nil(t, err)
+	assert.LessOrEqual(t, fetcher.fetches[DataFileName], 2)
+	assert.LessOrEqual(t, fetcher.bytes[DataFileName], int64(1024))
+	assert.Less(t, fetcher.bytes[IndexFileName], indexSize/2)
+	assert.Less(t, fetcher.bytes[DataFileName], dataSize/10)
+
+	// the same lookup again is served from the cache
+	fetches := fetcher.fetches[DataFileName]
+	_, err = reader.Get(intToByteSlice(500))
+	require.Nil(t, err)
+	assert.Equal(t, fetches, fetcher.fetches[DataFileName])
+}
+
+func TestReadFromHTTPSource(t *testing.T) {
+	path := writeSourceTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	server := httptest.NewServer(http.StripPrefix("/table/", http.FileServer(http.Dir(path))))
+	defer server.Close()
+
+	fetcher := newCountingFetcher(HTTPRangeFetcher{BaseURL: server.URL + "/table", Client: server.Client()})
+	source, err := NewFetcherSource(fetcher, FetchBlockSizeBytes(1024))
+	require.Nil(t, err)
+
+	reader, err := NewSSTableReader(ReadFromSource(source), ReadIndexLoader(&DiskIndexLoader{}), EnableHashCheckOnReads())
+	require.Nil(t, err)
+	defer closeReader(t, reader)
+
+	local, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	defer closeReader(t, local)
+	assertReadersEqual(t, local, reader)
+
+	_, err = HTTPRangeFetcher{BaseURL: server.URL + "/table"}.Size("missing.rio")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFetchingReaderAt(t *testing.T) {
+	content := make([]byte, 100)
+	for i := range content {
+		content[i] = byte(i)
+	}
+
+	fetcher := newCountingFetcher(memoryFetcher{"file": content})
+	source, err := NewFetcherSource(fetcher, FetchBlockSizeBytes(10), FetchMaxCachedBlocks(3), FetchReadAheadBlocks(0))
+	require.Nil(t, err)
+
+	r, size, err := source.File("file")
+	require.Nil(t, err)
+	assert.Equal(t, int64(100), size)
+
+	// spans three blocks with a single fetch
+	buf := make([]byte, 25)
+	n, err := r.ReadAt(buf, 5)
+	require.Nil(t, err)
+	assert.Equal(t, 25, n)
+	assert.Equal(t, content[5:30], buf)
+	assert.Equal(t, 1, fetcher.fetches["file"])
+	assert.Equal(t, int64(30), fetcher.bytes["file"])
+
+	// cached
+	n, err = r.ReadAt(buf[:10], 10)
+	require.Nil(t, err)
+	assert.Equal(t, 10, n)
+	assert.Equal(t, content[10:20], buf[:10])
+	assert.Equal(t, 1, fetcher.fetches["file"])
+
+	// reading beyond the end returns EOF with the bytes that were available
+	n, err = r.ReadAt(buf, 90)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 10, n)
+	assert.Equal(t, content[90:], buf[:10])
+
+	// the first blocks were evicted
+	_, err = r.ReadAt(buf[:10], 0)
+	require.Nil(t, err)
+	assert.Equal(t, 3, fetcher.fetches["file"])
+
+	_, _, err = source.File("missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFetchingReaderAtReadAhead(t *testing.T) {
+	content := make([]byte, 100)
+	fetcher := newCountingFetcher(memoryFetcher{"file": content})
+	source, err := NewFetcherSource(fetcher, FetchBlockSizeBytes(10), FetchReadAheadBlocks(2))
+	require.Nil(t, err)
+
+	r, _, err := source.File("file")
+	require.Nil(t, err)
+
+	// reading from the start is sequential, every fetch reads two blocks ahead
+	buf := make([]byte, 10)
+	for off := int64(0); off < 50; off += 10 {
+		_, err = r.ReadAt(buf, off)
+		require.Nil(t, err)
+	}
+	assert.Equal(t, 2, fetcher.fetches["file"])
+	assert.Equal(t, int64(60), fetcher.bytes["file"])
+
+	// random reads don't read ahead
+	_, err = r.ReadAt(buf, 90)
+	require.Nil(t, err)
+	assert.Equal(t, 3, fetcher.fetches["file"])
+	assert.Equal(t, int64(70), fetcher.bytes["file"])
+}
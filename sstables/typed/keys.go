@@ -0,0 +1,200 @@
+package typed
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// InvalidKeyEncoding is returned when a key can't be decoded, for example because it was written with another encoder.
+var InvalidKeyEncoding = errors.New("invalid key encoding")
+
+// KeyEncoder encodes keys to bytes that preserve their natural order, that means for any two keys a < b the encoding
+// of a sorts before the encoding of b according to bytes.Compare. Tables written with a KeyEncoder thus don't need a
+// custom comparator.
+type KeyEncoder[K any] interface {
+	// Append appends the encoding of k to dst and returns the extended slice.
+	Append(dst []byte, k K) []byte
+	// Decode decodes the key at the start of b and returns how many bytes it consumed.
+	Decode(b []byte) (K, int, error)
+}
+
+// Uint64Key encodes unsigned integers as fixed-size big-endian.
+type Uint64Key struct{}
+
+func (Uint64Key) Append(dst []byte, k uint64) []byte {
+	return binary.BigEndian.AppendUint64(dst, k)
+}
+
+func (Uint64Key) Decode(b []byte) (uint64, int, error) {
+	if len(b) < 8 {
+		return 0, 0, fmt.Errorf("%w: expected 8 bytes for uint64 but got %d", InvalidKeyEncoding, len(b))
+	}
+	return binary.BigEndian.Uint64(b), 8, nil
+}
+
+// Int64Key encodes signed integers as fixed-size big-endian with the sign bit flipped, so negative numbers sort first.
+type Int64Key struct{}
+
+func (Int64Key) Append(dst []byte, k int64) []byte {
+	return binary.BigEndian.AppendUint64(dst, uint64(k)^(1<<63))
+}
+
+func (Int64Key) Decode(b []byte) (int64, int, error) {
+	if len(b) < 8 {
+		return 0, 0, fmt.Errorf("%w: expected 8 bytes for int64 but got %d", InvalidKeyEncoding, len(b))
+	}
+	return int64(binary.BigEndian.Uint64(b) ^ (1 << 63)), 8, nil
+}
+
+// StringKey encodes strings by their bytes, escaping every zero byte as 0x00 0xFF and terminating with 0x00 0x01.
+// The terminator makes the encoding self-delimiting so it can be used in tuples, while a prefix still sorts first.
+type StringKey struct{}
+
+func (StringKey) Append(dst []byte, k string) []byte {
+	return appendEscaped(dst, []byte(k))
+}
+
+func (StringKey) Decode(b []byte) (string, int, error) {
+	k, n, err := decodeEscaped(b)
+	return string(k), n, err
+}
+
+// BytesKey encodes byte slices the same way as StringKey.
+type BytesKey struct{}
+
+func (BytesKey) Append(dst []byte, k []byte) []byte {
+	return appendEscaped(dst, k)
+}
+
+func (BytesKey) Decode(b []byte) ([]byte, int, error) {
+	return decodeEscaped(b)
+}
+
+const (
+	escapeByte     = 0x00
+	escapedZero    = 0xFF
+	terminatorByte = 0x01
+)
+
+func appendEscaped(dst []byte, k []byte) []byte {
+	for _, c := range k {
+		if c == escapeByte {
+			dst = append(dst, escapeByte, escapedZero)
+		} else {
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, escapeByte, terminatorByte)
+}
+
+func decodeEscaped(b []byte) ([]byte, int, error) {
+	k := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] != escapeByte {
+			k = append(k, b[i])
+			continue
+		}
+
+		if i+1 >= len(b) {
+			break
+		}
+
+		switch b[i+1] {
+		case terminatorByte:
+			return k, i + 2, nil
+		case escapedZero:
+			k = append(k, escapeByte)
+			i++
+		default:
+			return nil, 0, fmt.Errorf("%w: unexpected byte 0x%x after escape at position %d", InvalidKeyEncoding, b[i+1], i+1)
+		}
+	}
+	return nil, 0, fmt.Errorf("%w: missing terminator", InvalidKeyEncoding)
+}
+
+// Tuple2 is a composite key that is ordered by its first and then by its second element.
+type Tuple2[A any, B any] struct {
+	First  A
+	Second B
+}
+
+// Tuple2Key encodes a Tuple2 by concatenating the encodings of its elements.
+type Tuple2Key[A any, B any] struct {
+	First  KeyEncoder[A]
+	Second KeyEncoder[B]
+}
+
+func (t Tuple2Key[A, B]) Append(dst []byte, k Tuple2[A, B]) []byte {
+	dst = t.First.Append(dst, k.First)
+	return t.Second.Append(dst, k.Second)
+}
+
+func (t Tuple2Key[A, B]) Decode(b []byte) (Tuple2[A, B], int, error) {
+	var k Tuple2[A, B]
+	first, n, err := t.First.Decode(b)
+	if err != nil {
+		return k, 0, err
+	}
+
+	second, m, err := t.Second.Decode(b[n:])
+	if err != nil {
+		return k, 0, err
+	}
+
+	return Tuple2[A, B]{First: first, Second: second}, n + m, nil
+}
+
+// Tuple3 is a composite key that is ordered by its first, second and then by its third element.
+type Tuple3[A any, B any, C any] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// Tuple3Key encodes a Tuple3 by concatenating the encodings of its elements.
+type Tuple3Key[A any, B any, C any] struct {
+	First  KeyEncoder[A]
+	Second KeyEncoder[B]
+	Third  KeyEncoder[C]
+}
+
+func (t Tuple3Key[A, B, C]) Append(dst []byte, k Tuple3[A, B, C]) []byte {
+	dst = t.First.Append(dst, k.First)
+	dst = t.Second.Append(dst, k.Second)
+	return t.Third.Append(dst, k.Third)
+}
+
+func (t Tuple3Key[A, B, C]) Decode(b []byte) (Tuple3[A, B, C], int, error) {
+	var k Tuple3[A, B, C]
+	pair, n, err := Tuple2Key[A, B]{First: t.First, Second: t.Second}.Decode(b)
+	if err != nil {
+		return k, 0, err
+	}
+
+	third, m, err := t.Third.Decode(b[n:])
+	if err != nil {
+		return k, 0, err
+	}
+
+	return Tuple3[A, B, C]{First: pair.First, Second: pair.Second, Third: third}, n + m, nil
+}
+
+// encodeKey encodes a full key.
+func encodeKey[K any](encoder KeyEncoder[K], k K) []byte {
+	return encoder.Append(nil, k)
+}
+
+// decodeKey decodes a full key and fails if not all bytes were consumed.
+func decodeKey[K any](encoder KeyEncoder[K], b []byte) (K, error) {
+	k, n, err := encoder.Decode(b)
+	if err != nil {
+		return k, err
+	}
+
+	if n != len(b) {
+		var empty K
+		return empty, fmt.Errorf("%w: %d trailing bytes", InvalidKeyEncoding, len(b)-n)
+	}
+	return k, nil
+}
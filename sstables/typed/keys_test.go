@@ -0,0 +1,91 @@
+package typed
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertOrderPreserving checks that the encodings sort like the keys and that they decode to the original keys.
+func assertOrderPreserving[K any](t *testing.T, encoder KeyEncoder[K], sortedKeys []K) {
+	var encoded [][]byte
+	for _, k := range sortedKeys {
+		e := encodeKey(encoder, k)
+		decoded, err := decodeKey(encoder, e)
+		require.NoError(t, err)
+		assert.Equal(t, k, decoded)
+		encoded = append(encoded, e)
+	}
+
+	for i := 1; i < len(encoded); i++ {
+		assert.Negative(t, bytes.Compare(encoded[i-1], encoded[i]), "%v must sort before %v", sortedKeys[i-1], sortedKeys[i])
+	}
+}
+
+func TestUint64Key(t *testing.T) {
+	assertOrderPreserving[uint64](t, Uint64Key{}, []uint64{0, 1, 255, 256, 1 << 32, math.MaxUint64})
+}
+
+func TestInt64Key(t *testing.T) {
+	assertOrderPreserving[int64](t, Int64Key{}, []int64{math.MinInt64, -1 << 32, -256, -1, 0, 1, 255, 1 << 40, math.MaxInt64})
+
+	_, _, err := Int64Key{}.Decode([]byte{1, 2})
+	assert.ErrorIs(t, err, InvalidKeyEncoding)
+}
+
+func TestStringKey(t *testing.T) {
+	assertOrderPreserving[string](t, StringKey{}, []string{"", "\x00", "\x00\x00", "\x00\x01", "\x01", "a", "a\x00", "a\x00b", "a\x01", "ab", "b", "\xff"})
+
+	for _, invalid := range [][]byte{{'a'}, {'a', 0x00}, {'a', 0x00, 0x02}} {
+		_, _, err := StringKey{}.Decode(invalid)
+		assert.ErrorIs(t, err, InvalidKeyEncoding)
+	}
+
+	_, err := decodeKey[string](StringKey{}, []byte{'a', 0x00, 0x01, 'b'})
+	assert.ErrorIs(t, err, InvalidKeyEncoding)
+}
+
+func TestRandomStringsPreserveOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	var keys []string
+	seen := map[string]bool{}
+	for len(keys) < 1000 {
+		b := make([]byte, r.Intn(7))
+		for i := range b {
+			// a small alphabet with the escape and terminator bytes makes collisions with the encoding likely
+			b[i] = []byte{0x00, 0x01, 0xFF, 'a'}[r.Intn(4)]
+		}
+		if !seen[string(b)] {
+			seen[string(b)] = true
+			keys = append(keys, string(b))
+		}
+	}
+	sort.Strings(keys)
+	assertOrderPreserving[string](t, StringKey{}, keys)
+}
+
+func TestTupleKeys(t *testing.T) {
+	pairs := Tuple2Key[string, int64]{First: StringKey{}, Second: Int64Key{}}
+	assertOrderPreserving[Tuple2[string, int64]](t, pairs, []Tuple2[string, int64]{
+		{"", math.MinInt64},
+		{"", 5},
+		{"a", -1},
+		{"a", 0},
+		{"a\x00", -5},
+		{"ab", -100},
+		{"b", 1},
+	})
+
+	triples := Tuple3Key[uint64, string, []byte]{First: Uint64Key{}, Second: StringKey{}, Third: BytesKey{}}
+	assertOrderPreserving[Tuple3[uint64, string, []byte]](t, triples, []Tuple3[uint64, string, []byte]{
+		{1, "a", []byte{}},
+		{1, "a", []byte{0}},
+		{1, "b", []byte{}},
+		{2, "", []byte{1}},
+	})
+}
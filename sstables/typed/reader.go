@@ -0,0 +1,117 @@
+package typed
+
+import (
+	"fmt"
+
+	"github.com/thomasjungblut/go-sstables/sstables"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+)
+
+// Reader reads typed keys and values from an sstable that was written with the same KeyEncoder and ValueCodec.
+type Reader[K any, V any] struct {
+	reader sstables.SSTableReaderI
+	keys   KeyEncoder[K]
+	values ValueCodec[V]
+}
+
+func (r *Reader[K, V]) Contains(k K) (bool, error) {
+	return r.reader.Contains(encodeKey(r.keys, k))
+}
+
+// Get returns sstables.NotFound if the key doesn't exist.
+func (r *Reader[K, V]) Get(k K) (V, error) {
+	value, err := r.reader.Get(encodeKey(r.keys, k))
+	if err != nil {
+		var empty V
+		return empty, err
+	}
+	return r.unmarshal(value)
+}
+
+func (r *Reader[K, V]) Scan() (*Iterator[K, V], error) {
+	it, err := r.reader.Scan()
+	return r.iterator(it, err)
+}
+
+func (r *Reader[K, V]) ScanStartingAt(k K) (*Iterator[K, V], error) {
+	it, err := r.reader.ScanStartingAt(encodeKey(r.keys, k))
+	return r.iterator(it, err)
+}
+
+// ScanRange returns the keys in the closed range [keyLower, keyHigher].
+func (r *Reader[K, V]) ScanRange(keyLower K, keyHigher K) (*Iterator[K, V], error) {
+	it, err := r.reader.ScanRange(encodeKey(r.keys, keyLower), encodeKey(r.keys, keyHigher))
+	return r.iterator(it, err)
+}
+
+func (r *Reader[K, V]) MetaData() *proto.MetaData {
+	return r.reader.MetaData()
+}
+
+// Unwrap returns the underlying reader, for example to use it with the sstables.SuperSSTableReader.
+func (r *Reader[K, V]) Unwrap() sstables.SSTableReaderI {
+	return r.reader
+}
+
+func (r *Reader[K, V]) Close() error {
+	return r.reader.Close()
+}
+
+func (r *Reader[K, V]) iterator(it sstables.SSTableIteratorI, err error) (*Iterator[K, V], error) {
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator[K, V]{iterator: it, reader: r}, nil
+}
+
+func (r *Reader[K, V]) unmarshal(value []byte) (V, error) {
+	v, err := r.values.Unmarshal(value)
+	if err != nil {
+		return v, fmt.Errorf("error while unmarshalling value in '%s': %w", r.reader.BasePath(), err)
+	}
+	return v, nil
+}
+
+// Iterator returns the typed keys and values in ascending order.
+type Iterator[K any, V any] struct {
+	iterator sstables.SSTableIteratorI
+	reader   *Reader[K, V]
+}
+
+// Next returns sstables.Done as the error when the iterator is exhausted.
+func (it *Iterator[K, V]) Next() (K, V, error) {
+	var emptyKey K
+	var emptyValue V
+
+	key, value, err := it.iterator.Next()
+	if err != nil {
+		return emptyKey, emptyValue, err
+	}
+
+	k, err := decodeKey(it.reader.keys, key)
+	if err != nil {
+		return emptyKey, emptyValue, fmt.Errorf("error while decoding key in '%s': %w", it.reader.reader.BasePath(), err)
+	}
+
+	v, err := it.reader.unmarshal(value)
+	if err != nil {
+		return emptyKey, emptyValue, err
+	}
+
+	return k, v, nil
+}
+
+// NewReader opens a typed reader, the options are passed to sstables.NewSSTableReader.
+func NewReader[K any, V any](keys KeyEncoder[K], values ValueCodec[V], opts ...sstables.ReadOption) (*Reader[K, V], error) {
+	reader, err := sstables.NewSSTableReader(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return WrapReader(reader, keys, values), nil
+}
+
+// WrapReader returns a typed view of an already opened reader.
+func WrapReader[K any, V any](reader sstables.SSTableReaderI, keys KeyEncoder[K], values ValueCodec[V]) *Reader[K, V] {
+	return &Reader[K, V]{reader: reader, keys: keys, values: values}
+}
@@ -0,0 +1,129 @@
+package typed
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/sstables"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+)
+
+type account struct {
+	Name    string
+	Balance int
+}
+
+func newTempDir(t *testing.T) string {
+	tmpDir, err := os.MkdirTemp("", "sstables_Typed")
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, os.RemoveAll(tmpDir)) })
+	return tmpDir
+}
+
+func readAll[K any, V any](t *testing.T, it *Iterator[K, V]) ([]K, []V) {
+	var keys []K
+	var values []V
+	for {
+		k, v, err := it.Next()
+		if errors.Is(err, sstables.Done) {
+			return keys, values
+		}
+		require.NoError(t, err)
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+}
+
+func TestWriteAndReadInt64KeysWithJSON(t *testing.T) {
+	path := newTempDir(t)
+	writer, err := NewWriter[int64, account](Int64Key{}, JSONValue[account]{}, sstables.WriteBasePath(path))
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	for _, k := range []int64{-100, -1, 0, 42} {
+		require.NoError(t, writer.WriteNext(k, account{Name: "acc", Balance: int(k)}))
+	}
+	// the natural order is enforced
+	assert.Error(t, writer.WriteNext(7, account{}))
+	require.NoError(t, writer.Close())
+
+	reader, err := NewReader[int64, account](Int64Key{}, JSONValue[account]{}, sstables.ReadBasePath(path))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, reader.Close()) }()
+
+	v, err := reader.Get(-1)
+	require.NoError(t, err)
+	assert.Equal(t, account{Name: "acc", Balance: -1}, v)
+
+	_, err = reader.Get(1)
+	assert.ErrorIs(t, err, sstables.NotFound)
+
+	contains, err := reader.Contains(42)
+	require.NoError(t, err)
+	assert.True(t, contains)
+
+	it, err := reader.Scan()
+	require.NoError(t, err)
+	keys, values := readAll(t, it)
+	assert.Equal(t, []int64{-100, -1, 0, 42}, keys)
+	assert.Equal(t, -100, values[0].Balance)
+
+	it, err = reader.ScanRange(-1, 0)
+	require.NoError(t, err)
+	keys, _ = readAll(t, it)
+	assert.Equal(t, []int64{-1, 0}, keys)
+
+	it, err = reader.ScanStartingAt(-50)
+	require.NoError(t, err)
+	keys, _ = readAll(t, it)
+	assert.Equal(t, []int64{-1, 0, 42}, keys)
+	assert.Equal(t, uint64(4), reader.MetaData().NumRecords)
+}
+
+func TestWriteAndReadTupleKeysWithProto(t *testing.T) {
+	path := newTempDir(t)
+	keys := Tuple2Key[string, uint64]{First: StringKey{}, Second: Uint64Key{}}
+	values := ProtoValue[*proto.IndexEntry]{New: func() *proto.IndexEntry { return &proto.IndexEntry{} }}
+
+	writer, err := NewWriter[Tuple2[string, uint64], *proto.IndexEntry](keys, values, sstables.WriteBasePath(path))
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	expected := []Tuple2[string, uint64]{{"a", 2}, {"a", 10}, {"ab", 1}, {"b", 0}}
+	for _, k := range expected {
+		require.NoError(t, writer.WriteNext(k, &proto.IndexEntry{Key: []byte(k.First), ValueOffset: k.Second}))
+	}
+	require.NoError(t, writer.Close())
+
+	reader, err := NewReader[Tuple2[string, uint64], *proto.IndexEntry](keys, values, sstables.ReadBasePath(path))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, reader.Close()) }()
+
+	v, err := reader.Get(Tuple2[string, uint64]{"a", 10})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), v.ValueOffset)
+
+	it, err := reader.Scan()
+	require.NoError(t, err)
+	actual, _ := readAll(t, it)
+	assert.Equal(t, expected, actual)
+}
+
+func TestReaderWithWrongKeyEncoder(t *testing.T) {
+	path := newTempDir(t)
+	writer, err := NewWriter[string, []byte](StringKey{}, BytesValue{}, sstables.WriteBasePath(path))
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+	require.NoError(t, writer.WriteNext("abc", []byte{1}))
+	require.NoError(t, writer.Close())
+
+	reader, err := NewReader[uint64, []byte](Uint64Key{}, BytesValue{}, sstables.ReadBasePath(path))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, reader.Close()) }()
+
+	it, err := reader.Scan()
+	require.NoError(t, err)
+	_, _, err = it.Next()
+	assert.ErrorIs(t, err, InvalidKeyEncoding)
+}
@@ -0,0 +1,53 @@
+package typed
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ValueCodec marshals values to bytes and back.
+type ValueCodec[V any] interface {
+	Marshal(v V) ([]byte, error)
+	Unmarshal(b []byte) (V, error)
+}
+
+// BytesValue stores byte slices as they are.
+type BytesValue struct{}
+
+func (BytesValue) Marshal(v []byte) ([]byte, error) {
+	return v, nil
+}
+
+func (BytesValue) Unmarshal(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+// JSONValue marshals values with encoding/json.
+type JSONValue[V any] struct{}
+
+func (JSONValue[V]) Marshal(v V) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONValue[V]) Unmarshal(b []byte) (V, error) {
+	var v V
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+// ProtoValue marshals protobuf messages, New creates the empty message to unmarshal into, e.g.:
+// > typed.ProtoValue[*proto.MetaData]{New: func() *proto.MetaData { return &proto.MetaData{} }}
+type ProtoValue[V proto.Message] struct {
+	New func() V
+}
+
+func (ProtoValue[V]) Marshal(v V) ([]byte, error) {
+	return proto.Marshal(v)
+}
+
+func (c ProtoValue[V]) Unmarshal(b []byte) (V, error) {
+	v := c.New()
+	err := proto.Unmarshal(b, v)
+	return v, err
+}
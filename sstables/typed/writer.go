@@ -0,0 +1,44 @@
+package typed
+
+import (
+	"fmt"
+
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables"
+)
+
+// Writer writes typed keys and values into an sstable, the keys must be written in ascending natural order.
+type Writer[K any, V any] struct {
+	writer *sstables.SSTableStreamWriter
+	keys   KeyEncoder[K]
+	values ValueCodec[V]
+}
+
+func (w *Writer[K, V]) Open() error {
+	return w.writer.Open()
+}
+
+func (w *Writer[K, V]) WriteNext(k K, v V) error {
+	value, err := w.values.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("typed.WriteNext: error while marshalling value: %w", err)
+	}
+
+	return w.writer.WriteNext(encodeKey(w.keys, k), value)
+}
+
+func (w *Writer[K, V]) Close() error {
+	return w.writer.Close()
+}
+
+// NewWriter creates a new typed writer, the options are passed to the sstables.SSTableStreamWriter. The keys are
+// compared by their encoding, any key comparator in the options is replaced.
+func NewWriter[K any, V any](keys KeyEncoder[K], values ValueCodec[V], opts ...sstables.WriterOption) (*Writer[K, V], error) {
+	opts = append(opts, sstables.WithKeyComparator(skiplist.BytesComparator{}))
+	writer, err := sstables.NewSSTableStreamWriter(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer[K, V]{writer: writer, keys: keys, values: values}, nil
+}
@@ -0,0 +1,416 @@
+package sstables
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/steakknife/bloomfilter"
+	"github.com/thomasjungblut/go-sstables/recordio"
+	rProto "github.com/thomasjungblut/go-sstables/recordio/proto"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+	"github.com/thomasjungblut/go-sstables/sstables/proto"
+	pb "google.golang.org/protobuf/proto"
+)
+
+// VerifyProblem is a single integrity problem that Verify found in a table.
+type VerifyProblem struct {
+	// File is the name of the file that has the problem, e.g. DataFileName, empty if it concerns the whole table.
+	File    string
+	Message string
+}
+
+func (p VerifyProblem) String() string {
+	if p.File == "" {
+		return p.Message
+	}
+	return fmt.Sprintf("%s: %s", p.File, p.Message)
+}
+
+// VerifyReport lists all problems that Verify found in a table.
+type VerifyReport struct {
+	BasePath string
+	// NumRecords is the number of index entries whose values were verified.
+	NumRecords uint64
+	Problems   []VerifyProblem
+	// Truncated is true if more problems were found than VerifyMaxProblems allows to be reported.
+	Truncated bool
+}
+
+// Ok returns true if no problems were found.
+func (r *VerifyReport) Ok() bool {
+	return len(r.Problems) == 0
+}
+
+type verifier struct {
+	opts     *VerifyOptions
+	basePath string
+	report   *VerifyReport
+	metaData *proto.MetaData
+	filter   *bloomfilter.Filter
+}
+
+func (v *verifier) addProblem(file string, format string, args ...any) {
+	if len(v.report.Problems) >= v.opts.maxProblems {
+		v.report.Truncated = true
+		return
+	}
+	v.report.Problems = append(v.report.Problems, VerifyProblem{File: file, Message: fmt.Sprintf(format, args...)})
+}
+
+// verifyMetaData returns false if the metadata can't be parsed, which makes all further checks meaningless.
+func (v *verifier) verifyMetaData() (bool, error) {
+	v.metaData = &proto.MetaData{}
+	metaPath := filepath.Join(v.basePath, MetaFileName)
+	content, err := os.ReadFile(metaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// tables written before the metadata existed don't have it at all
+			return true, nil
+		}
+		return false, fmt.Errorf("error while reading metadata in '%s': %w", metaPath, err)
+	}
+
+	if len(content) == 0 {
+		v.addProblem(MetaFileName, "metadata is empty, the table is incomplete")
+		return false, nil
+	}
+
+	md := &proto.MetaData{}
+	err = pb.Unmarshal(content, md)
+	if err != nil {
+		v.addProblem(MetaFileName, "metadata can't be parsed: %v", err)
+		return false, nil
+	}
+	v.metaData = md
+
+	err = verifyMetaDataChecksum(content, md)
+	if err != nil {
+		v.addProblem(MetaFileName, "metadata checksum doesn't match: %v", err)
+	}
+
+	err = checkComparatorName(v.opts.keyComparator, md.ComparatorName)
+	if err != nil {
+		v.addProblem(MetaFileName, "%v", err)
+	}
+
+	return true, nil
+}
+
+func (v *verifier) verifyFileChecksums() error {
+	if len(v.metaData.FileChecksums) == 0 {
+		return nil
+	}
+
+	var names []string
+	for name := range v.metaData.FileChecksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(v.basePath, name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			v.addProblem(name, "file is missing")
+			continue
+		}
+
+		checksum, err := checksumFile(path)
+		if err != nil {
+			return err
+		}
+
+		if checksum != v.metaData.FileChecksums[name] {
+			v.addProblem(name, "file checksum doesn't match: %v", ChecksumError{checksum, v.metaData.FileChecksums[name]})
+		}
+	}
+
+	for _, name := range componentFileNames() {
+		if _, ok := v.metaData.FileChecksums[name]; ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(v.basePath, name)); err == nil {
+			v.addProblem(name, "file has no checksum recorded")
+		}
+	}
+
+	return nil
+}
+
+func (v *verifier) verifyFileSize(name string, expectedSize uint64) {
+	if expectedSize == 0 {
+		return
+	}
+
+	stat, err := os.Stat(filepath.Join(v.basePath, name))
+	if err != nil {
+		// missing files are reported when they are read
+		return
+	}
+
+	if uint64(stat.Size()) != expectedSize {
+		v.addProblem(name, "file has %d bytes, but the metadata says %d", stat.Size(), expectedSize)
+	}
+}
+
+func (v *verifier) verifyFilterAndTombstones() {
+	filter, err := readFilterIfExists(filepath.Join(v.basePath, BloomFileName))
+	if err != nil {
+		v.addProblem(BloomFileName, "bloom filter can't be read: %v", err)
+	}
+	v.filter = filter
+
+	tombstones, err := readRangeTombstonesIfExists(filepath.Join(v.basePath, RangeTombstoneFileName))
+	if err != nil {
+		v.addProblem(RangeTombstoneFileName, "range tombstones can't be read: %v", err)
+		return
+	}
+
+	if uint64(len(tombstones)) != v.metaData.NumRangeTombstones {
+		v.addProblem(RangeTombstoneFileName, "found %d range tombstones, but the metadata says %d",
+			len(tombstones), v.metaData.NumRangeTombstones)
+	}
+}
+
+// openValueReader returns a reader that can only read the values at their offset in the data file.
+func (v *verifier) openValueReader() (*SSTableReader, error) {
+	reader := &SSTableReader{opts: &SSTableReaderOptions{basePath: v.basePath}, metaData: v.metaData}
+	dataPath := filepath.Join(v.basePath, DataFileName)
+	if v.metaData.Version == 0 {
+		dataReader, err := rProto.NewMMapProtoReaderWithPath(dataPath)
+		if err != nil {
+			return nil, err
+		}
+		reader.v0DataReader = dataReader
+		return reader, dataReader.Open()
+	}
+
+	dataReader, err := recordio.NewMemoryMappedReaderWithPath(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	reader.dataReader = dataReader
+	return reader, dataReader.Open()
+}
+
+func (v *verifier) verifyIndexAndData() (err error) {
+	valueReader, err := v.openValueReader()
+	if err != nil {
+		v.addProblem(DataFileName, "data can't be opened: %v", err)
+		return nil
+	}
+	defer func() {
+		if valueReader.v0DataReader != nil {
+			err = errors.Join(err, valueReader.v0DataReader.Close())
+		}
+		if valueReader.dataReader != nil {
+			err = errors.Join(err, valueReader.dataReader.Close())
+		}
+	}()
+
+	indexReader, err := rProto.NewReader(rProto.ReaderPath(filepath.Join(v.basePath, IndexFileName)))
+	if err != nil {
+		v.addProblem(IndexFileName, "index can't be opened: %v", err)
+		return nil
+	}
+
+	err = indexReader.Open()
+	if err != nil {
+		v.addProblem(IndexFileName, "index can't be opened: %v", err)
+		return nil
+	}
+	defer func() {
+		err = errors.Join(err, indexReader.Close())
+	}()
+
+	var lastKey []byte
+	bloomMisses := uint64(0)
+	blobBytes := map[uint64]uint64{}
+	for {
+		entry := &proto.IndexEntry{}
+		_, err := indexReader.ReadNext(entry)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			v.addProblem(IndexFileName, "index can't be read after %d records: %v", v.report.NumRecords, err)
+			break
+		}
+
+		if v.report.NumRecords == 0 && v.metaData.Version > 0 && !bytes.Equal(entry.Key, v.metaData.MinKey) {
+			v.addProblem(IndexFileName, "first key %x doesn't match the min key %x", entry.Key, v.metaData.MinKey)
+		}
+		if lastKey != nil && v.opts.keyComparator.Compare(lastKey, entry.Key) >= 0 {
+			v.addProblem(IndexFileName, "key %x is not strictly ascending after %x", entry.Key, lastKey)
+		}
+
+		if v.filter != nil {
+			hash := fnv.New64()
+			_, _ = hash.Write(entry.Key)
+			if !v.filter.Contains(hash) {
+				bloomMisses++
+			}
+		}
+
+		value, err := valueReader.getValueAtOffset(IndexVal{Offset: entry.ValueOffset, Checksum: entry.Checksum}, false)
+		if err != nil {
+			v.addProblem(DataFileName, "value of key %x can't be read: %v", entry.Key, err)
+		} else if v.opts.blobStore != nil && v.metaData.ValueSeparation {
+			v.verifyBlobReference(entry.Key, value, blobBytes)
+		}
+
+		lastKey = entry.Key
+		v.report.NumRecords++
+	}
+
+	if v.report.NumRecords > 0 && v.metaData.Version > 0 && !bytes.Equal(lastKey, v.metaData.MaxKey) {
+		v.addProblem(IndexFileName, "last key %x doesn't match the max key %x", lastKey, v.metaData.MaxKey)
+	}
+	if v.metaData.Version > 0 && v.report.NumRecords != v.metaData.NumRecords {
+		v.addProblem(IndexFileName, "found %d records, but the metadata says %d", v.report.NumRecords, v.metaData.NumRecords)
+	}
+	if bloomMisses > 0 {
+		v.addProblem(BloomFileName, "bloom filter doesn't contain %d keys", bloomMisses)
+	}
+	if v.opts.blobStore != nil && v.metaData.ValueSeparation {
+		v.verifyBlobFileBytes(blobBytes)
+	}
+
+	return nil
+}
+
+// verifyBlobReference resolves the value if it's a blob reference, the referenced bytes are summed up per file.
+func (v *verifier) verifyBlobReference(key []byte, value []byte, blobBytes map[uint64]uint64) {
+	if len(value) == 0 {
+		return
+	}
+
+	_, ref, isReference, err := decodeValue(value)
+	if err != nil {
+		v.addProblem(DataFileName, "value of key %x can't be decoded: %v", key, err)
+		return
+	}
+	if !isReference {
+		return
+	}
+
+	blobBytes[ref.FileId] += ref.Length
+	_, err = v.opts.blobStore.Get(ref)
+	if err != nil {
+		v.addProblem(fmt.Sprintf(BlobFileNamePattern, ref.FileId), "value of key %x can't be resolved: %v", key, err)
+	}
+}
+
+func (v *verifier) verifyBlobFileBytes(blobBytes map[uint64]uint64) {
+	fileIds := map[uint64]struct{}{}
+	for fileId := range blobBytes {
+		fileIds[fileId] = struct{}{}
+	}
+	for fileId := range v.metaData.BlobFileBytes {
+		fileIds[fileId] = struct{}{}
+	}
+
+	var sortedIds []uint64
+	for fileId := range fileIds {
+		sortedIds = append(sortedIds, fileId)
+	}
+	sort.Slice(sortedIds, func(i, j int) bool { return sortedIds[i] < sortedIds[j] })
+
+	for _, fileId := range sortedIds {
+		if blobBytes[fileId] != v.metaData.BlobFileBytes[fileId] {
+			v.addProblem(MetaFileName, "found %d referenced bytes in blob file %d, but the metadata says %d",
+				blobBytes[fileId], fileId, v.metaData.BlobFileBytes[fileId])
+		}
+	}
+}
+
+// Verify checks the integrity of all files of the table in basePath: the checksums of the metadata and all other
+// files, the values in the data file, the ordering of the keys, the bloom filter and whether the number of records
+// and keys match the metadata. The blob references of tables with value separation are only resolved when the store
+// is given with VerifyWithBlobStore. All problems are listed in the returned report, an error is only returned if the
+// table couldn't be verified at all.
+// > report, err := sstables.Verify("some_path")
+func Verify(basePath string, verifyOptions ...VerifyOption) (*VerifyReport, error) {
+	opts := &VerifyOptions{
+		keyComparator: skiplist.BytesComparator{},
+		maxProblems:   100,
+	}
+
+	for _, verifyOption := range verifyOptions {
+		verifyOption(opts)
+	}
+
+	stat, err := os.Stat(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("error while verifying sstable in '%s': %w", basePath, err)
+	}
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("error while verifying sstable in '%s': not a directory", basePath)
+	}
+
+	v := &verifier{opts: opts, basePath: basePath, report: &VerifyReport{BasePath: basePath}}
+	if IsStagingDir(filepath.Base(filepath.Clean(basePath))) {
+		v.addProblem("", "table is a staging directory: %v", IncompleteTable)
+	}
+
+	ok, err := v.verifyMetaData()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return v.report, nil
+	}
+
+	err = v.verifyFileChecksums()
+	if err != nil {
+		return nil, err
+	}
+
+	v.verifyFileSize(DataFileName, v.metaData.DataBytes)
+	v.verifyFileSize(IndexFileName, v.metaData.IndexBytes)
+	v.verifyFilterAndTombstones()
+
+	err = v.verifyIndexAndData()
+	if err != nil {
+		return nil, err
+	}
+
+	return v.report, nil
+}
+
+// options
+
+type VerifyOptions struct {
+	keyComparator skiplist.Comparator[[]byte]
+	maxProblems   int
+	blobStore     *BlobStore
+}
+
+type VerifyOption func(*VerifyOptions)
+
+// VerifyWithKeyComparator sets the comparator the key ordering is checked with, defaults to skiplist.BytesComparator.
+func VerifyWithKeyComparator(cmp skiplist.Comparator[[]byte]) VerifyOption {
+	return func(args *VerifyOptions) {
+		args.keyComparator = cmp
+	}
+}
+
+// VerifyMaxProblems limits the number of problems in the report, defaults to 100.
+func VerifyMaxProblems(n int) VerifyOption {
+	return func(args *VerifyOptions) {
+		args.maxProblems = n
+	}
+}
+
+// VerifyWithBlobStore resolves all blob references of tables that were written WithValueSeparation, and checks that
+// the referenced bytes per blob file match the metadata.
+func VerifyWithBlobStore(store *BlobStore) VerifyOption {
+	return func(args *VerifyOptions) {
+		args.blobStore = store
+	}
+}
@@ -0,0 +1,243 @@
+package sstables
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thomasjungblut/go-sstables/skiplist"
+)
+
+func writeVerifyTestTable(t *testing.T) string {
+	return writeSourceTestTable(t, WithPartitionedIndex(256), WithHashIndex())
+}
+
+func sortedKeysOf(m map[string]uint64) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func problemFiles(report *VerifyReport) []string {
+	var files []string
+	for _, p := range report.Problems {
+		files = append(files, p.File)
+	}
+	return files
+}
+
+func corruptByteAt(t *testing.T, path string, offset int, mask byte) {
+	content, err := os.ReadFile(path)
+	require.Nil(t, err)
+	content[offset] ^= mask
+	require.Nil(t, os.WriteFile(path, content, 0666))
+}
+
+func TestVerifyHappyPath(t *testing.T) {
+	path := writeVerifyTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	reader, err := NewSSTableReader(ReadBasePath(path))
+	require.Nil(t, err)
+	md := reader.MetaData()
+	closeReader(t, reader)
+
+	assert.NotZero(t, md.MetaDataChecksum)
+	assert.Equal(t, []string{BloomFileName, DataFileName, HashIndexFileName, IndexFileName, IndexPartitionsFileName,
+		RangeTombstoneFileName}, sortedKeysOf(md.FileChecksums))
+
+	report, err := Verify(path)
+	require.Nil(t, err)
+	assert.True(t, report.Ok(), "%v", report.Problems)
+	assert.Equal(t, uint64(1000), report.NumRecords)
+	assert.Equal(t, path, report.BasePath)
+}
+
+func TestVerifyTablesWithoutChecksums(t *testing.T) {
+	for _, path := range []string{
+		"test_files/SimpleWriteHappyPathSSTable",
+		"test_files/SimpleWriteHappyPathSSTableWithBloom",
+		"test_files/SimpleWriteHappyPathSSTableWithMetaData",
+		"test_files/SimpleWriteHappyPathSSTableWithCRCHashes",
+		"test_files/SimpleWriteHappyPathSSTableRecordIOV2",
+	} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			report, err := Verify(path)
+			require.Nil(t, err)
+			assert.True(t, report.Ok(), "%v", report.Problems)
+			assert.Greater(t, report.NumRecords, uint64(0))
+		})
+	}
+}
+
+func TestVerifyCorruptedValue(t *testing.T) {
+	report, err := Verify("test_files/SimpleWriteHappyPathSSTableWithCRCHashesMismatch")
+	require.Nil(t, err)
+	require.False(t, report.Ok())
+	assert.Contains(t, problemFiles(report), DataFileName)
+}
+
+func TestVerifyCorruptedDataFile(t *testing.T) {
+	path := writeVerifyTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	stat, err := os.Stat(filepath.Join(path, DataFileName))
+	require.Nil(t, err)
+	corruptByteAt(t, filepath.Join(path, DataFileName), int(stat.Size())-1, 0xFF)
+
+	report, err := Verify(path)
+	require.Nil(t, err)
+	require.False(t, report.Ok())
+	assert.Equal(t, []string{DataFileName, DataFileName}, problemFiles(report))
+	assert.Contains(t, report.Problems[0].Message, "file checksum doesn't match")
+	assert.Contains(t, report.Problems[1].Message, "value of key")
+}
+
+func TestVerifyCorruptedMetaData(t *testing.T) {
+	path := writeVerifyTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	// the first byte is the tag of the number of records, this turns the 1000 records into 1001
+	corruptByteAt(t, filepath.Join(path, MetaFileName), 1, 0x01)
+
+	_, err := NewSSTableReader(ReadBasePath(path))
+	assert.ErrorIs(t, err, ChecksumError{})
+
+	report, err := Verify(path)
+	require.Nil(t, err)
+	require.False(t, report.Ok())
+	assert.Equal(t, MetaFileName, report.Problems[0].File)
+	assert.Contains(t, report.Problems[0].Message, "metadata checksum doesn't match")
+	assert.Contains(t, report.Problems, VerifyProblem{File: IndexFileName, Message: "found 1000 records, but the metadata says 1001"})
+}
+
+func TestVerifyMissingMetaDataChecksum(t *testing.T) {
+	for name, corrupt := range map[string]func(content []byte) []byte{
+		"zeroed": func(content []byte) []byte {
+			return append(content[:len(content)-8], make([]byte, 8)...)
+		},
+		"truncated": func(content []byte) []byte {
+			return content[:len(content)-metaDataChecksumFooterSizeBytes]
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeVerifyTestTable(t)
+			defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+			metaPath := filepath.Join(path, MetaFileName)
+			content, err := os.ReadFile(metaPath)
+			require.Nil(t, err)
+			require.Nil(t, os.WriteFile(metaPath, corrupt(content), 0666))
+
+			_, err = NewSSTableReader(ReadBasePath(path))
+			assert.Error(t, err)
+
+			report, err := Verify(path)
+			require.Nil(t, err)
+			require.False(t, report.Ok())
+			assert.Equal(t, MetaFileName, report.Problems[0].File)
+		})
+	}
+}
+
+func TestVerifyBlobReferences(t *testing.T) {
+	store := newTestBlobStore(t)
+	defer closeBlobStore(t, store)
+
+	values := map[int][]byte{1: bytes.Repeat([]byte{1}, 100), 2: {2}, 3: bytes.Repeat([]byte{3}, 100)}
+	path := writeBlobTestTable(t, store, values, WithValueSeparation(store, 10))
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	report, err := Verify(path, VerifyWithBlobStore(store))
+	require.Nil(t, err)
+	assert.True(t, report.Ok(), "%v", report.Problems)
+
+	require.Nil(t, store.Close())
+	require.Nil(t, os.Remove(store.filePath(0)))
+
+	// without the store, the references aren't resolved
+	report, err = Verify(path)
+	require.Nil(t, err)
+	assert.True(t, report.Ok(), "%v", report.Problems)
+
+	report, err = Verify(path, VerifyWithBlobStore(store))
+	require.Nil(t, err)
+	require.False(t, report.Ok())
+	blobFile := fmt.Sprintf(BlobFileNamePattern, 0)
+	assert.Equal(t, []string{blobFile, blobFile}, problemFiles(report))
+}
+
+func TestVerifyUnparseableMetaData(t *testing.T) {
+	path := writeVerifyTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+	require.Nil(t, os.WriteFile(filepath.Join(path, MetaFileName), []byte{0xFF}, 0666))
+
+	report, err := Verify(path)
+	require.Nil(t, err)
+	require.Len(t, report.Problems, 1)
+	assert.Contains(t, report.Problems[0].String(), MetaFileName+": metadata can't be parsed")
+}
+
+func TestVerifyMissingAndForeignFiles(t *testing.T) {
+	path := writeVerifyTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	other := writeHashIndexTestTable(t, variableLengthKeys(10))
+	defer func() { require.Nil(t, os.RemoveAll(other)) }()
+
+	require.Nil(t, os.Remove(filepath.Join(path, HashIndexFileName)))
+	bloom, err := os.ReadFile(filepath.Join(other, BloomFileName))
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(filepath.Join(path, BloomFileName), bloom, 0666))
+
+	report, err := Verify(path)
+	require.Nil(t, err)
+	require.False(t, report.Ok())
+
+	var messages []string
+	for _, p := range report.Problems {
+		messages = append(messages, p.String())
+	}
+	assert.Contains(t, messages, HashIndexFileName+": file is missing")
+	assert.Contains(t, messages, BloomFileName+": bloom filter doesn't contain 1000 keys")
+}
+
+func TestVerifyKeyOrderingAndCounts(t *testing.T) {
+	path := writeVerifyTestTable(t)
+	defer func() { require.Nil(t, os.RemoveAll(path)) }()
+
+	report, err := Verify(path, VerifyWithKeyComparator(reverseBytesComparator{}),
+		VerifyMaxProblems(5))
+	require.Nil(t, err)
+	require.False(t, report.Ok())
+	assert.Len(t, report.Problems, 5)
+	assert.True(t, report.Truncated)
+	assert.Equal(t, uint64(1000), report.NumRecords)
+}
+
+func TestVerifyIncompleteTable(t *testing.T) {
+	tmpDir := newStagingTestDir(t)
+	defer func() { require.Nil(t, os.RemoveAll(tmpDir)) }()
+
+	writer, err := NewSSTableStreamWriter(WriteBasePath(tmpDir), WithKeyComparator(skiplist.BytesComparator{}))
+	require.Nil(t, err)
+	require.Nil(t, writer.Open())
+	require.Nil(t, writer.WriteNext([]byte("a"), []byte("b")))
+
+	report, err := Verify(tmpDir)
+	require.Nil(t, err)
+	require.False(t, report.Ok())
+	assert.Equal(t, VerifyProblem{File: MetaFileName, Message: "metadata is empty, the table is incomplete"}, report.Problems[0])
+	require.Nil(t, writer.Close())
+
+	_, err = Verify(filepath.Join(tmpDir, "not_existing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}